@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// parseAccessLog 解析访问日志中的每一条记录
+func parseAccessLog(t *testing.T, buf *bytes.Buffer) []AccessRecord {
+	t.Helper()
+
+	var records []AccessRecord
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var rec AccessRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("Failed to parse access record %q: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+// 测试缓存组为每次操作写入一条 JSON 访问记录
+func TestGroupAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	getter := GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		return []byte("value"), nil
+	})
+	g := newTestGroup(t, getter, WithAccessLogger(NewAccessLogger(&buf, "node-1")))
+	ctx := context.Background()
+
+	g.Get(ctx, "key") // 未命中，从加载器加载
+	g.Get(ctx, "key") // 命中
+	g.Set(ctx, "other", []byte("v"))
+	g.Delete(ctx, "other")
+	g.Get(ctx, "")
+
+	records := parseAccessLog(t, &buf)
+	if len(records) != 5 {
+		t.Fatalf("Expected 5 access records, got %d", len(records))
+	}
+
+	want := []struct {
+		op, key string
+		hit     bool
+		failed  bool
+	}{
+		{"get", "key", false, false},
+		{"get", "key", true, false},
+		{"set", "other", false, false},
+		{"delete", "other", true, false},
+		{"get", "", false, true},
+	}
+	for i, w := range want {
+		rec := records[i]
+		if rec.Op != w.op || rec.Key != w.key || rec.Hit != w.hit {
+			t.Errorf("Record %d: expected op=%s key=%q hit=%v, got %+v", i, w.op, w.key, w.hit, rec)
+		}
+		if (rec.Error != "") != w.failed {
+			t.Errorf("Record %d: unexpected error field %q", i, rec.Error)
+		}
+		if rec.Node != "node-1" || rec.Group != g.name || rec.Time.IsZero() || rec.Latency < 0 {
+			t.Errorf("Record %d: missing common fields, got %+v", i, rec)
+		}
+	}
+}
+
+// 测试 Cache 直接记录访问日志
+func TestCacheAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	opts := DefaultCacheOptions()
+	opts.AccessLogger = NewAccessLogger(&buf, "")
+	c := NewCache(opts)
+	defer c.Close()
+
+	ctx := context.Background()
+	c.Set("key", ByteView{b: []byte("value")})
+	c.Get(ctx, "key")
+	c.Get(ctx, "missing")
+	c.Delete("missing")
+
+	records := parseAccessLog(t, &buf)
+	if len(records) != 4 {
+		t.Fatalf("Expected 4 access records, got %d", len(records))
+	}
+	if rec := records[1]; rec.Op != "get" || !rec.Hit || rec.Group != "" {
+		t.Errorf("Expected cache hit record, got %+v", rec)
+	}
+	if rec := records[2]; rec.Op != "get" || rec.Hit {
+		t.Errorf("Expected cache miss record, got %+v", rec)
+	}
+	if rec := records[3]; rec.Op != "delete" || rec.Hit {
+		t.Errorf("Expected delete of missing key to be recorded as a miss, got %+v", rec)
+	}
+
+}
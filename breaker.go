@@ -0,0 +1,315 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrPeerUnavailable 节点熔断中或半开状态下已有探测请求错误
+var ErrPeerUnavailable = errors.New("peer unavailable")
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // 正常，请求直接发往节点
+	BreakerOpen                         // 熔断，请求路由到其他节点
+	BreakerHalfOpen                     // 冷却结束，允许一个探测请求
+)
+
+// String 返回熔断器状态的名称
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// circuitBreaker 按连续失败次数熔断的熔断器
+// 连续失败 threshold 次后熔断，冷却 cooldown 后放行一个探测请求，探测成功则恢复，失败则重新熔断
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int // 连续失败次数
+	state     BreakerState
+	openedAt  time.Time        // 最近一次熔断的时间
+	probing   bool             // 半开状态下是否已有探测请求在执行
+	now       func() time.Time // 时钟，默认为 time.Now，测试时可替换
+}
+
+// newCircuitBreaker 创建熔断器
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow 判断是否放行请求，冷却结束后的第一个请求作为探测请求放行
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// available 判断是否可以放行请求，不改变熔断器状态
+func (b *circuitBreaker) available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		return b.now().Sub(b.openedAt) >= b.cooldown
+	case BreakerHalfOpen:
+		return !b.probing
+	default:
+		return true
+	}
+}
+
+// record 记录一次请求的结果
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		b.state = BreakerClosed
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// abandon 放弃探测请求而不记录结果，使下一个请求可以继续探测
+func (b *circuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// State 返回熔断器当前状态
+func (b *circuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// breakerPeer 记录请求结果的节点客户端，任何返回错误的请求都计为一次失败
+// 每次请求前向熔断器申请放行，半开状态下由第一个请求占用探测名额，选择节点时不改变熔断器状态
+type breakerPeer struct {
+	Peer
+	addr    string
+	breaker *circuitBreaker
+}
+
+// allow 申请放行请求，熔断中或半开状态下已有探测请求时返回 ErrPeerUnavailable
+func (p *breakerPeer) allow() error {
+	if !p.breaker.allow() {
+		return fmt.Errorf("%w: %s", ErrPeerUnavailable, p.addr)
+	}
+	return nil
+}
+
+// record 记录请求结果，熔断器状态变化时输出日志
+func (p *breakerPeer) record(err error) {
+	before := p.breaker.State()
+	p.breaker.record(err)
+	if after := p.breaker.State(); after != before {
+		logrus.Warnf("[G-Cache] circuit breaker for peer %s: %s -> %s", p.addr, before, after)
+	}
+}
+
+// recordContext 记录请求结果，调用方主动取消导致的错误不计入失败，只释放探测名额
+func (p *breakerPeer) recordContext(ctx context.Context, err error) {
+	if err != nil && ctx.Err() != nil {
+		p.breaker.abandon()
+		return
+	}
+	p.record(err)
+}
+
+// Get 实现 Peer 接口
+func (p *breakerPeer) Get(group, key string) ([]byte, error) {
+	if err := p.allow(); err != nil {
+		return nil, err
+	}
+	value, err := p.Peer.Get(group, key)
+	p.record(err)
+	return value, err
+}
+
+// GetContext 实现 ContextGetter 接口，底层客户端不支持取消时按 Get 读取
+// 调用方主动取消导致的错误不计入失败
+func (p *breakerPeer) GetContext(ctx context.Context, group, key string) ([]byte, error) {
+	cg, ok := p.Peer.(ContextGetter)
+	if !ok {
+		return p.Get(group, key)
+	}
+	if err := p.allow(); err != nil {
+		return nil, err
+	}
+	value, err := cg.GetContext(ctx, group, key)
+	p.recordContext(ctx, err)
+	return value, err
+}
+
+// GetWithTTL 实现 Peer 接口，调用方主动取消导致的错误不计入失败
+func (p *breakerPeer) GetWithTTL(ctx context.Context, group, key string) ([]byte, time.Duration, error) {
+	if err := p.allow(); err != nil {
+		return nil, 0, err
+	}
+	value, ttl, err := p.Peer.GetWithTTL(ctx, group, key)
+	p.recordContext(ctx, err)
+	return value, ttl, err
+}
+
+// GetIfPresent 实现 PresentGetter 接口，底层客户端不支持只查询缓存时返回错误，不计入失败
+func (p *breakerPeer) GetIfPresent(group, key string) ([]byte, bool, error) {
+	pg, ok := p.Peer.(PresentGetter)
+	if !ok {
+		return nil, false, fmt.Errorf("peer %s does not support cache-only lookups", p.addr)
+	}
+	if err := p.allow(); err != nil {
+		return nil, false, err
+	}
+	value, found, err := pg.GetIfPresent(group, key)
+	p.record(err)
+	return value, found, err
+}
+
+// SetNX 实现 LockPeer 接口，底层客户端不支持分布式锁时返回 ErrLockUnsupported
+func (p *breakerPeer) SetNX(ctx context.Context, group, key string, value []byte, ttl time.Duration) (bool, error) {
+	lp, ok := p.Peer.(LockPeer)
+	if !ok {
+		return false, ErrLockUnsupported
+	}
+	if err := p.allow(); err != nil {
+		return false, err
+	}
+	acquired, err := lp.SetNX(ctx, group, key, value, ttl)
+	p.record(err)
+	return acquired, err
+}
+
+// DeleteIfValue 实现 LockPeer 接口
+func (p *breakerPeer) DeleteIfValue(ctx context.Context, group, key string, value []byte) (bool, error) {
+	lp, ok := p.Peer.(LockPeer)
+	if !ok {
+		return false, ErrLockUnsupported
+	}
+	if err := p.allow(); err != nil {
+		return false, err
+	}
+	deleted, err := lp.DeleteIfValue(ctx, group, key, value)
+	p.record(err)
+	return deleted, err
+}
+
+// Set 实现 Peer 接口
+func (p *breakerPeer) Set(ctx context.Context, group, key string, value []byte) error {
+	if err := p.allow(); err != nil {
+		return err
+	}
+	err := p.Peer.Set(ctx, group, key, value)
+	p.record(err)
+	return err
+}
+
+// Delete 实现 Peer 接口
+func (p *breakerPeer) Delete(group, key string) (bool, error) {
+	if err := p.allow(); err != nil {
+		return false, err
+	}
+	ok, err := p.Peer.Delete(group, key)
+	p.record(err)
+	return ok, err
+}
+
+// BatchDelete 实现 Peer 接口
+func (p *breakerPeer) BatchDelete(ctx context.Context, group string, keys []string) (int, error) {
+	if err := p.allow(); err != nil {
+		return 0, err
+	}
+	n, err := p.Peer.BatchDelete(ctx, group, keys)
+	p.record(err)
+	return n, err
+}
+
+// WithCircuitBreaker 开启节点熔断：连续失败 threshold 次后将节点熔断，
+// 熔断期间选择哈希环上的下一个节点，冷却 cooldown 后放行一个探测请求，成功则恢复
+func WithCircuitBreaker(threshold int, cooldown time.Duration) PickerOption {
+	return func(cp *ClientPicker) {
+		if threshold > 0 {
+			cp.breakerThreshold = threshold
+			cp.breakerCooldown = cooldown
+		}
+	}
+}
+
+// BreakerStates 返回各节点熔断器的状态，未开启熔断时返回空
+func (cp *ClientPicker) BreakerStates() map[string]BreakerState {
+	cp.mu.RLock()
+	defer cp.mu.RUnlock()
+
+	states := make(map[string]BreakerState, len(cp.breakers))
+	for addr, b := range cp.breakers {
+		states[addr] = b.State()
+	}
+	return states
+}
+
+// pickAvailable 主节点熔断时选择哈希环上下一个可用的节点，调用此方法必须持有锁
+// 只检查熔断器状态，不占用半开状态的探测名额，探测名额在请求发出时由 breakerPeer 占用
+func (cp *ClientPicker) pickAvailable(key, addr string) string {
+	b, ok := cp.breakers[addr]
+	if !ok || b.available() {
+		return addr
+	}
+
+	exclude := map[string]bool{addr: true}
+	for other, b := range cp.breakers {
+		if !b.available() {
+			exclude[other] = true
+		}
+	}
+
+	return cp.consHash.GetExcluding(key, exclude)
+}
@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// 测试熔断器的状态转换
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newCircuitBreaker(3, time.Minute)
+	b.now = func() time.Time { return now }
+
+	failure := errors.New("unavailable")
+
+	// 未达到阈值前保持关闭，成功会重置计数
+	b.record(failure)
+	b.record(failure)
+	b.record(nil)
+	b.record(failure)
+	b.record(failure)
+	if got := b.State(); got != BreakerClosed {
+		t.Fatalf("Expected breaker to stay closed below threshold, got %s", got)
+	}
+
+	b.record(failure)
+	if got := b.State(); got != BreakerOpen {
+		t.Fatalf("Expected breaker to open after 3 consecutive failures, got %s", got)
+	}
+	if b.allow() {
+		t.Fatalf("Expected open breaker to reject requests during cooldown")
+	}
+
+	// 冷却结束后只放行一个探测请求
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatalf("Expected a probe to be allowed after cooldown")
+	}
+	if got := b.State(); got != BreakerHalfOpen {
+		t.Fatalf("Expected breaker to be half-open, got %s", got)
+	}
+	if b.allow() {
+		t.Fatalf("Expected only one probe while half-open")
+	}
+
+	// 探测失败重新熔断
+	b.record(failure)
+	if got := b.State(); got != BreakerOpen {
+		t.Fatalf("Expected failed probe to reopen the breaker, got %s", got)
+	}
+
+	// 探测成功恢复
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatalf("Expected a probe to be allowed after second cooldown")
+	}
+	b.record(nil)
+	if got := b.State(); got != BreakerClosed {
+		t.Fatalf("Expected successful probe to close the breaker, got %s", got)
+	}
+}
+
+// 测试节点熔断期间路由到其他节点，探测成功后恢复
+func TestClientPickerCircuitBreaker(t *testing.T) {
+	cp := newClientPicker("self", WithCircuitBreaker(3, time.Minute))
+	defer cp.Close()
+
+	peers := map[string]*fakePeer{"A": newFakePeer("A"), "B": newFakePeer("B")}
+	cp.mu.Lock()
+	for addr, p := range peers {
+		cp.set(addr, p)
+	}
+	cp.mu.Unlock()
+
+	now := time.Unix(0, 0)
+	for _, b := range cp.breakers {
+		b.now = func() time.Time { return now }
+	}
+
+	// 找到归属 A 的键
+	var key string
+	for i := 0; ; i++ {
+		key = fmt.Sprintf("key-%d", i)
+		if cp.consHash.Get(key) == "A" {
+			break
+		}
+	}
+
+	peers["A"].data[key] = []byte("value")
+	peers["A"].err = errors.New("unavailable")
+	for range 3 {
+		peer, ok, _ := cp.PickPeer(key)
+		if !ok {
+			t.Fatalf("Expected a peer for %s", key)
+		}
+		peer.Get("group", key)
+	}
+	if got := cp.BreakerStates()["A"]; got != BreakerOpen {
+		t.Fatalf("Expected breaker for A to open after 3 failures, got %s", got)
+	}
+
+	// 熔断期间路由到 B
+	peer, ok, _ := cp.PickPeer(key)
+	if !ok || peer.(*breakerPeer).Peer != peers["B"] {
+		t.Fatalf("Expected key to be routed to B while A is open")
+	}
+
+	// 冷却结束后探测 A，成功则恢复
+	now = now.Add(time.Minute)
+	peers["A"].err = nil
+	peer, ok, _ = cp.PickPeer(key)
+	if !ok || peer.(*breakerPeer).Peer != peers["A"] {
+		t.Fatalf("Expected probe to be routed to A after cooldown")
+	}
+
+	// 只选择节点而不发出请求时不占用探测名额
+	peer, ok, _ = cp.PickPeer(key)
+	if !ok || peer.(*breakerPeer).Peer != peers["A"] {
+		t.Fatalf("Expected picking without a call not to claim the probe")
+	}
+	if got := cp.BreakerStates()["A"]; got != BreakerOpen {
+		t.Fatalf("Expected picking not to change breaker for A, got %s", got)
+	}
+	peer.Get("group", key)
+	if got := cp.BreakerStates()["A"]; got != BreakerClosed {
+		t.Fatalf("Expected successful probe to close breaker for A, got %s", got)
+	}
+}
+
+// 测试半开状态下只放行一个探测请求，探测进行中的其他请求直接返回 ErrPeerUnavailable
+func TestBreakerPeerProbe(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newCircuitBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+	gated := &gatedSetPeer{fakePeer: newFakePeer("A"), release: make(chan struct{})}
+	peer := &breakerPeer{Peer: gated, addr: "A", breaker: b}
+
+	b.record(errors.New("unavailable"))
+	if _, err := peer.Get("group", "key"); !errors.Is(err, ErrPeerUnavailable) {
+		t.Fatalf("Expected ErrPeerUnavailable while open, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	done := make(chan error)
+	go func() { done <- peer.Set(context.Background(), "group", "key", []byte("value")) }()
+	for b.State() != BreakerHalfOpen {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := peer.Get("group", "key"); !errors.Is(err, ErrPeerUnavailable) {
+		t.Fatalf("Expected ErrPeerUnavailable while probing, got %v", err)
+	}
+
+	close(gated.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Expected probe to succeed, got %v", err)
+	}
+	if got := b.State(); got != BreakerClosed {
+		t.Fatalf("Expected successful probe to close the breaker, got %s", got)
+	}
+
+	// 调用方取消的探测不计入结果，释放探测名额
+	b.record(errors.New("unavailable"))
+	now = now.Add(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	gated.err = context.Canceled
+	peer.GetWithTTL(ctx, "group", "key")
+	if !b.available() {
+		t.Fatalf("Expected cancelled probe to release the probe slot")
+	}
+}
+
+// 测试开启熔断后仍然支持只查询缓存
+func TestBreakerPeerGetIfPresent(t *testing.T) {
+	cp := newClientPicker("self", WithCircuitBreaker(1, time.Minute))
+	defer cp.Close()
+
+	peerA := newFakePeer("A")
+	peerA.data["key"] = []byte("value")
+	cp.mu.Lock()
+	cp.set("A", peerA)
+	cp.mu.Unlock()
+
+	peer, ok, _ := cp.PickPeer("key")
+	if !ok {
+		t.Fatalf("Expected peer A to be picked")
+	}
+	pg, ok := peer.(PresentGetter)
+	if !ok {
+		t.Fatalf("Expected breaker-wrapped peer to implement PresentGetter")
+	}
+	value, found, err := pg.GetIfPresent("group", "key")
+	if err != nil || !found || string(value) != "value" {
+		t.Fatalf("Expected cached value, got %q %v %v", value, found, err)
+	}
+	if _, found, err := pg.GetIfPresent("group", "missing"); err != nil || found {
+		t.Fatalf("Expected clean miss, got %v %v", found, err)
+	}
+	if got := cp.BreakerStates()["A"]; got != BreakerClosed {
+		t.Fatalf("Expected misses not to trip the breaker, got %s", got)
+	}
+}
@@ -1,10 +1,36 @@
 package cache
 
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/lyy42995004/Cache-Go/store"
+)
+
 // ByteView 只读的字节视图，用于缓存数据
 type ByteView struct {
 	b []byte
 }
 
+// 编译时，强制检查 ByteView 类型是否实现了 io.ReaderAt 接口
+var _ io.ReaderAt = ByteView{}
+
+// NewByteView 将 v 序列化为字节视图，用于缓存结构化数据
+func NewByteView(v encoding.BinaryMarshaler) (ByteView, error) {
+	data, err := v.MarshalBinary()
+	if err != nil {
+		return ByteView{}, err
+	}
+	return ByteView{b: data}, nil
+}
+
+// Into 将视图中的数据反序列化到 v，v 得到的是数据的拷贝
+func (b ByteView) Into(v encoding.BinaryUnmarshaler) error {
+	return v.UnmarshalBinary(cloneBytes(b.b))
+}
+
 func (b ByteView) Len() int {
 	return len(b.b)
 }
@@ -17,8 +43,63 @@ func (b ByteView) String() string {
 	return string(b.b)
 }
 
+// Slice 返回 [offset, offset+length) 区间数据的拷贝，区间超出范围时截断到边界
+// length 为负数时读取到末尾
+func (b ByteView) Slice(offset, length int64) []byte {
+	size := int64(len(b.b))
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > size {
+		offset = size
+	}
+
+	// 先与剩余长度比较，避免 offset+length 溢出
+	var end int64
+	if length < 0 || length > size-offset {
+		end = size
+	} else {
+		end = offset + length
+	}
+
+	return cloneBytes(b.b[offset:end])
+}
+
+// ReadAt 实现 io.ReaderAt 接口，从 off 处开始读取数据到 p 中
+func (b ByteView) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("ByteView.ReadAt: negative offset")
+	}
+	if off >= int64(len(b.b)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, b.b[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
 func cloneBytes(b []byte) []byte {
 	c := make([]byte, len(b))
 	copy(c, b)
 	return c
-}
\ No newline at end of file
+}
+
+// byteViewCodec 持久化存储中 ByteView 的序列化方式，原样保存字节
+type byteViewCodec struct{}
+
+// Encode 实现 store.Codec 接口
+func (byteViewCodec) Encode(value store.Value) ([]byte, error) {
+	bv, ok := value.(ByteView)
+	if !ok {
+		return nil, fmt.Errorf("cannot encode value of type %T, want ByteView", value)
+	}
+	return bv.b, nil
+}
+
+// Decode 实现 store.Codec 接口
+func (byteViewCodec) Decode(data []byte) (store.Value, error) {
+	return ByteView{b: data}, nil
+}
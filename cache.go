@@ -1,36 +1,88 @@
 package cache
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/lyy42995004/Cache-Go/singleflight"
 	"github.com/lyy42995004/Cache-Go/store"
 
 	"github.com/sirupsen/logrus"
 )
 
+// ErrCacheClosed 缓存已关闭错误
+var ErrCacheClosed = errors.New("cache is closed")
+
+// ErrCacheUninitialized 缓存尚未初始化错误，缓存在首次写入时初始化
+var ErrCacheUninitialized = errors.New("cache is not initialized")
+
+// ErrValueType 缓存值类型不是 ByteView 错误
+var ErrValueType = errors.New("cached value is not a ByteView")
+
 // Cache 对底层缓存存储的封装
 type Cache struct {
 	mu          sync.RWMutex
-	store       store.Store  // 底层存储缓存
-	opts        CacheOptions // 缓存配置
-	hits        int64        // 缓存命中次数
-	misses      int64        // 缓存未命中次数
-	initialized int32        // 原子变量，标记缓存是否已初始化
-	closed      int32        // 原子变量，标记缓存是否已关闭
+	store       store.Store      // 底层存储缓存
+	opts        CacheOptions     // 缓存配置
+	hits        int64            // 缓存命中次数
+	misses      int64            // 缓存未命中次数
+	initialized int32            // 原子变量，标记缓存是否已初始化
+	closed      int32            // 原子变量，标记缓存是否已关闭
+	hotKeys     *hotKeyTracker   // 热点键统计，为空时不统计
+	evictions   *evictionSink    // 淘汰事件缓冲区，为空时不投递
+	pressure    *pressureMonitor // 内存压力检查，为空时不检查
+	budgeted    bool             // 是否注册到共享内存预算，由 mu 保护
+	// loads 合并 GetOrLoad 对同一个键的并发加载
+	loads singleflight.Group
 }
 
 // CacheOptions 缓存配置选项
 type CacheOptions struct {
-	CacheType       store.CacheType // 缓存类型: LRU, LRU2
+	CacheType       store.CacheType // 缓存类型: LRU, LRU2, Bolt
 	MaxBytes        int64           // 最大内存
 	BucketCount     uint16          // 缓存桶数量 (LRU2)
 	CapPerBucket    uint16          // 每个缓存桶的容量 (LRU2)
 	Level2Cap       uint16          // 二级缓存桶的容量 (LRU2)
 	CleanupInterval time.Duration   // 清理事件间隔
+	CleanupBatch    int             // 每次清理每个桶最多检查的项数 (LRU2)，0 表示检查全部
+	CleanupWorkers  int             // 定期清理的并发协程数 (LRU2)，<= 1 表示逐个桶清理
+	MaxAge          time.Duration   // 最大存活时间，0 表示不限制
+	DefaultTTL      time.Duration   // Set 未指定过期时间时使用的默认过期时间，0 表示永不过期
 	OnEvicted       func(key string, value store.Value)
+	Admission       store.AdmissionPolicy // 准入策略 (LRU)
+	StrictExpiry    bool                  // 严格过期，Get/Len 同步清理过期项，统计结果不包含过期数据
+	EvictionSamples int                   // 近似 LRU 淘汰时的采样数 (LRU)，0 表示精确 LRU
+	PromoteAfter    int                   // 访问多少次后晋升到二级缓存 (LRU2)，<= 1 表示首次命中即晋升
+	PromoteWindow   time.Duration         // 统计晋升访问次数的时间窗口 (LRU2)，0 表示不限制
+	NoPromote       bool                  // 读取不调整缓存项的顺序，只按写入顺序和过期时间淘汰
+	// ShardHash 计算键所属桶的哈希函数 (LRU2)，可与节点路由使用同一个哈希或换用更快的哈希，为空时使用 BKDR 哈希
+	ShardHash func(key string) uint32
+	// OnSetError 写入失败时的回调，可用于重试、告警或转存到其他位置
+	OnSetError func(key string, value ByteView, err error)
+	// AccessLogger 访问日志，记录每次 Get、Set、Delete 操作，为空时不记录
+	AccessLogger *AccessLogger
+	// HotKeys 统计访问频率时保留的热点键数量，可通过 TopKeys 查询，0 表示不统计
+	HotKeys int
+	// CopyOnSet 写入时拷贝值的字节，调用方之后修改自己的缓冲区不会影响缓存中的值
+	// 每次写入多一次内存分配和拷贝；Group 写入前已经拷贝，只有直接使用 Cache 且会复用缓冲区时才需要开启
+	CopyOnSet bool
+	// ValueDecoder 读取时将不是 ByteView 的值转换为 ByteView，用于直接向存储写入自定义 Value 的场景
+	// 返回 false 表示无法转换，按类型不匹配处理；为空时只接受 ByteView
+	ValueDecoder func(store.Value) (ByteView, bool)
+	// EvictionBuffer 淘汰事件缓冲区的容量，大于 0 时通过 EvictionEvents 投递淘汰事件，用于构建 write-behind 等下游存储
+	// OnEvicted 在淘汰路径上同步调用，消费者较慢时应使用事件 channel 代替
+	EvictionBuffer int
+	// EvictionOverflow 淘汰事件缓冲区已满时的处理策略，默认阻塞
+	EvictionOverflow OverflowPolicy
+	// MemoryPressure 进程堆占用过高时自动收缩缓存容量，压力缓解后恢复，为空时不启用 (LRU, LRU2)
+	MemoryPressure *MemoryPressureOptions
+	// Path 磁盘存储的数据文件路径 (Bolt)，使用 Bolt 前需要导入 store/boltstore 包
+	Path string
 }
 
 // DefaultCacheOptions 返回默认的缓存配置
@@ -48,20 +100,42 @@ func DefaultCacheOptions() CacheOptions {
 
 // NewCache 创建一个新的缓存实例
 func NewCache(opts CacheOptions) *Cache {
-	return &Cache{
+	c := &Cache{
 		opts: opts,
 	}
+	if opts.HotKeys > 0 {
+		c.hotKeys = newHotKeyTracker(opts.HotKeys)
+	}
+	if opts.EvictionBuffer > 0 {
+		c.evictions = newEvictionSink(opts.EvictionBuffer, opts.EvictionOverflow)
+	}
+	if opts.MemoryPressure != nil && opts.MaxBytes > 0 {
+		c.pressure = newPressureMonitor(*opts.MemoryPressure)
+		go c.watchMemoryPressure()
+	}
+	// 磁盘存储打开时可能已有数据，立即初始化以便读取；打开失败时下次写入重试
+	if opts.CacheType == store.Bolt {
+		if err := c.ensureInitialized(); err != nil {
+			logrus.Errorf("[G-Cache] failed to open bolt store at %s, reads miss until a write reopens it: %v", opts.Path, err)
+		}
+	}
+	return c
 }
 
-// ensureInitialized 确保缓存已初始化
-func (c *Cache) ensureInitialized() {
+// ensureInitialized 确保缓存已初始化，缓存类型无效时返回错误，下次写入时重试
+func (c *Cache) ensureInitialized() error {
 	if atomic.LoadInt32(&c.initialized) == 1 {
-		return
+		return nil
 	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	// 关闭后不再重新创建存储
+	if atomic.LoadInt32(&c.closed) == 1 {
+		return ErrCacheClosed
+	}
+
 	if c.initialized == 0 {
 		storeOpts := store.Options{
 			MaxBytes:        c.opts.MaxBytes,
@@ -69,108 +143,666 @@ func (c *Cache) ensureInitialized() {
 			CapPerBucket:    c.opts.CapPerBucket,
 			Level2Cap:       c.opts.Level2Cap,
 			CleanupInterval: c.opts.CleanupInterval,
-			OnEvicted:       c.opts.OnEvicted,
+			CleanupBatch:    c.opts.CleanupBatch,
+			CleanupWorkers:  c.opts.CleanupWorkers,
+			MaxAge:          c.opts.MaxAge,
+			OnEvicted:       c.onEvicted(),
+			Admission:       c.opts.Admission,
+			StrictExpiry:    c.opts.StrictExpiry,
+			EvictionSamples: c.opts.EvictionSamples,
+			PromoteAfter:    c.opts.PromoteAfter,
+			PromoteWindow:   c.opts.PromoteWindow,
+			NoPromote:       c.opts.NoPromote,
+			ShardHash:       c.opts.ShardHash,
+			Path:            c.opts.Path,
+			Codec:           byteViewCodec{},
 		}
 
 		// 创建存储实例
-		c.store = store.NewStore(c.opts.CacheType, storeOpts)
+		s, err := store.NewStore(c.opts.CacheType, storeOpts)
+		if err != nil {
+			logrus.Errorf("Failed to initialize cache: %v", err)
+			return err
+		}
+		c.store = s
+		c.checkStoreSupport()
 
 		atomic.StoreInt32(&c.initialized, 1)
 
 		logrus.Infof("Cache initialized with type %s, max bytes: %d", c.opts.CacheType, c.opts.MaxBytes)
 	}
+	return nil
 }
 
-// Set 向缓存中添加 key-value 对
-func (c *Cache) Set(key string, value ByteView) {
+// useMemoryLimiter 标记缓存注册到共享内存预算，存储不支持按字节淘汰时输出警告
+func (c *Cache) useMemoryLimiter() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.budgeted = true
+	if c.store != nil {
+		c.checkStoreSupport()
+	}
+}
+
+// checkStoreSupport 存储不支持已开启的共享内存预算或内存压力收缩时输出警告，这些选项对该存储不生效
+// 调用此方法必须持有写锁
+func (c *Cache) checkStoreSupport() {
+	if _, ok := c.store.(byteEvicter); !ok && c.budgeted {
+		logrus.Warnf("[G-Cache] cache type %s does not support byte eviction, memory limiter has no effect", c.opts.CacheType)
+	}
+	if _, ok := c.store.(maxBytesSetter); !ok && c.pressure != nil {
+		logrus.Warnf("[G-Cache] cache type %s does not support resizing, memory pressure has no effect", c.opts.CacheType)
+	}
+}
+
+// storeLocked 返回底层存储，缓存已关闭时返回 ErrCacheClosed，尚未初始化时返回 ErrCacheUninitialized
+// 调用此方法必须持有锁；Close 持有写锁释放存储，因此持锁期间的操作不会遇到关闭了一半的缓存
+func (c *Cache) storeLocked() (store.Store, error) {
+	if atomic.LoadInt32(&c.closed) == 1 {
+		return nil, ErrCacheClosed
+	}
+	if c.store == nil {
+		return nil, ErrCacheUninitialized
+	}
+	return c.store, nil
+}
+
+// Set 向缓存中添加 key-value 对，写入被底层存储拒绝时返回错误
+func (c *Cache) Set(key string, value ByteView) error {
+	value = c.own(value)
+	if c.opts.AccessLogger == nil {
+		return c.set(key, value)
+	}
+
+	start := time.Now()
+	err := c.set(key, value)
+	c.opts.AccessLogger.log("", "set", key, false, start, err)
+	return err
+}
+
+// own 开启 CopyOnSet 时返回值的拷贝，使缓存持有不可变的字节
+func (c *Cache) own(value ByteView) ByteView {
+	if !c.opts.CopyOnSet {
+		return value
+	}
+	return ByteView{b: cloneBytes(value.b)}
+}
+
+// set 向缓存中添加 key-value 对
+func (c *Cache) set(key string, value ByteView) error {
 	if atomic.LoadInt32(&c.closed) == 1 {
 		logrus.Warnf("Attempted to add to a closed cache: %s", key)
-		return
+		return c.setFailed(key, value, ErrCacheClosed)
 	}
 
-	c.ensureInitialized()
+	// 设置了默认过期时间时，不再写入永不过期的项
+	if c.opts.DefaultTTL > 0 {
+		return c.setWithExpiration(key, value, time.Now().Add(c.opts.DefaultTTL))
+	}
+
+	if err := c.ensureInitialized(); err != nil {
+		return c.setFailed(key, value, err)
+	}
+
+	c.mu.RLock()
+	s, err := c.storeLocked()
+	if err == nil {
+		if err = s.Set(key, value); err != nil {
+			logrus.Warnf("Failed to add key %s to cache: %v", key, err)
+		}
+	}
+	c.mu.RUnlock()
 
-	if err := c.store.Set(key, value); err != nil {
-		logrus.Warnf("Failed to add key %s to cache: %v", key, err)
+	if err != nil {
+		return c.setFailed(key, value, err)
 	}
+	return nil
 }
 
-// SetWithExpiration 向缓存中添加一个带过期时间的 key-value 对
-func (c *Cache) SetWithExpiration(key string, value ByteView, expirationTime time.Time) {
+// SetWithExpiration 向缓存中添加一个带过期时间的 key-value 对，已过期的值直接忽略
+func (c *Cache) SetWithExpiration(key string, value ByteView, expirationTime time.Time) error {
+	value = c.own(value)
+	if c.opts.AccessLogger == nil {
+		return c.setWithExpiration(key, value, expirationTime)
+	}
+
+	start := time.Now()
+	err := c.setWithExpiration(key, value, expirationTime)
+	c.opts.AccessLogger.log("", "set", key, false, start, err)
+	return err
+}
+
+// setWithExpiration 向缓存中添加一个带过期时间的 key-value 对
+func (c *Cache) setWithExpiration(key string, value ByteView, expirationTime time.Time) error {
 	if atomic.LoadInt32(&c.closed) == 1 {
 		logrus.Warnf("Attempted to add to a closed cache: %s", key)
-		return
+		return c.setFailed(key, value, ErrCacheClosed)
 	}
 
-	c.ensureInitialized()
+	if err := c.ensureInitialized(); err != nil {
+		return c.setFailed(key, value, err)
+	}
 
 	// 计算过期时间
 	ex := time.Until(expirationTime)
 	if ex <= 0 {
 		logrus.Debugf("Key %s already expired, not adding to cache", key)
-		return
+		return nil
 	}
 
 	// 设置到底层存储
-	if err := c.store.SetWithExpiration(key, value, ex); err != nil {
-		logrus.Warnf("Failed to add key %s to cache with expiration: %v", key, err)
+	c.mu.RLock()
+	s, err := c.storeLocked()
+	if err == nil {
+		if err = s.SetWithExpiration(key, value, ex); err != nil {
+			logrus.Warnf("Failed to add key %s to cache with expiration: %v", key, err)
+		}
+	}
+	c.mu.RUnlock()
+
+	if err != nil {
+		return c.setFailed(key, value, err)
 	}
+	return nil
 }
 
-// Get 从缓存中获取值
+// setFailed 调用写入失败回调，返回原错误
+func (c *Cache) setFailed(key string, value ByteView, err error) error {
+	if c.opts.OnSetError != nil {
+		c.opts.OnSetError(key, value, err)
+	}
+	return err
+}
+
+// Append 将 data 追加到键对应的值之后，键不存在时创建，返回追加后的总长度
+// 读取与写回在同一把锁内完成，并发追加不会丢失数据；expiration 为 0 时与 Set 相同
+func (c *Cache) Append(key string, data []byte, expiration time.Duration) (int, error) {
+	if atomic.LoadInt32(&c.closed) == 1 {
+		return 0, ErrCacheClosed
+	}
+
+	if err := c.ensureInitialized(); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, err := c.storeLocked()
+	if err != nil {
+		return 0, err
+	}
+
+	var old []byte
+	if val, ok := s.Get(key); ok {
+		bv, ok := val.(ByteView)
+		if !ok {
+			return 0, ErrValueType
+		}
+		old = bv.b
+	}
+
+	// ByteView 不可变，拼接到新的切片中
+	b := make([]byte, len(old)+len(data))
+	copy(b, old)
+	copy(b[len(old):], data)
+	view := ByteView{b: b}
+
+	if expiration <= 0 {
+		expiration = c.opts.DefaultTTL
+	}
+
+	if expiration > 0 {
+		err = s.SetWithExpiration(key, view, expiration)
+	} else {
+		err = s.Set(key, view)
+	}
+	if err != nil {
+		logrus.Warnf("Failed to append to key %s: %v", key, err)
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Get 从缓存中获取值，缓存已关闭或未初始化时视为未命中
 // TODO: Context使用
 func (c *Cache) Get(ctx context.Context, key string) (value ByteView, ok bool) {
-	if atomic.LoadInt32(&c.closed) == 1 {
-		return ByteView{}, false
+	value, ok, _ = c.GetE(ctx, key)
+	return value, ok
+}
+
+// GetE 从缓存中获取值，与 Get 不同，缓存已关闭时返回 ErrCacheClosed，
+// 尚未写入过数据（未初始化）时返回 ErrCacheUninitialized，便于发现误用
+func (c *Cache) GetE(ctx context.Context, key string) (ByteView, bool, error) {
+	if c.opts.AccessLogger == nil {
+		return c.get(key)
+	}
+
+	start := time.Now()
+	view, ok, err := c.get(key)
+	c.opts.AccessLogger.log("", "get", key, ok, start, err)
+	return view, ok, err
+}
+
+// GetOrLoad 从缓存中获取值，未命中时调用 loader 加载并写入缓存，同一个键同时只调用一次 loader，
+// 其他调用等待并共享结果；loader 返回的过期时间 <= 0 时与 Set 相同，使用 DefaultTTL
+// loader 返回错误时不写入缓存，所有等待的调用都返回该错误；写入失败时仍返回加载的值，错误交给 OnSetError
+func (c *Cache) GetOrLoad(ctx context.Context, key string, loader func() (ByteView, time.Duration, error)) (ByteView, error) {
+	if c.opts.AccessLogger == nil {
+		view, _, err := c.getOrLoad(key, loader)
+		return view, err
+	}
+
+	start := time.Now()
+	view, hit, err := c.getOrLoad(key, loader)
+	c.opts.AccessLogger.log("", "get", key, hit, start, err)
+	return view, err
+}
+
+// getOrLoad 从缓存中获取值，未命中时加载，hit 表示是否命中缓存
+func (c *Cache) getOrLoad(key string, loader func() (ByteView, time.Duration, error)) (ByteView, bool, error) {
+	view, ok, err := c.get(key)
+	if ok {
+		return view, true, nil
+	}
+	// 尚未初始化时由加载后的写入完成初始化
+	if err != nil && err != ErrCacheUninitialized {
+		return ByteView{}, false, err
+	}
+
+	v, err := c.loads.Do(key, func() (any, error) {
+		// 上一次加载可能在本次读取之后刚刚写入
+		if view, _, ok := c.lookup(key); ok {
+			return view, nil
+		}
+
+		view, expiration, err := loader()
+		if err != nil {
+			return nil, err
+		}
+		view = c.own(view)
+		if expiration > 0 {
+			c.setWithExpiration(key, view, time.Now().Add(expiration))
+		} else {
+			c.set(key, view)
+		}
+		return view, nil
+	})
+	if err != nil {
+		return ByteView{}, false, err
+	}
+	return v.(ByteView), false, nil
+}
+
+// get 从缓存中获取值
+func (c *Cache) get(key string) (ByteView, bool, error) {
+	if c.hotKeys != nil {
+		c.hotKeys.record(key)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, err := c.storeLocked()
+	if err != nil {
+		if err == ErrCacheUninitialized {
+			atomic.AddInt64(&c.misses, 1)
+		}
+		return ByteView{}, false, err
 	}
 
-	if atomic.LoadInt32(&c.initialized) == 0 {
+	val, found := s.Get(key)
+	if !found {
 		atomic.AddInt64(&c.misses, 1)
-		return ByteView{}, false
+		return ByteView{}, false, nil
+	}
+
+	atomic.AddInt64(&c.hits, 1)
+
+	if bv, ok := c.decode(val); ok {
+		return bv, ok, nil
+	}
+
+	logrus.Warnf("Type assertion failed for key %s, expected ByteView", key)
+	atomic.AddInt64(&c.misses, 1)
+	return ByteView{}, false, ErrValueType
+}
+
+// expiration 返回键的过期时间，永不过期、未缓存或存储不支持查询过期时间时返回 false
+func (c *Cache) expiration(key string) (time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, err := c.storeLocked()
+	if err != nil {
+		return time.Time{}, false
 	}
+	return storeExpiration(s, key)
+}
 
+// storeExpiration 查询存储中键的过期时间，存储不支持查询过期时间时返回 false
+func storeExpiration(s store.Store, key string) (time.Time, bool) {
+	g, ok := s.(interface {
+		GetExpiration(key string) (time.Time, bool)
+	})
+	if !ok {
+		return time.Time{}, false
+	}
+	return g.GetExpiration(key)
+}
+
+// GetWithVersion 从缓存中获取值及其版本号，配合 SetIfVersion 实现读取-修改-写入
+func (c *Cache) GetWithVersion(key string) (ByteView, uint64, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	val, found := c.store.Get(key)
+	s, err := c.storeLocked()
+	if err != nil {
+		return ByteView{}, 0, false
+	}
+
+	val, version, found := s.GetWithVersion(key)
 	if !found {
 		atomic.AddInt64(&c.misses, 1)
+		return ByteView{}, 0, false
+	}
+
+	bv, ok := c.decode(val)
+	if !ok {
+		atomic.AddInt64(&c.misses, 1)
+		return ByteView{}, 0, false
+	}
+	atomic.AddInt64(&c.hits, 1)
+	return bv, version, true
+}
+
+// GetAndTouch 获取缓存值，命中时原子地将过期时间重置为 now + newTTL，适用于会话等按访问续期的缓存
+// newTTL <= 0 时永不过期
+func (c *Cache) GetAndTouch(key string, newTTL time.Duration) (ByteView, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, err := c.storeLocked()
+	if err != nil {
 		return ByteView{}, false
 	}
 
+	val, found := s.GetAndTouch(key, newTTL)
+	if !found {
+		atomic.AddInt64(&c.misses, 1)
+		return ByteView{}, false
+	}
+
+	bv, ok := c.decode(val)
+	if !ok {
+		atomic.AddInt64(&c.misses, 1)
+		return ByteView{}, false
+	}
 	atomic.AddInt64(&c.hits, 1)
+	return bv, true
+}
 
+// decode 将存储中的值转换为 ByteView，不是 ByteView 时交给 ValueDecoder 转换
+func (c *Cache) decode(val store.Value) (ByteView, bool) {
 	if bv, ok := val.(ByteView); ok {
-		return bv, ok
+		return bv, true
+	}
+	if c.opts.ValueDecoder == nil {
+		return ByteView{}, false
 	}
+	return c.opts.ValueDecoder(val)
+}
 
-	logrus.Warnf("Type assertion failed for key %s, expected ByteView", key)
-	atomic.AddInt64(&c.misses, 1)
-	return ByteView{}, false
+// SetIfVersion 键的当前版本号等于 expectedVersion 时写入并返回 true，否则返回 false
+// 键不存在时版本号为 0，传入 0 表示仅在键不存在时写入；写入成功后版本号更新
+func (c *Cache) SetIfVersion(key string, value ByteView, expectedVersion uint64) (bool, error) {
+	return c.setIfVersion(key, c.own(value), expectedVersion, 0)
+}
+
+// setIfVersion 按版本号条件写入，写入成功后在 ttl 后过期，ttl <= 0 时使用 DefaultTTL
+func (c *Cache) setIfVersion(key string, value ByteView, expectedVersion uint64, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		ttl = c.opts.DefaultTTL
+	}
+	if atomic.LoadInt32(&c.closed) == 1 {
+		return false, c.setFailed(key, value, ErrCacheClosed)
+	}
+
+	if err := c.ensureInitialized(); err != nil {
+		return false, c.setFailed(key, value, err)
+	}
+
+	c.mu.RLock()
+	var ok bool
+	s, err := c.storeLocked()
+	if err == nil {
+		if ok, err = s.SetIfVersion(key, value, expectedVersion, ttl); err != nil {
+			logrus.Warnf("Failed to add key %s to cache with version %d: %v", key, expectedVersion, err)
+		}
+	}
+	c.mu.RUnlock()
+
+	if err != nil {
+		return false, c.setFailed(key, value, err)
+	}
+	return ok, nil
+}
+
+// SetNX 键不存在或已过期时写入并在 expirationTime 过期，返回是否写入
+// expirationTime 已过时不写入并返回 false
+func (c *Cache) SetNX(key string, value ByteView, expirationTime time.Time) (bool, error) {
+	value = c.own(value)
+	if atomic.LoadInt32(&c.closed) == 1 {
+		return false, c.setFailed(key, value, ErrCacheClosed)
+	}
+	if err := c.ensureInitialized(); err != nil {
+		return false, c.setFailed(key, value, err)
+	}
+
+	ttl := time.Until(expirationTime)
+	if ttl <= 0 {
+		return false, nil
+	}
+
+	c.mu.RLock()
+	var ok bool
+	s, err := c.storeLocked()
+	if err == nil {
+		ok, err = s.SetIfVersion(key, value, 0, ttl)
+	}
+	c.mu.RUnlock()
+
+	if err != nil {
+		return false, c.setFailed(key, value, err)
+	}
+	return ok, nil
+}
+
+// DeleteIfValue 键的当前值等于 value 时删除并返回 true，比较和删除之间值被修改时重新比较
+func (c *Cache) DeleteIfValue(key string, value []byte) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, err := c.storeLocked()
+	if err != nil {
+		return false
+	}
+
+	for {
+		current, version, ok := s.GetWithVersion(key)
+		if !ok {
+			return false
+		}
+		bv, ok := current.(ByteView)
+		if !ok || !bytes.Equal(bv.b, value) {
+			return false
+		}
+		if deleted, _ := s.SetIfVersion(key, nil, version, 0); deleted {
+			return true
+		}
+	}
+}
+
+// Expire 修改键的过期时间，不改变缓存值，键不存在或已过期时返回 false
+// expirationTime 已过时删除该键
+func (c *Cache) Expire(key string, expirationTime time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, err := c.storeLocked()
+	if err != nil {
+		return false
+	}
+
+	for {
+		current, version, ok := s.GetWithVersion(key)
+		if !ok {
+			return false
+		}
+		ttl := time.Until(expirationTime)
+		if ttl <= 0 {
+			current = nil
+		}
+		if done, err := s.SetIfVersion(key, current, version, ttl); err != nil {
+			return false
+		} else if done {
+			return true
+		}
+	}
+}
+
+// RangeGet 获取缓存值中 [offset, offset+length) 区间的数据，区间超出范围时截断到边界
+// 只拷贝请求的区间，适用于大对象的部分读取（如 HTTP Range 请求）
+func (c *Cache) RangeGet(ctx context.Context, key string, offset, length int64) ([]byte, bool) {
+	view, ok := c.Get(ctx, key)
+	if !ok {
+		return nil, false
+	}
+
+	return view.Slice(offset, length), true
+}
+
+// Scan 分页遍历缓存中的键，cursor 为 0 时从头开始，返回的游标为 0 时遍历结束
+// 与 Snapshot 不同，每页之间不持有锁，适用于大缓存的遍历
+func (c *Cache) Scan(cursor uint64, count int) ([]string, uint64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, err := c.storeLocked()
+	if err != nil {
+		return nil, 0
+	}
+	return s.Scan(cursor, count)
+}
+
+// Snapshot 创建一个相同配置的新缓存，并写入当前所有未过期的项
+// 这是某一时刻的拷贝而非实时镜像，之后对任一缓存的修改不会影响另一个
+func (c *Cache) Snapshot() *Cache {
+	dst := NewCache(c.opts)
+	c.CopyTo(dst)
+	return dst
+}
+
+// snapshotEntry 某一时刻缓存中的一项，expireAt 为零值表示永不过期
+type snapshotEntry struct {
+	key      string
+	value    ByteView
+	expireAt time.Time
+}
+
+// snapshot 持锁收集当前所有未过期的项，之后的写入不再持有锁
+func (c *Cache) snapshot() ([]snapshotEntry, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, err := c.storeLocked()
+	if err == ErrCacheUninitialized {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []snapshotEntry
+	s.ForEach(func(key string, value store.Value, expireAt time.Time) bool {
+		if bv, ok := value.(ByteView); ok {
+			entries = append(entries, snapshotEntry{key, bv, expireAt})
+		}
+		return true
+	})
+	return entries, nil
+}
+
+// CopyTo 将当前所有未过期的项连同剩余过期时间写入 dst，返回写入失败的错误
+func (c *Cache) CopyTo(dst *Cache) error {
+	entries, err := c.snapshot()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, e := range entries {
+		var err error
+		if e.expireAt.IsZero() {
+			err = dst.Set(e.key, e.value)
+		} else {
+			err = dst.SetWithExpiration(e.key, e.value, e.expireAt)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("copy key %s: %w", e.key, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // Delete 从缓存中删除一个 key
 func (c *Cache) Delete(key string) bool {
-	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+	if c.opts.AccessLogger == nil {
+		return c.remove(key)
+	}
+
+	start := time.Now()
+	deleted := c.remove(key)
+	c.opts.AccessLogger.log("", "delete", key, deleted, start, nil)
+	return deleted
+}
+
+// remove 从缓存中删除一个 key
+func (c *Cache) remove(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, err := c.storeLocked()
+	if err != nil {
 		return false
 	}
+	return s.Delete(key)
+}
 
+// Rename 将 oldKey 的值和过期时间原子地移动到 newKey，覆盖 newKey 原有的值
+// oldKey 不存在或已过期时返回 false，newKey 保持不变；可用于将预先写入的临时键发布为正式键
+func (c *Cache) Rename(oldKey, newKey string) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	return c.store.Delete(key)
+	s, err := c.storeLocked()
+	if err != nil {
+		return false
+	}
+	return s.Rename(oldKey, newKey)
 }
 
 // Clear 清空缓存
 func (c *Cache) Clear() {
-	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
-		return
-	}
-
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.store.Clear()
+	s, err := c.storeLocked()
+	if err != nil {
+		return
+	}
+	s.Clear()
 
 	// 重置统计信息
 	atomic.StoreInt64(&c.hits, 0)
@@ -179,22 +811,65 @@ func (c *Cache) Clear() {
 
 // Len 返回缓存的当前存储项数量
 func (c *Cache) Len() int {
-	if atomic.LoadInt32(&c.closed) == 1 || atomic.LoadInt32(&c.initialized) == 0 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, err := c.storeLocked()
+	if err != nil {
 		return 0
 	}
+	return s.Len()
+}
+
+// byteEvicter 支持按字节统计和淘汰的存储
+type byteEvicter interface {
+	UsedBytes() int64
+	EvictBytes(n int64) int64
+}
 
+// usedBytes 返回底层存储占用的字节数，存储不支持统计时返回 0
+func (c *Cache) usedBytes() int64 {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return c.store.Len()
+	s, err := c.storeLocked()
+	if err != nil {
+		return 0
+	}
+	if e, ok := s.(byteEvicter); ok {
+		return e.UsedBytes()
+	}
+	return 0
+}
+
+// evictBytes 按最久未使用顺序淘汰至少 n 字节，返回实际释放的字节数
+func (c *Cache) evictBytes(n int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, err := c.storeLocked()
+	if err != nil {
+		return 0
+	}
+	if e, ok := s.(byteEvicter); ok {
+		return e.EvictBytes(n)
+	}
+	return 0
 }
 
 // Close 关闭缓存，释放资源
+// 先标记为已关闭使新的操作返回 ErrCacheClosed，再等待持有锁的操作完成后释放底层存储
 func (c *Cache) Close() {
 	// 如果已关闭，返回；如果未关闭，改为已关闭
 	if !atomic.CompareAndSwapInt32(&c.closed, 0, 1) {
 		return
 	}
+	if c.evictions != nil {
+		c.evictions.stop()
+	}
+	if c.pressure != nil {
+		c.pressure.stop()
+	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -206,6 +881,9 @@ func (c *Cache) Close() {
 		}
 		c.store = nil
 	}
+	if c.evictions != nil {
+		c.evictions.close()
+	}
 
 	// 重置缓存状态
 	atomic.StoreInt32(&c.initialized, 0)
@@ -232,6 +910,32 @@ func (c *Cache) Stats() map[string]any {
 		} else {
 			stats["hit_rate"] = 0.0
 		}
+
+		// 存储层的访问、淘汰和过期统计
+		c.mu.RLock()
+		if s, err := c.storeLocked(); err == nil {
+			storeStats := s.Stats()
+			stats["store_hits"] = storeStats.Hits
+			stats["store_misses"] = storeStats.Misses
+			stats["evictions"] = storeStats.Evictions
+			stats["expirations"] = storeStats.Expirations
+		}
+
+		// 定期清理统计
+		if cs, ok := c.store.(interface{ CleanupStats() store.CleanupStats }); ok {
+			cleanup := cs.CleanupStats()
+			stats["cleanup_sweeps"] = cleanup.Sweeps
+			stats["cleanup_last_reaped"] = cleanup.LastReaped
+			stats["cleanup_last_examined"] = cleanup.LastExamined
+			stats["cleanup_last_duration"] = cleanup.LastDuration
+		}
+		c.mu.RUnlock()
+	}
+	if c.evictions != nil {
+		stats["evictions_dropped"] = c.DroppedEvictions()
+	}
+	if c.pressure != nil {
+		stats["memory_pressure"] = c.pressure.underPressure()
 	}
 
 	return stats
@@ -0,0 +1,821 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lyy42995004/Cache-Go/store"
+	_ "github.com/lyy42995004/Cache-Go/store/boltstore"
+)
+
+// 测试 RangeGet 方法
+func TestCacheRangeGet(t *testing.T) {
+	c := NewCache(DefaultCacheOptions())
+	defer c.Close()
+
+	ctx := context.Background()
+	c.Set("blob", ByteView{b: []byte("0123456789")})
+
+	tests := []struct {
+		name           string
+		offset, length int64
+		want           string
+	}{
+		{"区间内", 2, 3, "234"},
+		{"读取到末尾", 7, -1, "789"},
+		{"部分越界", 8, 10, "89"},
+		{"负偏移", -5, 3, "012"},
+		{"完全越界", 20, 5, ""},
+		{"长度溢出", 2, math.MaxInt64, "23456789"},
+		{"偏移和长度都很大", math.MaxInt64, math.MaxInt64, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := c.RangeGet(ctx, "blob", tt.offset, tt.length)
+			if !ok {
+				t.Fatalf("Expected key to be found")
+			}
+			if string(got) != tt.want {
+				t.Fatalf("RangeGet(%d, %d) = %q, want %q", tt.offset, tt.length, got, tt.want)
+			}
+		})
+	}
+
+	// 键不存在
+	if _, ok := c.RangeGet(ctx, "missing", 0, 1); ok {
+		t.Fatalf("Expected RangeGet to miss for absent key")
+	}
+
+	// 修改返回的切片不影响缓存
+	got, _ := c.RangeGet(ctx, "blob", 0, 3)
+	got[0] = 'x'
+	if view, _ := c.Get(ctx, "blob"); view.String() != "0123456789" {
+		t.Fatalf("Cached value was mutated through RangeGet result: %s", view.String())
+	}
+}
+
+// 测试写入被拒绝时错误返回给调用方
+func TestCacheSetRejected(t *testing.T) {
+	opts := DefaultCacheOptions()
+	opts.CacheType = store.LRU
+	opts.MaxBytes = 16
+	c := NewCache(opts)
+
+	ctx := context.Background()
+	if err := c.Set("small", ByteView{b: []byte("value")}); err != nil {
+		t.Fatalf("Set failed for small value: %v", err)
+	}
+
+	// 超过总容量的值被拒绝
+	big := ByteView{b: make([]byte, 32)}
+	if err := c.Set("big", big); !errors.Is(err, store.ErrValueTooLarge) {
+		t.Fatalf("Expected ErrValueTooLarge, got %v", err)
+	}
+	if err := c.SetWithExpiration("big", big, time.Now().Add(time.Minute)); !errors.Is(err, store.ErrValueTooLarge) {
+		t.Fatalf("Expected ErrValueTooLarge with expiration, got %v", err)
+	}
+	if _, ok := c.Get(ctx, "big"); ok {
+		t.Fatalf("Expected rejected value to be absent")
+	}
+	if _, ok := c.Get(ctx, "small"); !ok {
+		t.Fatalf("Expected existing value to survive a rejected write")
+	}
+
+	// 已过期的值直接忽略，不视为错误
+	if err := c.SetWithExpiration("stale", ByteView{b: []byte("v")}, time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("Expected nil error for already-expired value, got %v", err)
+	}
+
+	c.Close()
+	if err := c.Set("small", ByteView{b: []byte("value")}); err != ErrCacheClosed {
+		t.Fatalf("Expected ErrCacheClosed, got %v", err)
+	}
+}
+
+// 测试快照复制当前未过期的项
+func TestCacheSnapshot(t *testing.T) {
+	for _, cacheType := range []store.CacheType{store.LRU, store.LRU2} {
+		t.Run(string(cacheType), func(t *testing.T) {
+			opts := DefaultCacheOptions()
+			opts.CacheType = cacheType
+			src := NewCache(opts)
+			defer src.Close()
+
+			ctx := context.Background()
+			expireAt := time.Now().Add(time.Hour)
+			src.Set("forever", ByteView{b: []byte("v1")})
+			src.SetWithExpiration("ttl", ByteView{b: []byte("v2")}, expireAt)
+
+			clone := src.Snapshot()
+			defer clone.Close()
+
+			for key, want := range map[string]string{"forever": "v1", "ttl": "v2"} {
+				view, ok := clone.Get(ctx, key)
+				if !ok || view.String() != want {
+					t.Fatalf("Expected clone to have %s=%s, got %q, %v", key, want, view.String(), ok)
+				}
+			}
+			if clone.Len() != 2 {
+				t.Fatalf("Expected 2 items in clone, got %d", clone.Len())
+			}
+
+			// 剩余过期时间被保留
+			var cloneExpireAt time.Time
+			clone.store.ForEach(func(key string, value store.Value, exp time.Time) bool {
+				if key == "ttl" {
+					cloneExpireAt = exp
+				}
+				return true
+			})
+			if diff := cloneExpireAt.Sub(expireAt); diff < -time.Second || diff > time.Second {
+				t.Fatalf("Expected clone TTL near %v, got %v", expireAt, cloneExpireAt)
+			}
+
+			// 之后修改源缓存不影响快照
+			src.Set("forever", ByteView{b: []byte("changed")})
+			src.Delete("ttl")
+			src.Set("new", ByteView{b: []byte("v3")})
+
+			if view, _ := clone.Get(ctx, "forever"); view.String() != "v1" {
+				t.Errorf("Expected clone to keep v1, got %q", view.String())
+			}
+			if _, ok := clone.Get(ctx, "ttl"); !ok {
+				t.Errorf("Expected clone to keep ttl after source delete")
+			}
+			if _, ok := clone.Get(ctx, "new"); ok {
+				t.Errorf("Expected clone not to see keys added later")
+			}
+		})
+	}
+}
+
+// 测试复制时跳过已过期的项
+func TestCacheCopyToSkipsExpired(t *testing.T) {
+	opts := DefaultCacheOptions()
+	opts.CacheType = store.LRU
+	src := NewCache(opts)
+	defer src.Close()
+
+	src.SetWithExpiration("short", ByteView{b: []byte("v")}, time.Now().Add(5*time.Millisecond))
+	src.Set("keep", ByteView{b: []byte("v")})
+	time.Sleep(10 * time.Millisecond)
+
+	dst := NewCache(opts)
+	defer dst.Close()
+	if err := src.CopyTo(dst); err != nil {
+		t.Fatalf("CopyTo failed: %v", err)
+	}
+	if dst.Len() != 1 {
+		t.Fatalf("Expected only the live item to be copied, got %d items", dst.Len())
+	}
+	if _, ok := dst.Get(context.Background(), "short"); ok {
+		t.Errorf("Expected expired item to be skipped")
+	}
+}
+
+// 测试默认过期时间
+func TestCacheDefaultTTL(t *testing.T) {
+	opts := DefaultCacheOptions()
+	opts.CacheType = store.LRU
+	opts.DefaultTTL = time.Minute
+	c := NewCache(opts)
+	defer c.Close()
+
+	expirations := func() map[string]time.Time {
+		result := make(map[string]time.Time)
+		c.store.ForEach(func(key string, value store.Value, expireAt time.Time) bool {
+			result[key] = expireAt
+			return true
+		})
+		return result
+	}
+
+	before := time.Now()
+	c.Set("default", ByteView{b: []byte("v")})
+	explicit := time.Now().Add(time.Hour)
+	c.SetWithExpiration("explicit", ByteView{b: []byte("v")}, explicit)
+
+	got := expirations()
+	// Set 使用默认过期时间
+	if exp := got["default"]; exp.Before(before.Add(time.Minute)) || exp.After(time.Now().Add(time.Minute)) {
+		t.Errorf("Expected default TTL of 1m, got expiration %v", exp)
+	}
+	// 显式过期时间优先
+	if diff := got["explicit"].Sub(explicit); diff < -time.Second || diff > time.Second {
+		t.Errorf("Expected explicit expiration %v, got %v", explicit, got["explicit"])
+	}
+
+	// 默认过期时间为 0 时保持永不过期
+	opts.DefaultTTL = 0
+	noTTL := NewCache(opts)
+	defer noTTL.Close()
+	noTTL.Set("forever", ByteView{b: []byte("v")})
+	noTTL.store.ForEach(func(key string, value store.Value, expireAt time.Time) bool {
+		if !expireAt.IsZero() {
+			t.Errorf("Expected no expiration without DefaultTTL, got %v", expireAt)
+		}
+		return true
+	})
+}
+
+// 测试并发追加
+func TestCacheAppend(t *testing.T) {
+	for _, cacheType := range []store.CacheType{store.LRU, store.LRU2} {
+		t.Run(string(cacheType), func(t *testing.T) {
+			opts := DefaultCacheOptions()
+			opts.CacheType = cacheType
+			c := NewCache(opts)
+			defer c.Close()
+
+			const goroutines, perGoroutine = 20, 50
+			var wg sync.WaitGroup
+			for g := range goroutines {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					for i := range perGoroutine {
+						if _, err := c.Append("log", fmt.Appendf(nil, "%02d-%02d;", g, i), 0); err != nil {
+							t.Errorf("Append failed: %v", err)
+						}
+					}
+				}(g)
+			}
+			wg.Wait()
+
+			view, ok := c.Get(context.Background(), "log")
+			if !ok {
+				t.Fatalf("Expected appended key to exist")
+			}
+			entries := strings.Split(strings.TrimSuffix(view.String(), ";"), ";")
+			if len(entries) != goroutines*perGoroutine {
+				t.Fatalf("Expected %d entries, got %d", goroutines*perGoroutine, len(entries))
+			}
+			seen := make(map[string]bool)
+			for _, e := range entries {
+				seen[e] = true
+			}
+			for g := range goroutines {
+				for i := range perGoroutine {
+					if !seen[fmt.Sprintf("%02d-%02d", g, i)] {
+						t.Fatalf("Missing entry %02d-%02d", g, i)
+					}
+				}
+			}
+
+			// 返回追加后的总长度
+			n, err := c.Append("log", []byte("x"), 0)
+			if err != nil || n != view.Len()+1 {
+				t.Fatalf("Expected new length %d, got %d, %v", view.Len()+1, n, err)
+			}
+		})
+	}
+}
+
+// 测试追加到非 ByteView 值时报错
+func TestCacheAppendTypeMismatch(t *testing.T) {
+	c := NewCache(DefaultCacheOptions())
+	defer c.Close()
+
+	c.ensureInitialized()
+	c.store.Set("other", otherValue("value"))
+
+	if _, err := c.Append("other", []byte("data"), 0); err != ErrValueType {
+		t.Fatalf("Expected ErrValueType, got %v", err)
+	}
+
+	// 键不存在时创建
+	if n, err := c.Append("new", []byte("abc"), time.Minute); err != nil || n != 3 {
+		t.Fatalf("Expected new key with length 3, got %d, %v", n, err)
+	}
+}
+
+// otherValue 非 ByteView 的缓存值
+type otherValue string
+
+func (v otherValue) Len() int {
+	return len(v)
+}
+
+// failingStore 写入总是失败的存储
+type failingStore struct {
+	store.Store
+	err error
+}
+
+func (s *failingStore) Set(key string, value store.Value) error {
+	return s.err
+}
+
+func (s *failingStore) SetWithExpiration(key string, value store.Value, expiration time.Duration) error {
+	return s.err
+}
+
+// 测试写入失败时调用 OnSetError 回调
+func TestCacheOnSetError(t *testing.T) {
+	type failure struct {
+		key   string
+		value string
+		err   error
+	}
+	var failures []failure
+
+	opts := DefaultCacheOptions()
+	opts.OnSetError = func(key string, value ByteView, err error) {
+		failures = append(failures, failure{key, value.String(), err})
+	}
+	c := NewCache(opts)
+	c.ensureInitialized()
+
+	storeErr := errors.New("disk full")
+	inner := c.store
+	c.store = &failingStore{Store: inner, err: storeErr}
+
+	if err := c.Set("a", ByteView{b: []byte("1")}); err != storeErr {
+		t.Fatalf("Expected store error from Set, got %v", err)
+	}
+	if err := c.SetWithExpiration("b", ByteView{b: []byte("2")}, time.Now().Add(time.Minute)); err != storeErr {
+		t.Fatalf("Expected store error from SetWithExpiration, got %v", err)
+	}
+	// 已过期的值不视为失败
+	c.SetWithExpiration("stale", ByteView{b: []byte("3")}, time.Now().Add(-time.Second))
+
+	want := []failure{{"a", "1", storeErr}, {"b", "2", storeErr}}
+	if len(failures) != len(want) {
+		t.Fatalf("Expected %d hook calls, got %d: %v", len(want), len(failures), failures)
+	}
+	for i, f := range want {
+		if failures[i] != f {
+			t.Errorf("Hook call %d = %v, want %v", i, failures[i], f)
+		}
+	}
+
+	// 关闭后的写入同样通知
+	c.store = inner
+	c.Close()
+	failures = nil
+	c.Set("c", ByteView{b: []byte("4")})
+	if len(failures) != 1 || failures[0].key != "c" || failures[0].err != ErrCacheClosed {
+		t.Fatalf("Expected hook call with ErrCacheClosed, got %v", failures)
+	}
+}
+
+// point 实现二进制序列化的测试类型
+type point struct {
+	X, Y int32
+}
+
+func (p point) MarshalBinary() ([]byte, error) {
+	if p.X < 0 {
+		return nil, errors.New("negative coordinate")
+	}
+	return binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, uint32(p.X)), uint32(p.Y)), nil
+}
+
+func (p *point) UnmarshalBinary(data []byte) error {
+	if len(data) != 8 {
+		return fmt.Errorf("invalid point length %d", len(data))
+	}
+	p.X = int32(binary.BigEndian.Uint32(data))
+	p.Y = int32(binary.BigEndian.Uint32(data[4:]))
+	return nil
+}
+
+// 测试结构化数据经 NewByteView 和 Into 在缓存中往返
+func TestByteViewMarshalRoundTrip(t *testing.T) {
+	c := NewCache(DefaultCacheOptions())
+	defer c.Close()
+
+	view, err := NewByteView(point{X: 3, Y: -7})
+	if err != nil {
+		t.Fatalf("NewByteView failed: %v", err)
+	}
+	if err := c.Set("p", view); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	cached, ok := c.Get(context.Background(), "p")
+	if !ok {
+		t.Fatalf("Expected key to be found")
+	}
+	var got point
+	if err := cached.Into(&got); err != nil {
+		t.Fatalf("Into failed: %v", err)
+	}
+	if got != (point{X: 3, Y: -7}) {
+		t.Fatalf("Expected {3 -7}, got %+v", got)
+	}
+
+	// 序列化和反序列化的错误返回给调用方
+	if _, err := NewByteView(point{X: -1}); err == nil {
+		t.Fatalf("Expected marshal error")
+	}
+	if err := (ByteView{b: []byte("short")}).Into(&got); err == nil {
+		t.Fatalf("Expected unmarshal error")
+	}
+}
+
+// 测试缓存类型无效时写入返回错误
+func TestCacheUnknownType(t *testing.T) {
+	opts := DefaultCacheOptions()
+	opts.CacheType = "lfu"
+	c := NewCache(opts)
+	defer c.Close()
+
+	if err := c.Set("key", ByteView{b: []byte("value")}); !errors.Is(err, store.ErrUnknownCacheType) {
+		t.Fatalf("Expected ErrUnknownCacheType from Set, got %v", err)
+	}
+	if err := c.SetWithExpiration("key", ByteView{b: []byte("value")}, time.Now().Add(time.Minute)); !errors.Is(err, store.ErrUnknownCacheType) {
+		t.Fatalf("Expected ErrUnknownCacheType from SetWithExpiration, got %v", err)
+	}
+	if _, err := c.Append("key", []byte("value"), 0); !errors.Is(err, store.ErrUnknownCacheType) {
+		t.Fatalf("Expected ErrUnknownCacheType from Append, got %v", err)
+	}
+	if _, ok := c.Get(context.Background(), "key"); ok {
+		t.Fatalf("Expected miss from uninitialized cache")
+	}
+}
+
+// 测试 GetE 区分未命中、未初始化和已关闭
+func TestCacheGetE(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache(DefaultCacheOptions())
+
+	if _, ok, err := c.GetE(ctx, "key"); ok || err != ErrCacheUninitialized {
+		t.Fatalf("Expected ErrCacheUninitialized before first write, got %v, %v", ok, err)
+	}
+	// Get 保持原有行为
+	if _, ok := c.Get(ctx, "key"); ok {
+		t.Fatalf("Expected Get to miss before first write")
+	}
+
+	c.Set("key", ByteView{b: []byte("value")})
+	if view, ok, err := c.GetE(ctx, "key"); !ok || err != nil || view.String() != "value" {
+		t.Fatalf("Expected hit, got %q, %v, %v", view.String(), ok, err)
+	}
+	if _, ok, err := c.GetE(ctx, "missing"); ok || err != nil {
+		t.Fatalf("Expected plain miss without error, got %v, %v", ok, err)
+	}
+
+	c.Close()
+	if _, ok, err := c.GetE(ctx, "key"); ok || err != ErrCacheClosed {
+		t.Fatalf("Expected ErrCacheClosed after Close, got %v, %v", ok, err)
+	}
+	if _, ok := c.Get(ctx, "key"); ok {
+		t.Fatalf("Expected Get to miss after Close")
+	}
+}
+
+// 测试 Stats 包含存储层的淘汰和过期统计
+func TestCacheStoreStats(t *testing.T) {
+	opts := DefaultCacheOptions()
+	opts.CacheType = store.LRU
+	opts.MaxBytes = 6
+	c := NewCache(opts)
+	defer c.Close()
+
+	ctx := context.Background()
+	// 每项 2 字节，写入 d 时淘汰 a
+	for _, key := range []string{"a", "b", "c", "d"} {
+		c.Set(key, ByteView{b: []byte("v")})
+	}
+	c.Get(ctx, "b")
+	c.Get(ctx, "a")
+
+	stats := c.Stats()
+	if stats["hits"].(int64) != 1 || stats["misses"].(int64) != 1 {
+		t.Fatalf("Expected 1 hit and 1 miss, got %v", stats)
+	}
+	if stats["store_hits"].(int64) != 1 || stats["store_misses"].(int64) != 1 {
+		t.Fatalf("Expected store counters to match, got %v", stats)
+	}
+	if stats["evictions"].(int64) != 1 || stats["expirations"].(int64) != 0 {
+		t.Fatalf("Expected 1 eviction and no expirations, got %v", stats)
+	}
+}
+
+// 测试 100 个协程同时读取未缓存的键时 loader 只调用一次，加载失败时不写入缓存
+func TestCacheGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache(DefaultCacheOptions())
+	defer c.Close()
+
+	var loads int32
+	loader := func() (ByteView, time.Duration, error) {
+		atomic.AddInt32(&loads, 1)
+		time.Sleep(50 * time.Millisecond)
+		return ByteView{b: []byte("value")}, time.Hour, nil
+	}
+
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if view, err := c.GetOrLoad(ctx, "key", loader); err != nil || view.String() != "value" {
+				t.Errorf("Expected loaded value, got %q, %v", view.String(), err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Fatalf("Expected loader to run once, ran %d times", n)
+	}
+	if expireAt, ok := c.expiration("key"); !ok || time.Until(expireAt) <= 0 {
+		t.Fatalf("Expected the returned TTL to be applied, got %v %v", expireAt, ok)
+	}
+
+	errLoad := errors.New("load failed")
+	if _, err := c.GetOrLoad(ctx, "bad", func() (ByteView, time.Duration, error) {
+		return ByteView{}, 0, errLoad
+	}); !errors.Is(err, errLoad) {
+		t.Fatalf("Expected loader error, got %v", err)
+	}
+	if _, ok := c.Get(ctx, "bad"); ok {
+		t.Fatalf("Expected failed load not to populate the cache")
+	}
+
+	c.Close()
+	if _, err := c.GetOrLoad(ctx, "key", loader); err != ErrCacheClosed {
+		t.Fatalf("Expected ErrCacheClosed after Close, got %v", err)
+	}
+}
+
+// 测试两个写入方竞争同一版本时只有一个成功
+func TestCacheSetIfVersionRace(t *testing.T) {
+	for _, cacheType := range []store.CacheType{store.LRU, store.LRU2} {
+		t.Run(string(cacheType), func(t *testing.T) {
+			opts := DefaultCacheOptions()
+			opts.CacheType = cacheType
+			c := NewCache(opts)
+			defer c.Close()
+
+			c.Set("counter", ByteView{b: []byte("0")})
+
+			for round := range 200 {
+				_, version, ok := c.GetWithVersion("counter")
+				if !ok {
+					t.Fatalf("Expected counter to exist")
+				}
+
+				var wg sync.WaitGroup
+				var successes int32
+				for w := range 2 {
+					wg.Add(1)
+					go func(w int) {
+						defer wg.Done()
+						ok, err := c.SetIfVersion("counter", ByteView{b: fmt.Appendf(nil, "%d-%d", round, w)}, version)
+						if err != nil {
+							t.Errorf("SetIfVersion failed: %v", err)
+						}
+						if ok {
+							atomic.AddInt32(&successes, 1)
+						}
+					}(w)
+				}
+				wg.Wait()
+
+				if successes != 1 {
+					t.Fatalf("Round %d: expected exactly one CAS to succeed, got %d", round, successes)
+				}
+			}
+		})
+	}
+}
+
+// 测试关闭与进行中的读写并发时不会 panic，关闭后的操作统一返回 ErrCacheClosed
+func TestCacheConcurrentClose(t *testing.T) {
+	for _, cacheType := range []store.CacheType{store.LRU, store.LRU2} {
+		t.Run(string(cacheType), func(t *testing.T) {
+			for range 20 {
+				opts := DefaultCacheOptions()
+				opts.CacheType = cacheType
+				c := NewCache(opts)
+
+				var wg sync.WaitGroup
+				stop := make(chan struct{})
+				for w := range 4 {
+					wg.Add(1)
+					go func(w int) {
+						defer wg.Done()
+						for i := 0; ; i++ {
+							select {
+							case <-stop:
+								return
+							default:
+							}
+							key := fmt.Sprintf("key-%d-%d", w, i%32)
+							if err := c.Set(key, ByteView{b: []byte("v")}); err != nil && !errors.Is(err, ErrCacheClosed) {
+								t.Errorf("Unexpected Set error: %v", err)
+							}
+							if _, _, err := c.GetE(context.Background(), key); err != nil &&
+								!errors.Is(err, ErrCacheClosed) && !errors.Is(err, ErrCacheUninitialized) {
+								t.Errorf("Unexpected GetE error: %v", err)
+							}
+							c.Delete(key)
+							c.Len()
+						}
+					}(w)
+				}
+
+				time.Sleep(time.Millisecond)
+				c.Close()
+				close(stop)
+				wg.Wait()
+
+				if err := c.Set("after", ByteView{b: []byte("v")}); !errors.Is(err, ErrCacheClosed) {
+					t.Fatalf("Expected ErrCacheClosed from Set after Close, got %v", err)
+				}
+				if _, _, err := c.GetE(context.Background(), "after"); !errors.Is(err, ErrCacheClosed) {
+					t.Fatalf("Expected ErrCacheClosed from GetE after Close, got %v", err)
+				}
+				if c.Len() != 0 {
+					t.Fatalf("Expected Len 0 after Close, got %d", c.Len())
+				}
+				c.Close()
+			}
+		})
+	}
+}
+
+// reusedBuffer MarshalBinary 直接返回内部缓冲区，模拟复用缓冲区的调用方
+type reusedBuffer struct {
+	buf []byte
+}
+
+func (r *reusedBuffer) MarshalBinary() ([]byte, error) {
+	return r.buf, nil
+}
+
+// 测试开启 CopyOnSet 后，调用方修改缓冲区不影响缓存中的值
+func TestCacheCopyOnSet(t *testing.T) {
+	for _, copyOnSet := range []bool{false, true} {
+		t.Run(fmt.Sprintf("CopyOnSet=%v", copyOnSet), func(t *testing.T) {
+			opts := DefaultCacheOptions()
+			opts.CopyOnSet = copyOnSet
+			c := NewCache(opts)
+			defer c.Close()
+
+			buf := []byte("hello")
+			c.Set("set", ByteView{b: buf})
+			c.SetWithExpiration("ttl", ByteView{b: buf}, time.Now().Add(time.Hour))
+			c.SetIfVersion("cas", ByteView{b: buf}, 0)
+
+			r := &reusedBuffer{buf: []byte("hello")}
+			view, err := NewByteView(r)
+			if err != nil {
+				t.Fatalf("NewByteView failed: %v", err)
+			}
+			c.Set("marshaled", view)
+
+			copy(buf, "jello")
+			copy(r.buf, "jello")
+
+			for _, key := range []string{"set", "ttl", "cas", "marshaled"} {
+				got, ok := c.Get(context.Background(), key)
+				if !ok {
+					t.Fatalf("Expected %s to be cached", key)
+				}
+				want := "hello"
+				if !copyOnSet {
+					// 不拷贝时缓存与调用方共享底层数组
+					want = "jello"
+				}
+				if got.String() != want {
+					t.Fatalf("Key %s: expected %q, got %q", key, want, got.String())
+				}
+			}
+		})
+	}
+}
+
+// 测试 Rename 发布预先写入的键
+func TestCacheRename(t *testing.T) {
+	c := NewCache(DefaultCacheOptions())
+
+	if c.Rename("staged", "live") {
+		t.Fatalf("Expected rename on uninitialized cache to fail")
+	}
+
+	c.Set("live", ByteView{b: []byte("v1")})
+	c.Set("staged", ByteView{b: []byte("v2")})
+
+	if c.Rename("missing", "live") {
+		t.Fatalf("Expected rename of missing key to fail")
+	}
+	if !c.Rename("staged", "live") {
+		t.Fatalf("Expected rename of staged key to succeed")
+	}
+	if v, ok := c.Get(context.Background(), "live"); !ok || v.String() != "v2" {
+		t.Fatalf("Expected live=v2, got %q %v", v.String(), ok)
+	}
+	if _, ok := c.Get(context.Background(), "staged"); ok {
+		t.Fatalf("Expected staged to be gone")
+	}
+
+	c.Close()
+	if c.Rename("live", "other") {
+		t.Fatalf("Expected rename on closed cache to fail")
+	}
+}
+
+// 测试长度为 0 的 ByteView 可以写入并作为命中读取，与不存在的键区分
+func TestCacheEmptyValue(t *testing.T) {
+	for _, cacheType := range []store.CacheType{store.LRU, store.LRU2} {
+		t.Run(string(cacheType), func(t *testing.T) {
+			opts := DefaultCacheOptions()
+			opts.CacheType = cacheType
+			c := NewCache(opts)
+			defer c.Close()
+
+			if err := c.Set("empty", ByteView{}); err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+			if err := c.SetWithExpiration("empty-ttl", ByteView{b: []byte{}}, time.Now().Add(time.Hour)); err != nil {
+				t.Fatalf("SetWithExpiration failed: %v", err)
+			}
+
+			for _, key := range []string{"empty", "empty-ttl"} {
+				if v, ok := c.Get(context.Background(), key); !ok || v.Len() != 0 {
+					t.Fatalf("Expected empty value for %s to be a hit, got %q %v", key, v.String(), ok)
+				}
+			}
+			if _, ok := c.Get(context.Background(), "absent"); ok {
+				t.Fatalf("Expected absent key to miss")
+			}
+			if n := c.Len(); n != 2 {
+				t.Fatalf("Expected 2 items, got %d", n)
+			}
+		})
+	}
+}
+
+// 测试 ValueDecoder 将自定义 Value 转换为 ByteView
+func TestCacheValueDecoder(t *testing.T) {
+	ctx := context.Background()
+
+	// 默认只接受 ByteView
+	c := NewCache(DefaultCacheOptions())
+	defer c.Close()
+	c.ensureInitialized()
+	c.store.Set("other", otherValue("value"))
+	if _, ok, err := c.GetE(ctx, "other"); ok || err != ErrValueType {
+		t.Fatalf("Expected ErrValueType without decoder, got %v %v", ok, err)
+	}
+
+	opts := DefaultCacheOptions()
+	opts.ValueDecoder = func(v store.Value) (ByteView, bool) {
+		ov, ok := v.(otherValue)
+		if !ok {
+			return ByteView{}, false
+		}
+		return ByteView{b: []byte("decoded:" + string(ov))}, true
+	}
+	c = NewCache(opts)
+	defer c.Close()
+	c.ensureInitialized()
+	c.store.Set("other", otherValue("value"))
+	c.Set("plain", ByteView{b: []byte("plain")})
+
+	got, ok, err := c.GetE(ctx, "other")
+	if err != nil || !ok || got.String() != "decoded:value" {
+		t.Fatalf("Expected decoded value, got %q %v %v", got.String(), ok, err)
+	}
+	if got, _, ok := c.GetWithVersion("other"); !ok || got.String() != "decoded:value" {
+		t.Fatalf("Expected GetWithVersion to decode, got %q %v", got.String(), ok)
+	}
+	// ByteView 不经过 ValueDecoder
+	if got, ok := c.Get(ctx, "plain"); !ok || got.String() != "plain" {
+		t.Fatalf("Expected ByteView to be returned as is, got %q %v", got.String(), ok)
+	}
+}
+
+// 测试使用磁盘存储的缓存在重新打开后仍能读取数据
+func TestCacheBoltStore(t *testing.T) {
+	opts := DefaultCacheOptions()
+	opts.CacheType = store.Bolt
+	opts.Path = filepath.Join(t.TempDir(), "cache.db")
+
+	c := NewCache(opts)
+	if err := c.Set("key", ByteView{b: []byte("value")}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	c.Close()
+
+	c = NewCache(opts)
+	defer c.Close()
+	view, ok := c.Get(context.Background(), "key")
+	if !ok || view.String() != "value" {
+		t.Fatalf("Expected value to persist across reopen, got %q %v", view.String(), ok)
+	}
+}
@@ -4,27 +4,47 @@ import (
 	"context"
 	"fmt"
 	"time"
+	"unicode/utf8"
 
 	pb "github.com/lyy42995004/Cache-Go/pb"
 	"github.com/sirupsen/logrus"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type Client struct {
-	addr    string           // gRPC 服务器的地址
-	svcName string           // 服务名称
-	etcdCli *clientv3.Client // etcd 客户端实例
-	conn    *grpc.ClientConn // gRPC 连接实例
-	grpcCli pb.GCacheClient  // gcache服务的  gRPC 客户端实例
+	addr        string           // gRPC 服务器的地址
+	svcName     string           // 服务名称
+	etcdCli     *clientv3.Client // etcd 客户端实例
+	conn        *grpc.ClientConn // gRPC 连接实例
+	grpcCli     pb.GCacheClient  // gcache服务的  gRPC 客户端实例
+	callTimeout time.Duration    // 调用方未设置截止时间时的默认超时
+}
+
+// defaultCallTimeout 默认的单次调用超时
+const defaultCallTimeout = 3 * time.Second
+
+// ClientOption 定义 Client 的配置选项
+type ClientOption func(*Client)
+
+// WithCallTimeout 设置 Get、Set、Delete 的默认超时，调用方的 context 已有截止时间时以调用方为准
+func WithCallTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		if d > 0 {
+			c.callTimeout = d
+		}
+	}
 }
 
 // 编译时，强制检查 Client 类型是否实现了 Peer 接口
 var _ Peer = (*Client)(nil)
 
 // NewClient 创建一个 Client 实例
-func NewClient(addr, svcName string, etcdCli *clientv3.Client) (*Client, error) {
+func NewClient(addr, svcName string, etcdCli *clientv3.Client, opts ...ClientOption) (*Client, error) {
 	// 处理 etcd 客户端
 	var err error
 	if etcdCli == nil {
@@ -37,6 +57,11 @@ func NewClient(addr, svcName string, etcdCli *clientv3.Client) (*Client, error)
 		}
 	}
 
+	return dialClient(addr, svcName, etcdCli, opts...)
+}
+
+// dialClient 建立到节点的 gRPC 连接，etcdCli 可以为空
+func dialClient(addr, svcName string, etcdCli *clientv3.Client, opts ...ClientOption) (*Client, error) {
 	// 建立 gRPC 连接
 	// TODO: Dial在v2版本会被弃用，改用NewClient
 	conn, err := grpc.Dial(addr,
@@ -53,39 +78,122 @@ func NewClient(addr, svcName string, etcdCli *clientv3.Client) (*Client, error)
 	grpcClient := pb.NewGCacheClient(conn)
 
 	client := &Client{
-		addr:    addr,
-		svcName: svcName,
-		etcdCli: etcdCli,
-		conn:    conn,
-		grpcCli: grpcClient,
+		addr:        addr,
+		svcName:     svcName,
+		etcdCli:     etcdCli,
+		conn:        conn,
+		grpcCli:     grpcClient,
+		callTimeout: defaultCallTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(client)
 	}
 
 	return client, nil
 }
 
+// callContext 调用方的 context 没有截止时间时，附加默认超时
+func (c *Client) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.callTimeout)
+}
+
+// wireKey 返回在请求中传输的键，键不是合法的 UTF-8 时 protobuf 无法编码，改为通过二进制元数据传输，请求中的键留空
+// 不识别该元数据的旧版本节点收到空键时返回错误，不会误用其他键
+func wireKey(ctx context.Context, key string) (context.Context, string) {
+	if utf8.ValidString(key) {
+		return ctx, key
+	}
+	return metadata.AppendToOutgoingContext(ctx, keyHeader, key), ""
+}
+
+// wireKeys 返回在批量请求中传输的键，有任意一个键不是合法的 UTF-8 时所有键按顺序通过二进制元数据传输
+func wireKeys(ctx context.Context, keys []string) (context.Context, []string) {
+	kv := make([]string, 0, 2*len(keys))
+	valid := true
+	for _, key := range keys {
+		valid = valid && utf8.ValidString(key)
+		kv = append(kv, keyHeader, key)
+	}
+	if valid {
+		return ctx, keys
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...), nil
+}
+
 // Get 实现 Peer 接口
 func (c *Client) Get(group, key string) ([]byte, error) {
-	// 如果在 3 秒内没有收到服务端的响应，上下文会自动取消，gRPC 调用也会终止
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	return c.GetContext(context.Background(), group, key)
+}
+
+// GetContext 实现 ContextGetter 接口，ctx 取消时 gRPC 调用随之终止
+func (c *Client) GetContext(ctx context.Context, group, key string) ([]byte, error) {
+	value, _, err := c.GetWithTTL(ctx, group, key)
+	return value, err
+}
+
+// GetWithTTL 实现 Peer 接口，通过响应中的 ttl 字段获取值在远程节点的剩余过期时间
+// 不返回该字段的旧版本节点的 ttl 为 0
+func (c *Client) GetWithTTL(ctx context.Context, group, key string) ([]byte, time.Duration, error) {
+	// 如果在超时时间内没有收到服务端的响应，上下文会自动取消，gRPC 调用也会终止
+	ctx, cancel := c.callContext(ctx)
 	defer cancel()
 
+	ctx, key = wireKey(ctx, key)
 	resp, err := c.grpcCli.Get(ctx, &pb.Request{
 		Group: group,
 		Key:   key,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get value from gcache: %v", err)
+		return nil, 0, fmt.Errorf("failed to get value from gcache: %v", err)
 	}
 
-	return resp.GetValue(), nil
+	return resp.GetValue(), time.Duration(resp.GetTtl()), nil
+}
+
+// GetIfPresent 实现 PresentGetter 接口，只查询远程节点的缓存，未缓存时返回 false
+// 使用单独的 GetIfPresent 调用，不支持该调用的旧版本节点返回 Unimplemented 错误，不会加载数据
+func (c *Client) GetIfPresent(group, key string) ([]byte, bool, error) {
+	ctx, cancel := c.callContext(context.Background())
+	defer cancel()
+
+	ctx, key = wireKey(ctx, key)
+	resp, err := c.grpcCli.GetIfPresent(ctx, &pb.Request{
+		Group: group,
+		Key:   key,
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get value from gcache: %v", err)
+	}
+
+	return resp.GetValue(), true, nil
 }
 
 // Set 实现 Peer 接口
 func (c *Client) Set(ctx context.Context, group, key string, value []byte) error {
+	// 传递写入的剩余过期时间，使远程副本与本地副本同时过期
+	var ttl time.Duration
+	if expireAt, ok := expireAtFrom(ctx); ok {
+		if ttl = time.Until(expireAt); ttl <= 0 {
+			return nil
+		}
+	}
+
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+
+	ctx, key = wireKey(ctx, key)
 	resp, err := c.grpcCli.Set(ctx, &pb.Request{
 		Group: group,
 		Key:   key,
 		Value: value,
+		Ttl:   int64(ttl),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to set value to gcache: %v", err)
@@ -95,11 +203,50 @@ func (c *Client) Set(ctx context.Context, group, key string, value []byte) error
 	return nil
 }
 
+// SetNX 实现 LockPeer 接口，锁不存在或已过期时获取，已被占用时返回 false
+// 使用单独的 SetNX 调用，不支持该调用的旧版本节点返回 Unimplemented 错误，不会被当作普通写入
+func (c *Client) SetNX(ctx context.Context, group, key string, value []byte, ttl time.Duration) (bool, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+
+	ctx, key = wireKey(ctx, key)
+	resp, err := c.grpcCli.SetNX(ctx, &pb.Request{
+		Group: group,
+		Key:   key,
+		Value: value,
+		Ttl:   int64(ttl),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock from gcache: %v", err)
+	}
+
+	return resp.GetValue(), nil
+}
+
+// DeleteIfValue 实现 LockPeer 接口，锁的令牌等于 value 时释放
+func (c *Client) DeleteIfValue(ctx context.Context, group, key string, value []byte) (bool, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+
+	ctx, key = wireKey(ctx, key)
+	resp, err := c.grpcCli.CompareAndDelete(ctx, &pb.Request{
+		Group: group,
+		Key:   key,
+		Value: value,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to release lock from gcache: %v", err)
+	}
+
+	return resp.GetValue(), nil
+}
+
 // Delete 实现 Peer 接口
 func (c *Client) Delete(group, key string) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := c.callContext(context.Background())
 	defer cancel()
 
+	ctx, key = wireKey(ctx, key)
 	resp, err := c.grpcCli.Delete(ctx, &pb.Request{
 		Group: group,
 		Key:   key,
@@ -111,6 +258,23 @@ func (c *Client) Delete(group, key string) (bool, error) {
 	return resp.GetValue(), nil
 }
 
+// BatchDelete 实现 Peer 接口
+func (c *Client) BatchDelete(ctx context.Context, group string, keys []string) (int, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+
+	ctx, keys = wireKeys(ctx, keys)
+	resp, err := c.grpcCli.BatchDelete(ctx, &pb.BatchRequest{
+		Group: group,
+		Keys:  keys,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to batch delete values from gcache: %v", err)
+	}
+
+	return int(resp.GetCount()), nil
+}
+
 // Close 实现 Peer 接口
 func (c *Client) Close() error {
 	if c.conn != nil {
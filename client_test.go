@@ -0,0 +1,294 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/lyy42995004/Cache-Go/pb"
+	"github.com/lyy42995004/Cache-Go/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// deadlineRecorder 记录每次调用的截止时间
+type deadlineRecorder struct {
+	pb.GCacheClient
+	deadlines []time.Duration // 调用时距离截止时间的剩余时长，-1 表示没有截止时间
+}
+
+func (r *deadlineRecorder) record(ctx context.Context) {
+	if deadline, ok := ctx.Deadline(); ok {
+		r.deadlines = append(r.deadlines, time.Until(deadline))
+	} else {
+		r.deadlines = append(r.deadlines, -1)
+	}
+}
+
+func (r *deadlineRecorder) Get(ctx context.Context, in *pb.Request, opts ...grpc.CallOption) (*pb.ResponseForGet, error) {
+	r.record(ctx)
+	return &pb.ResponseForGet{}, nil
+}
+
+func (r *deadlineRecorder) Set(ctx context.Context, in *pb.Request, opts ...grpc.CallOption) (*pb.ResponseForGet, error) {
+	r.record(ctx)
+	return &pb.ResponseForGet{}, nil
+}
+
+func (r *deadlineRecorder) Delete(ctx context.Context, in *pb.Request, opts ...grpc.CallOption) (*pb.ResponseForDelete, error) {
+	r.record(ctx)
+	return &pb.ResponseForDelete{}, nil
+}
+
+// 测试默认调用超时
+func TestClientCallTimeout(t *testing.T) {
+	rec := &deadlineRecorder{}
+	c := &Client{grpcCli: rec, callTimeout: defaultCallTimeout}
+	WithCallTimeout(500 * time.Millisecond)(c)
+
+	// 调用方没有截止时间时使用默认超时
+	c.Get("group", "key")
+	c.Set(context.Background(), "group", "key", []byte("value"))
+	c.Delete("group", "key")
+
+	// 调用方的截止时间优先
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	c.Set(ctx, "group", "key", []byte("value"))
+
+	if len(rec.deadlines) != 4 {
+		t.Fatalf("Expected 4 calls, got %d", len(rec.deadlines))
+	}
+	for i, d := range rec.deadlines[:3] {
+		if d <= 0 || d > 500*time.Millisecond {
+			t.Errorf("Call %d: expected configured 500ms deadline, got %v", i, d)
+		}
+	}
+	if d := rec.deadlines[3]; d < 50*time.Second {
+		t.Errorf("Expected caller deadline of 1m to win, got %v", d)
+	}
+
+	// 非正数的超时被忽略
+	WithCallTimeout(0)(c)
+	if c.callTimeout != 500*time.Millisecond {
+		t.Errorf("Expected non-positive timeout to be ignored, got %v", c.callTimeout)
+	}
+}
+
+// loopbackClient 将调用直接转发给进程内的 Server，并把请求元数据传递给服务端
+// 请求经过 protobuf 编解码，与网络传输一样拒绝无法编码的字段
+type loopbackClient struct {
+	pb.GCacheClient
+	srv *Server
+}
+
+// wire 对请求做一次 protobuf 编解码
+func wire[T proto.Message](in T) (T, error) {
+	data, err := proto.Marshal(in)
+	if err != nil {
+		return in, err
+	}
+	out := in.ProtoReflect().New().Interface().(T)
+	return out, proto.Unmarshal(data, out)
+}
+
+func (l *loopbackClient) Get(ctx context.Context, in *pb.Request, opts ...grpc.CallOption) (*pb.ResponseForGet, error) {
+	in, err := wire(in)
+	if err != nil {
+		return nil, err
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return l.srv.Get(metadata.NewIncomingContext(ctx, md), in)
+}
+
+func (l *loopbackClient) GetIfPresent(ctx context.Context, in *pb.Request, opts ...grpc.CallOption) (*pb.ResponseForGet, error) {
+	in, err := wire(in)
+	if err != nil {
+		return nil, err
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return l.srv.GetIfPresent(metadata.NewIncomingContext(ctx, md), in)
+}
+
+func (l *loopbackClient) Set(ctx context.Context, in *pb.Request, opts ...grpc.CallOption) (*pb.ResponseForGet, error) {
+	in, err := wire(in)
+	if err != nil {
+		return nil, err
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return l.srv.Set(metadata.NewIncomingContext(ctx, md), in)
+}
+
+func (l *loopbackClient) Delete(ctx context.Context, in *pb.Request, opts ...grpc.CallOption) (*pb.ResponseForDelete, error) {
+	in, err := wire(in)
+	if err != nil {
+		return nil, err
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return l.srv.Delete(metadata.NewIncomingContext(ctx, md), in)
+}
+
+func (l *loopbackClient) BatchDelete(ctx context.Context, in *pb.BatchRequest, opts ...grpc.CallOption) (*pb.ResponseForBatchDelete, error) {
+	in, err := wire(in)
+	if err != nil {
+		return nil, err
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return l.srv.BatchDelete(metadata.NewIncomingContext(ctx, md), in)
+}
+
+func (l *loopbackClient) SetNX(ctx context.Context, in *pb.Request, opts ...grpc.CallOption) (*pb.ResponseForSetNX, error) {
+	in, err := wire(in)
+	if err != nil {
+		return nil, err
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return l.srv.SetNX(metadata.NewIncomingContext(ctx, md), in)
+}
+
+func (l *loopbackClient) CompareAndDelete(ctx context.Context, in *pb.Request, opts ...grpc.CallOption) (*pb.ResponseForDelete, error) {
+	in, err := wire(in)
+	if err != nil {
+		return nil, err
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return l.srv.CompareAndDelete(metadata.NewIncomingContext(ctx, md), in)
+}
+
+// 测试只查询缓存的请求经过服务端时不会加载数据，未缓存时客户端返回 false
+func TestClientGetIfPresent(t *testing.T) {
+	var loads int32
+	g := newTestGroup(t, GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		atomic.AddInt32(&loads, 1)
+		return []byte("loaded"), nil
+	}))
+	if err := g.Set(context.Background(), "cached", []byte("value")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	c := &Client{grpcCli: &loopbackClient{srv: &Server{}}, callTimeout: defaultCallTimeout}
+
+	value, ok, err := c.GetIfPresent(g.name, "cached")
+	if err != nil || !ok || string(value) != "value" {
+		t.Fatalf("Expected cached value, got %q %v %v", value, ok, err)
+	}
+
+	value, ok, err = c.GetIfPresent(g.name, "missing")
+	if err != nil || ok {
+		t.Fatalf("Expected clean miss, got %q %v %v", value, ok, err)
+	}
+	if n := atomic.LoadInt32(&loads); n != 0 {
+		t.Fatalf("Expected loader not to be called, got %d calls", n)
+	}
+
+	// 普通 Get 仍然会加载数据
+	if value, err := c.Get(g.name, "missing"); err != nil || string(value) != "loaded" {
+		t.Fatalf("Expected Get to load, got %q %v", value, err)
+	}
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Fatalf("Expected 1 load, got %d", n)
+	}
+}
+
+// 测试客户端写入时传递剩余过期时间，服务端按该时间写入
+func TestClientSetPropagatesTTL(t *testing.T) {
+	g := newTestGroup(t, nil, WithExpiration(time.Hour), WithCacheOptions(CacheOptions{
+		CacheType: store.LRU,
+		MaxBytes:  1 << 20,
+	}))
+	c := &Client{grpcCli: &loopbackClient{srv: &Server{}}, callTimeout: defaultCallTimeout}
+
+	expireAt := time.Now().Add(time.Minute)
+	if err := c.Set(withExpireAt(context.Background(), expireAt), g.name, "key", []byte("value")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if local := localExpiration(t, g, "key"); !closeTimes(local, expireAt) {
+		t.Fatalf("Expected expiry %v, got %v", expireAt, local)
+	}
+}
+
+// 测试客户端读取时获取服务端返回的剩余过期时间，永不过期的值不返回过期时间
+func TestClientGetWithTTL(t *testing.T) {
+	g := newTestGroup(t, nil, WithCacheOptions(CacheOptions{
+		CacheType: store.LRU,
+		MaxBytes:  1 << 20,
+	}))
+	ctx := context.Background()
+	if err := g.Set(withExpireAt(ctx, time.Now().Add(time.Minute)), "ttl", []byte("value")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := g.Set(ctx, "forever", []byte("value")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	c := &Client{grpcCli: &loopbackClient{srv: &Server{}}, callTimeout: defaultCallTimeout}
+
+	value, ttl, err := c.GetWithTTL(ctx, g.name, "ttl")
+	if err != nil || string(value) != "value" {
+		t.Fatalf("Expected value, got %q %v", value, err)
+	}
+	if ttl <= 59*time.Second || ttl > time.Minute {
+		t.Fatalf("Expected remaining ttl close to 1m, got %v", ttl)
+	}
+
+	if _, ttl, err := c.GetWithTTL(ctx, g.name, "forever"); err != nil || ttl != 0 {
+		t.Fatalf("Expected no ttl for a key without expiry, got %v %v", ttl, err)
+	}
+
+	// 读取后过期、尚未移出缓存的键返回已过期标记
+	if err := g.Set(withExpireAt(ctx, time.Now().Add(20*time.Millisecond)), "short", []byte("value")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if ttl := remainingTTL(g, "short"); ttl != expiredTTL {
+		t.Fatalf("Expected expired marker, got %v", ttl)
+	}
+}
+
+// testBinaryKeys 包含空字节和非法 UTF-8 字节序列的键
+var testBinaryKeys = []string{"a\x00b", "\xff\xfe", "\x00", "valid\xc3"}
+
+// 测试任意字节序列的键经过客户端和服务端后读写删除的都是同一个键
+func TestClientBinaryKeys(t *testing.T) {
+	g := newTestGroup(t, nil)
+	c := &Client{grpcCli: &loopbackClient{srv: &Server{}}, callTimeout: defaultCallTimeout}
+	ctx := context.Background()
+
+	for _, key := range testBinaryKeys {
+		if err := c.Set(ctx, g.name, key, []byte("v"+key)); err != nil {
+			t.Fatalf("Set %q failed: %v", key, err)
+		}
+		// 写入本地缓存的是原始的键
+		if view, ok := g.GetIfPresent(ctx, key); !ok || view.String() != "v"+key {
+			t.Fatalf("Expected %q to be cached under the exact key, got %q %v", key, view.String(), ok)
+		}
+		if value, err := c.Get(g.name, key); err != nil || string(value) != "v"+key {
+			t.Fatalf("Get %q: expected value, got %q %v", key, value, err)
+		}
+		if value, ok, err := c.GetIfPresent(g.name, key); err != nil || !ok || string(value) != "v"+key {
+			t.Fatalf("GetIfPresent %q: expected value, got %q %v %v", key, value, ok, err)
+		}
+	}
+
+	if deleted, err := c.Delete(g.name, testBinaryKeys[0]); err != nil || !deleted {
+		t.Fatalf("Delete %q: expected success, got %v %v", testBinaryKeys[0], deleted, err)
+	}
+	if _, ok := g.GetIfPresent(ctx, testBinaryKeys[0]); ok {
+		t.Fatalf("Expected %q to be deleted", testBinaryKeys[0])
+	}
+	if _, ok := g.GetIfPresent(ctx, "a"); ok {
+		t.Fatalf("Expected no key truncated at the null byte")
+	}
+
+	// 合法 UTF-8 的键与非法的键混合时按原顺序传输
+	if n, err := c.BatchDelete(ctx, g.name, append([]string{"plain"}, testBinaryKeys[1:]...)); err != nil || n != len(testBinaryKeys)-1 {
+		t.Fatalf("BatchDelete: expected %d deletions, got %d %v", len(testBinaryKeys)-1, n, err)
+	}
+	for _, key := range testBinaryKeys {
+		if _, ok := g.GetIfPresent(ctx, key); ok {
+			t.Fatalf("Expected %q to be deleted", key)
+		}
+	}
+}
@@ -8,17 +8,55 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
+// ErrNoNodes 哈希环为空且没有备用节点错误
+var ErrNoNodes = errors.New("no nodes in hash ring")
+
+// ErrEmptyKey 键为空错误
+var ErrEmptyKey = errors.New("empty key")
+
+// ErrNoValidNodes 传入的节点全部为空，哈希环没有变化错误
+var ErrNoValidNodes = errors.New("no non-empty nodes provided")
+
+// ErrEmptyNode 节点名为空错误
+var ErrEmptyNode = errors.New("empty node name")
+
+// ErrDuplicateNode 节点重复错误
+var ErrDuplicateNode = errors.New("duplicate node")
+
+// ErrInvalidReplicas 虚拟节点数不是正数错误
+var ErrInvalidReplicas = errors.New("replicas must be positive")
+
 // Map 一致性哈希
 type Map struct {
 	mu            sync.RWMutex
-	config        *Config          // 配置信息
-	keys          []int            // 哈希环
-	hashMap       map[int]string   // 哈希环到节点的映射
-	nodeReplicas  map[string]int   // 节点到虚拟节点数量的映射
-	nodeCounts    map[string]int64 // 节点负载统计
-	totalRequests int64            // 总请求数
+	config        *Config           // 配置信息
+	keys          []int             // 哈希环
+	hashMap       map[int]string    // 哈希环到节点的映射
+	nodeReplicas  map[string]int    // 节点到虚拟节点数量的映射
+	nodeCounts    map[string]int64  // 节点负载统计
+	countsMu      sync.Mutex        // 持有读锁时保护 nodeCounts，持有写锁时不需要
+	totalRequests int64             // 总请求数
+	balanceEvery  time.Duration     // 后台负载检查间隔，<=0 表示不启动后台均衡
+	fallbackNodes []string          // 哈希环为空时使用的静态备用节点
+	fallback      *Map              // 由备用节点构成的哈希环
+	degraded      int32             // 原子变量，标记是否正在使用备用节点
+	watches       []*keyWatch       // 键归属变化的监听
+	lookup        *lookupCache      // 热点键的查找结果缓存，为空时不缓存
+	pins          map[string]string // 固定到指定节点的键
+	generation    uint64            // 原子变量，哈希环的变化次数
+	closeCh       chan struct{}     // 关闭后台均衡协程
+	closeOnce     sync.Once
+}
+
+// keyWatch 监听单个键的归属节点
+type keyWatch struct {
+	key   string
+	owner string      // 最近一次通知的归属节点
+	ch    chan string // 归属变化时发送新的节点
 }
 
 // Option 配置选项
@@ -31,16 +69,59 @@ func New(opts ...Option) *Map {
 		hashMap:      make(map[int]string),
 		nodeReplicas: make(map[string]int),
 		nodeCounts:   make(map[string]int64),
+		balanceEvery: time.Second,
+		closeCh:      make(chan struct{}),
 	}
 
 	for _, opt := range opts {
 		opt(m) // 执行传入的配置选项函数
 	}
 
-	m.startBalancer() // 启动负载均衡器
+	if len(m.fallbackNodes) > 0 {
+		m.fallback = New(WithConfig(m.config), WithBalanceInterval(0))
+		m.fallback.Add(m.fallbackNodes...)
+	}
+
+	if m.balanceEvery > 0 {
+		m.startBalancer() // 启动负载均衡器
+	}
 	return m
 }
 
+// NewWithNodes 创建一致性哈希实例并添加虚拟节点数相同的一组节点，所有节点添加完后只排序一次
+// 节点列表为空、包含空节点名或重复节点，以及配置的 DefaultReplicas 不是正数时返回错误
+func NewWithNodes(nodes []string, opts ...Option) (*Map, error) {
+	if len(nodes) == 0 {
+		return nil, ErrNoValidNodes
+	}
+	seen := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		if node == "" {
+			return nil, ErrEmptyNode
+		}
+		if _, ok := seen[node]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateNode, node)
+		}
+		seen[node] = struct{}{}
+	}
+
+	m := New(opts...)
+	if m.config.DefaultReplicas <= 0 {
+		m.Close()
+		return nil, ErrInvalidReplicas
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, node := range nodes {
+		m.addNode(node, m.config.DefaultReplicas)
+	}
+	sort.Ints(m.keys)
+	m.ringChanged()
+	return m, nil
+}
+
 // WithConfig 设置配置
 func WithConfig(config *Config) Option {
 	return func(m *Map) {
@@ -48,7 +129,22 @@ func WithConfig(config *Config) Option {
 	}
 }
 
-// Add 添加节点
+// WithBalanceInterval 设置后台负载检查间隔，d <= 0 时不启动后台均衡，只能通过 Rebalance 手动触发
+func WithBalanceInterval(d time.Duration) Option {
+	return func(m *Map) {
+		m.balanceEvery = d
+	}
+}
+
+// WithFallbackNodes 设置静态备用节点，所有节点被移除导致哈希环为空时，
+// Get 在备用节点中选择，避免所有请求都回源到数据源
+func WithFallbackNodes(nodes ...string) Option {
+	return func(m *Map) {
+		m.fallbackNodes = nodes
+	}
+}
+
+// Add 添加节点，空节点被跳过，全部为空时返回 ErrNoValidNodes 且不修改哈希环
 func (m *Map) Add(nodes ...string) error {
 	if len(nodes) == 0 {
 		return errors.New("no nodes provided")
@@ -57,29 +153,99 @@ func (m *Map) Add(nodes ...string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	added := 0
 	for _, node := range nodes {
 		if node == "" {
 			continue
 		}
 		m.addNode(node, m.config.DefaultReplicas)
+		added++
+	}
+	if added == 0 {
+		return ErrNoValidNodes
 	}
 
 	sort.Ints(m.keys)
+	m.ringChanged()
 	return nil
 }
 
 // addNode 添加节点的虚拟节点
 func (m *Map) addNode(node string, replicas int) {
 	for i := range replicas {
-		hash := int(m.config.HashFunc(fmt.Appendf(nil, "%s-%d", node, i)))
+		hash := m.vnodeHash(node, i)
 		m.keys = append(m.keys, hash)
 		m.hashMap[hash] = node
 	}
 	m.nodeReplicas[node] = replicas
 }
 
-// Get 获取节点
+// vnodeHash 计算节点第 i 个虚拟节点在哈希环上的位置
+func (m *Map) vnodeHash(node string, i int) int {
+	key := m.config.VirtualNodeKey
+	if key == nil {
+		key = DefaultVirtualNodeKey
+	}
+	return int(m.config.HashFunc(key(node, i)))
+}
+
+// Get 获取节点，键为空或没有可用节点时返回空字符串
 func (m *Map) Get(key string) string {
+	node, _ := m.GetE(key)
+	return node
+}
+
+// GetE 获取节点，键为空时返回 ErrEmptyKey，哈希环为空且没有备用节点时返回 ErrNoNodes
+func (m *Map) GetE(key string) (string, error) {
+	if key == "" {
+		return "", ErrEmptyKey
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.keys) == 0 {
+		if node := m.getFallback(key); node != "" {
+			return node, nil
+		}
+		return "", ErrNoNodes
+	}
+	atomic.StoreInt32(&m.degraded, 0)
+
+	node, ok := m.pinned(key)
+	if !ok && m.lookup != nil {
+		node, ok = m.lookup.get(key)
+	}
+	if !ok {
+		node = m.hashMap[m.keys[m.search(key)]]
+		if m.lookup != nil {
+			m.lookup.put(key, node)
+		}
+	}
+
+	// 并发的 GetE 都只持有读锁，更新 nodeCounts 需要互斥
+	m.countsMu.Lock()
+	m.nodeCounts[node]++
+	atomic.AddInt64(&m.totalRequests, 1)
+	m.countsMu.Unlock()
+
+	return node, nil
+}
+
+// getFallback 哈希环为空时从备用节点中选择，没有备用节点时返回空字符串
+func (m *Map) getFallback(key string) string {
+	if m.fallback == nil {
+		return ""
+	}
+	if atomic.CompareAndSwapInt32(&m.degraded, 0, 1) {
+		logrus.Warnf("[consistenthash] ring is empty, routing to fallback nodes %v", m.fallbackNodes)
+	}
+	return m.fallback.GetExcluding(key, nil)
+}
+
+// GetExcluding 从键在哈希环上的位置开始顺时针查找，返回第一个不在 exclude 中的节点
+// 所有节点都被排除时返回空字符串，用于跳过已知故障节点后重试，不计入负载统计
+func (m *Map) GetExcluding(key string, exclude map[string]bool) string {
 	if key == "" {
 		return ""
 	}
@@ -90,7 +256,62 @@ func (m *Map) Get(key string) string {
 	if len(m.keys) == 0 {
 		return ""
 	}
+	if node, ok := m.pinned(key); ok && !exclude[node] {
+		return node
+	}
 
+	start := m.search(key)
+	for i := range len(m.keys) {
+		node := m.hashMap[m.keys[(start+i)%len(m.keys)]]
+		if !exclude[node] {
+			return node
+		}
+	}
+	return ""
+}
+
+// GetN 从键在哈希环上的位置开始顺时针查找，返回最多 n 个不同的节点，第一个为主节点
+// 用于选择键的多个副本节点，不计入负载统计
+func (m *Map) GetN(key string, n int) []string {
+	if key == "" || n <= 0 {
+		return nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.keys) == 0 {
+		return nil
+	}
+
+	n = min(n, len(m.nodeReplicas))
+	nodes := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	if node, ok := m.pinned(key); ok {
+		seen[node] = true
+		nodes = append(nodes, node)
+	}
+	start := m.search(key)
+	for i := 0; i < len(m.keys) && len(nodes) < n; i++ {
+		node := m.hashMap[m.keys[(start+i)%len(m.keys)]]
+		if !seen[node] {
+			seen[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// Replicas 返回节点在哈希环上的虚拟节点数，节点不存在时返回 0
+func (m *Map) Replicas(node string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.nodeReplicas[node]
+}
+
+// search 返回键在哈希环上对应的虚拟节点下标，调用此方法必须持有锁且哈希环非空
+func (m *Map) search(key string) int {
 	hash := int(m.config.HashFunc([]byte(key)))
 	// 二分查找
 	idx := sort.Search(len(m.keys), func(i int) bool {
@@ -101,13 +322,7 @@ func (m *Map) Get(key string) string {
 	if idx == len(m.keys) {
 		idx = 0
 	}
-
-	node := m.hashMap[m.keys[idx]]
-	count := m.nodeCounts[node]
-	m.nodeCounts[node] = count + 1
-	atomic.AddInt64(&m.totalRequests, 1)
-
-	return node
+	return idx
 }
 
 // GetStats 获取负载统计信息
@@ -115,6 +330,9 @@ func (m *Map) GetStats() map[string]float64 {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	m.countsMu.Lock()
+	defer m.countsMu.Unlock()
+
 	stats := make(map[string]float64)
 	total := atomic.LoadInt64(&m.totalRequests)
 	if total == 0 {
@@ -127,6 +345,61 @@ func (m *Map) GetStats() map[string]float64 {
 	return stats
 }
 
+// KeySpaceShares 返回各节点按虚拟节点位置理论上负责的键空间比例，与实际请求量无关
+// 每个虚拟节点负责从前一个虚拟节点到自身之间的区间，第一个虚拟节点同时负责环尾到环首的区间
+// 与 GetStats 对比可以区分负载不均是由哈希分布还是热点键造成的；哈希环为空时返回空映射
+func (m *Map) KeySpaceShares() map[string]float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	shares := make(map[string]float64, len(m.nodeReplicas))
+	if len(m.keys) == 0 {
+		return shares
+	}
+
+	// 哈希值为 uint32，环的大小为 2^32
+	const ringSize = float64(math.MaxUint32) + 1
+	prev := m.keys[len(m.keys)-1] - (math.MaxUint32 + 1)
+	for _, hash := range m.keys {
+		shares[m.hashMap[hash]] += float64(hash-prev) / ringSize
+		prev = hash
+	}
+	return shares
+}
+
+// ExportStats 导出各节点的负载统计和总请求数，用于重启后通过 ImportStats 恢复
+func (m *Map) ExportStats() (map[string]int64, int64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	m.countsMu.Lock()
+	defer m.countsMu.Unlock()
+
+	counts := make(map[string]int64, len(m.nodeCounts))
+	for node, count := range m.nodeCounts {
+		counts[node] = count
+	}
+	return counts, atomic.LoadInt64(&m.totalRequests)
+}
+
+// ImportStats 恢复之前导出的负载统计，使负载均衡无需重新积累样本
+// 只导入当前哈希环中存在的节点，已不存在的节点的请求数从总数中扣除
+func (m *Map) ImportStats(counts map[string]int64, total int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var kept int64
+	for node, count := range counts {
+		if _, ok := m.nodeReplicas[node]; !ok || count < 0 {
+			total -= max(count, 0)
+			continue
+		}
+		m.nodeCounts[node] = count
+		kept += count
+	}
+	atomic.StoreInt64(&m.totalRequests, max(total, kept))
+}
+
 // Remove 移除节点
 func (m *Map) Remove(node string) error {
 	if node == "" {
@@ -141,9 +414,93 @@ func (m *Map) Remove(node string) error {
 		return fmt.Errorf("node %s not found", node)
 	}
 
-	// 移除节点的所有虚拟节点
+	m.removeNode(node, replicas)
+
+	delete(m.nodeCounts, node)
+	delete(m.nodeReplicas, node)
+	m.ringChanged()
+	return nil
+}
+
+// Update 在一次哈希环变化中移除 remove 中的节点并添加 add 中的节点，只排序一次
+// 已存在的节点不重复添加，不存在的节点忽略，适合批量处理节点上下线
+func (m *Map) Update(add, remove []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	changed := false
+	for _, node := range remove {
+		replicas := m.nodeReplicas[node]
+		if replicas == 0 {
+			continue
+		}
+		m.removeNode(node, replicas)
+		delete(m.nodeCounts, node)
+		delete(m.nodeReplicas, node)
+		changed = true
+	}
+
+	added := false
+	for _, node := range add {
+		if node == "" || m.nodeReplicas[node] > 0 {
+			continue
+		}
+		m.addNode(node, m.config.DefaultReplicas)
+		added = true
+	}
+
+	if added {
+		sort.Ints(m.keys)
+	}
+	if changed || added {
+		m.ringChanged()
+	}
+}
+
+// RemoveMatching 在一次哈希环变化中移除所有满足 match 的节点，返回移除的节点数
+// 适合整个机架或可用区下线时按地址前缀批量移除，哈希环只过滤一遍，不需要重新排序
+func (m *Map) RemoveMatching(match func(node string) bool) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := make(map[string]bool)
+	for node := range m.nodeReplicas {
+		if match(node) {
+			removed[node] = true
+		}
+	}
+	if len(removed) == 0 {
+		return 0
+	}
+
+	// 过滤后的哈希环保持有序
+	keys := m.keys[:0]
+	for _, hash := range m.keys {
+		if removed[m.hashMap[hash]] {
+			delete(m.hashMap, hash)
+			continue
+		}
+		keys = append(keys, hash)
+	}
+	m.keys = keys
+
+	for node := range removed {
+		delete(m.nodeCounts, node)
+		delete(m.nodeReplicas, node)
+	}
+	m.ringChanged()
+	return len(removed)
+}
+
+// Generation 返回哈希环的变化次数，每次添加、移除节点或重新平衡加一
+func (m *Map) Generation() uint64 {
+	return atomic.LoadUint64(&m.generation)
+}
+
+// removeNode 移除节点的所有虚拟节点，调用此方法必须持有锁
+func (m *Map) removeNode(node string, replicas int) {
 	for i := range replicas {
-		hash := int(m.config.HashFunc([]byte(fmt.Sprintf("%s-%d", node, i))))
+		hash := m.vnodeHash(node, i)
 		delete(m.hashMap, hash)
 		for j, keyHash := range m.keys {
 			if keyHash == hash {
@@ -152,27 +509,111 @@ func (m *Map) Remove(node string) error {
 			}
 		}
 	}
-
-	delete(m.nodeCounts, node)
-	delete(m.nodeReplicas, node)
-	return nil
 }
 
 // startBalancer 将checkAndRebalance移到单独的goroutine中
 func (m *Map) startBalancer() {
 	go func() {
-		ticker := time.NewTicker(time.Second)
+		ticker := time.NewTicker(m.balanceEvery)
 		defer ticker.Stop()
 
-		for range ticker.C {
-			m.checkAndRebalance()
+		for {
+			select {
+			case <-ticker.C:
+				m.checkAndRebalance()
+			case <-m.closeCh:
+				return
+			}
 		}
 	}()
 }
 
+// WatchKey 监听键的归属节点，哈希环变化（添加、移除节点或重新平衡）导致归属改变时，
+// 通道中发送新的归属节点；消费不及时只保留最新的归属，Close 时关闭通道
+func (m *Map) WatchKey(key string) <-chan string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w := &keyWatch{key: key, owner: m.owner(key), ch: make(chan string, 1)}
+	select {
+	case <-m.closeCh:
+		close(w.ch)
+		return w.ch
+	default:
+	}
+
+	m.watches = append(m.watches, w)
+	return w.ch
+}
+
+// Close 停止后台均衡协程并关闭所有监听通道
+func (m *Map) Close() {
+	m.closeOnce.Do(func() {
+		close(m.closeCh)
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		for _, w := range m.watches {
+			close(w.ch)
+		}
+		m.watches = nil
+
+		if m.fallback != nil {
+			m.fallback.Close()
+		}
+	})
+}
+
+// ringChanged 哈希环变化后清空查找缓存，并通知归属改变的监听，调用此方法必须持有锁
+func (m *Map) ringChanged() {
+	atomic.AddUint64(&m.generation, 1)
+	if m.lookup != nil {
+		m.lookup.clear()
+	}
+
+	for _, w := range m.watches {
+		owner := m.owner(w.key)
+		if owner == w.owner {
+			continue
+		}
+		w.owner = owner
+
+		// 丢弃尚未消费的旧归属，只保留最新的
+		select {
+		case <-w.ch:
+		default:
+		}
+		w.ch <- owner
+	}
+}
+
+// owner 返回键当前的归属节点，不计入负载统计，调用此方法必须持有锁
+func (m *Map) owner(key string) string {
+	if len(m.keys) == 0 {
+		if m.fallback == nil {
+			return ""
+		}
+		return m.fallback.GetExcluding(key, nil)
+	}
+	if node, ok := m.pinned(key); ok {
+		return node
+	}
+	return m.hashMap[m.keys[m.search(key)]]
+}
+
+// Rebalance 同步执行一次负载检查与虚拟节点调整，与后台均衡器的逻辑相同
+// 便于在测试中注入负载后确定性地触发调整
+func (m *Map) Rebalance() {
+	m.checkAndRebalance()
+}
+
 // checkAndRebalance 检查并重新平衡虚拟节点
 func (m *Map) checkAndRebalance() {
-	if atomic.LoadInt64(&m.totalRequests) < 1000 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if atomic.LoadInt64(&m.totalRequests) < 1000 || len(m.nodeReplicas) == 0 {
 		return // 样本太少，无需调整
 	}
 
@@ -190,16 +631,16 @@ func (m *Map) checkAndRebalance() {
 	}
 }
 
-// rebalanceNodes 重新平衡节点
+// rebalanceNodes 重新平衡节点，调用此方法必须持有锁
 func (m *Map) rebalanceNodes() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	avgLoad := float64(m.totalRequests) / float64(len(m.nodeReplicas))
 
 	// 调整每个节点的虚拟节点数量
 	for node, count := range m.nodeCounts {
 		currentReplicas := m.nodeReplicas[node]
+		if currentReplicas == 0 {
+			continue // 节点已被移除
+		}
 		loadRatio := float64(count) / avgLoad
 
 		var newReplicas int
@@ -221,9 +662,7 @@ func (m *Map) rebalanceNodes() {
 
 		if newReplicas != currentReplicas {
 			// 重新添加节点的虚拟节点
-			if err := m.Remove(node); err != nil {
-				continue // 如果移除失败，跳过这个节点
-			}
+			m.removeNode(node, currentReplicas)
 			m.addNode(node, newReplicas)
 		}
 	}
@@ -236,4 +675,5 @@ func (m *Map) rebalanceNodes() {
 
 	// 重新排序
 	sort.Ints(m.keys)
+	m.ringChanged()
 }
@@ -0,0 +1,908 @@
+package consistenthash
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestConfig 返回测试使用的配置，避免修改全局 DefaultConfig
+func newTestConfig() *Config {
+	return &Config{
+		DefaultReplicas:      50,
+		MinReplicas:          10,
+		MaxReplicas:          200,
+		HashFunc:             crc32.ChecksumIEEE,
+		LoadBalanceThreshold: 0.25,
+	}
+}
+
+// 测试手动触发重新平衡
+func TestRebalance(t *testing.T) {
+	m := New(WithConfig(newTestConfig()), WithBalanceInterval(0))
+	if err := m.Add("A", "B"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	// 注入倾斜的负载：A 承担 75%，B 承担 25%
+	m.nodeCounts["A"] = 750
+	m.nodeCounts["B"] = 250
+	m.totalRequests = 1000
+
+	m.Rebalance()
+
+	// 平均负载 500：A 负载比 1.5，虚拟节点 50/1.5=33；B 负载比 0.5，虚拟节点 50*1.5=75
+	if got := m.nodeReplicas["A"]; got != 33 {
+		t.Errorf("Expected A to have 33 replicas, got %d", got)
+	}
+	if got := m.nodeReplicas["B"]; got != 75 {
+		t.Errorf("Expected B to have 75 replicas, got %d", got)
+	}
+	if len(m.keys) != 33+75 || len(m.hashMap) != 33+75 {
+		t.Errorf("Expected %d ring positions, got keys=%d hashMap=%d", 33+75, len(m.keys), len(m.hashMap))
+	}
+	for i := 1; i < len(m.keys); i++ {
+		if m.keys[i-1] > m.keys[i] {
+			t.Fatalf("Ring is not sorted after rebalance")
+		}
+	}
+
+	// 计数器被重置
+	if m.totalRequests != 0 || m.nodeCounts["A"] != 0 || m.nodeCounts["B"] != 0 {
+		t.Errorf("Expected counters to be reset, got total=%d counts=%v", m.totalRequests, m.nodeCounts)
+	}
+}
+
+// 测试样本不足或负载均衡时不调整
+func TestRebalanceNoop(t *testing.T) {
+	m := New(WithConfig(newTestConfig()), WithBalanceInterval(0))
+	m.Add("A", "B")
+
+	// 样本太少
+	m.nodeCounts["A"] = 90
+	m.nodeCounts["B"] = 10
+	m.totalRequests = 100
+	m.Rebalance()
+	if m.nodeReplicas["A"] != 50 || m.nodeReplicas["B"] != 50 {
+		t.Errorf("Expected no adjustment with too few samples, got %v", m.nodeReplicas)
+	}
+
+	// 负载不均衡度未超过阈值
+	m.nodeCounts["A"] = 550
+	m.nodeCounts["B"] = 450
+	m.totalRequests = 1000
+	m.Rebalance()
+	if m.nodeReplicas["A"] != 50 || m.nodeReplicas["B"] != 50 {
+		t.Errorf("Expected no adjustment below threshold, got %v", m.nodeReplicas)
+	}
+}
+
+// 测试虚拟节点数量受最小值和最大值限制
+func TestRebalanceClamp(t *testing.T) {
+	m := New(WithConfig(newTestConfig()), WithBalanceInterval(0))
+	m.Add("A", "B")
+
+	m.nodeCounts["A"] = 990
+	m.nodeCounts["B"] = 10
+	m.totalRequests = 1000
+	m.Rebalance()
+
+	// A: 50/1.98=25；B: 50*1.98=99
+	if got := m.nodeReplicas["A"]; got != 25 {
+		t.Errorf("Expected A to have 25 replicas, got %d", got)
+	}
+	if got := m.nodeReplicas["B"]; got != 99 {
+		t.Errorf("Expected B to have 99 replicas, got %d", got)
+	}
+
+	// 持续倾斜会触及上下限
+	for range 5 {
+		m.nodeCounts["A"] = 999
+		m.nodeCounts["B"] = 1
+		m.totalRequests = 1000
+		m.Rebalance()
+	}
+	if got := m.nodeReplicas["A"]; got != 10 {
+		t.Errorf("Expected A to be clamped to MinReplicas 10, got %d", got)
+	}
+	if got := m.nodeReplicas["B"]; got != 200 {
+		t.Errorf("Expected B to be clamped to MaxReplicas 200, got %d", got)
+	}
+}
+
+// 测试跳过指定节点查找
+func TestGetExcluding(t *testing.T) {
+	m := New(WithConfig(newTestConfig()), WithBalanceInterval(0))
+	m.Add("A", "B", "C")
+
+	for i := range 100 {
+		key := fmt.Sprintf("key-%d", i)
+		primary := m.Get(key)
+
+		// 不排除任何节点时与 Get 一致
+		if got := m.GetExcluding(key, nil); got != primary {
+			t.Fatalf("GetExcluding(%s, nil) = %s, want %s", key, got, primary)
+		}
+
+		// 排除主节点时返回下一个不同的节点
+		second := m.GetExcluding(key, map[string]bool{primary: true})
+		if second == "" || second == primary {
+			t.Fatalf("Expected a node other than %s for %s, got %q", primary, key, second)
+		}
+
+		// 排除前两个节点时返回剩余的节点
+		third := m.GetExcluding(key, map[string]bool{primary: true, second: true})
+		if third == "" || third == primary || third == second {
+			t.Fatalf("Expected the remaining node for %s, got %q", key, third)
+		}
+	}
+
+	// 排除全部节点
+	if got := m.GetExcluding("key", map[string]bool{"A": true, "B": true, "C": true}); got != "" {
+		t.Errorf("Expected empty result when all nodes are excluded, got %q", got)
+	}
+
+	// 空哈希环
+	empty := New(WithConfig(newTestConfig()), WithBalanceInterval(0))
+	if got := empty.GetExcluding("key", nil); got != "" {
+		t.Errorf("Expected empty result for empty ring, got %q", got)
+	}
+}
+
+// 测试排除节点后的结果与顺时针遍历哈希环一致
+func TestGetExcludingOrder(t *testing.T) {
+	config := newTestConfig()
+	config.DefaultReplicas = 1
+	// 哈希值直接取节点名中的数字
+	config.HashFunc = func(data []byte) uint32 {
+		n, _ := strconv.Atoi(strings.SplitN(string(data), "-", 2)[0])
+		return uint32(n)
+	}
+	m := New(WithConfig(config), WithBalanceInterval(0))
+	m.Add("10", "20", "30")
+
+	// 键 15 顺时针依次经过 20、30、10
+	if got := m.GetExcluding("15", map[string]bool{"20": true}); got != "30" {
+		t.Errorf("Expected 30, got %s", got)
+	}
+	if got := m.GetExcluding("15", map[string]bool{"20": true, "30": true}); got != "10" {
+		t.Errorf("Expected wrap-around to 10, got %s", got)
+	}
+}
+
+// 测试按顺时针顺序返回多个不同节点
+func TestGetN(t *testing.T) {
+	m := New(WithConfig(newTestConfig()), WithBalanceInterval(0))
+	m.Add("A", "B", "C")
+
+	for i := range 100 {
+		key := fmt.Sprintf("key-%d", i)
+		nodes := m.GetN(key, 2)
+		if len(nodes) != 2 || nodes[0] == nodes[1] {
+			t.Fatalf("Expected 2 distinct nodes for %s, got %v", key, nodes)
+		}
+		// 第一个节点是主节点，第二个节点与排除主节点后的结果一致
+		if primary := m.GetExcluding(key, nil); nodes[0] != primary {
+			t.Fatalf("Expected primary %s first, got %v", primary, nodes)
+		}
+		if next := m.GetExcluding(key, map[string]bool{nodes[0]: true}); nodes[1] != next {
+			t.Fatalf("Expected %s second, got %v", next, nodes)
+		}
+	}
+
+	// 请求数量超过节点数时返回全部节点
+	if nodes := m.GetN("key", 5); len(nodes) != 3 {
+		t.Errorf("Expected all 3 nodes, got %v", nodes)
+	}
+	if nodes := m.GetN("key", 0); nodes != nil {
+		t.Errorf("Expected nil for n=0, got %v", nodes)
+	}
+}
+
+// 测试哈希环为空时使用备用节点
+func TestFallbackNodes(t *testing.T) {
+	m := New(WithConfig(newTestConfig()), WithBalanceInterval(0), WithFallbackNodes("F1", "F2"))
+	m.Add("A", "B")
+
+	// 哈希环非空时不使用备用节点
+	for i := range 50 {
+		if node := m.Get(fmt.Sprintf("key-%d", i)); node != "A" && node != "B" {
+			t.Fatalf("Expected A or B while ring is populated, got %s", node)
+		}
+	}
+
+	m.Remove("A")
+	m.Remove("B")
+
+	// 所有节点被移除后路由到备用节点，同一个键结果稳定
+	used := make(map[string]bool)
+	for i := range 50 {
+		key := fmt.Sprintf("key-%d", i)
+		node := m.Get(key)
+		if node != "F1" && node != "F2" {
+			t.Fatalf("Expected fallback node for %s, got %q", key, node)
+		}
+		if again := m.Get(key); again != node {
+			t.Fatalf("Expected stable fallback routing for %s, got %s then %s", key, node, again)
+		}
+		used[node] = true
+	}
+	if len(used) != 2 {
+		t.Errorf("Expected keys to spread across both fallback nodes, got %v", used)
+	}
+	if atomic.LoadInt32(&m.degraded) != 1 {
+		t.Errorf("Expected degraded flag to be set")
+	}
+
+	// 节点恢复后不再使用备用节点
+	m.Add("C")
+	if node := m.Get("key"); node != "C" {
+		t.Errorf("Expected C after recovery, got %s", node)
+	}
+	if atomic.LoadInt32(&m.degraded) != 0 {
+		t.Errorf("Expected degraded flag to be cleared after recovery")
+	}
+
+	// 未配置备用节点时保持原有行为
+	plain := New(WithConfig(newTestConfig()), WithBalanceInterval(0))
+	plain.Add("A")
+	plain.Remove("A")
+	if node := plain.Get("key"); node != "" {
+		t.Errorf("Expected empty result without fallback nodes, got %q", node)
+	}
+}
+
+// 测试监听键的归属变化
+func TestWatchKey(t *testing.T) {
+	config := newTestConfig()
+	config.DefaultReplicas = 1
+	// 哈希值直接取节点名中的数字
+	config.HashFunc = func(data []byte) uint32 {
+		n, _ := strconv.Atoi(strings.SplitN(string(data), "-", 2)[0])
+		return uint32(n)
+	}
+	m := New(WithConfig(config), WithBalanceInterval(0))
+	m.Add("10", "30")
+
+	// 键 15 当前归属 30
+	ch := m.WatchKey("15")
+
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got, ok := <-ch:
+			if !ok || got != want {
+				t.Fatalf("Expected owner %q, got %q (open=%v)", want, got, ok)
+			}
+		default:
+			t.Fatalf("Expected owner change to %q, got nothing", want)
+		}
+	}
+	expectNone := func() {
+		t.Helper()
+		select {
+		case got := <-ch:
+			t.Fatalf("Expected no notification, got %q", got)
+		default:
+		}
+	}
+
+	// 添加不影响归属的节点
+	m.Add("40")
+	expectNone()
+
+	// 添加节点 20，键 15 改为归属 20
+	m.Add("20")
+	expect("20")
+
+	// 移除无关节点
+	m.Remove("40")
+	expectNone()
+
+	// 移除节点 20，归属回到 30
+	m.Remove("20")
+	expect("30")
+
+	// 未消费时只保留最新的归属
+	m.Add("20")
+	m.Remove("20")
+	m.Remove("30")
+	expect("10")
+
+	// Close 后关闭通道
+	m.Close()
+	if _, ok := <-ch; ok {
+		t.Fatalf("Expected channel to be closed after Close")
+	}
+	if _, ok := <-m.WatchKey("15"); ok {
+		t.Fatalf("Expected WatchKey after Close to return a closed channel")
+	}
+	m.Close()
+}
+
+// 测试导出和导入负载统计
+func TestExportImportStats(t *testing.T) {
+	old := New(WithConfig(newTestConfig()), WithBalanceInterval(0))
+	old.Add("A", "B", "C")
+	old.nodeCounts["A"] = 800
+	old.nodeCounts["B"] = 300
+	old.nodeCounts["C"] = 100
+	old.totalRequests = 1200
+
+	counts, total := old.ExportStats()
+	if total != 1200 || counts["A"] != 800 {
+		t.Fatalf("Unexpected exported stats: %v, %d", counts, total)
+	}
+	// 导出的是副本
+	counts["A"] = 0
+	if old.nodeCounts["A"] != 800 {
+		t.Fatalf("Expected exported map to be a copy")
+	}
+	counts["A"] = 800
+
+	// 重启后节点 C 已不存在
+	m := New(WithConfig(newTestConfig()), WithBalanceInterval(0))
+	m.Add("A", "B")
+	m.ImportStats(counts, total)
+
+	if m.nodeCounts["A"] != 800 || m.nodeCounts["B"] != 300 {
+		t.Fatalf("Expected imported counts, got %v", m.nodeCounts)
+	}
+	if _, ok := m.nodeCounts["C"]; ok {
+		t.Fatalf("Expected unknown node C to be ignored")
+	}
+	if m.totalRequests != 1100 {
+		t.Fatalf("Expected total 1100 after dropping C, got %d", m.totalRequests)
+	}
+
+	// 导入后无需重新积累样本即可平衡：平均 550，A 负载比 1.45，B 负载比 0.55
+	m.Rebalance()
+	if got := m.nodeReplicas["A"]; got != 34 {
+		t.Errorf("Expected A to have 34 replicas after rebalance, got %d", got)
+	}
+	if got := m.nodeReplicas["B"]; got != 72 {
+		t.Errorf("Expected B to have 72 replicas after rebalance, got %d", got)
+	}
+}
+
+// 测试查找缓存在哈希环变化后失效
+func TestLookupCacheInvalidation(t *testing.T) {
+	m := New(WithConfig(newTestConfig()), WithBalanceInterval(0), WithLookupCache(64))
+	defer m.Close()
+	m.Add("A", "B", "C")
+
+	keys := make([]string, 200)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+
+	// check 比较带缓存的 Get 与不带缓存的查找结果
+	check := func(stage string) {
+		t.Helper()
+		for range 2 {
+			for _, key := range keys {
+				m.mu.RLock()
+				want := m.owner(key)
+				m.mu.RUnlock()
+				if got := m.Get(key); got != want {
+					t.Fatalf("%s: Get(%s) = %s, want %s", stage, key, got, want)
+				}
+			}
+		}
+	}
+
+	check("initial")
+	if m.lookup.ll.Len() != 64 {
+		t.Fatalf("Expected lookup cache to be bounded at 64 entries, got %d", m.lookup.ll.Len())
+	}
+
+	m.Add("D")
+	check("after add")
+
+	m.Remove("A")
+	check("after remove")
+
+	m.nodeCounts["B"] = 900
+	m.nodeCounts["C"] = 100
+	m.totalRequests = 1000
+	m.Rebalance()
+	check("after rebalance")
+}
+
+// 测试热点键的 Get 开销
+func BenchmarkGetHotKeys(b *testing.B) {
+	hotKeys := make([]string, 32)
+	for i := range hotKeys {
+		hotKeys[i] = fmt.Sprintf("user:profile:%d", i)
+	}
+
+	for _, bc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"NoCache", nil},
+		{"LookupCache", []Option{WithLookupCache(128)}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			config := newTestConfig()
+			config.DefaultReplicas = 200
+			m := New(append([]Option{WithConfig(config), WithBalanceInterval(0)}, bc.opts...)...)
+			defer m.Close()
+			for i := range 50 {
+				m.Add(fmt.Sprintf("10.0.0.%d:8001", i))
+			}
+
+			b.ResetTimer()
+			for i := range b.N {
+				m.Get(hotKeys[i%len(hotKeys)])
+			}
+		})
+	}
+}
+
+// 测试批量更新节点只产生一次哈希环变化
+func TestUpdate(t *testing.T) {
+	m := New(WithConfig(newTestConfig()), WithBalanceInterval(0))
+	if err := m.Add("A", "B"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	before := m.Generation()
+	m.Update([]string{"C", "D", "A"}, []string{"B", "missing"})
+	if got := m.Generation() - before; got != 1 {
+		t.Fatalf("Expected a single ring change, got %d", got)
+	}
+
+	// 已存在的 A 不重复添加
+	for node, want := range map[string]int{"A": 50, "B": 0, "C": 50, "D": 50} {
+		if got := m.Replicas(node); got != want {
+			t.Errorf("Expected %s to have %d replicas, got %d", node, want, got)
+		}
+	}
+	if len(m.keys) != 150 || len(m.hashMap) != 150 {
+		t.Errorf("Expected 150 ring positions, got keys=%d hashMap=%d", len(m.keys), len(m.hashMap))
+	}
+	for i := 1; i < len(m.keys); i++ {
+		if m.keys[i-1] > m.keys[i] {
+			t.Fatalf("Ring is not sorted after update")
+		}
+	}
+
+	// 没有变化时不通知
+	before = m.Generation()
+	m.Update([]string{"A"}, []string{"B"})
+	if got := m.Generation(); got != before {
+		t.Fatalf("Expected no ring change for no-op update")
+	}
+}
+
+// 测试固定的键不受节点增减和重新平衡影响，取消固定后恢复哈希路由
+func TestPin(t *testing.T) {
+	m := New(WithConfig(newTestConfig()), WithBalanceInterval(0), WithLookupCache(16))
+	if err := m.Add("A", "B", "C"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	// 找到不归属 A 的键
+	var key string
+	for i := 0; ; i++ {
+		key = "key-" + strconv.Itoa(i)
+		if m.Get(key) != "A" {
+			break
+		}
+	}
+	hashed := m.Get(key)
+
+	m.Pin(key, "A")
+	if got := m.Get(key); got != "A" {
+		t.Fatalf("Expected pinned key to route to A, got %s", got)
+	}
+
+	m.Add("D", "E")
+	m.Remove(hashed)
+	m.nodeCounts["A"] = 900
+	m.nodeCounts["C"] = 100
+	m.totalRequests = 1000
+	m.Rebalance()
+	if got := m.Get(key); got != "A" {
+		t.Fatalf("Expected pinned key to stay on A after ring changes, got %s", got)
+	}
+	if got := m.GetN(key, 2); len(got) != 2 || got[0] != "A" {
+		t.Fatalf("Expected pinned node to be the primary replica, got %v", got)
+	}
+
+	// 固定的节点移除后按哈希环路由，重新加入后固定恢复生效
+	m.Remove("A")
+	if got := m.Get(key); got == "A" || got == "" {
+		t.Fatalf("Expected hash routing while pinned node is absent, got %q", got)
+	}
+	m.Add("A")
+	if got := m.Get(key); got != "A" {
+		t.Fatalf("Expected pin to apply again after A rejoins, got %s", got)
+	}
+
+	m.Unpin(key)
+	if got, want := m.Get(key), m.GetExcluding(key, nil); got != want {
+		t.Fatalf("Expected hash routing after unpin, got %s want %s", got, want)
+	}
+}
+
+// 测试 GetE 区分空键、空哈希环和正常路由
+func TestGetE(t *testing.T) {
+	m := New(WithConfig(newTestConfig()), WithBalanceInterval(0))
+
+	if _, err := m.GetE("key"); !errors.Is(err, ErrNoNodes) {
+		t.Fatalf("Expected ErrNoNodes for empty ring, got %v", err)
+	}
+
+	m.Add("A", "B")
+	if _, err := m.GetE(""); !errors.Is(err, ErrEmptyKey) {
+		t.Fatalf("Expected ErrEmptyKey for empty key, got %v", err)
+	}
+
+	node, err := m.GetE("key")
+	if err != nil || (node != "A" && node != "B") {
+		t.Fatalf("Expected key to route to A or B, got %q, %v", node, err)
+	}
+	if got := m.Get("key"); got != node {
+		t.Fatalf("Expected Get to agree with GetE, got %s want %s", got, node)
+	}
+
+	// 哈希环为空时路由到备用节点不视为错误
+	fallback := New(WithConfig(newTestConfig()), WithBalanceInterval(0), WithFallbackNodes("F"))
+	if node, err := fallback.GetE("key"); err != nil || node != "F" {
+		t.Fatalf("Expected fallback node F, got %q, %v", node, err)
+	}
+}
+
+// referenceRing 按其他语言实现的常见写法独立构造哈希环：虚拟节点键为 "node#i"，crc32 哈希，升序排列
+func referenceRing(replicas map[string]int) ([]int, map[int]string) {
+	var keys []int
+	owners := make(map[int]string)
+	for node, n := range replicas {
+		for i := range n {
+			hash := int(crc32.ChecksumIEEE([]byte(node + "#" + strconv.Itoa(i))))
+			keys = append(keys, hash)
+			owners[hash] = node
+		}
+	}
+	sort.Ints(keys)
+	return keys, owners
+}
+
+// referenceGet 在参考哈希环上查找键的归属节点
+func referenceGet(keys []int, owners map[int]string, key string) string {
+	hash := int(crc32.ChecksumIEEE([]byte(key)))
+	idx := sort.SearchInts(keys, hash)
+	return owners[keys[idx%len(keys)]]
+}
+
+// 测试自定义虚拟节点键格式，添加、移除和重新平衡后的哈希环与参考实现一致
+func TestVirtualNodeKey(t *testing.T) {
+	config := newTestConfig()
+	config.VirtualNodeKey = func(node string, i int) []byte {
+		return fmt.Appendf(nil, "%s#%d", node, i)
+	}
+	m := New(WithConfig(config), WithBalanceInterval(0))
+
+	check := func(stage string, replicas map[string]int) {
+		t.Helper()
+		keys, owners := referenceRing(replicas)
+		if !reflect.DeepEqual(m.keys, keys) {
+			t.Fatalf("%s: ring positions differ from reference implementation", stage)
+		}
+		for i := range 1000 {
+			key := "key-" + strconv.Itoa(i)
+			if got, want := m.Get(key), referenceGet(keys, owners, key); got != want {
+				t.Fatalf("%s: Get(%s) = %s, reference = %s", stage, key, got, want)
+			}
+		}
+	}
+
+	m.Add("10.0.0.1:8001", "10.0.0.2:8001", "10.0.0.3:8001")
+	check("Add", map[string]int{"10.0.0.1:8001": 50, "10.0.0.2:8001": 50, "10.0.0.3:8001": 50})
+
+	m.Remove("10.0.0.2:8001")
+	check("Remove", map[string]int{"10.0.0.1:8001": 50, "10.0.0.3:8001": 50})
+
+	// 重新平衡后 10.0.0.1 负载比 1.5，虚拟节点 33；10.0.0.3 负载比 0.5，虚拟节点 75
+	m.mu.Lock()
+	m.nodeCounts["10.0.0.1:8001"] = 750
+	m.nodeCounts["10.0.0.3:8001"] = 250
+	m.totalRequests = 1000
+	m.mu.Unlock()
+	m.Rebalance()
+	check("Rebalance", map[string]int{"10.0.0.1:8001": 33, "10.0.0.3:8001": 75})
+}
+
+// 测试全部为空的节点不修改哈希环并返回 ErrNoValidNodes
+func TestAddEmptyNodes(t *testing.T) {
+	m := New(WithConfig(newTestConfig()), WithBalanceInterval(0))
+
+	for _, nodes := range [][]string{{""}, {"", ""}} {
+		if err := m.Add(nodes...); !errors.Is(err, ErrNoValidNodes) {
+			t.Fatalf("Add(%q): expected ErrNoValidNodes, got %v", nodes, err)
+		}
+	}
+	if len(m.keys) != 0 || m.Generation() != 0 {
+		t.Fatalf("Expected ring unchanged, got %d positions, generation %d", len(m.keys), m.Generation())
+	}
+
+	// 混有空节点时添加其余节点
+	if err := m.Add("", "A"); err != nil {
+		t.Fatalf("Add with one valid node failed: %v", err)
+	}
+	if m.Get("key") != "A" || m.Generation() != 1 {
+		t.Fatalf("Expected A to be added, got %q generation %d", m.Get("key"), m.Generation())
+	}
+
+	if err := m.Add(); err == nil || errors.Is(err, ErrNoValidNodes) {
+		t.Fatalf("Expected a distinct error for no nodes, got %v", err)
+	}
+}
+
+// 测试 NewWithNodes 在构造时填充哈希环，路由结果与逐个 Add 相同，非法输入返回错误
+func TestNewWithNodes(t *testing.T) {
+	nodes := []string{"A", "B", "C"}
+	m, err := NewWithNodes(nodes, WithConfig(newTestConfig()), WithBalanceInterval(0))
+	if err != nil {
+		t.Fatalf("NewWithNodes failed: %v", err)
+	}
+	defer m.Close()
+
+	if len(m.keys) != 150 || !sort.IntsAreSorted(m.keys) {
+		t.Fatalf("Expected 150 sorted positions, got %d sorted=%v", len(m.keys), sort.IntsAreSorted(m.keys))
+	}
+	for _, node := range nodes {
+		if r := m.Replicas(node); r != 50 {
+			t.Fatalf("Expected 50 replicas for %s, got %d", node, r)
+		}
+	}
+	if g := m.Generation(); g != 1 {
+		t.Fatalf("Expected a single ring change, got generation %d", g)
+	}
+
+	added := New(WithConfig(newTestConfig()), WithBalanceInterval(0))
+	defer added.Close()
+	for _, node := range nodes {
+		added.Add(node)
+	}
+	for i := range 100 {
+		key := "key" + strconv.Itoa(i)
+		if got, want := m.Get(key), added.Get(key); got != want {
+			t.Fatalf("Expected %s to route to %s, got %s", key, want, got)
+		}
+	}
+
+	config := newTestConfig()
+	config.DefaultReplicas = 0
+	for _, tc := range []struct {
+		nodes []string
+		opts  []Option
+		want  error
+	}{
+		{nil, nil, ErrNoValidNodes},
+		{[]string{"A", ""}, nil, ErrEmptyNode},
+		{[]string{"A", "B", "A"}, nil, ErrDuplicateNode},
+		{[]string{"A"}, []Option{WithConfig(config)}, ErrInvalidReplicas},
+	} {
+		opts := append([]Option{WithBalanceInterval(0)}, tc.opts...)
+		if m, err := NewWithNodes(tc.nodes, opts...); !errors.Is(err, tc.want) || m != nil {
+			t.Fatalf("NewWithNodes(%q): expected %v, got %v", tc.nodes, tc.want, err)
+		}
+	}
+}
+
+// 测试 Trace 按顺时针顺序返回不同物理节点的位置
+func TestTrace(t *testing.T) {
+	config := newTestConfig()
+	config.DefaultReplicas = 2
+	// 哈希值直接取键中的数字，节点 10 的虚拟节点哈希为 10、11
+	config.HashFunc = func(data []byte) uint32 {
+		n, _ := strconv.Atoi(string(data))
+		return uint32(n)
+	}
+	config.VirtualNodeKey = func(node string, i int) []byte {
+		n, _ := strconv.Atoi(node)
+		return []byte(strconv.Itoa(n + i))
+	}
+	m := New(WithConfig(config), WithBalanceInterval(0))
+	m.Add("10", "20", "30")
+	// 哈希环：10 11 20 21 30 31
+
+	got := m.Trace("15", 3)
+	want := []RingEntry{
+		{KeyHash: 15, Index: 2, Hash: 20, Node: "20"},
+		{KeyHash: 15, Index: 4, Hash: 30, Node: "30"},
+		{KeyHash: 15, Index: 0, Hash: 10, Node: "10"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %+v, got %+v", want, got)
+	}
+
+	// 键大于所有虚拟节点时回到起始位置
+	got = m.Trace("35", 2)
+	want = []RingEntry{
+		{KeyHash: 35, Index: 0, Hash: 10, Node: "10"},
+		{KeyHash: 35, Index: 2, Hash: 20, Node: "20"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected wrap-around %+v, got %+v", want, got)
+	}
+
+	// 请求数量超过节点数时返回全部节点
+	if got := m.Trace("15", 10); len(got) != 3 {
+		t.Errorf("Expected all 3 nodes, got %+v", got)
+	}
+	if got := m.Trace("15", 0); got != nil {
+		t.Errorf("Expected nil for n=0, got %+v", got)
+	}
+	// 不计入负载统计
+	if stats := m.GetStats(); len(stats) != 0 {
+		t.Errorf("Expected Trace not to affect stats, got %v", stats)
+	}
+}
+
+// 测试按虚拟节点间隔计算各节点负责的键空间比例
+func TestKeySpaceShares(t *testing.T) {
+	config := newTestConfig()
+	config.DefaultReplicas = 2
+	config.HashFunc = func(data []byte) uint32 {
+		n, _ := strconv.Atoi(string(data))
+		return uint32(n)
+	}
+	config.VirtualNodeKey = func(node string, i int) []byte {
+		n, _ := strconv.Atoi(node)
+		return []byte(strconv.Itoa(n + i))
+	}
+	m := New(WithConfig(config), WithBalanceInterval(0))
+
+	if shares := m.KeySpaceShares(); len(shares) != 0 {
+		t.Fatalf("Expected no shares for empty ring, got %v", shares)
+	}
+
+	m.Add("10", "20", "30")
+	// 哈希环：10 11 20 21 30 31
+	// 节点 10 负责 (31, 2^32) ∪ [0, 11]，节点 20 负责 (11, 21]，节点 30 负责 (21, 31]
+	const ringSize = float64(1 << 32)
+	want := map[string]float64{
+		"10": (ringSize - 20) / ringSize,
+		"20": 10 / ringSize,
+		"30": 10 / ringSize,
+	}
+	shares := m.KeySpaceShares()
+	sum := 0.0
+	for node, share := range shares {
+		if math.Abs(share-want[node]) > 1e-12 {
+			t.Errorf("Expected share %v for node %s, got %v", want[node], node, share)
+		}
+		sum += share
+	}
+	if len(shares) != 3 || math.Abs(sum-1) > 1e-9 {
+		t.Fatalf("Expected 3 shares summing to 1, got %v (sum %v)", shares, sum)
+	}
+}
+
+// 测试默认哈希下各节点的键空间比例之和为 1，且不受请求统计影响
+func TestKeySpaceSharesDefaultHash(t *testing.T) {
+	m := New(WithConfig(newTestConfig()), WithBalanceInterval(0))
+	m.Add("A", "B", "C")
+	for i := range 100 {
+		m.Get(strconv.Itoa(i))
+	}
+
+	sum := 0.0
+	for node, share := range m.KeySpaceShares() {
+		if share <= 0 || share >= 1 {
+			t.Errorf("Expected share of node %s in (0, 1), got %v", node, share)
+		}
+		sum += share
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Fatalf("Expected shares to sum to 1, got %v", sum)
+	}
+}
+
+// 测试按地址前缀批量移除节点
+func TestRemoveMatching(t *testing.T) {
+	m := New(WithConfig(newTestConfig()), WithBalanceInterval(0))
+	nodes := []string{"10.0.1.1:8001", "10.0.1.2:8001", "10.0.2.1:8001", "10.0.2.2:8001", "10.0.3.1:8001"}
+	if err := m.Add(nodes...); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	before := m.Generation()
+	n := m.RemoveMatching(func(node string) bool {
+		return strings.HasPrefix(node, "10.0.2.")
+	})
+	if n != 2 {
+		t.Fatalf("Expected 2 nodes removed, got %d", n)
+	}
+	if got := m.Generation() - before; got != 1 {
+		t.Fatalf("Expected a single ring change, got %d", got)
+	}
+
+	if len(m.keys) != 150 || len(m.hashMap) != 150 {
+		t.Fatalf("Expected 150 ring positions, got keys=%d hashMap=%d", len(m.keys), len(m.hashMap))
+	}
+	if !sort.IntsAreSorted(m.keys) {
+		t.Fatalf("Ring is not sorted after RemoveMatching")
+	}
+	for i := range 1000 {
+		if node := m.Get(fmt.Sprintf("key-%d", i)); strings.HasPrefix(node, "10.0.2.") {
+			t.Fatalf("Expected removed node not to be routed to, got %s", node)
+		}
+	}
+	for _, node := range []string{"10.0.2.1:8001", "10.0.2.2:8001"} {
+		if r := m.Replicas(node); r != 0 {
+			t.Errorf("Expected %s to have no replicas, got %d", node, r)
+		}
+	}
+
+	// 没有匹配的节点时不产生变化
+	before = m.Generation()
+	if n := m.RemoveMatching(func(node string) bool { return false }); n != 0 {
+		t.Fatalf("Expected no nodes removed, got %d", n)
+	}
+	if m.Generation() != before {
+		t.Fatalf("Expected no ring change when nothing matches")
+	}
+}
+
+// 测试并发添加节点、查找和读取统计，需要配合 go test -race 运行
+func TestConcurrentAddGet(t *testing.T) {
+	m := New(WithBalanceInterval(0))
+	defer m.Close()
+	if err := m.Add("node-0"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 20 {
+				if err := m.Add(fmt.Sprintf("node-%d-%d", i, j)); err != nil {
+					t.Errorf("Add failed: %v", err)
+				}
+			}
+		}()
+	}
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 500 {
+				if node := m.Get(fmt.Sprintf("key-%d-%d", i, j)); node == "" {
+					t.Errorf("Expected a node for every key")
+				}
+				if j%100 == 0 {
+					m.GetStats()
+					m.ExportStats()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	counts, total := m.ExportStats()
+	if total != 16*500 {
+		t.Fatalf("Expected %d requests, got %d", 16*500, total)
+	}
+	var sum int64
+	for _, count := range counts {
+		sum += count
+	}
+	if sum != total {
+		t.Fatalf("Expected per-node counts to sum to %d, got %d", total, sum)
+	}
+}
@@ -1,13 +1,23 @@
 package consistenthash
 
-import "hash/crc32"
+import (
+	"fmt"
+	"hash/crc32"
+)
 
 type Config struct {
-	DefaultReplicas      int                      // 每个真实节点对应的虚拟节点数
-	MinReplicas          int                      // 最小虚拟节点数
-	MaxReplicas          int                      // 最大虚拟节点数
-	HashFunc             func(data []byte) uint32 // 哈希函数
-	LoadBalanceThreshold float64                  // 负载均衡阈值，超过此值触发虚拟节点调整
+	DefaultReplicas      int                             // 每个真实节点对应的虚拟节点数
+	MinReplicas          int                             // 最小虚拟节点数
+	MaxReplicas          int                             // 最大虚拟节点数
+	HashFunc             func(data []byte) uint32        // 哈希函数
+	LoadBalanceThreshold float64                         // 负载均衡阈值，超过此值触发虚拟节点调整
+	VirtualNodeKey       func(node string, i int) []byte // 第 i 个虚拟节点参与哈希的键，为空时使用 DefaultVirtualNodeKey
+}
+
+// DefaultVirtualNodeKey 默认的虚拟节点键格式 "node-i"
+// 与其他语言实现共用同一个哈希环时，需要将 Config.VirtualNodeKey 设置为对方的格式，例如 "node#i"
+func DefaultVirtualNodeKey(node string, i int) []byte {
+	return fmt.Appendf(nil, "%s-%d", node, i)
 }
 
 // DefaultConfig 默认配置
@@ -17,4 +27,5 @@ var DefaultConfig = &Config{
 	MaxReplicas:          200,
 	HashFunc:             crc32.ChecksumIEEE,
 	LoadBalanceThreshold: 0.25, // 25% 的负载不均衡度触发调整
+	VirtualNodeKey:       DefaultVirtualNodeKey,
 }
@@ -0,0 +1,80 @@
+package consistenthash
+
+import (
+	"container/list"
+	"sync"
+)
+
+// WithLookupCache 缓存最近 size 个键的查找结果，热点键的 Get 无需重复计算哈希和二分查找
+// 哈希环变化（添加、移除节点或重新平衡）时清空缓存
+func WithLookupCache(size int) Option {
+	return func(m *Map) {
+		if size > 0 {
+			m.lookup = newLookupCache(size)
+		}
+	}
+}
+
+// lookupCache 键到节点的 LRU 缓存
+type lookupCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+// lookupEntry 缓存的查找结果
+type lookupEntry struct {
+	key  string
+	node string
+}
+
+// newLookupCache 创建查找缓存
+func newLookupCache(size int) *lookupCache {
+	return &lookupCache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+// get 返回键缓存的节点
+func (c *lookupCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	c.ll.MoveToFront(elem)
+	return elem.Value.(*lookupEntry).node, true
+}
+
+// put 缓存键的节点，超出容量时淘汰最久未使用的键
+func (c *lookupCache) put(key, node string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*lookupEntry).node = node
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&lookupEntry{key: key, node: node})
+	if c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*lookupEntry).key)
+	}
+}
+
+// clear 清空缓存
+func (c *lookupCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	clear(c.items)
+}
@@ -0,0 +1,39 @@
+package consistenthash
+
+// Pin 将键固定到节点，Get 时优先返回固定的节点，不受哈希和重新平衡的影响
+// 固定的节点不在哈希环中时按哈希环正常路由，节点加入后固定重新生效
+func (m *Map) Pin(key, node string) {
+	if key == "" || node == "" {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.pins == nil {
+		m.pins = make(map[string]string)
+	}
+	m.pins[key] = node
+	m.ringChanged()
+}
+
+// Unpin 取消键的固定，恢复按哈希环路由
+func (m *Map) Unpin(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.pins[key]; !ok {
+		return
+	}
+	delete(m.pins, key)
+	m.ringChanged()
+}
+
+// pinned 返回键固定且仍在哈希环中的节点，调用此方法必须持有锁
+func (m *Map) pinned(key string) (string, bool) {
+	node, ok := m.pins[key]
+	if !ok || m.nodeReplicas[node] == 0 {
+		return "", false
+	}
+	return node, true
+}
@@ -0,0 +1,45 @@
+package consistenthash
+
+// RingEntry Trace 返回的哈希环位置，用于排查副本的放置
+type RingEntry struct {
+	KeyHash int    // 键的哈希值，同一次 Trace 的所有条目相同
+	Index   int    // 虚拟节点在哈希环上的下标
+	Hash    int    // 虚拟节点的哈希值
+	Node    string // 虚拟节点对应的物理节点
+}
+
+// Trace 从键在哈希环上的位置开始顺时针查找，返回最多 n 个不同物理节点第一次出现的位置
+// 第一个条目的 Index 即键的起始下标；只反映哈希环本身，不考虑 Pin，不计入负载统计
+func (m *Map) Trace(key string, n int) []RingEntry {
+	if key == "" || n <= 0 {
+		return nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.keys) == 0 {
+		return nil
+	}
+
+	keyHash := int(m.config.HashFunc([]byte(key)))
+	n = min(n, len(m.nodeReplicas))
+	entries := make([]RingEntry, 0, n)
+	seen := make(map[string]bool, n)
+	start := m.search(key)
+	for i := 0; i < len(m.keys) && len(entries) < n; i++ {
+		idx := (start + i) % len(m.keys)
+		node := m.hashMap[m.keys[idx]]
+		if seen[node] {
+			continue
+		}
+		seen[node] = true
+		entries = append(entries, RingEntry{
+			KeyHash: keyHash,
+			Index:   idx,
+			Hash:    m.keys[idx],
+			Node:    node,
+		})
+	}
+	return entries
+}
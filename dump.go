@@ -0,0 +1,194 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCorruptRecord 导出数据中的记录不完整或格式错误
+var ErrCorruptRecord = errors.New("corrupt dump record")
+
+// 导出数据由若干条记录依次组成，每条记录的格式为（整数均为大端序）：
+//
+//	uint32 键长度 | 键 | uint32 值长度 | 值 | int64 过期时间（Unix 纳秒，0 表示永不过期）
+//
+// 记录之间没有分隔符，数据在记录边界结束即为完整的导出
+
+// Dump 将当前所有未过期的项按导出格式写入 w，返回写入的记录数
+// 与 Snapshot 相同，写入的是某一时刻的拷贝，写入 w 时不持有缓存的锁
+// 写入失败时返回已完整写入 w 的记录数
+func (c *Cache) Dump(w io.Writer) (int, error) {
+	entries, err := c.snapshot()
+	if err != nil {
+		return 0, err
+	}
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	ends := make([]int64, 0, len(entries)) // 每条记录结束时的累计字节数
+	for _, e := range entries {
+		if err := writeRecord(bw, e); err != nil {
+			return completeRecords(ends, cw.n), err
+		}
+		ends = append(ends, cw.n+int64(bw.Buffered()))
+	}
+	if err := bw.Flush(); err != nil {
+		return completeRecords(ends, cw.n), err
+	}
+	return len(entries), nil
+}
+
+// countingWriter 统计成功写入底层 Writer 的字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// completeRecords 返回前 written 字节中包含的完整记录数
+func completeRecords(ends []int64, written int64) int {
+	return sort.Search(len(ends), func(i int) bool { return ends[i] > written })
+}
+
+// DumpMap 以映射的形式返回当前所有未过期的项的拷贝，用于测试断言和排查问题
+// 修改返回的映射和值不会影响缓存；缓存已关闭或尚未写入时返回空映射
+func (c *Cache) DumpMap() map[string][]byte {
+	entries, _ := c.snapshot()
+	m := make(map[string][]byte, len(entries))
+	for _, e := range entries {
+		m[e.key] = e.value.ByteSLice()
+	}
+	return m
+}
+
+// writeRecord 写入一条记录
+func writeRecord(w io.Writer, e snapshotEntry) error {
+	if len(e.key) > math.MaxUint32 || e.value.Len() > math.MaxUint32 {
+		return fmt.Errorf("dump key %s: entry too large", e.key)
+	}
+
+	var expireAt int64
+	if !e.expireAt.IsZero() {
+		expireAt = e.expireAt.UnixNano()
+	}
+
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(e.key)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, e.key); err != nil {
+		return err
+	}
+	binary.BigEndian.PutUint32(header[:], uint32(e.value.Len()))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(e.value.b); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.BigEndian, expireAt)
+}
+
+// Preload 从 r 中读取 Dump 导出的记录并写入缓存，用于冷启动预热，返回写入的记录数
+// 读到不完整或格式错误的记录时停止，已读取的有效记录保留在缓存中，返回的错误包装了 ErrCorruptRecord
+// 导出后已过期的记录会被跳过，不计入返回的数量
+func (c *Cache) Preload(r io.Reader) (int, error) {
+	if atomic.LoadInt32(&c.closed) == 1 {
+		return 0, ErrCacheClosed
+	}
+
+	br := bufio.NewReader(r)
+	loaded := 0
+	for record := 0; ; record++ {
+		e, err := readRecord(br)
+		if err == io.EOF {
+			return loaded, nil
+		}
+		if err != nil {
+			return loaded, fmt.Errorf("preload record %d: %w", record, err)
+		}
+
+		if e.expireAt.IsZero() {
+			err = c.Set(e.key, e.value)
+		} else if time.Now().Before(e.expireAt) {
+			err = c.SetWithExpiration(e.key, e.value, e.expireAt)
+		} else {
+			continue
+		}
+		if err != nil {
+			return loaded, fmt.Errorf("preload key %s: %w", e.key, err)
+		}
+		loaded++
+	}
+}
+
+// readRecord 读取一条记录，数据在记录边界结束时返回 io.EOF
+func readRecord(r io.Reader) (snapshotEntry, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.EOF {
+			return snapshotEntry{}, io.EOF
+		}
+		return snapshotEntry{}, corrupt(err)
+	}
+	key, err := readField(r, binary.BigEndian.Uint32(header[:]))
+	if err != nil {
+		return snapshotEntry{}, err
+	}
+
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return snapshotEntry{}, corrupt(err)
+	}
+	value, err := readField(r, binary.BigEndian.Uint32(header[:]))
+	if err != nil {
+		return snapshotEntry{}, err
+	}
+
+	var expireAt int64
+	if err := binary.Read(r, binary.BigEndian, &expireAt); err != nil {
+		return snapshotEntry{}, corrupt(err)
+	}
+	if expireAt < 0 {
+		return snapshotEntry{}, fmt.Errorf("%w: negative expiration", ErrCorruptRecord)
+	}
+	if len(key) == 0 {
+		return snapshotEntry{}, fmt.Errorf("%w: empty key", ErrCorruptRecord)
+	}
+
+	e := snapshotEntry{key: string(key), value: ByteView{b: value}}
+	if expireAt > 0 {
+		e.expireAt = time.Unix(0, expireAt)
+	}
+	return e, nil
+}
+
+// readField 读取 n 字节，按实际读到的数据增长缓冲区，长度被破坏时不会预先分配过大的内存
+func readField(r io.Reader, n uint32) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, int64(n)); err != nil {
+		return nil, corrupt(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// corrupt 数据在记录中途结束时返回 ErrCorruptRecord，其他读取错误原样返回
+func corrupt(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: truncated", ErrCorruptRecord)
+	}
+	return err
+}
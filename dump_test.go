@@ -0,0 +1,220 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lyy42995004/Cache-Go/store"
+)
+
+// appendRecord 按导出格式追加一条记录
+func appendRecord(buf []byte, key, value string, expireAt int64) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(key)))
+	buf = append(buf, key...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(value)))
+	buf = append(buf, value...)
+	return binary.BigEndian.AppendUint64(buf, uint64(expireAt))
+}
+
+// 测试从构造的数据流预热，过期的记录被跳过
+func TestCachePreload(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+
+	var stream []byte
+	stream = appendRecord(stream, "a", "1", 0)
+	stream = appendRecord(stream, "b", "22", future.UnixNano())
+	stream = appendRecord(stream, "expired", "x", time.Now().Add(-time.Hour).UnixNano())
+	stream = appendRecord(stream, "empty", "", 0)
+
+	c := NewCache(DefaultCacheOptions())
+	defer c.Close()
+
+	n, err := c.Preload(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("Preload failed: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("Expected 3 records loaded, got %d", n)
+	}
+
+	for key, want := range map[string]string{"a": "1", "b": "22", "empty": ""} {
+		v, ok := c.Get(context.Background(), key)
+		if !ok || v.String() != want {
+			t.Fatalf("Expected %s=%q, got %q (found %v)", key, want, v.String(), ok)
+		}
+	}
+	if _, ok := c.Get(context.Background(), "expired"); ok {
+		t.Fatalf("Expected expired record to be skipped")
+	}
+}
+
+// 测试数据流被截断时保留之前的有效记录并返回 ErrCorruptRecord
+func TestCachePreloadTruncated(t *testing.T) {
+	var stream []byte
+	stream = appendRecord(stream, "a", "1", 0)
+	stream = appendRecord(stream, "b", "2", 0)
+	full := appendRecord(stream, "c", "333", 0)
+
+	// 在第三条记录的每个位置截断
+	for cut := len(stream) + 1; cut < len(full); cut++ {
+		c := NewCache(DefaultCacheOptions())
+
+		n, err := c.Preload(bytes.NewReader(full[:cut]))
+		if !errors.Is(err, ErrCorruptRecord) {
+			t.Fatalf("Cut at %d: expected ErrCorruptRecord, got %v", cut, err)
+		}
+		if n != 2 {
+			t.Fatalf("Cut at %d: expected 2 records loaded, got %d", cut, n)
+		}
+		if _, ok := c.Get(context.Background(), "b"); !ok {
+			t.Fatalf("Cut at %d: expected valid prefix to be cached", cut)
+		}
+		if _, ok := c.Get(context.Background(), "c"); ok {
+			t.Fatalf("Cut at %d: expected truncated record not to be cached", cut)
+		}
+		c.Close()
+	}
+}
+
+// 测试被破坏的长度字段不会预先分配内存
+func TestCachePreloadCorruptLength(t *testing.T) {
+	stream := binary.BigEndian.AppendUint32(nil, 0xFFFFFFFF)
+	stream = append(stream, "short"...)
+
+	c := NewCache(DefaultCacheOptions())
+	defer c.Close()
+
+	if _, err := c.Preload(bytes.NewReader(stream)); !errors.Is(err, ErrCorruptRecord) {
+		t.Fatalf("Expected ErrCorruptRecord, got %v", err)
+	}
+}
+
+// 测试 Dump 导出的数据可以预热到另一个缓存
+func TestCacheDumpPreloadRoundTrip(t *testing.T) {
+	src := NewCache(DefaultCacheOptions())
+	defer src.Close()
+
+	expireAt := time.Now().Add(time.Hour)
+	src.Set("plain", ByteView{b: []byte("v1")})
+	src.SetWithExpiration("ttl", ByteView{b: []byte("v2")}, expireAt)
+
+	var buf bytes.Buffer
+	n, err := src.Dump(&buf)
+	if err != nil || n != 2 {
+		t.Fatalf("Dump failed: n=%d err=%v", n, err)
+	}
+
+	g := newTestGroup(t, nil)
+	n, err = g.Preload(&buf)
+	if err != nil || n != 2 {
+		t.Fatalf("Preload failed: n=%d err=%v", n, err)
+	}
+
+	for key, want := range map[string]string{"plain": "v1", "ttl": "v2"} {
+		v, err := g.Get(context.Background(), key)
+		if err != nil || v.String() != want {
+			t.Fatalf("Expected %s=%q, got %q (err %v)", key, want, v.String(), err)
+		}
+	}
+
+	// 过期时间随记录保留
+	var got time.Time
+	g.mainCache.mu.RLock()
+	g.mainCache.store.ForEach(func(key string, _ store.Value, at time.Time) bool {
+		if key == "ttl" {
+			got = at
+		}
+		return true
+	})
+	g.mainCache.mu.RUnlock()
+	if got.IsZero() || got.Sub(expireAt).Abs() > time.Second {
+		t.Fatalf("Expected expiration near %v, got %v", expireAt, got)
+	}
+}
+
+// failingWriter 接受前 limit 字节后返回错误
+type failingWriter struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if room := w.limit - w.buf.Len(); len(p) > room {
+		w.buf.Write(p[:room])
+		return room, errors.New("disk full")
+	}
+	return w.buf.Write(p)
+}
+
+// 测试写入失败时 Dump 返回已完整写入的记录数
+func TestCacheDumpPartialWrite(t *testing.T) {
+	c := NewCache(DefaultCacheOptions())
+	defer c.Close()
+	for _, key := range []string{"a", "b", "c"} {
+		c.Set(key, ByteView{b: []byte("v")})
+	}
+
+	// 每条记录 4+1+4+1+8 = 18 字节，40 字节包含两条完整记录
+	w := &failingWriter{limit: 40}
+	n, err := c.Dump(w)
+	if err == nil || n != 2 {
+		t.Fatalf("Expected 2 records written before the error, got n=%d err=%v", n, err)
+	}
+
+	g := newTestGroup(t, nil)
+	if loaded, _ := g.Preload(bytes.NewReader(w.buf.Bytes()[:36])); loaded != 2 {
+		t.Fatalf("Expected the 2 complete records to preload, got %d", loaded)
+	}
+
+	if n, err := c.Dump(&failingWriter{}); err == nil || n != 0 {
+		t.Fatalf("Expected no records written, got n=%d err=%v", n, err)
+	}
+}
+
+// 测试 DumpMap 返回未过期的项的拷贝
+func TestCacheDumpMap(t *testing.T) {
+	for _, cacheType := range []store.CacheType{store.LRU, store.LRU2} {
+		t.Run(string(cacheType), func(t *testing.T) {
+			opts := DefaultCacheOptions()
+			opts.CacheType = cacheType
+			c := NewCache(opts)
+			defer c.Close()
+
+			if m := c.DumpMap(); len(m) != 0 {
+				t.Fatalf("Expected empty dump before first write, got %v", m)
+			}
+
+			c.Set("a", ByteView{b: []byte("1")})
+			c.SetWithExpiration("b", ByteView{b: []byte("2")}, time.Now().Add(time.Hour))
+			c.SetWithExpiration("expired", ByteView{b: []byte("3")}, time.Now().Add(50*time.Millisecond))
+			// LRU2 的时钟精度为 100ms
+			time.Sleep(250 * time.Millisecond)
+
+			m := c.DumpMap()
+			want := map[string]string{"a": "1", "b": "2"}
+			if len(m) != len(want) {
+				t.Fatalf("Expected %v, got %v", want, m)
+			}
+			for key, value := range want {
+				if string(m[key]) != value {
+					t.Fatalf("Expected %s=%s, got %q", key, value, m[key])
+				}
+			}
+
+			// 修改返回的值不影响缓存
+			m["a"][0] = 'x'
+			if view, _ := c.Get(context.Background(), "a"); view.String() != "1" {
+				t.Fatalf("Expected cache to be unaffected by dump mutation, got %q", view.String())
+			}
+
+			c.Close()
+			if m := c.DumpMap(); len(m) != 0 {
+				t.Fatalf("Expected empty dump after Close, got %v", m)
+			}
+		})
+	}
+}
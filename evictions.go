@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/lyy42995004/Cache-Go/store"
+	"github.com/sirupsen/logrus"
+)
+
+// EvictionEvent 缓存项被淘汰的事件
+type EvictionEvent struct {
+	Key   string
+	Value store.Value
+}
+
+// OverflowPolicy 淘汰事件缓冲区已满时的处理策略
+type OverflowPolicy int
+
+const (
+	// OverflowBlock 等待消费者取走事件，淘汰路径会被慢消费者阻塞，保证不丢失事件
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDrop 丢弃事件并计数
+	OverflowDrop
+	// OverflowLog 丢弃事件并记录警告日志
+	OverflowLog
+)
+
+// evictionSink 将淘汰事件投递到带缓冲的 channel，使淘汰路径不受消费者延迟影响
+type evictionSink struct {
+	mu      sync.RWMutex
+	ch      chan EvictionEvent
+	done    chan struct{} // 关闭时唤醒阻塞的投递
+	policy  OverflowPolicy
+	closed  bool
+	dropped int64 // 原子变量，缓冲区已满丢弃的事件数
+}
+
+// newEvictionSink 创建容量为 size 的淘汰事件缓冲区
+func newEvictionSink(size int, policy OverflowPolicy) *evictionSink {
+	return &evictionSink{
+		ch:     make(chan EvictionEvent, size),
+		done:   make(chan struct{}),
+		policy: policy,
+	}
+}
+
+// send 投递淘汰事件，缓冲区已满时按策略阻塞或丢弃
+func (s *evictionSink) send(key string, value store.Value) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return
+	}
+
+	ev := EvictionEvent{Key: key, Value: value}
+	if s.policy == OverflowBlock {
+		select {
+		case s.ch <- ev:
+		case <-s.done:
+		}
+		return
+	}
+
+	select {
+	case s.ch <- ev:
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+		if s.policy == OverflowLog {
+			logrus.Warnf("[G-Cache] eviction event buffer full, dropped event for key %s", key)
+		}
+	}
+}
+
+// stop 唤醒因缓冲区已满阻塞的投递，之后的投递不再阻塞
+// 阻塞的投递可能持有缓存的锁，必须在关闭缓存获取锁之前调用
+func (s *evictionSink) stop() {
+	close(s.done)
+}
+
+// close 等待进行中的投递结束后关闭 channel，消费者取完剩余事件后退出，调用前必须先调用 stop
+func (s *evictionSink) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	close(s.ch)
+}
+
+// EvictionEvents 返回淘汰事件的 channel，未设置 EvictionBuffer 时返回 nil
+// 缓存关闭后 channel 被关闭，消费者可以用 range 读取直到结束
+func (c *Cache) EvictionEvents() <-chan EvictionEvent {
+	if c.evictions == nil {
+		return nil
+	}
+	return c.evictions.ch
+}
+
+// DroppedEvictions 返回因缓冲区已满被丢弃的淘汰事件数
+func (c *Cache) DroppedEvictions() int64 {
+	if c.evictions == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&c.evictions.dropped)
+}
+
+// onEvicted 返回传给存储的淘汰回调，同时调用 OnEvicted 和投递淘汰事件
+func (c *Cache) onEvicted() func(key string, value store.Value) {
+	if c.evictions == nil {
+		return c.opts.OnEvicted
+	}
+	callback := c.opts.OnEvicted
+	return func(key string, value store.Value) {
+		if callback != nil {
+			callback(key, value)
+		}
+		c.evictions.send(key, value)
+	}
+}
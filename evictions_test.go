@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lyy42995004/Cache-Go/store"
+)
+
+// newEvictionTestCache 创建容量很小的 LRU 缓存，写入少量数据即会触发淘汰
+func newEvictionTestCache(buffer int, policy OverflowPolicy) *Cache {
+	opts := DefaultCacheOptions()
+	opts.CacheType = store.LRU
+	opts.MaxBytes = 100
+	opts.EvictionBuffer = buffer
+	opts.EvictionOverflow = policy
+	return NewCache(opts)
+}
+
+// 测试淘汰事件按顺序投递到 channel，缓存关闭后 channel 关闭
+func TestCacheEvictionEvents(t *testing.T) {
+	c := newEvictionTestCache(100, OverflowBlock)
+	if c.EvictionEvents() == nil {
+		t.Fatalf("Expected eviction channel to be enabled")
+	}
+
+	var evictedOrder []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ev := range c.EvictionEvents() {
+			evictedOrder = append(evictedOrder, ev.Key)
+		}
+	}()
+
+	for i := range 20 {
+		c.Set(fmt.Sprintf("key-%02d", i), ByteView{b: make([]byte, 10)})
+	}
+	remaining := c.Len()
+	c.Close()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected eviction channel to be closed after Close")
+	}
+	if len(evictedOrder) != 20-remaining {
+		t.Fatalf("Expected %d eviction events, got %d", 20-remaining, len(evictedOrder))
+	}
+	for i, key := range evictedOrder {
+		if want := fmt.Sprintf("key-%02d", i); key != want {
+			t.Fatalf("Expected event %d for %s, got %s", i, want, key)
+		}
+	}
+}
+
+// 测试 drop 策略下消费者不读取时淘汰不会阻塞，丢弃的事件被计数
+func TestCacheEvictionEventsDrop(t *testing.T) {
+	c := newEvictionTestCache(1, OverflowDrop)
+	defer c.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 100 {
+			c.Set(fmt.Sprintf("key-%02d", i), ByteView{b: make([]byte, 10)})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected evictions not to block on a slow consumer")
+	}
+
+	if len(c.EvictionEvents()) != 1 {
+		t.Fatalf("Expected buffered event to be kept, got %d", len(c.EvictionEvents()))
+	}
+	if dropped := c.DroppedEvictions(); dropped == 0 {
+		t.Fatalf("Expected dropped events to be counted")
+	}
+	if _, ok := c.Stats()["evictions_dropped"]; !ok {
+		t.Fatalf("Expected evictions_dropped in stats")
+	}
+}
+
+// 测试 block 策略下没有消费者时 Close 不会死锁
+func TestCacheEvictionEventsBlockClose(t *testing.T) {
+	c := newEvictionTestCache(1, OverflowBlock)
+
+	setDone := make(chan struct{})
+	go func() {
+		defer close(setDone)
+		for i := range 20 {
+			c.Set(fmt.Sprintf("key-%02d", i), ByteView{b: make([]byte, 10)})
+		}
+	}()
+
+	// 等待写入阻塞在已满的缓冲区上
+	deadline := time.Now().Add(2 * time.Second)
+	for len(c.EvictionEvents()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected eviction event to be buffered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected Close not to deadlock with a blocked eviction")
+	}
+	<-setDone
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lyy42995004/Cache-Go/store"
+)
+
+// storeFlusher 有待写回数据的存储
+type storeFlusher interface {
+	Flush() error
+}
+
+// storeSweeper 可以同步清理过期项的存储
+type storeSweeper interface {
+	Sweep()
+}
+
+// Flush 同步写回存储中缓冲的数据并清理过期项，返回后 Len 和 UsedBytes 不包含过期项
+// 用于优雅关闭前或测试中得到确定的状态；缓存尚未初始化时不做任何操作
+func (c *Cache) Flush() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, err := c.storeLocked()
+	if errors.Is(err, ErrCacheUninitialized) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return flushStore(s)
+}
+
+// flushStore 写回并清理存储，存储不支持时跳过对应步骤
+func flushStore(s store.Store) error {
+	var err error
+	if f, ok := s.(storeFlusher); ok {
+		err = f.Flush()
+	}
+	if sw, ok := s.(storeSweeper); ok {
+		sw.Sweep()
+	}
+	return err
+}
+
+// Flush 等待进行中的异步同步和读修复写入其他节点，然后刷新本地缓存
+// 返回时此前的 Set、Delete 已同步到其他节点（同步失败的只记录日志），本地缓存不包含过期项
+func (g *Group) Flush() error {
+	if atomic.LoadInt32(&g.closed) == 1 {
+		return ErrGroupClosed
+	}
+
+	g.syncs.wait()
+	return g.mainCache.Flush()
+}
+
+// inflight 记录进行中的后台任务，零值可用
+type inflight struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{} // 等待者关注的通道，任务全部完成时关闭
+}
+
+// run 在新协程中执行 fn
+func (f *inflight) run(fn func()) {
+	f.mu.Lock()
+	f.n++
+	f.mu.Unlock()
+
+	go func() {
+		defer f.done()
+		fn()
+	}()
+}
+
+// done 结束一个任务，全部完成时唤醒等待者
+func (f *inflight) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.n--
+	if f.n == 0 && f.idle != nil {
+		close(f.idle)
+		f.idle = nil
+	}
+}
+
+// wait 等待调用时进行中的任务及其间新增的任务全部完成
+func (f *inflight) wait() {
+	f.mu.Lock()
+	if f.n == 0 {
+		f.mu.Unlock()
+		return
+	}
+	if f.idle == nil {
+		f.idle = make(chan struct{})
+	}
+	idle := f.idle
+	f.mu.Unlock()
+
+	<-idle
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lyy42995004/Cache-Go/store"
+)
+
+// 测试 Flush 返回后 Len 和 UsedBytes 不包含过期项
+func TestCacheFlush(t *testing.T) {
+	for _, cacheType := range []store.CacheType{store.LRU, store.LRU2} {
+		t.Run(string(cacheType), func(t *testing.T) {
+			opts := DefaultCacheOptions()
+			opts.CacheType = cacheType
+			c := NewCache(opts)
+			defer c.Close()
+
+			if err := c.Flush(); err != nil {
+				t.Fatalf("Expected Flush on uninitialized cache to succeed, got %v", err)
+			}
+
+			for i := range 5 {
+				c.SetWithExpiration(fmt.Sprintf("short-%d", i), ByteView{b: []byte("v")}, time.Now().Add(50*time.Millisecond))
+			}
+			c.Set("keep", ByteView{b: []byte("value")})
+			// LRU2 的时钟精度为 100ms
+			time.Sleep(250 * time.Millisecond)
+
+			if err := c.Flush(); err != nil {
+				t.Fatalf("Flush failed: %v", err)
+			}
+			if n := c.Len(); n != 1 {
+				t.Fatalf("Expected 1 item after Flush, got %d", n)
+			}
+			if cacheType == store.LRU {
+				if n := c.usedBytes(); n != int64(len("keep")+len("value")) {
+					t.Fatalf("Expected UsedBytes to exclude expired items, got %d", n)
+				}
+			}
+
+			c.Close()
+			if err := c.Flush(); err != ErrCacheClosed {
+				t.Fatalf("Expected ErrCacheClosed after Close, got %v", err)
+			}
+		})
+	}
+}
+
+// 测试 Flush 等待异步读取触发的过期删除完成
+func TestCacheFlushWaitsForAsyncDeletes(t *testing.T) {
+	evicted := make(chan string, 10)
+	opts := DefaultCacheOptions()
+	opts.CacheType = store.LRU
+	opts.OnEvicted = func(key string, value store.Value) {
+		evicted <- key
+	}
+	c := NewCache(opts)
+	defer c.Close()
+
+	c.SetWithExpiration("key", ByteView{b: []byte("v")}, time.Now().Add(20*time.Millisecond))
+	time.Sleep(50 * time.Millisecond)
+
+	// 读取到过期项时异步删除
+	if _, ok := c.Get(context.Background(), "key"); ok {
+		t.Fatalf("Expected expired key to miss")
+	}
+	if err := c.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	select {
+	case key := <-evicted:
+		if key != "key" {
+			t.Fatalf("Expected eviction of key, got %s", key)
+		}
+	default:
+		t.Fatalf("Expected expired key to be evicted before Flush returned")
+	}
+}
+
+// 测试 Group.Flush 返回时异步同步已全部到达其他节点
+func TestGroupFlush(t *testing.T) {
+	peer := &slowPeer{fakePeer: newFakePeer("A"), delay: 20 * time.Millisecond}
+	g := newTestGroup(t, nil)
+	g.RegisterPeers(&slowPicker{peer: peer})
+
+	ctx := context.Background()
+	for i := range 5 {
+		if err := g.Set(ctx, fmt.Sprintf("key-%d", i), []byte("value")); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	if err := g.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	peer.mu.Lock()
+	n := len(peer.data)
+	peer.mu.Unlock()
+	if n != 5 {
+		t.Fatalf("Expected all 5 writes on peer after Flush, got %d", n)
+	}
+
+	g.Close()
+	if err := g.Flush(); err != ErrGroupClosed {
+		t.Fatalf("Expected ErrGroupClosed after Close, got %v", err)
+	}
+}
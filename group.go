@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -22,6 +23,20 @@ type contextKey string
 
 const fromPeerKey contextKey = "from_peer"
 
+// expireAtKey 写入请求携带的过期时间，同步到其他节点时传递，使各节点的副本同时过期
+const expireAtKey contextKey = "expire_at"
+
+// withExpireAt 在 context 中记录写入的过期时间
+func withExpireAt(ctx context.Context, expireAt time.Time) context.Context {
+	return context.WithValue(ctx, expireAtKey, expireAt)
+}
+
+// expireAtFrom 返回 context 中记录的过期时间
+func expireAtFrom(ctx context.Context) (time.Time, bool) {
+	expireAt, ok := ctx.Value(expireAtKey).(time.Time)
+	return expireAt, ok
+}
+
 // ErrKeyRequired 键不能为空错误
 var ErrKeyRequired = errors.New("key is required")
 
@@ -31,6 +46,9 @@ var ErrValueRequired = errors.New("value is required")
 // ErrGroupClosed 组已关闭错误
 var ErrGroupClosed = errors.New("cache group is closed")
 
+// ErrOverloaded 并发加载过多，请求被丢弃错误
+var ErrOverloaded = errors.New("cache group is overloaded")
+
 // Getter 加载键值的回调函数接口
 type Getter interface {
 	Get(ctx context.Context, key string) ([]byte, error)
@@ -46,14 +64,35 @@ func (f GetterFunc) Get(ctx context.Context, key string) ([]byte, error) {
 
 // Group 缓存组
 type Group struct {
-	name       string
-	getter     Getter              // 数据加载回调
-	mainCache  *Cache              // 本地缓存实例
-	peers      PeerPicker          // 分布式节点选择器
-	loader     *singleflight.Group // 单飞组，防止缓存穿透
-	expiration time.Duration
-	closed     int32
-	stats      groupStats // 统计信息
+	name         string
+	getter       Getter              // 数据加载回调
+	mainCache    *Cache              // 本地缓存实例
+	peers        PeerPicker          // 分布式节点选择器
+	loader       *singleflight.Group // 单飞组，防止缓存穿透
+	loadBreaker  *circuitBreaker     // 数据源熔断器，为空时不熔断
+	expiration   time.Duration
+	limiter      *MemoryLimiter // 共享内存预算，为空时只受本组 MaxBytes 限制
+	maxLoads     int64          // 最大并发加载数，0 表示不限制
+	maxWaiters   int64          // 最大等待加载的请求数，0 表示不限制
+	loading      int64          // 当前正在执行的加载数
+	waiting      int64          // 当前等待加载结果的请求数
+	decay        float64        // 加载耗时滑动平均的衰减因子，取值 (0, 1]
+	replicas     int            // 每个键的副本数
+	writeQuorum  int            // 写入法定副本数，0 表示异步同步到主节点
+	readRepair   bool           // 从主节点读取后是否修复其他副本
+	readStrategy ReadStrategy   // 从副本读取时选择节点的策略
+	readCursor   uint64         // 轮询读取的计数，原子操作
+	hedgeDelay   time.Duration  // 对冲读取前等待主节点响应的时间，0 表示不开启
+	throttle     *loadThrottle  // 按键限制加载频率，为空时不限制
+	refreshTTL   bool           // 同步到其他节点成功后是否延长本地副本的过期时间
+	peerFallback bool           // 从其他节点获取失败时是否回退到本地数据源加载
+	emptyValues  bool           // 是否允许写入长度为 0 的值，开启后 nil 值视为删除
+	casRetries   int            // Update 遇到版本冲突时的最大重试次数
+	accessLog    *AccessLogger  // 访问日志，为空时不记录
+	syncs        inflight       // 进行中的异步同步和读修复，Flush 时等待
+	locks        lockTable      // 本节点持有的分布式锁
+	closed       int32
+	stats        groupStats // 统计信息
 }
 
 // groupStats 缓存组的相关信息
@@ -66,8 +105,18 @@ type groupStats struct {
 	loaderHits   int64 // 从加载器获取成功次数
 	loaderErrors int64 // 从加载器获取失败次数
 	loadDuration int64 // 加载总耗时（纳秒）
+	shedLoads    int64 // 因过载被丢弃的请求数
+	loadEWMA     int64 // 加载耗时的指数加权滑动平均（纳秒）
+	readRepairs  int64 // 读修复写入的副本数
+	hedgedReads  int64 // 对冲读取向第二个副本发起的请求数
+	throttled    int64 // 因加载限流未调用加载器的次数
+	loadRejects  int64 // 因数据源熔断未调用加载器的次数
+	waitTimeouts int64 // 等待正在进行的加载超时的请求数
 }
 
+// defaultLatencyDecay 默认的加载耗时衰减因子
+const defaultLatencyDecay = 0.2
+
 // GroupOption 定义 Group 的配置选项
 type GroupOption func(*Group)
 
@@ -78,6 +127,66 @@ func WithExpiration(d time.Duration) GroupOption {
 	}
 }
 
+// WithRefreshTTLOnPeerWrite 同步写入到其他节点成功后，将本地副本的过期时间延长同步耗时
+// 远程副本从收到请求时开始计算剩余时间，会比本地副本晚过期一个网络延迟；开启后本地副本不会早于远程副本过期
+func WithRefreshTTLOnPeerWrite() GroupOption {
+	return func(g *Group) {
+		g.refreshTTL = true
+	}
+}
+
+// WithEmptyValues 允许 Set 写入长度为 0 的值，用于缓存"已知为空"的结果（负缓存），读取时作为命中返回
+// 开启后 nil 值视为删除键；其他节点同步的写入经 gRPC 传输后无法区分 nil 与空值，按空值写入
+// 默认不开启，写入空值返回 ErrValueRequired
+func WithEmptyValues() GroupOption {
+	return func(g *Group) {
+		g.emptyValues = true
+	}
+}
+
+// WithLoaderFallbackOnPeerError 设置从所属节点获取失败时是否回退到本地数据源加载，默认开启
+// 开启时单个节点故障不会导致请求失败，加载结果缓存在本地；关闭时直接返回节点的错误，
+// 适用于数据源只能由所属节点访问或需要避免数据源被故障节点的流量击穿的场景
+func WithLoaderFallbackOnPeerError(enabled bool) GroupOption {
+	return func(g *Group) {
+		g.peerFallback = enabled
+	}
+}
+
+// WithLoadShedding 设置过载保护阈值
+// 正在执行的加载数达到 maxLoads，或等待加载结果的请求数达到 maxWaiters 时，
+// 新的未命中请求直接返回 ErrOverloaded，缓存命中不受影响；阈值为 0 表示不限制
+func WithLoadShedding(maxLoads, maxWaiters int) GroupOption {
+	return func(g *Group) {
+		g.maxLoads = int64(maxLoads)
+		g.maxWaiters = int64(maxWaiters)
+	}
+}
+
+// WithLoadWaitTimeout 设置并发未命中请求等待同一个键正在进行的加载的最长时间，
+// 超时返回 singleflight.ErrWaitTimeout，不影响正在进行的加载及其结果写入缓存；d <= 0 时一直等待
+func WithLoadWaitTimeout(d time.Duration) GroupOption {
+	return func(g *Group) {
+		g.loader.WaitTimeout = max(d, 0)
+	}
+}
+
+// WithMaxInFlightLoads 设置正在进行的加载数的告警阈值，超过时记录警告日志，用于发现没有结束的加载；n <= 0 时不检查
+func WithMaxInFlightLoads(n int) GroupOption {
+	return func(g *Group) {
+		g.loader.MaxInFlight = max(n, 0)
+	}
+}
+
+// WithLatencyDecay 设置加载耗时滑动平均的衰减因子，越大越偏向最近的加载，取值 (0, 1]
+func WithLatencyDecay(decay float64) GroupOption {
+	return func(g *Group) {
+		if decay > 0 && decay <= 1 {
+			g.decay = decay
+		}
+	}
+}
+
 // WithCacheOptions 创建本地缓存实例
 func WithCacheOptions(opts CacheOptions) GroupOption {
 	return func(g *Group) {
@@ -96,16 +205,24 @@ func NewGroup(name string, cacheBytes int64, getter Getter, opts ...GroupOption)
 	cacheOpts.MaxBytes = cacheBytes
 
 	g := &Group{
-		name:      name,
-		getter:    getter,
-		mainCache: NewCache(cacheOpts),
-		loader:    &singleflight.Group{},
+		name:         name,
+		getter:       getter,
+		mainCache:    NewCache(cacheOpts),
+		loader:       &singleflight.Group{},
+		decay:        defaultLatencyDecay,
+		replicas:     1,
+		peerFallback: true,
+		casRetries:   defaultUpdateRetries,
 	}
 
 	for _, opt := range opts {
 		opt(g)
 	}
 
+	if g.limiter != nil {
+		g.limiter.register(g)
+	}
+
 	// 注册到全局组映射
 	groupsMu.Lock()
 	defer groupsMu.Unlock()
@@ -127,29 +244,123 @@ func GetGroup(name string) *Group {
 	return groups[name]
 }
 
+// Source 返回值的来源
+type Source int
+
+const (
+	SourceNotFound Source = iota // 未获取到值
+	SourceLocal                  // 本地缓存
+	SourcePeer                   // 其他节点
+	SourceLoader                 // 数据源加载器
+)
+
+// String 返回来源的名称
+func (s Source) String() string {
+	switch s {
+	case SourceLocal:
+		return "local"
+	case SourcePeer:
+		return "peer"
+	case SourceLoader:
+		return "loader"
+	default:
+		return "not_found"
+	}
+}
+
 // Get 从缓存获取数据
 func (g *Group) Get(ctx context.Context, key string) (ByteView, error) {
+	view, _, err := g.GetWithSource(ctx, key)
+	return view, err
+}
+
+// GetWithSource 从缓存获取数据，并返回值的来源，出错时来源为 SourceNotFound
+// 并发请求共享同一次加载时，来源与执行加载的请求相同
+func (g *Group) GetWithSource(ctx context.Context, key string) (ByteView, Source, error) {
+	if g.accessLog == nil {
+		return g.get(ctx, key)
+	}
+
+	start := time.Now()
+	view, source, err := g.get(ctx, key)
+	g.accessLog.log(g.name, "get", key, source == SourceLocal, start, err)
+	return view, source, err
+}
+
+// get 从缓存获取数据
+func (g *Group) get(ctx context.Context, key string) (ByteView, Source, error) {
 	// 检查组是否已关闭
 	if atomic.LoadInt32(&g.closed) == 1 {
-		return ByteView{}, ErrGroupClosed
+		return ByteView{}, SourceNotFound, ErrGroupClosed
 	}
 	if key == "" {
-		return ByteView{}, ErrKeyRequired
+		return ByteView{}, SourceNotFound, ErrKeyRequired
 	}
 
 	// 从本地缓存获取
 	view, ok := g.mainCache.Get(ctx, key)
 	if ok {
 		atomic.AddInt64(&g.stats.localHits, 1)
-		return view, nil
+		return view, SourceLocal, nil
 	}
 
 	atomic.AddInt64(&g.stats.localMisses, 1)
 	return g.load(ctx, key)
 }
 
+// GetIfPresent 只查询本地缓存和键所属的远程节点，不调用数据源，两者都未缓存时返回 false
+// 用于尽力而为的快速路径；远程节点命中的值不会写入本地缓存，不支持 PresentGetter 的节点不会被查询
+func (g *Group) GetIfPresent(ctx context.Context, key string) (ByteView, bool) {
+	if atomic.LoadInt32(&g.closed) == 1 || key == "" {
+		return ByteView{}, false
+	}
+
+	if view, ok := g.mainCache.Get(ctx, key); ok {
+		atomic.AddInt64(&g.stats.localHits, 1)
+		return view, true
+	}
+	atomic.AddInt64(&g.stats.localMisses, 1)
+
+	if g.peers == nil {
+		return ByteView{}, false
+	}
+	peer, ok, isSelf := g.peers.PickPeer(key)
+	if !ok || isSelf {
+		return ByteView{}, false
+	}
+	pg, ok := peer.(PresentGetter)
+	if !ok {
+		return ByteView{}, false
+	}
+
+	value, found, err := pg.GetIfPresent(g.name, key)
+	if err != nil {
+		atomic.AddInt64(&g.stats.peerMisses, 1)
+		logrus.Warnf("[G-Cache] failed to get from peer: %v", err)
+		return ByteView{}, false
+	}
+	if !found {
+		atomic.AddInt64(&g.stats.peerMisses, 1)
+		return ByteView{}, false
+	}
+	atomic.AddInt64(&g.stats.peerHits, 1)
+	return ByteView{b: value}, true
+}
+
 // Set 设置缓存值
 func (g *Group) Set(ctx context.Context, key string, value []byte) error {
+	if g.accessLog == nil {
+		return g.set(ctx, key, value)
+	}
+
+	start := time.Now()
+	err := g.set(ctx, key, value)
+	g.accessLog.log(g.name, "set", key, false, start, err)
+	return err
+}
+
+// set 设置缓存值
+func (g *Group) set(ctx context.Context, key string, value []byte) error {
 	// 检查组是否已关闭
 	if atomic.LoadInt32(&g.closed) == 1 {
 		return ErrGroupClosed
@@ -157,25 +368,50 @@ func (g *Group) Set(ctx context.Context, key string, value []byte) error {
 	if key == "" {
 		return ErrKeyRequired
 	}
-	if len(value) == 0 {
+	if value == nil && g.emptyValues && ctx.Value(fromPeerKey) == nil {
+		_, err := g.delete(ctx, key)
+		return err
+	}
+	if len(value) == 0 && !g.emptyValues {
 		return ErrValueRequired
 	}
 
 	// 创建缓存视图
 	view := ByteView{b: cloneBytes(value)}
 
-	// 设置到本地缓存
-	if g.expiration > 0 {
-		g.mainCache.SetWithExpiration(key, view, time.Now().Add(g.expiration))
+	// 其他节点同步过来的写入使用原请求的过期时间，否则使用本组的过期时间
+	expireAt, hasExpiry := expireAtFrom(ctx)
+	if !hasExpiry && g.expiration > 0 {
+		expireAt, hasExpiry = time.Now().Add(g.expiration), true
+		ctx = withExpireAt(ctx, expireAt)
+	}
+
+	// 设置到本地缓存，写入被拒绝时不再同步到其他节点
+	var err error
+	if hasExpiry {
+		err = g.mainCache.SetWithExpiration(key, view, expireAt)
 	} else {
-		g.mainCache.Set(key, view)
+		err = g.mainCache.Set(key, view)
+	}
+	if err != nil {
+		return err
 	}
+	g.enforceLimit()
 
+	return g.propagate(ctx, key, value)
+}
+
+// propagate 将本地的写入同步到其他节点，写入本身来自其他节点时不再同步
+func (g *Group) propagate(ctx context.Context, key string, value []byte) error {
 	// 检查是否是从其他节点同步过来的请求
 	isPeerRequest := ctx.Value(fromPeerKey) != nil
 	// 如果不是从其他节点同步过来的请求，且启用了分布式模式，同步到其他节点
 	if !isPeerRequest && g.peers != nil {
-		go g.syncToPeers(ctx, "set", key, value)
+		// 设置了写入法定数量时，同步等待副本确认
+		if picker, ok := g.peers.(ReplicaPicker); ok && g.writeQuorum > 0 {
+			return g.writeReplicas(ctx, picker, key, value)
+		}
+		g.syncs.run(func() { g.syncToPeers(ctx, "set", key, value) })
 	}
 
 	return nil
@@ -183,25 +419,108 @@ func (g *Group) Set(ctx context.Context, key string, value []byte) error {
 
 // Delete 删除缓存值
 func (g *Group) Delete(ctx context.Context, key string) error {
+	if g.accessLog == nil {
+		_, err := g.delete(ctx, key)
+		return err
+	}
+
+	start := time.Now()
+	deleted, err := g.delete(ctx, key)
+	g.accessLog.log(g.name, "delete", key, deleted, start, err)
+	return err
+}
+
+// delete 删除缓存值，返回本地缓存中是否存在该键
+func (g *Group) delete(ctx context.Context, key string) (bool, error) {
 	// 检查组是否已关闭
 	if atomic.LoadInt32(&g.closed) == 1 {
-		return ErrGroupClosed
+		return false, ErrGroupClosed
 	}
 	if key == "" {
-		return ErrKeyRequired
+		return false, ErrKeyRequired
 	}
 
 	// 从本地缓存删除
-	g.mainCache.Delete(key)
+	deleted := g.mainCache.Delete(key)
 
 	// 检查是否是从其他节点同步过来的请求
 	isPeerRequest := ctx.Value(fromPeerKey) != nil
 	// 如果不是从其他节点同步过来的请求，且启用了分布式模式，同步到其他节点
 	if !isPeerRequest && g.peers != nil {
-		go g.syncToPeers(ctx, "delete", key, nil)
+		g.syncs.run(func() { g.syncToPeers(ctx, "delete", key, nil) })
 	}
 
-	return nil
+	return deleted, nil
+}
+
+// MDelete 批量删除缓存值，按所属节点对键分组，每个节点只发起一次批量删除请求
+// 返回被删除的键数量
+func (g *Group) MDelete(ctx context.Context, keys []string) (int, error) {
+	// 检查组是否已关闭
+	if atomic.LoadInt32(&g.closed) == 1 {
+		return 0, ErrGroupClosed
+	}
+	for _, key := range keys {
+		if key == "" {
+			return 0, ErrKeyRequired
+		}
+	}
+
+	// 检查是否是从其他节点同步过来的请求
+	isPeerRequest := ctx.Value(fromPeerKey) != nil
+
+	count := 0
+	peerKeys := make(map[Peer][]string)
+	for _, key := range keys {
+		// 从本地缓存删除
+		removed := g.mainCache.Delete(key)
+
+		if !isPeerRequest && g.peers != nil {
+			if peer, ok, isSelf := g.peers.PickPeer(key); ok && !isSelf {
+				peerKeys[peer] = append(peerKeys[peer], key)
+				continue
+			}
+		}
+
+		if removed {
+			count++
+		}
+	}
+
+	if len(peerKeys) == 0 {
+		return count, nil
+	}
+
+	// 并发向各节点发起批量删除
+	syncCtx := context.WithValue(ctx, fromPeerKey, true)
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+	)
+	for peer, keys := range peerKeys {
+		wg.Add(1)
+		go func(peer Peer, keys []string) {
+			defer wg.Done()
+
+			n, err := peer.BatchDelete(syncCtx, g.name, keys)
+
+			mu.Lock()
+			defer mu.Unlock()
+			count += n
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}(peer, keys)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		logrus.Errorf("[G-Cache] failed to batch delete from peers: %v", errs)
+		return count, errors.Join(errs...)
+	}
+	return count, nil
 }
 
 // Clear 清空缓存
@@ -215,6 +534,18 @@ func (g *Group) Clear() {
 	logrus.Infof("[G-Cache] cleared cache for group [%s]", g.name)
 }
 
+// Preload 从 r 中读取 Cache.Dump 导出的记录写入本地缓存，返回写入的记录数
+// 用于冷启动预热，不经过数据源和远程节点；记录损坏时保留已写入的部分并返回错误
+func (g *Group) Preload(r io.Reader) (int, error) {
+	if atomic.LoadInt32(&g.closed) == 1 {
+		return 0, ErrGroupClosed
+	}
+
+	n, err := g.mainCache.Preload(r)
+	logrus.Infof("[G-Cache] preloaded %d entries into group [%s]", n, g.name)
+	return n, err
+}
+
 // Close 关闭组并释放资源
 func (g *Group) Close() error {
 	// 如果已经关闭，直接返回
@@ -222,6 +553,11 @@ func (g *Group) Close() error {
 		return nil
 	}
 
+	// 从共享内存预算中注销
+	if g.limiter != nil {
+		g.limiter.unregister(g)
+	}
+
 	// 关闭本地缓存
 	if g.mainCache != nil {
 		g.mainCache.Close()
@@ -236,68 +572,208 @@ func (g *Group) Close() error {
 	return nil
 }
 
+// loadResult 一次加载的结果，由 singleflight 共享给并发请求
+type loadResult struct {
+	view     ByteView
+	source   Source
+	expireAt time.Time // 从其他节点获取时远程副本的过期时间，零值表示不过期或未知
+}
+
 // load 加载数据
-func (g *Group) load(ctx context.Context, key string) (ByteView, error) {
+func (g *Group) load(ctx context.Context, key string) (ByteView, Source, error) {
+	// 等待加载结果的请求过多时直接丢弃
+	if g.maxWaiters > 0 {
+		if atomic.AddInt64(&g.waiting, 1) > g.maxWaiters {
+			atomic.AddInt64(&g.waiting, -1)
+			atomic.AddInt64(&g.stats.shedLoads, 1)
+			return ByteView{}, SourceNotFound, ErrOverloaded
+		}
+		defer atomic.AddInt64(&g.waiting, -1)
+	}
+
 	// 使用 singleflight 确保并发请求只加载一次
 	start := time.Now()
-	viewi, err := g.loader.Do(key, func() (any, error) {
+	resi, err := g.loader.Do(key, func() (any, error) {
+		// 并发加载过多时不再启动新的加载
+		if g.maxLoads > 0 {
+			if atomic.AddInt64(&g.loading, 1) > g.maxLoads {
+				atomic.AddInt64(&g.loading, -1)
+				return nil, ErrOverloaded
+			}
+			defer atomic.AddInt64(&g.loading, -1)
+		}
 		return g.loadData(ctx, key)
 	})
 
+	if err == ErrOverloaded {
+		atomic.AddInt64(&g.stats.shedLoads, 1)
+		return ByteView{}, SourceNotFound, err
+	}
+	if err == ErrLoadThrottled {
+		return ByteView{}, SourceNotFound, err
+	}
+	if err == ErrLoaderUnavailable {
+		atomic.AddInt64(&g.stats.loadRejects, 1)
+		return ByteView{}, SourceNotFound, err
+	}
+	// 等待超时的请求没有执行加载，加载仍在进行，不计入加载次数、耗时和错误
+	if err == singleflight.ErrWaitTimeout {
+		atomic.AddInt64(&g.stats.waitTimeouts, 1)
+		return ByteView{}, SourceNotFound, err
+	}
+
 	// 记录加载时间
-	load := time.Since(start).Nanoseconds()
-	atomic.AddInt64(&g.stats.loadDuration, load)
+	load := time.Since(start)
+	atomic.AddInt64(&g.stats.loadDuration, load.Nanoseconds())
 	atomic.AddInt64(&g.stats.loads, 1)
+	g.observeLoad(load)
 
 	if err != nil {
 		atomic.AddInt64(&g.stats.loaderErrors, 1)
-		return ByteView{}, err
+		return ByteView{}, SourceNotFound, err
 	}
 
-	view := viewi.(ByteView)
+	res := resi.(loadResult)
+	view := res.view
 
-	// 设置到本地缓存
+	// 设置到本地缓存，从其他节点获取的值不晚于远程副本过期，远程副本已过期时不缓存
+	expireAt := res.expireAt
+	now := time.Now()
 	if g.expiration > 0 {
-		g.mainCache.SetWithExpiration(key, view, time.Now().Add(g.expiration))
-	} else {
+		if local := now.Add(g.expiration); expireAt.IsZero() || local.Before(expireAt) {
+			expireAt = local
+		}
+	}
+	switch {
+	case expireAt.IsZero():
 		g.mainCache.Set(key, view)
+	case now.Before(expireAt):
+		g.mainCache.SetWithExpiration(key, view, expireAt)
 	}
+	g.enforceLimit()
 
-	return view, nil
+	return view, res.source, nil
+}
+
+// observeLoad 将一次加载耗时计入滑动平均
+func (g *Group) observeLoad(d time.Duration) {
+	for {
+		old := atomic.LoadInt64(&g.stats.loadEWMA)
+		next := int64(d)
+		if old > 0 {
+			next = int64(g.decay*float64(d) + (1-g.decay)*float64(old))
+		}
+		if atomic.CompareAndSwapInt64(&g.stats.loadEWMA, old, next) {
+			return
+		}
+	}
+}
+
+// AvgLoadLatency 返回最近加载耗时的指数加权滑动平均
+func (g *Group) AvgLoadLatency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&g.stats.loadEWMA))
+}
+
+// enforceLimit 写入本地缓存后检查共享内存预算
+func (g *Group) enforceLimit() {
+	if g.limiter != nil {
+		g.limiter.enforce()
+	}
 }
 
 // loadData 实际加载数据的方法
-func (g *Group) loadData(ctx context.Context, key string) (ByteView, error) {
+func (g *Group) loadData(ctx context.Context, key string) (loadResult, error) {
+	var peerErr error // 最近一次从其他节点获取失败的错误
+
+	// 开启对冲读取时同时读取两个副本，均失败时直接从数据源加载
+	value, expireAt, hedged, err := g.hedgedGet(ctx, key)
+	if hedged {
+		if err == nil {
+			atomic.AddInt64(&g.stats.peerHits, 1)
+			return loadResult{view: value, source: SourcePeer, expireAt: expireAt}, nil
+		}
+		peerErr = err
+		atomic.AddInt64(&g.stats.peerMisses, 1)
+		logrus.Warnf("[G-Cache] failed to get from replicas: %v", err)
+	}
+
 	// 尝试从远程节点获取
-	if g.peers != nil {
+	if g.peers != nil && !hedged {
+		if peer, ok := g.pickReadPeer(key); ok {
+			value, expireAt, err := g.getFromPeer(ctx, peer, key)
+			if err == nil {
+				atomic.AddInt64(&g.stats.peerHits, 1)
+				return loadResult{view: value, source: SourcePeer, expireAt: expireAt}, nil
+			}
+			peerErr = err
+			atomic.AddInt64(&g.stats.peerMisses, 1)
+			logrus.Warnf("[G-Cache] failed to get from replica, falling back to primary: %v", err)
+		}
+
 		peer, ok, isSelf := g.peers.PickPeer(key)
 		if ok && !isSelf {
-			value, err := g.getFromPeer(ctx, peer, key)
+			value, expireAt, err := g.getFromPeer(ctx, peer, key)
 			if err == nil {
 				atomic.AddInt64(&g.stats.peerHits, 1)
-				return value, nil
+				if picker, ok := g.peers.(ReplicaPicker); ok && g.readRepair {
+					g.syncs.run(func() { g.repairReplicas(picker, peer, key, value.ByteSLice(), expireAt) })
+				}
+				return loadResult{view: value, source: SourcePeer, expireAt: expireAt}, nil
 			}
+			peerErr = err
 			atomic.AddInt64(&g.stats.peerMisses, 1)
 			logrus.Warnf("[G-Cache] failed to get from peer: %v", err)
 		}
 	}
 
+	// 关闭回退时不从数据源加载，返回节点的错误
+	if peerErr != nil && !g.peerFallback {
+		return loadResult{}, peerErr
+	}
+
+	// 限流期间返回最近一次加载的值
+	if g.throttle != nil {
+		if view, valid, ok := g.throttle.allow(key, time.Now()); !ok {
+			atomic.AddInt64(&g.stats.throttled, 1)
+			if !valid {
+				return loadResult{}, ErrLoadThrottled
+			}
+			return loadResult{view: view, source: SourceLoader}, nil
+		}
+	}
+
 	// 从数据源加载数据
-	bytes, err := g.getter.Get(ctx, key)
+	bytes, err := g.getFromLoader(ctx, key)
+	if err == ErrLoaderUnavailable {
+		return loadResult{}, err
+	}
 	if err != nil {
-		return ByteView{}, fmt.Errorf("failed to get data: %w", err)
+		return loadResult{}, fmt.Errorf("failed to get data: %w", err)
 	}
 	atomic.AddInt64(&g.stats.loaderHits, 1)
-	return ByteView{b: cloneBytes(bytes)}, nil
+	view := ByteView{b: cloneBytes(bytes)}
+	if g.throttle != nil {
+		g.throttle.record(key, view)
+	}
+	return loadResult{view: view, source: SourceLoader}, nil
 }
 
-// getFromPeer 从其他节点获取数据
-func (g *Group) getFromPeer(ctx context.Context, peer Peer, key string) (ByteView, error) {
-	bytes, err := peer.Get(g.name, key)
+// getFromPeer 从其他节点获取数据，ctx 取消会中止读取
+// 同时返回远程副本的过期时间，零值表示不过期或未知；远程副本返回时已过期则为当前时间，不会缓存到本地
+func (g *Group) getFromPeer(ctx context.Context, peer Peer, key string) (ByteView, time.Time, error) {
+	bytes, ttl, err := peer.GetWithTTL(ctx, g.name, key)
 	if err != nil {
-		return ByteView{}, fmt.Errorf("failed to get from peer: %w", err)
+		return ByteView{}, time.Time{}, fmt.Errorf("failed to get from peer: %w", err)
+	}
+
+	var expireAt time.Time
+	switch {
+	case ttl > 0:
+		expireAt = time.Now().Add(ttl)
+	case ttl < 0:
+		expireAt = time.Now()
 	}
-	return ByteView{b: bytes}, nil
+	return ByteView{b: bytes}, expireAt, nil
 }
 
 // syncToPeers 同步操作到其他节点
@@ -312,13 +788,22 @@ func (g *Group) syncToPeers(ctx context.Context, op string, key string, value []
 		return
 	}
 
-	// 创建同步请求上下文
+	// 创建同步请求上下文，传递写入的过期时间
 	syncCtx := context.WithValue(context.Background(), fromPeerKey, true)
+	expireAt, hasExpiry := expireAtFrom(ctx)
+	if hasExpiry {
+		syncCtx = withExpireAt(syncCtx, expireAt)
+	}
 
 	var err error
 	switch op {
 	case "set":
+		start := time.Now()
 		err = peer.Set(syncCtx, g.name, key, value)
+		if err == nil && hasExpiry && g.refreshTTL {
+			// 远程副本最多晚过期一个往返时间
+			g.mainCache.Expire(key, expireAt.Add(time.Since(start)))
+		}
 	case "delete":
 		_, err = peer.Delete(g.name, key)
 	}
@@ -340,16 +825,23 @@ func (g *Group) RegisterPeers(peers PeerPicker) {
 // Stats 返回缓存统计信息
 func (g *Group) Stats() map[string]any {
 	stats := map[string]any{
-		"name":          g.name,
-		"closed":        atomic.LoadInt32(&g.closed) == 1,
-		"expiration":    g.expiration,
-		"loads":         atomic.LoadInt64(&g.stats.loads),
-		"local_hits":    atomic.LoadInt64(&g.stats.localHits),
-		"local_misses":  atomic.LoadInt64(&g.stats.localMisses),
-		"peer_hits":     atomic.LoadInt64(&g.stats.peerHits),
-		"peer_misses":   atomic.LoadInt64(&g.stats.peerMisses),
-		"loader_hits":   atomic.LoadInt64(&g.stats.loaderHits),
-		"loader_errors": atomic.LoadInt64(&g.stats.loaderErrors),
+		"name":            g.name,
+		"closed":          atomic.LoadInt32(&g.closed) == 1,
+		"expiration":      g.expiration,
+		"loads":           atomic.LoadInt64(&g.stats.loads),
+		"local_hits":      atomic.LoadInt64(&g.stats.localHits),
+		"local_misses":    atomic.LoadInt64(&g.stats.localMisses),
+		"peer_hits":       atomic.LoadInt64(&g.stats.peerHits),
+		"peer_misses":     atomic.LoadInt64(&g.stats.peerMisses),
+		"loader_hits":     atomic.LoadInt64(&g.stats.loaderHits),
+		"loader_errors":   atomic.LoadInt64(&g.stats.loaderErrors),
+		"shed_loads":      atomic.LoadInt64(&g.stats.shedLoads),
+		"read_repairs":    atomic.LoadInt64(&g.stats.readRepairs),
+		"hedged_reads":    atomic.LoadInt64(&g.stats.hedgedReads),
+		"throttled_loads": atomic.LoadInt64(&g.stats.throttled),
+		"rejected_loads":  atomic.LoadInt64(&g.stats.loadRejects),
+		"wait_timeouts":   atomic.LoadInt64(&g.stats.waitTimeouts),
+		"inflight_loads":  g.loader.InFlight(),
 	}
 
 	// 计算各种命中率
@@ -361,6 +853,7 @@ func (g *Group) Stats() map[string]any {
 	totalLoads := stats["loads"].(int64)
 	if totalLoads > 0 {
 		stats["avg_load_time_ms"] = float64(atomic.LoadInt64(&g.stats.loadDuration)) / float64(totalLoads) / float64(time.Millisecond)
+		stats["recent_load_time_ms"] = float64(g.AvgLoadLatency()) / float64(time.Millisecond)
 	}
 
 	// 添加缓存大小
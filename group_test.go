@@ -0,0 +1,963 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lyy42995004/Cache-Go/singleflight"
+	"github.com/lyy42995004/Cache-Go/store"
+)
+
+// fakePeer 用于测试的内存节点
+type fakePeer struct {
+	mu      sync.Mutex
+	name    string
+	data    map[string][]byte
+	batches [][]string           // 收到的批量删除请求
+	gets    int                  // 收到的 Get 请求数
+	err     error                // 非空时所有操作返回该错误
+	expires map[string]time.Time // Set 请求携带的过期时间
+	ttl     time.Duration        // GetWithTTL 返回的剩余过期时间
+}
+
+func newFakePeer(name string) *fakePeer {
+	return &fakePeer{name: name, data: make(map[string][]byte), expires: make(map[string]time.Time)}
+}
+
+func (p *fakePeer) Get(group, key string) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.gets++
+	if p.err != nil {
+		return nil, p.err
+	}
+	value, ok := p.data[key]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found on %s", key, p.name)
+	}
+	return value, nil
+}
+
+func (p *fakePeer) GetWithTTL(ctx context.Context, group, key string) ([]byte, time.Duration, error) {
+	value, err := p.Get(group, key)
+	return value, p.ttl, err
+}
+
+func (p *fakePeer) GetIfPresent(group, key string) ([]byte, bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.gets++
+	if p.err != nil {
+		return nil, false, p.err
+	}
+	value, ok := p.data[key]
+	return value, ok, nil
+}
+
+func (p *fakePeer) Set(ctx context.Context, group, key string, value []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	p.data[key] = value
+	if expireAt, ok := expireAtFrom(ctx); ok {
+		p.expires[key] = expireAt
+	}
+	return nil
+}
+
+func (p *fakePeer) SetNX(ctx context.Context, group, key string, value []byte, ttl time.Duration) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return false, p.err
+	}
+	if _, ok := p.data[key]; ok {
+		return false, nil
+	}
+	p.data[key] = value
+	return true, nil
+}
+
+func (p *fakePeer) DeleteIfValue(ctx context.Context, group, key string, value []byte) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return false, p.err
+	}
+	if current, ok := p.data[key]; !ok || string(current) != string(value) {
+		return false, nil
+	}
+	delete(p.data, key)
+	return true, nil
+}
+
+func (p *fakePeer) Delete(group, key string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return false, p.err
+	}
+	_, ok := p.data[key]
+	delete(p.data, key)
+	return ok, nil
+}
+
+func (p *fakePeer) BatchDelete(ctx context.Context, group string, keys []string) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.batches = append(p.batches, append([]string(nil), keys...))
+	if p.err != nil {
+		return 0, p.err
+	}
+	count := 0
+	for _, key := range keys {
+		if _, ok := p.data[key]; ok {
+			delete(p.data, key)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (p *fakePeer) Close() error {
+	return nil
+}
+
+// fakePicker 根据 owner 函数将键路由到固定节点，replicas 给出所有键共用的副本顺序
+type fakePicker struct {
+	self     string
+	peers    map[string]*fakePeer
+	owner    func(key string) string
+	replicas []string
+}
+
+func (p *fakePicker) PickPeer(key string) (Peer, bool, bool) {
+	name := p.owner(key)
+	if name == p.self {
+		return nil, true, true
+	}
+	peer, ok := p.peers[name]
+	if !ok {
+		return nil, false, false
+	}
+	return peer, true, false
+}
+
+func (p *fakePicker) PickPeers(key string, n int) ([]Peer, bool) {
+	var peers []Peer
+	self := false
+	for _, name := range p.replicas[:min(n, len(p.replicas))] {
+		if name == p.self {
+			self = true
+			continue
+		}
+		peers = append(peers, p.peers[name])
+	}
+	return peers, self
+}
+
+func (p *fakePicker) PeerByAddr(addr string) (Peer, bool) {
+	peer, ok := p.peers[addr]
+	return peer, ok
+}
+
+func (p *fakePicker) Close() error {
+	return nil
+}
+
+// ownerByPrefix 按键的首字母路由：a 开头路由到 A，b 开头路由到 B，其余路由到自身
+func ownerByPrefix(key string) string {
+	switch key[0] {
+	case 'a':
+		return "A"
+	case 'b':
+		return "B"
+	default:
+		return "self"
+	}
+}
+
+// newTestGroup 创建测试用的缓存组，测试结束时销毁
+func newTestGroup(t *testing.T, getter Getter, opts ...GroupOption) *Group {
+	t.Helper()
+	if getter == nil {
+		getter = GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+			return nil, errors.New("no loader")
+		})
+	}
+	g := NewGroup(t.Name(), 1<<20, getter, opts...)
+	t.Cleanup(func() { g.Close() })
+	return g
+}
+
+// 测试批量删除按节点分组
+func TestGroupMDelete(t *testing.T) {
+	peerA, peerB := newFakePeer("A"), newFakePeer("B")
+	picker := &fakePicker{
+		self:  "self",
+		peers: map[string]*fakePeer{"A": peerA, "B": peerB},
+		owner: ownerByPrefix,
+	}
+
+	g := newTestGroup(t, nil)
+	g.RegisterPeers(picker)
+
+	ctx := context.Background()
+	for _, key := range []string{"a1", "a2", "b1", "s1", "s2"} {
+		g.mainCache.Set(key, ByteView{b: []byte("value")})
+	}
+	for _, key := range []string{"a1", "a2", "a3"} {
+		peerA.data[key] = []byte("value")
+	}
+	peerB.data["b1"] = []byte("value")
+
+	count, err := g.MDelete(ctx, []string{"a1", "a2", "a3", "b1", "b2", "s1", "s3"})
+	if err != nil {
+		t.Fatalf("MDelete failed: %v", err)
+	}
+
+	// A 删除 3 个，B 删除 1 个，本地删除 1 个
+	if count != 5 {
+		t.Errorf("Expected 5 keys removed, got %d", count)
+	}
+
+	// 每个节点只收到一次批量请求，且只包含自己负责的键
+	check := func(peer *fakePeer, want []string) {
+		t.Helper()
+		if len(peer.batches) != 1 {
+			t.Fatalf("Expected peer %s to receive exactly 1 batch, got %d", peer.name, len(peer.batches))
+		}
+		got := peer.batches[0]
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Peer %s received %v, want %v", peer.name, got, want)
+		}
+	}
+	check(peerA, []string{"a1", "a2", "a3"})
+	check(peerB, []string{"b1", "b2"})
+
+	// 本地副本也被删除
+	for _, key := range []string{"a1", "a2", "b1", "s1"} {
+		if _, ok := g.mainCache.Get(ctx, key); ok {
+			t.Errorf("Expected local copy of %s to be deleted", key)
+		}
+	}
+	if _, ok := g.mainCache.Get(ctx, "s2"); !ok {
+		t.Errorf("Expected s2 to remain in local cache")
+	}
+}
+
+// 测试来自其他节点的批量删除只作用于本地
+func TestGroupMDeleteFromPeer(t *testing.T) {
+	peerA := newFakePeer("A")
+	picker := &fakePicker{
+		self:  "self",
+		peers: map[string]*fakePeer{"A": peerA},
+		owner: ownerByPrefix,
+	}
+
+	g := newTestGroup(t, nil)
+	g.RegisterPeers(picker)
+
+	g.mainCache.Set("a1", ByteView{b: []byte("value")})
+
+	ctx := context.WithValue(context.Background(), fromPeerKey, true)
+	count, err := g.MDelete(ctx, []string{"a1", "a2"})
+	if err != nil {
+		t.Fatalf("MDelete failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 key removed locally, got %d", count)
+	}
+	if len(peerA.batches) != 0 {
+		t.Errorf("Expected no batch to be forwarded for a peer request, got %v", peerA.batches)
+	}
+
+	// 空键
+	if _, err := g.MDelete(context.Background(), []string{"a1", ""}); err != ErrKeyRequired {
+		t.Errorf("Expected ErrKeyRequired, got %v", err)
+	}
+}
+
+// 测试节点批量删除失败时返回错误
+func TestGroupMDeletePeerError(t *testing.T) {
+	peerA, peerB := newFakePeer("A"), newFakePeer("B")
+	peerB.err = errors.New("peer unavailable")
+	picker := &fakePicker{
+		self:  "self",
+		peers: map[string]*fakePeer{"A": peerA, "B": peerB},
+		owner: ownerByPrefix,
+	}
+
+	g := newTestGroup(t, nil)
+	g.RegisterPeers(picker)
+	peerA.data["a1"] = []byte("value")
+
+	count, err := g.MDelete(context.Background(), []string{"a1", "b1"})
+	if err == nil {
+		t.Fatalf("Expected error from failing peer")
+	}
+	if count != 1 {
+		t.Errorf("Expected successful peer deletions to be counted, got %d", count)
+	}
+}
+
+// 测试本地写入被拒绝时 Set 返回错误且不同步到其他节点
+func TestGroupSetRejected(t *testing.T) {
+	peerA := newFakePeer("A")
+	picker := &fakePicker{
+		self:  "self",
+		peers: map[string]*fakePeer{"A": peerA},
+		owner: ownerByPrefix,
+	}
+
+	g := newTestGroup(t, nil, WithCacheOptions(CacheOptions{CacheType: store.LRU, MaxBytes: 64}))
+	g.RegisterPeers(picker)
+
+	err := g.Set(context.Background(), "a1", make([]byte, 128))
+	if !errors.Is(err, store.ErrValueTooLarge) {
+		t.Fatalf("Expected ErrValueTooLarge, got %v", err)
+	}
+	// 写入失败时直接返回，不会启动同步协程
+	if len(peerA.data) != 0 {
+		t.Errorf("Expected rejected write not to be synced, got %v", peerA.data)
+	}
+}
+
+// 测试过载时丢弃未命中请求，缓存命中不受影响
+func TestGroupLoadShedding(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	getter := GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		started <- struct{}{}
+		<-release
+		return []byte("value-" + key), nil
+	})
+
+	g := newTestGroup(t, getter, WithLoadShedding(2, 0))
+	ctx := context.Background()
+	if err := g.Set(ctx, "hot", []byte("cached")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// 占满并发加载名额
+	var wg sync.WaitGroup
+	for _, key := range []string{"slow1", "slow2"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			if _, err := g.Get(ctx, key); err != nil {
+				t.Errorf("Expected in-flight load of %s to succeed, got %v", key, err)
+			}
+		}(key)
+	}
+	<-started
+	<-started
+
+	// 新的未命中请求被丢弃
+	if _, err := g.Get(ctx, "miss"); err != ErrOverloaded {
+		t.Fatalf("Expected ErrOverloaded for excess miss, got %v", err)
+	}
+
+	// 缓存命中仍然正常
+	view, err := g.Get(ctx, "hot")
+	if err != nil || view.String() != "cached" {
+		t.Fatalf("Expected cache hit during overload, got %q, %v", view.String(), err)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if got := g.Stats()["shed_loads"].(int64); got != 1 {
+		t.Errorf("Expected 1 shed load, got %d", got)
+	}
+
+	// 负载恢复后可以正常加载
+	if view, err := g.Get(ctx, "miss"); err != nil || view.String() != "value-miss" {
+		t.Fatalf("Expected load to succeed after overload, got %q, %v", view.String(), err)
+	}
+}
+
+// 测试等待加载结果的请求数超过阈值时被丢弃
+func TestGroupLoadSheddingWaiters(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	getter := GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		started <- struct{}{}
+		<-release
+		return []byte("value"), nil
+	})
+
+	g := newTestGroup(t, getter, WithLoadShedding(0, 1))
+	ctx := context.Background()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := g.Get(ctx, "key")
+		done <- err
+	}()
+	<-started
+
+	// 同一个键的请求也需要排队等待，超过阈值直接返回
+	if _, err := g.Get(ctx, "key"); err != ErrOverloaded {
+		t.Fatalf("Expected ErrOverloaded for excess waiter, got %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Expected first load to succeed, got %v", err)
+	}
+}
+
+// 测试加载耗时滑动平均偏向最近的加载
+func TestGroupAvgLoadLatency(t *testing.T) {
+	g := newTestGroup(t, nil, WithLatencyDecay(0.5))
+
+	if got := g.AvgLoadLatency(); got != 0 {
+		t.Fatalf("Expected zero latency before any load, got %v", got)
+	}
+
+	// 第一次加载直接作为初始值
+	g.observeLoad(100 * time.Millisecond)
+	if got := g.AvgLoadLatency(); got != 100*time.Millisecond {
+		t.Fatalf("Expected 100ms after first load, got %v", got)
+	}
+
+	// 100 -> 60 -> 40 -> 30
+	for _, d := range []time.Duration{20, 20, 20} {
+		g.observeLoad(d * time.Millisecond)
+	}
+	if got := g.AvgLoadLatency(); got != 30*time.Millisecond {
+		t.Fatalf("Expected 30ms after recent fast loads, got %v", got)
+	}
+
+	// 累计平均仍受早期慢加载影响，滑动平均更接近最近的值
+	cumulative := (100 + 20*3) * time.Millisecond / 4
+	if got := g.AvgLoadLatency(); got >= cumulative {
+		t.Errorf("Expected EWMA %v to be below cumulative average %v", got, cumulative)
+	}
+
+	// 衰减因子越大，越快跟上最新值
+	fast := NewGroup(t.Name()+"-fast", 1<<20, g.getter, WithLatencyDecay(0.9))
+	t.Cleanup(func() { fast.Close() })
+	fast.observeLoad(100 * time.Millisecond)
+	fast.observeLoad(20 * time.Millisecond)
+	if got := fast.AvgLoadLatency(); got != 28*time.Millisecond {
+		t.Errorf("Expected 28ms with decay 0.9, got %v", got)
+	}
+}
+
+// 测试 GetWithSource 返回值的来源
+func TestGroupGetWithSource(t *testing.T) {
+	getter := GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		if key == "missing" {
+			return nil, errors.New("not found")
+		}
+		return []byte("loaded"), nil
+	})
+	g := newTestGroup(t, getter)
+
+	peerA := newFakePeer("A")
+	peerA.data["apple"] = []byte("remote")
+	g.RegisterPeers(&fakePicker{self: "self", peers: map[string]*fakePeer{"A": peerA}, owner: ownerByPrefix})
+
+	ctx := context.Background()
+	cases := []struct {
+		key    string
+		source Source
+		value  string
+	}{
+		{"apple", SourcePeer, "remote"},
+		{"apple", SourceLocal, "remote"},
+		{"key", SourceLoader, "loaded"},
+		{"key", SourceLocal, "loaded"},
+	}
+	for _, c := range cases {
+		view, source, err := g.GetWithSource(ctx, c.key)
+		if err != nil {
+			t.Fatalf("GetWithSource(%s) failed: %v", c.key, err)
+		}
+		if source != c.source || view.String() != c.value {
+			t.Errorf("GetWithSource(%s): expected %s %q, got %s %q", c.key, c.source, c.value, source, view.String())
+		}
+	}
+
+	if _, source, err := g.GetWithSource(ctx, "missing"); err == nil || source != SourceNotFound {
+		t.Fatalf("Expected error with SourceNotFound for missing key, got %s, %v", source, err)
+	}
+}
+
+// 测试 GetIfPresent 只查询本地缓存和远程节点，不调用数据源
+func TestGroupGetIfPresent(t *testing.T) {
+	var loads int32
+	getter := GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		atomic.AddInt32(&loads, 1)
+		return nil, errors.New("loader must not be called")
+	})
+	g := newTestGroup(t, getter)
+
+	peerA, peerB := newFakePeer("A"), newFakePeer("B")
+	peerA.data["apple"] = []byte("remote")
+	peerB.err = errors.New("unreachable")
+	g.RegisterPeers(&fakePicker{
+		self:  "self",
+		peers: map[string]*fakePeer{"A": peerA, "B": peerB},
+		owner: ownerByPrefix,
+	})
+
+	ctx := context.Background()
+	if err := g.Set(ctx, "key", []byte("local")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	cases := []struct {
+		key   string
+		found bool
+		value string
+	}{
+		{"key", true, "local"},
+		{"apple", true, "remote"},
+		{"avocado", false, ""}, // 远程节点未缓存
+		{"banana", false, ""},  // 远程节点出错
+		{"missing", false, ""}, // 本节点负责但未缓存
+		{"", false, ""},
+	}
+	for _, c := range cases {
+		view, ok := g.GetIfPresent(ctx, c.key)
+		if ok != c.found || view.String() != c.value {
+			t.Errorf("GetIfPresent(%q): expected %v %q, got %v %q", c.key, c.found, c.value, ok, view.String())
+		}
+	}
+
+	if n := atomic.LoadInt32(&loads); n != 0 {
+		t.Fatalf("Expected loader not to be called, got %d calls", n)
+	}
+	// 远程节点命中的值不写入本地缓存
+	if _, ok := g.mainCache.Get(ctx, "apple"); ok {
+		t.Fatalf("Expected peer value not to be cached locally")
+	}
+}
+
+// localExpiration 返回键在本地缓存中的过期时间
+func localExpiration(t *testing.T, g *Group, key string) time.Time {
+	t.Helper()
+	g.mainCache.mu.RLock()
+	defer g.mainCache.mu.RUnlock()
+
+	expireAt, ok := g.mainCache.store.(interface {
+		GetExpiration(key string) (time.Time, bool)
+	}).GetExpiration(key)
+	if !ok {
+		t.Fatalf("Expected %s to be cached locally", key)
+	}
+	return expireAt
+}
+
+// closeTimes 判断两个时间是否在误差范围内，缓存按剩余时长写入，过期时间会有微小偏差
+func closeTimes(a, b time.Time) bool {
+	d := a.Sub(b)
+	return d > -10*time.Millisecond && d < 10*time.Millisecond
+}
+
+// waitForPeerSet 等待异步同步的写入到达节点，返回携带的过期时间
+func waitForPeerSet(t *testing.T, peer *fakePeer, key string) time.Time {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		peer.mu.Lock()
+		expireAt, ok := peer.expires[key]
+		peer.mu.Unlock()
+		if ok {
+			return expireAt
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %s to be synced to peer %s with a TTL", key, peer.name)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// 测试同步写入时传递过期时间，本地和远程副本同时过期
+func TestGroupSetPropagatesTTL(t *testing.T) {
+	peerA := newFakePeer("A")
+	g := newTestGroup(t, nil, WithExpiration(time.Minute), WithCacheOptions(CacheOptions{
+		CacheType: store.LRU,
+		MaxBytes:  1 << 20,
+	}))
+	g.RegisterPeers(&fakePicker{
+		self:  "self",
+		peers: map[string]*fakePeer{"A": peerA},
+		owner: ownerByPrefix,
+	})
+
+	if err := g.Set(context.Background(), "a-key", []byte("value")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	remote := waitForPeerSet(t, peerA, "a-key")
+	if local := localExpiration(t, g, "a-key"); !closeTimes(local, remote) {
+		t.Fatalf("Expected remote expiry %v to match local %v", remote, local)
+	}
+
+	// 其他节点同步过来的写入使用原请求的过期时间，而不是本组的过期时间
+	expireAt := time.Now().Add(10 * time.Second)
+	ctx := withExpireAt(context.WithValue(context.Background(), fromPeerKey, true), expireAt)
+	if err := g.Set(ctx, "s-key", []byte("value")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if local := localExpiration(t, g, "s-key"); !closeTimes(local, expireAt) {
+		t.Fatalf("Expected propagated expiry %v, got %v", expireAt, local)
+	}
+}
+
+// slowPeer 写入前等待一段时间的节点
+type slowPeer struct {
+	*fakePeer
+	delay time.Duration
+}
+
+func (p *slowPeer) Set(ctx context.Context, group, key string, value []byte) error {
+	time.Sleep(p.delay)
+	return p.fakePeer.Set(ctx, group, key, value)
+}
+
+// slowPicker 将所有键路由到同一个节点
+type slowPicker struct {
+	peer *slowPeer
+}
+
+func (p *slowPicker) PickPeer(key string) (Peer, bool, bool) {
+	return p.peer, true, false
+}
+
+func (p *slowPicker) Close() error {
+	return nil
+}
+
+// 测试开启 WithRefreshTTLOnPeerWrite 后同步成功时延长本地副本的过期时间
+func TestGroupRefreshTTLOnPeerWrite(t *testing.T) {
+	peer := &slowPeer{fakePeer: newFakePeer("A"), delay: 50 * time.Millisecond}
+	g := newTestGroup(t, nil, WithExpiration(time.Minute), WithRefreshTTLOnPeerWrite(), WithCacheOptions(CacheOptions{
+		CacheType: store.LRU,
+		MaxBytes:  1 << 20,
+	}))
+	g.RegisterPeers(&slowPicker{peer: peer})
+
+	if err := g.Set(context.Background(), "key", []byte("value")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	before := localExpiration(t, g, "key")
+	remote := waitForPeerSet(t, peer.fakePeer, "key")
+	if !closeTimes(remote, before) {
+		t.Fatalf("Expected propagated expiry %v, got %v", before, remote)
+	}
+
+	// 等待同步完成后本地副本被延长
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		after := localExpiration(t, g, "key")
+		if after.Sub(before) >= peer.delay {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected local expiry to be extended by at least %v, got %v", peer.delay, after.Sub(before))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if value, ok := g.mainCache.Get(context.Background(), "key"); !ok || value.String() != "value" {
+		t.Fatalf("Expected value to be kept after refresh, got %q %v", value.String(), ok)
+	}
+}
+
+// 测试所属节点获取失败时回退到本地数据源并缓存结果
+func TestGroupLoaderFallbackOnPeerError(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			peerA := newFakePeer("A")
+			peerA.err = errors.New("peer unavailable")
+			var loads int32
+			getter := GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+				atomic.AddInt32(&loads, 1)
+				return []byte("loaded-" + key), nil
+			})
+
+			g := newTestGroup(t, getter, WithLoaderFallbackOnPeerError(enabled))
+			g.RegisterPeers(&fakePicker{
+				self:  "self",
+				peers: map[string]*fakePeer{"A": peerA},
+				owner: ownerByPrefix,
+			})
+
+			ctx := context.Background()
+			view, err := g.Get(ctx, "a-key")
+			if !enabled {
+				if err == nil || !strings.Contains(err.Error(), "peer unavailable") {
+					t.Fatalf("Expected peer error without fallback, got %q %v", view.String(), err)
+				}
+				if n := atomic.LoadInt32(&loads); n != 0 {
+					t.Fatalf("Expected loader not to be called without fallback, got %d calls", n)
+				}
+				return
+			}
+
+			if err != nil || view.String() != "loaded-a-key" {
+				t.Fatalf("Expected value from loader, got %q %v", view.String(), err)
+			}
+			stats := g.Stats()
+			if stats["peer_misses"].(int64) != 1 || stats["loader_hits"].(int64) != 1 {
+				t.Fatalf("Expected 1 peer miss and 1 loader hit, got %v", stats)
+			}
+
+			// 结果缓存在本地，再次读取不访问节点和数据源
+			if view, err := g.Get(ctx, "a-key"); err != nil || view.String() != "loaded-a-key" {
+				t.Fatalf("Expected cached value, got %q %v", view.String(), err)
+			}
+			if n := atomic.LoadInt32(&loads); n != 1 {
+				t.Fatalf("Expected loader to be called once, got %d", n)
+			}
+		})
+	}
+}
+
+// 测试等待正在进行的加载超时后返回错误，加载完成后结果仍写入缓存
+func TestGroupLoadWaitTimeout(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	getter := GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		close(started)
+		<-release
+		return []byte("value"), nil
+	})
+	g := newTestGroup(t, getter, WithLoadWaitTimeout(20*time.Millisecond))
+
+	ctx := context.Background()
+	leader := make(chan error, 1)
+	go func() {
+		_, err := g.Get(ctx, "key")
+		leader <- err
+	}()
+	<-started
+
+	if _, err := g.Get(ctx, "key"); !errors.Is(err, singleflight.ErrWaitTimeout) {
+		t.Fatalf("Expected ErrWaitTimeout for waiting request, got %v", err)
+	}
+
+	close(release)
+	if err := <-leader; err != nil {
+		t.Fatalf("Expected leader load to succeed, got %v", err)
+	}
+
+	// 等待超时单独计数，不计入加载次数和加载错误
+	stats := g.Stats()
+	if n := stats["wait_timeouts"].(int64); n != 1 {
+		t.Fatalf("Expected 1 wait timeout, got %d", n)
+	}
+	if n := stats["loads"].(int64); n != 1 {
+		t.Fatalf("Expected only the leader load to be counted, got %d", n)
+	}
+	if n := stats["loader_errors"].(int64); n != 0 {
+		t.Fatalf("Expected no loader errors, got %d", n)
+	}
+	if view, err := g.Get(ctx, "key"); err != nil || view.String() != "value" {
+		t.Fatalf("Expected loaded value to be cached, got %q %v", view.String(), err)
+	}
+}
+
+// 测试开启 WithEmptyValues 后空值作为命中返回，nil 值删除键
+func TestGroupEmptyValues(t *testing.T) {
+	ctx := context.Background()
+
+	g := newTestGroup(t, nil)
+	if err := g.Set(ctx, "key", []byte{}); !errors.Is(err, ErrValueRequired) {
+		t.Fatalf("Expected ErrValueRequired by default, got %v", err)
+	}
+
+	g = NewGroup(t.Name()+"-empty", 1<<20, GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		return nil, errors.New("no loader")
+	}), WithEmptyValues())
+	defer g.Close()
+
+	if err := g.Set(ctx, "key", []byte{}); err != nil {
+		t.Fatalf("Set of empty value failed: %v", err)
+	}
+	v, source, err := g.GetWithSource(ctx, "key")
+	if err != nil || v.Len() != 0 || source != SourceLocal {
+		t.Fatalf("Expected empty value as a local hit, got %q %v %v", v.String(), source, err)
+	}
+
+	if err := g.Set(ctx, "key", nil); err != nil {
+		t.Fatalf("Set of nil value failed: %v", err)
+	}
+	if _, err := g.Get(ctx, "key"); err == nil {
+		t.Fatalf("Expected nil value to delete the key")
+	}
+
+	// 其他节点同步的 nil 值按空值写入
+	peerCtx := context.WithValue(ctx, fromPeerKey, true)
+	if err := g.Set(peerCtx, "synced", nil); err != nil {
+		t.Fatalf("Set from peer failed: %v", err)
+	}
+	if v, ok := g.GetIfPresent(ctx, "synced"); !ok || v.Len() != 0 {
+		t.Fatalf("Expected synced nil value to be stored as empty, got %q %v", v.String(), ok)
+	}
+}
+
+// ttlPeer 读取时返回固定剩余过期时间的节点
+type ttlPeer struct {
+	*fakePeer
+	ttl time.Duration
+}
+
+func (p *ttlPeer) GetWithTTL(ctx context.Context, group, key string) ([]byte, time.Duration, error) {
+	value, err := p.Get(group, key)
+	return value, p.ttl, err
+}
+
+// 测试从其他节点获取的值按远程副本的剩余过期时间缓存到本地，与远程副本同时过期
+func TestGroupPeerTTL(t *testing.T) {
+	peer := &ttlPeer{fakePeer: newFakePeer("A"), ttl: 200 * time.Millisecond}
+	peer.data["a-key"] = []byte("remote")
+	picker := &ttlPicker{peer: peer}
+	g := newTestGroup(t, nil, WithExpiration(time.Hour), WithCacheOptions(CacheOptions{
+		CacheType: store.LRU,
+		MaxBytes:  1 << 20,
+	}))
+	g.RegisterPeers(picker)
+
+	ctx := context.Background()
+	start := time.Now()
+	if v, err := g.Get(ctx, "a-key"); err != nil || v.String() != "remote" {
+		t.Fatalf("Expected remote value, got %q %v", v.String(), err)
+	}
+	if local := localExpiration(t, g, "a-key"); !closeTimes(local, start.Add(peer.ttl)) {
+		t.Fatalf("Expected local copy to expire with the peer's at %v, got %v", start.Add(peer.ttl), local)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if _, ok := g.mainCache.Get(ctx, "a-key"); ok {
+		t.Fatalf("Expected local copy to expire with the peer's")
+	}
+
+	// 本组过期时间更短时使用本组的过期时间
+	peer.ttl = 2 * time.Hour
+	g.mainCache.Delete("a-key")
+	start = time.Now()
+	g.Get(ctx, "a-key")
+	if local := localExpiration(t, g, "a-key"); !closeTimes(local, start.Add(time.Hour)) {
+		t.Fatalf("Expected group expiration to cap the peer's ttl, got %v", local)
+	}
+
+	// 远程副本返回时已过期，值照常返回但不缓存到本地
+	peer.ttl = expiredTTL
+	g.mainCache.Delete("a-key")
+	if v, err := g.Get(ctx, "a-key"); err != nil || v.String() != "remote" {
+		t.Fatalf("Expected remote value, got %q %v", v.String(), err)
+	}
+	if _, ok := g.mainCache.Get(ctx, "a-key"); ok {
+		t.Fatalf("Expected value expired on the peer not to be cached locally")
+	}
+}
+
+// ttlPicker 将所有键路由到同一个远程节点
+type ttlPicker struct {
+	peer Peer
+}
+
+func (p *ttlPicker) PickPeer(key string) (Peer, bool, bool) {
+	return p.peer, true, false
+}
+
+func (p *ttlPicker) Close() error {
+	return nil
+}
+
+// 测试写入不属于当前节点的键后立即读取，从本地缓存返回，不经过所属节点
+func TestGroupReadAfterWriteServedLocally(t *testing.T) {
+	peerA := newFakePeer("A")
+	picker := &fakePicker{
+		self:  "self",
+		peers: map[string]*fakePeer{"A": peerA},
+		owner: ownerByPrefix,
+	}
+	g := newTestGroup(t, nil)
+	g.RegisterPeers(picker)
+
+	ctx := context.Background()
+	if err := g.Set(ctx, "a-key", []byte("fresh")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	v, source, err := g.GetWithSource(ctx, "a-key")
+	if err != nil || v.String() != "fresh" || source != SourceLocal {
+		t.Fatalf("Expected fresh local value, got %q %v %v", v.String(), source, err)
+	}
+
+	peerA.mu.Lock()
+	defer peerA.mu.Unlock()
+	if peerA.gets != 0 {
+		t.Fatalf("Expected no reads from the owning peer, got %d", peerA.gets)
+	}
+}
+
+// 测试包含空字节和非法 UTF-8 字节序列的键在本地和远程节点上按原样读写删除
+func TestGroupBinaryKeys(t *testing.T) {
+	peerA := newFakePeer("A")
+	picker := &fakePicker{
+		self:  "self",
+		peers: map[string]*fakePeer{"A": peerA},
+		owner: ownerByPrefix,
+	}
+	g := newTestGroup(t, nil)
+	g.RegisterPeers(picker)
+	ctx := context.Background()
+
+	local := []string{"\x00", "k\x00ey", "\xff\xfe"}
+	for _, key := range local {
+		if err := g.Set(ctx, key, []byte("v"+key)); err != nil {
+			t.Fatalf("Set %q failed: %v", key, err)
+		}
+		if view, err := g.Get(ctx, key); err != nil || view.String() != "v"+key {
+			t.Fatalf("Get %q: expected value, got %q %v", key, view.String(), err)
+		}
+	}
+	if _, ok := g.GetIfPresent(ctx, "k"); ok {
+		t.Fatalf("Expected no key truncated at the null byte")
+	}
+	if err := g.Delete(ctx, local[1]); err != nil {
+		t.Fatalf("Delete %q failed: %v", local[1], err)
+	}
+	if _, ok := g.GetIfPresent(ctx, local[1]); ok {
+		t.Fatalf("Expected %q to be deleted", local[1])
+	}
+
+	// 远程节点收到的是原始的键
+	remote := "a\x00\xff"
+	peerA.data[remote] = []byte("remote")
+	if view, err := g.Get(ctx, remote); err != nil || view.String() != "remote" {
+		t.Fatalf("Expected value from peer, got %q %v", view.String(), err)
+	}
+	if err := g.Delete(ctx, remote); err != nil {
+		t.Fatalf("Delete %q failed: %v", remote, err)
+	}
+	g.syncs.wait()
+	peerA.mu.Lock()
+	defer peerA.mu.Unlock()
+	if _, ok := peerA.data[remote]; ok {
+		t.Fatalf("Expected %q to be deleted on the peer", remote)
+	}
+}
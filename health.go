@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+
+	"google.golang.org/grpc/connectivity"
+)
+
+// HealthStatus 节点健康状态，用于就绪探针
+type HealthStatus struct {
+	Initialized   bool `json:"initialized"`    // 是否完成首次服务发现
+	EtcdConnected bool `json:"etcd_connected"` // 是否与 etcd 保持连接
+	PeerCount     int  `json:"peer_count"`     // 已连接的其他节点数
+	Ready         bool `json:"ready"`          // 以上条件均满足且节点数不少于要求
+}
+
+// WithMinPeers 设置节点就绪前至少需要发现的其他节点数
+func WithMinPeers(n int) PickerOption {
+	return func(cp *ClientPicker) {
+		if n >= 0 {
+			cp.minPeers = n
+		}
+	}
+}
+
+// HealthStatus 返回节点的健康状态
+func (cp *ClientPicker) HealthStatus() HealthStatus {
+	cp.mu.RLock()
+	peerCount := len(cp.clients)
+	cp.mu.RUnlock()
+
+	status := HealthStatus{
+		Initialized:   atomic.LoadInt32(&cp.initialized) == 1,
+		EtcdConnected: cp.etcdHealthy(),
+		PeerCount:     peerCount,
+	}
+	status.Ready = status.Initialized && status.EtcdConnected && status.PeerCount >= cp.minPeers
+	return status
+}
+
+// etcdConnected 检查与 etcd 的连接状态
+func (cp *ClientPicker) etcdConnected() bool {
+	if cp.etcdCli == nil {
+		return false
+	}
+	conn := cp.etcdCli.ActiveConnection()
+	if conn == nil {
+		return false
+	}
+	state := conn.GetState()
+	return state == connectivity.Ready || state == connectivity.Idle
+}
+
+// HealthHandler 返回就绪探针的 HTTP 处理函数，就绪时返回 200，否则返回 503，响应体为 JSON 格式的状态
+func HealthHandler(check func() HealthStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := check()
+
+		w.Header().Set("Content-Type", "application/json")
+		if status.Ready {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(status)
+	}
+}
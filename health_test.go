@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// 测试节点健康状态
+func TestClientPickerHealthStatus(t *testing.T) {
+	cp := newClientPicker("self", WithMinPeers(2))
+	defer cp.Close()
+
+	connected := true
+	cp.etcdHealthy = func() bool { return connected }
+
+	// 未完成服务发现
+	status := cp.HealthStatus()
+	if status.Initialized || status.Ready {
+		t.Fatalf("Expected not ready before discovery, got %+v", status)
+	}
+
+	atomic.StoreInt32(&cp.initialized, 1)
+	addPeers := func(n int) {
+		cp.mu.Lock()
+		defer cp.mu.Unlock()
+		for i := range n {
+			addr := fmt.Sprintf("10.0.0.%d:8001", len(cp.clients)+i)
+			cp.set(addr, newFakePeer(addr))
+		}
+	}
+
+	// 节点数不足
+	addPeers(1)
+	status = cp.HealthStatus()
+	if !status.Initialized || !status.EtcdConnected || status.PeerCount != 1 || status.Ready {
+		t.Fatalf("Expected not ready with 1 of 2 peers, got %+v", status)
+	}
+
+	// 满足所有条件
+	addPeers(1)
+	status = cp.HealthStatus()
+	if status.PeerCount != 2 || !status.Ready {
+		t.Fatalf("Expected ready with 2 peers, got %+v", status)
+	}
+
+	// etcd 连接断开
+	connected = false
+	status = cp.HealthStatus()
+	if status.EtcdConnected || status.Ready {
+		t.Fatalf("Expected not ready without etcd, got %+v", status)
+	}
+
+	// 未创建 etcd 客户端时视为未连接
+	if cp.etcdConnected() {
+		t.Errorf("Expected etcdConnected to be false without an etcd client")
+	}
+}
+
+// 测试就绪探针的 HTTP 状态码
+func TestHealthHandler(t *testing.T) {
+	for _, ready := range []bool{true, false} {
+		handler := HealthHandler(func() HealthStatus {
+			return HealthStatus{Initialized: true, EtcdConnected: ready, PeerCount: 3, Ready: ready}
+		})
+
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+		want := http.StatusOK
+		if !ready {
+			want = http.StatusServiceUnavailable
+		}
+		if rec.Code != want {
+			t.Errorf("Expected status %d for ready=%v, got %d", want, ready, rec.Code)
+		}
+
+		var status HealthStatus
+		if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if status.Ready != ready || status.PeerCount != 3 {
+			t.Errorf("Unexpected response body %+v", status)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+
+	"github.com/lyy42995004/Cache-Go/store"
+)
+
+// KeyCount 键及其估计访问次数
+type KeyCount struct {
+	Key   string
+	Count uint64
+}
+
+// hotKeyTracker 使用 count-min sketch 统计键的访问次数，并保留估计次数最高的 capacity 个键
+// sketch 的内存固定，估计值不小于真实值，误差上限约为 e/width 乘以总访问次数
+type hotKeyTracker struct {
+	mu       sync.Mutex
+	sketch   *store.CountMinSketch // 不衰减的访问计数
+	capacity int
+	top      hotKeyHeap              // 按估计次数排列的最小堆，堆顶为候选中访问最少的键
+	index    map[string]*hotKeyEntry // 候选键与堆中条目的映射
+}
+
+// hotKeyEntry 候选热点键
+type hotKeyEntry struct {
+	key   string
+	count uint64
+	pos   int // 在堆中的位置
+}
+
+// newHotKeyTracker 创建热点键统计，capacity 为保留的候选键数量
+func newHotKeyTracker(capacity int) *hotKeyTracker {
+	// 计数器个数随候选数量增长
+	return &hotKeyTracker{
+		sketch:   store.NewCountMinSketch(max(4096, 64*capacity), 0),
+		capacity: capacity,
+		index:    make(map[string]*hotKeyEntry, capacity),
+	}
+}
+
+// record 记录一次访问，估计次数超过候选中的最小值时替换该候选
+func (t *hotKeyTracker) record(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	count := uint64(t.sketch.Increment(key))
+
+	if e, ok := t.index[key]; ok {
+		e.count = count
+		heap.Fix(&t.top, e.pos)
+		return
+	}
+	if len(t.top) < t.capacity {
+		e := &hotKeyEntry{key: key, count: count}
+		heap.Push(&t.top, e)
+		t.index[key] = e
+		return
+	}
+	if count > t.top[0].count {
+		// 复用堆顶条目，替换为新的键
+		e := t.top[0]
+		delete(t.index, e.key)
+		e.key, e.count = key, count
+		t.index[key] = e
+		heap.Fix(&t.top, 0)
+	}
+}
+
+// topKeys 按估计次数从高到低返回最多 n 个键
+func (t *hotKeyTracker) topKeys(n int) []KeyCount {
+	t.mu.Lock()
+	keys := make([]KeyCount, 0, len(t.top))
+	for _, e := range t.top {
+		keys = append(keys, KeyCount{Key: e.key, Count: e.count})
+	}
+	t.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Count != keys[j].Count {
+			return keys[i].Count > keys[j].Count
+		}
+		return keys[i].Key < keys[j].Key
+	})
+	if n >= 0 && n < len(keys) {
+		keys = keys[:n]
+	}
+	return keys
+}
+
+// hotKeyHeap 候选热点键的最小堆，实现 heap.Interface
+type hotKeyHeap []*hotKeyEntry
+
+func (h hotKeyHeap) Len() int           { return len(h) }
+func (h hotKeyHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+
+func (h hotKeyHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos = i
+	h[j].pos = j
+}
+
+func (h *hotKeyHeap) Push(x any) {
+	e := x.(*hotKeyEntry)
+	e.pos = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *hotKeyHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// TopKeys 返回访问次数最多的 n 个键及其估计次数，按次数从高到低排列
+// 需要在 CacheOptions 中设置 HotKeys 开启统计，未开启时返回空
+func (c *Cache) TopKeys(n int) []KeyCount {
+	if c.hotKeys == nil {
+		return nil
+	}
+	return c.hotKeys.topKeys(n)
+}
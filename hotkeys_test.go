@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+// 测试倾斜访问下热点键统计返回真正的热点键
+func TestCacheTopKeys(t *testing.T) {
+	opts := DefaultCacheOptions()
+	opts.HotKeys = 20
+	c := NewCache(opts)
+	defer c.Close()
+
+	ctx := context.Background()
+	truth := make(map[string]uint64)
+	access := func(key string) {
+		c.Get(ctx, key)
+		truth[key]++
+	}
+
+	// 10 个热点键各访问 500~950 次，5000 个冷键各访问 1~3 次，交错进行
+	var keys []string
+	for i := range 10 {
+		for range 500 + 50*i {
+			keys = append(keys, fmt.Sprintf("hot-%d", i))
+		}
+	}
+	for i := range 5000 {
+		for range 1 + i%3 {
+			keys = append(keys, fmt.Sprintf("cold-%d", i))
+		}
+	}
+	rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	for _, key := range keys {
+		access(key)
+	}
+
+	top := c.TopKeys(10)
+	if len(top) != 10 {
+		t.Fatalf("Expected 10 top keys, got %d", len(top))
+	}
+
+	// count-min sketch 估计值不小于真实值，误差不超过 e/width * 总访问次数
+	bound := uint64(float64(len(keys)) * 2.72 / 4096)
+	for i, kc := range top {
+		want := fmt.Sprintf("hot-%d", 9-i)
+		if kc.Key != want {
+			t.Errorf("Top key %d: expected %s, got %s (%d)", i, want, kc.Key, kc.Count)
+		}
+		if kc.Count < truth[kc.Key] || kc.Count > truth[kc.Key]+bound {
+			t.Errorf("Count for %s = %d, expected within [%d, %d]", kc.Key, kc.Count, truth[kc.Key], truth[kc.Key]+bound)
+		}
+	}
+
+	// 未开启统计时返回空
+	plain := NewCache(DefaultCacheOptions())
+	defer plain.Close()
+	plain.Get(ctx, "key")
+	if got := plain.TopKeys(10); got != nil {
+		t.Fatalf("Expected no top keys without tracking, got %v", got)
+	}
+}
@@ -0,0 +1,256 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// errPeerClosed 节点客户端已关闭错误
+var errPeerClosed = errors.New("peer client is closed")
+
+// WithIdleTimeout 关闭超过 d 没有使用的节点连接，下次选中该节点时重新连接
+// 适用于节点很多、每个节点只访问其中少数节点的大集群，减少文件描述符和内存占用；d <= 0 时不关闭
+func WithIdleTimeout(d time.Duration) PickerOption {
+	return func(cp *ClientPicker) {
+		if d > 0 {
+			cp.idleTimeout = d
+		}
+	}
+}
+
+// idlePeer 空闲超时后关闭连接的节点客户端，连接关闭后的第一次调用重新连接
+type idlePeer struct {
+	addr    string
+	dial    func(addr string) (Peer, error)
+	mu      sync.Mutex
+	peer    Peer      // 为空表示连接已因空闲关闭
+	active  int       // 正在进行的调用数，大于 0 时不会关闭连接
+	lastUse time.Time // 最近一次调用结束的时间
+	closed  bool
+}
+
+// newIdlePeer 包装已建立连接的客户端
+func newIdlePeer(addr string, peer Peer, dial func(addr string) (Peer, error)) *idlePeer {
+	return &idlePeer{addr: addr, dial: dial, peer: peer, lastUse: time.Now()}
+}
+
+// acquire 返回可用的客户端，连接已关闭时重新连接，调用结束后必须调用 release
+func (p *idlePeer) acquire() (Peer, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, errPeerClosed
+	}
+	if p.peer == nil {
+		peer, err := p.dial(p.addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reopen connection to %s: %w", p.addr, err)
+		}
+		p.peer = peer
+		logrus.Debugf("[G-Cache] reopened idle connection to %s", p.addr)
+	}
+	p.active++
+	return p.peer, nil
+}
+
+// release 结束一次调用
+func (p *idlePeer) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.active--
+	p.lastUse = time.Now()
+}
+
+// closeIfIdle 连接在 now 之前已空闲超过 timeout 时关闭，返回是否关闭了连接
+func (p *idlePeer) closeIfIdle(now time.Time, timeout time.Duration) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.peer == nil || p.active > 0 || now.Sub(p.lastUse) < timeout {
+		return false
+	}
+	if err := p.peer.Close(); err != nil {
+		logrus.Warnf("[G-Cache] failed to close idle connection to %s: %v", p.addr, err)
+	}
+	p.peer = nil
+	return true
+}
+
+// connected 返回连接是否处于打开状态
+func (p *idlePeer) connected() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.peer != nil
+}
+
+// Get 实现 Peer 接口
+func (p *idlePeer) Get(group, key string) ([]byte, error) {
+	peer, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer p.release()
+
+	return peer.Get(group, key)
+}
+
+// GetContext 实现 ContextGetter 接口，底层客户端不支持取消时按 Get 读取
+func (p *idlePeer) GetContext(ctx context.Context, group, key string) ([]byte, error) {
+	peer, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer p.release()
+
+	if cg, ok := peer.(ContextGetter); ok {
+		return cg.GetContext(ctx, group, key)
+	}
+	return peer.Get(group, key)
+}
+
+// GetWithTTL 实现 Peer 接口
+func (p *idlePeer) GetWithTTL(ctx context.Context, group, key string) ([]byte, time.Duration, error) {
+	peer, err := p.acquire()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer p.release()
+
+	return peer.GetWithTTL(ctx, group, key)
+}
+
+// GetIfPresent 实现 PresentGetter 接口，底层客户端不支持只查询缓存时返回错误
+func (p *idlePeer) GetIfPresent(group, key string) ([]byte, bool, error) {
+	peer, err := p.acquire()
+	if err != nil {
+		return nil, false, err
+	}
+	defer p.release()
+
+	pg, ok := peer.(PresentGetter)
+	if !ok {
+		return nil, false, fmt.Errorf("peer %s does not support cache-only lookups", p.addr)
+	}
+	return pg.GetIfPresent(group, key)
+}
+
+// Set 实现 Peer 接口
+func (p *idlePeer) Set(ctx context.Context, group, key string, value []byte) error {
+	peer, err := p.acquire()
+	if err != nil {
+		return err
+	}
+	defer p.release()
+
+	return peer.Set(ctx, group, key, value)
+}
+
+// SetNX 实现 LockPeer 接口，底层客户端不支持分布式锁时返回 ErrLockUnsupported
+func (p *idlePeer) SetNX(ctx context.Context, group, key string, value []byte, ttl time.Duration) (bool, error) {
+	peer, err := p.acquire()
+	if err != nil {
+		return false, err
+	}
+	defer p.release()
+
+	lp, ok := peer.(LockPeer)
+	if !ok {
+		return false, ErrLockUnsupported
+	}
+	return lp.SetNX(ctx, group, key, value, ttl)
+}
+
+// DeleteIfValue 实现 LockPeer 接口
+func (p *idlePeer) DeleteIfValue(ctx context.Context, group, key string, value []byte) (bool, error) {
+	peer, err := p.acquire()
+	if err != nil {
+		return false, err
+	}
+	defer p.release()
+
+	lp, ok := peer.(LockPeer)
+	if !ok {
+		return false, ErrLockUnsupported
+	}
+	return lp.DeleteIfValue(ctx, group, key, value)
+}
+
+// Delete 实现 Peer 接口
+func (p *idlePeer) Delete(group, key string) (bool, error) {
+	peer, err := p.acquire()
+	if err != nil {
+		return false, err
+	}
+	defer p.release()
+
+	return peer.Delete(group, key)
+}
+
+// BatchDelete 实现 Peer 接口
+func (p *idlePeer) BatchDelete(ctx context.Context, group string, keys []string) (int, error) {
+	peer, err := p.acquire()
+	if err != nil {
+		return 0, err
+	}
+	defer p.release()
+
+	return peer.BatchDelete(ctx, group, keys)
+}
+
+// Close 实现 Peer 接口，关闭后不再重新连接
+func (p *idlePeer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	if p.peer == nil {
+		return nil
+	}
+	err := p.peer.Close()
+	p.peer = nil
+	return err
+}
+
+// closeIdleClients 定期关闭空闲的节点连接
+func (cp *ClientPicker) closeIdleClients() {
+	// 检查间隔为超时的一半，连接最多在空闲 1.5 倍超时后关闭
+	ticker := time.NewTicker(cp.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cp.ctx.Done():
+			return
+		case now := <-ticker.C:
+			cp.closeIdle(now)
+		}
+	}
+}
+
+// closeIdle 关闭在 now 之前空闲超过超时时间的连接，返回关闭的连接数
+func (cp *ClientPicker) closeIdle(now time.Time) int {
+	// 重新连接时持有客户端的锁，不持有 cp.mu 检查，避免阻塞节点选择
+	cp.mu.RLock()
+	peers := make([]*idlePeer, 0, len(cp.idle))
+	for _, p := range cp.idle {
+		peers = append(peers, p)
+	}
+	cp.mu.RUnlock()
+
+	closed := 0
+	for _, p := range peers {
+		if p.closeIfIdle(now, cp.idleTimeout) {
+			closed++
+			logrus.Debugf("[G-Cache] closed idle connection to %s", p.addr)
+		}
+	}
+	return closed
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newIdleTestPicker 创建开启空闲超时的 ClientPicker，记录连接次数
+func newIdleTestPicker(t *testing.T, timeout time.Duration) (*ClientPicker, *int32) {
+	t.Helper()
+	cp := newClientPicker("self", WithIdleTimeout(timeout))
+	t.Cleanup(func() { cp.Close() })
+
+	var dials int32
+	cp.dial = func(addr string) (Peer, error) {
+		atomic.AddInt32(&dials, 1)
+		p := newFakePeer(addr)
+		p.data["key"] = []byte("value-" + addr)
+		return p, nil
+	}
+	return cp, &dials
+}
+
+// 测试空闲连接超时后关闭，下次 Get 时透明地重新连接
+func TestClientPickerIdleTimeout(t *testing.T) {
+	cp, dials := newIdleTestPicker(t, time.Minute)
+	cp.dialPeers([]string{"A"})
+
+	peer, ok, self := cp.PickPeer("key")
+	if !ok || self {
+		t.Fatalf("Expected remote peer A, got ok=%v self=%v", ok, self)
+	}
+	idle := cp.idle["A"]
+
+	// 未超时不关闭
+	if n := cp.closeIdle(time.Now()); n != 0 {
+		t.Fatalf("Expected no connection closed before timeout, got %d", n)
+	}
+
+	if n := cp.closeIdle(time.Now().Add(2 * time.Minute)); n != 1 {
+		t.Fatalf("Expected idle connection to be closed, got %d", n)
+	}
+	if idle.connected() {
+		t.Fatalf("Expected connection to be closed")
+	}
+
+	// 节点仍在哈希环中，下次调用重新连接
+	peer, ok, _ = cp.PickPeer("key")
+	if !ok {
+		t.Fatalf("Expected peer A to remain selectable after idle close")
+	}
+	value, err := peer.Get("group", "key")
+	if err != nil || string(value) != "value-A" {
+		t.Fatalf("Expected Get to reopen connection, got %q %v", value, err)
+	}
+	if !idle.connected() {
+		t.Fatalf("Expected connection to be reopened")
+	}
+	if n := atomic.LoadInt32(dials); n != 2 {
+		t.Fatalf("Expected 2 dials, got %d", n)
+	}
+}
+
+// 测试进行中的调用不会被关闭
+func TestClientPickerIdleTimeoutActive(t *testing.T) {
+	cp, _ := newIdleTestPicker(t, time.Minute)
+	cp.dialPeers([]string{"A"})
+	idle := cp.idle["A"]
+
+	if _, err := idle.acquire(); err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	if n := cp.closeIdle(time.Now().Add(time.Hour)); n != 0 {
+		t.Fatalf("Expected active connection to stay open, got %d closed", n)
+	}
+	idle.release()
+
+	if n := cp.closeIdle(time.Now().Add(time.Hour)); n != 1 {
+		t.Fatalf("Expected connection to close once released, got %d", n)
+	}
+
+	// 关闭的客户端不再重新连接
+	idle.Close()
+	if _, err := idle.Get("group", "key"); err != errPeerClosed {
+		t.Fatalf("Expected errPeerClosed after Close, got %v", err)
+	}
+}
+
+// 测试后台协程按超时关闭空闲连接
+func TestClientPickerIdleTimeoutBackground(t *testing.T) {
+	cp, _ := newIdleTestPicker(t, 20*time.Millisecond)
+	cp.startStatic([]string{"A"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for cp.idle["A"].connected() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected idle connection to be closed in the background")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
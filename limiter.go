@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MemoryLimiter 多个缓存组共享的全局内存预算
+// 所有注册组的缓存占用之和超过预算时，从占用最大的组开始淘汰
+// 只有支持字节统计和淘汰的存储（LRU、LRU2、分层存储）参与预算，其他存储的占用视为 0，注册时输出警告
+type MemoryLimiter struct {
+	mu       sync.Mutex
+	maxBytes int64
+	groups   map[*Group]struct{}
+}
+
+// NewMemoryLimiter 创建全局内存预算，maxBytes <= 0 表示不限制
+func NewMemoryLimiter(maxBytes int64) *MemoryLimiter {
+	return &MemoryLimiter{
+		maxBytes: maxBytes,
+		groups:   make(map[*Group]struct{}),
+	}
+}
+
+// WithMemoryLimiter 将组注册到共享内存预算
+func WithMemoryLimiter(l *MemoryLimiter) GroupOption {
+	return func(g *Group) {
+		g.limiter = l
+	}
+}
+
+// MaxBytes 返回全局内存预算
+func (l *MemoryLimiter) MaxBytes() int64 {
+	return l.maxBytes
+}
+
+// UsedBytes 返回所有注册组的缓存占用之和
+func (l *MemoryLimiter) UsedBytes() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var total int64
+	for g := range l.groups {
+		total += g.mainCache.usedBytes()
+	}
+	return total
+}
+
+// register 注册缓存组
+func (l *MemoryLimiter) register(g *Group) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.groups[g] = struct{}{}
+	g.mainCache.useMemoryLimiter()
+}
+
+// unregister 注销缓存组
+func (l *MemoryLimiter) unregister(g *Group) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.groups, g)
+}
+
+// enforce 检查全局占用，超出预算时从占用最大的组淘汰
+func (l *MemoryLimiter) enforce() {
+	if l.maxBytes <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for {
+		var total, largestBytes int64
+		var largest *Group
+		for g := range l.groups {
+			used := g.mainCache.usedBytes()
+			total += used
+			if used > largestBytes {
+				largest, largestBytes = g, used
+			}
+		}
+
+		excess := total - l.maxBytes
+		if excess <= 0 || largest == nil {
+			return
+		}
+
+		freed := largest.mainCache.evictBytes(excess)
+		logrus.Debugf("[G-Cache] memory limiter evicted %d bytes from group [%s]", freed, largest.name)
+		if freed <= 0 {
+			return
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/lyy42995004/Cache-Go/store"
+)
+
+// 测试多个组共享内存预算
+func TestMemoryLimiter(t *testing.T) {
+	limiter := NewMemoryLimiter(1000)
+	cacheOpts := CacheOptions{CacheType: store.LRU, MaxBytes: 1 << 20}
+
+	small := newTestGroup(t, nil, WithCacheOptions(cacheOpts), WithMemoryLimiter(limiter))
+	large := NewGroup(t.Name()+"-large", 1<<20, small.getter, WithCacheOptions(cacheOpts), WithMemoryLimiter(limiter))
+	t.Cleanup(func() { large.Close() })
+
+	ctx := context.Background()
+	value := make([]byte, 96) // 每项占用 len("key-00") + 96 = 102 字节
+
+	// small 写入 3 项，large 写入 6 项，共 918 字节，未超出预算
+	for i := range 3 {
+		if err := small.Set(ctx, fmt.Sprintf("key-%02d", i), value); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	for i := range 6 {
+		if err := large.Set(ctx, fmt.Sprintf("key-%02d", i), value); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	if got := limiter.UsedBytes(); got != 918 {
+		t.Fatalf("Expected 918 bytes used, got %d", got)
+	}
+
+	// small 再写入 1 项后超出预算，从占用最大的 large 中淘汰
+	if err := small.Set(ctx, "key-03", value); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got := limiter.UsedBytes(); got > limiter.MaxBytes() {
+		t.Fatalf("Expected global usage %d to be within budget %d", got, limiter.MaxBytes())
+	}
+	if got := small.mainCache.Len(); got != 4 {
+		t.Errorf("Expected smaller group to keep all 4 items, got %d", got)
+	}
+	if got := large.mainCache.Len(); got != 5 {
+		t.Errorf("Expected larger group to be trimmed to 5 items, got %d", got)
+	}
+	// 被淘汰的是 large 中最久未使用的项
+	if _, ok := large.mainCache.Get(ctx, "key-00"); ok {
+		t.Errorf("Expected least recently used item of larger group to be evicted")
+	}
+
+	// 关闭后的组不再计入预算
+	large.Close()
+	if got := limiter.UsedBytes(); got != 4*102 {
+		t.Errorf("Expected %d bytes after closing larger group, got %d", 4*102, got)
+	}
+}
+
+// 测试默认的 LRU2 存储参与共享内存预算
+func TestMemoryLimiterDefaultStore(t *testing.T) {
+	limiter := NewMemoryLimiter(4000)
+	g := newTestGroup(t, nil, WithMemoryLimiter(limiter))
+	if g.mainCache.opts.CacheType != store.LRU2 {
+		t.Fatalf("Expected default cache type lru2, got %s", g.mainCache.opts.CacheType)
+	}
+
+	ctx := context.Background()
+	value := make([]byte, 94) // 每项占用 len("key-00") + 94 = 100 字节
+	for i := range 80 {
+		if err := g.Set(ctx, fmt.Sprintf("key-%02d", i), value); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	if got := limiter.UsedBytes(); got > limiter.MaxBytes() {
+		t.Fatalf("Expected usage %d to be within budget %d", got, limiter.MaxBytes())
+	}
+	if got := g.Stats()["cache_evictions"].(int64); got == 0 {
+		t.Fatalf("Expected evictions to be recorded")
+	}
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrLoaderUnavailable 数据源熔断中，未命中的请求不再调用 Getter 错误
+var ErrLoaderUnavailable = errors.New("loader unavailable")
+
+// WithLoaderBreaker 开启数据源熔断：Getter 连续失败 threshold 次后熔断，
+// 熔断期间未命中的请求直接返回 ErrLoaderUnavailable，冷却 cooldown 后放行一个探测请求，成功则恢复
+// 命中本地缓存和从其他节点读取不受影响；调用方主动取消导致的错误不计入失败
+func WithLoaderBreaker(threshold int, cooldown time.Duration) GroupOption {
+	return func(g *Group) {
+		if threshold > 0 {
+			g.loadBreaker = newCircuitBreaker(threshold, cooldown)
+		}
+	}
+}
+
+// LoaderBreakerState 返回数据源熔断器的状态，未开启熔断时返回 BreakerClosed
+func (g *Group) LoaderBreakerState() BreakerState {
+	if g.loadBreaker == nil {
+		return BreakerClosed
+	}
+	return g.loadBreaker.State()
+}
+
+// getFromLoader 调用 Getter 加载数据，开启熔断时记录结果，熔断中直接返回 ErrLoaderUnavailable
+func (g *Group) getFromLoader(ctx context.Context, key string) ([]byte, error) {
+	b := g.loadBreaker
+	if b == nil {
+		return g.getter.Get(ctx, key)
+	}
+	if !b.allow() {
+		return nil, ErrLoaderUnavailable
+	}
+
+	bytes, err := g.getter.Get(ctx, key)
+	if err != nil && ctx.Err() != nil {
+		b.abandon()
+		return nil, err
+	}
+
+	before := b.State()
+	b.record(err)
+	if after := b.State(); after != before {
+		logrus.Warnf("[G-Cache] loader circuit breaker for group %s: %s -> %s", g.name, before, after)
+	}
+	return bytes, err
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试数据源连续失败后熔断，熔断期间未命中直接失败，探测成功后恢复
+func TestGroupLoaderBreaker(t *testing.T) {
+	var calls int32
+	var failing atomic.Bool
+	failing.Store(true)
+	g := newTestGroup(t, GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		if failing.Load() {
+			return nil, errors.New("database down")
+		}
+		return []byte("value-" + key), nil
+	}), WithLoaderBreaker(3, time.Minute))
+
+	now := time.Now()
+	g.loadBreaker.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	if err := g.Set(ctx, "cached", []byte("v")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	for _, key := range []string{"a", "b", "c"} {
+		if _, err := g.Get(ctx, key); err == nil || errors.Is(err, ErrLoaderUnavailable) {
+			t.Fatalf("Expected loader error for %s, got %v", key, err)
+		}
+	}
+	if state := g.LoaderBreakerState(); state != BreakerOpen {
+		t.Fatalf("Expected breaker to open after 3 failures, got %v", state)
+	}
+
+	// 熔断期间未命中直接失败，不调用数据源
+	if _, err := g.Get(ctx, "d"); !errors.Is(err, ErrLoaderUnavailable) {
+		t.Fatalf("Expected ErrLoaderUnavailable, got %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Fatalf("Expected loader not to be called while open, got %d calls", n)
+	}
+	if v, err := g.Get(ctx, "cached"); err != nil || v.String() != "v" {
+		t.Fatalf("Expected hits to be served while open, got %q %v", v.String(), err)
+	}
+	if n := g.Stats()["rejected_loads"].(int64); n != 1 {
+		t.Fatalf("Expected 1 rejected load, got %d", n)
+	}
+
+	// 冷却结束后探测成功，熔断器恢复
+	failing.Store(false)
+	now = now.Add(2 * time.Minute)
+	if v, err := g.Get(ctx, "d"); err != nil || v.String() != "value-d" {
+		t.Fatalf("Expected probe to succeed, got %q %v", v.String(), err)
+	}
+	if state := g.LoaderBreakerState(); state != BreakerClosed {
+		t.Fatalf("Expected breaker to close after a successful probe, got %v", state)
+	}
+	if _, err := g.Get(ctx, "e"); err != nil {
+		t.Fatalf("Expected loads to resume, got %v", err)
+	}
+}
+
+// 测试调用方取消导致的失败不计入熔断，也不会使探测请求一直占用
+func TestGroupLoaderBreakerCancelled(t *testing.T) {
+	g := newTestGroup(t, GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("database down")
+	}), WithLoaderBreaker(1, time.Minute))
+
+	now := time.Now()
+	g.loadBreaker.now = func() time.Time { return now }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := g.Get(ctx, "a"); err == nil {
+		t.Fatalf("Expected cancelled load to fail")
+	}
+	if state := g.LoaderBreakerState(); state != BreakerClosed {
+		t.Fatalf("Expected cancellation not to count as a failure, got %v", state)
+	}
+
+	if _, err := g.Get(context.Background(), "b"); err == nil {
+		t.Fatalf("Expected loader error")
+	}
+	if state := g.LoaderBreakerState(); state != BreakerOpen {
+		t.Fatalf("Expected breaker to open, got %v", state)
+	}
+
+	// 探测请求被取消后，下一个请求仍可探测
+	now = now.Add(2 * time.Minute)
+	if _, err := g.Get(ctx, "c"); err == nil {
+		t.Fatalf("Expected cancelled probe to fail")
+	}
+	if _, err := g.Get(context.Background(), "d"); errors.Is(err, ErrLoaderUnavailable) {
+		t.Fatalf("Expected next request to probe the loader, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrLockUnsupported 键所属的节点不支持分布式锁错误
+var ErrLockUnsupported = errors.New("peer does not support locks")
+
+// Lock 在键所属的节点上获取分布式锁，锁在 ttl 后自动过期，用于跨节点协调只能运行一个实例的任务
+// 获取成功时返回释放锁的函数，只有锁仍由本次调用持有时才会删除，锁已过期并被他人获取时不影响对方
+// 锁已被占用时 acquired 为 false，返回的函数不做任何操作
+// 锁保存在节点的锁表中，与缓存键互不影响，也不会因缓存容量不足被淘汰
+func (g *Group) Lock(ctx context.Context, key string, ttl time.Duration) (unlock func(), acquired bool, err error) {
+	noop := func() {}
+	if atomic.LoadInt32(&g.closed) == 1 {
+		return noop, false, ErrGroupClosed
+	}
+	if key == "" {
+		return noop, false, ErrKeyRequired
+	}
+	if ttl <= 0 {
+		return noop, false, fmt.Errorf("lock ttl must be positive, got %v", ttl)
+	}
+
+	token, err := newLockToken()
+	if err != nil {
+		return noop, false, err
+	}
+
+	peer, remote, err := g.lockOwner(key)
+	if err != nil {
+		return noop, false, err
+	}
+
+	if remote {
+		acquired, err = peer.SetNX(ctx, g.name, key, token, ttl)
+	} else {
+		acquired, err = g.setNX(key, token, ttl)
+	}
+	if err != nil || !acquired {
+		return noop, false, err
+	}
+
+	var once sync.Once
+	unlock = func() {
+		once.Do(func() {
+			if remote {
+				// 释放锁不应受调用方已取消的 context 影响
+				if _, err := peer.DeleteIfValue(context.Background(), g.name, key, token); err != nil {
+					logrus.Warnf("[G-Cache] failed to release lock %s: %v", key, err)
+				}
+				return
+			}
+			g.deleteIfValue(key, token)
+		})
+	}
+	return unlock, true, nil
+}
+
+// lockOwner 返回持有锁键的远程节点，键属于当前节点或未启用分布式模式时 remote 为 false
+func (g *Group) lockOwner(key string) (peer LockPeer, remote bool, err error) {
+	if g.peers == nil {
+		return nil, false, nil
+	}
+	p, ok, isSelf := g.peers.PickPeer(key)
+	if !ok || isSelf {
+		return nil, false, nil
+	}
+	lp, ok := p.(LockPeer)
+	if !ok {
+		return nil, false, ErrLockUnsupported
+	}
+	return lp, true, nil
+}
+
+// setNX 在本地锁表中获取锁，锁不存在或已过期时写入，不同步到其他节点
+func (g *Group) setNX(key string, value []byte, ttl time.Duration) (bool, error) {
+	if atomic.LoadInt32(&g.closed) == 1 {
+		return false, ErrGroupClosed
+	}
+	if ttl <= 0 {
+		return false, fmt.Errorf("lock ttl must be positive, got %v", ttl)
+	}
+	return g.locks.acquire(key, value, time.Now().Add(ttl)), nil
+}
+
+// deleteIfValue 锁的令牌等于 value 时从本地锁表中释放
+func (g *Group) deleteIfValue(key string, value []byte) bool {
+	if atomic.LoadInt32(&g.closed) == 1 {
+		return false
+	}
+	return g.locks.release(key, value)
+}
+
+// minLockPrune 锁表中的锁数量达到该值后才清理过期的锁
+const minLockPrune = 64
+
+// lockTable 节点持有的分布式锁，独立于缓存存储，不受容量淘汰影响，零值可用
+// 过期的锁在同一个键再次加锁时覆盖，锁数量翻倍时统一清理
+type lockTable struct {
+	mu    sync.Mutex
+	held  map[string]heldLock
+	prune int // 锁数量达到该值时清理过期的锁
+}
+
+// heldLock 锁的持有者令牌和过期时间
+type heldLock struct {
+	token    []byte
+	expireAt time.Time
+}
+
+// acquire 锁不存在或已过期时以 token 持有到 expireAt，返回是否获取成功
+func (t *lockTable) acquire(key string, token []byte, expireAt time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if l, ok := t.held[key]; ok && now.Before(l.expireAt) {
+		return false
+	}
+	if t.held == nil {
+		t.held = make(map[string]heldLock)
+	}
+	if len(t.held) >= max(t.prune, minLockPrune) {
+		t.pruneExpired(now)
+	}
+	t.held[key] = heldLock{token: cloneBytes(token), expireAt: expireAt}
+	return true
+}
+
+// release 锁仍由 token 持有且未过期时释放，返回是否释放
+func (t *lockTable) release(key string, token []byte) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	l, ok := t.held[key]
+	if !ok || !bytes.Equal(l.token, token) {
+		return false
+	}
+	delete(t.held, key)
+	return time.Now().Before(l.expireAt)
+}
+
+// pruneExpired 删除过期的锁，调用此方法必须持有锁
+func (t *lockTable) pruneExpired(now time.Time) {
+	for key, l := range t.held {
+		if !now.Before(l.expireAt) {
+			delete(t.held, key)
+		}
+	}
+	t.prune = 2 * len(t.held)
+}
+
+// newLockToken 生成标识锁持有者的随机令牌
+func newLockToken() ([]byte, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	return []byte(hex.EncodeToString(b)), nil
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试并发获取同一把锁时同一时刻只有一个持有者
+func TestGroupLockMutualExclusion(t *testing.T) {
+	g := newTestGroup(t, nil)
+	ctx := context.Background()
+
+	var holders, maxHolders, done int32
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&done) < 20 {
+				unlock, ok, err := g.Lock(ctx, "lock:job", time.Minute)
+				if err != nil {
+					t.Errorf("Lock failed: %v", err)
+					return
+				}
+				if !ok {
+					time.Sleep(time.Millisecond)
+					continue
+				}
+				n := atomic.AddInt32(&holders, 1)
+				for {
+					m := atomic.LoadInt32(&maxHolders)
+					if n <= m || atomic.CompareAndSwapInt32(&maxHolders, m, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&holders, -1)
+				atomic.AddInt32(&done, 1)
+				unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if m := atomic.LoadInt32(&maxHolders); m != 1 {
+		t.Fatalf("Expected at most 1 concurrent holder, got %d", m)
+	}
+}
+
+// 测试锁过期后可以重新获取，旧持有者释放时不影响新持有者
+func TestGroupLockExpiry(t *testing.T) {
+	g := newTestGroup(t, nil)
+	ctx := context.Background()
+
+	unlockOld, ok, err := g.Lock(ctx, "lock:job", 50*time.Millisecond)
+	if err != nil || !ok {
+		t.Fatalf("Expected first Lock to succeed, got %v %v", ok, err)
+	}
+	if _, ok, _ := g.Lock(ctx, "lock:job", time.Minute); ok {
+		t.Fatalf("Expected Lock to fail while held")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	unlockNew, ok, err := g.Lock(ctx, "lock:job", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("Expected Lock to succeed after expiry, got %v %v", ok, err)
+	}
+
+	unlockOld()
+	if _, ok, _ := g.Lock(ctx, "lock:job", time.Minute); ok {
+		t.Fatalf("Expected stale unlock to leave the new holder's lock in place")
+	}
+
+	unlockNew()
+	unlock, ok, err := g.Lock(ctx, "lock:job", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("Expected Lock to succeed after unlock, got %v %v", ok, err)
+	}
+	unlock()
+}
+
+// 测试锁在键所属的远程节点上获取和释放
+func TestGroupLockRemote(t *testing.T) {
+	peerA := newFakePeer("A")
+	g := newTestGroup(t, nil)
+	g.RegisterPeers(&fakePicker{
+		self:  "self",
+		peers: map[string]*fakePeer{"A": peerA},
+		owner: ownerByPrefix,
+	})
+	ctx := context.Background()
+
+	unlock, ok, err := g.Lock(ctx, "a-lock", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("Expected Lock to succeed, got %v %v", ok, err)
+	}
+	if _, held := peerA.data["a-lock"]; !held {
+		t.Fatalf("Expected lock to be stored on owner peer")
+	}
+	if _, ok := g.mainCache.Get(ctx, "a-lock"); ok {
+		t.Fatalf("Expected lock not to be stored locally")
+	}
+	if _, ok, _ := g.Lock(ctx, "a-lock", time.Minute); ok {
+		t.Fatalf("Expected Lock to fail while held on peer")
+	}
+
+	unlock()
+	if _, held := peerA.data["a-lock"]; held {
+		t.Fatalf("Expected unlock to remove lock from owner peer")
+	}
+}
+
+// 测试客户端通过服务端获取和释放锁
+func TestClientLock(t *testing.T) {
+	g := newTestGroup(t, nil)
+	c := &Client{grpcCli: &loopbackClient{srv: &Server{}}, callTimeout: defaultCallTimeout}
+	ctx := context.Background()
+
+	if ok, err := c.SetNX(ctx, g.name, "lock", []byte("token-1"), time.Minute); err != nil || !ok {
+		t.Fatalf("Expected SetNX to succeed, got %v %v", ok, err)
+	}
+	if ok, err := c.SetNX(ctx, g.name, "lock", []byte("token-2"), time.Minute); err != nil || ok {
+		t.Fatalf("Expected SetNX to report held lock, got %v %v", ok, err)
+	}
+
+	if ok, err := c.DeleteIfValue(ctx, g.name, "lock", []byte("token-2")); err != nil || ok {
+		t.Fatalf("Expected DeleteIfValue with wrong token to fail, got %v %v", ok, err)
+	}
+	if ok, err := c.DeleteIfValue(ctx, g.name, "lock", []byte("token-1")); err != nil || !ok {
+		t.Fatalf("Expected DeleteIfValue to succeed, got %v %v", ok, err)
+	}
+	if ok, err := c.SetNX(ctx, g.name, "lock", []byte("token-2"), time.Minute); err != nil || !ok {
+		t.Fatalf("Expected SetNX to succeed after release, got %v %v", ok, err)
+	}
+	if _, ok := g.mainCache.Get(ctx, "lock"); ok {
+		t.Fatalf("Expected lock not to be stored in the cache")
+	}
+	if _, err := c.SetNX(ctx, g.name, "other", []byte("token"), 0); err == nil {
+		t.Fatalf("Expected SetNX without ttl to fail")
+	}
+}
+
+// 测试锁不占用缓存空间，缓存写满淘汰时锁仍被持有
+func TestGroupLockSurvivesEviction(t *testing.T) {
+	g := NewGroup(t.Name(), 1024, GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		return nil, errors.New("no loader")
+	}))
+	t.Cleanup(func() { g.Close() })
+	ctx := context.Background()
+
+	unlock, ok, err := g.Lock(ctx, "lock:job", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("Expected Lock to succeed, got %v %v", ok, err)
+	}
+	defer unlock()
+
+	value := make([]byte, 128)
+	for i := range 100 {
+		if err := g.Set(ctx, fmt.Sprintf("key-%d", i), value); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	if _, ok, _ := g.Lock(ctx, "lock:job", time.Minute); ok {
+		t.Fatalf("Expected lock to stay held after cache eviction")
+	}
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNamespaceNotFound 命名空间不存在错误
+var ErrNamespaceNotFound = errors.New("namespace not found")
+
+// MultiCache 在一个进程内管理多个相互独立的命名空间（如 users、sessions），每个命名空间有自己的容量和配置
+// 各命名空间分别淘汰，统计信息汇总；只用于本地缓存，比为每个命名空间创建 Group 更轻量
+type MultiCache struct {
+	caches map[string]*Cache // 创建后不再修改，读取无需加锁
+}
+
+// NewMultiCache 按命名空间到配置的映射创建缓存，命名空间不能为空
+func NewMultiCache(namespaces map[string]CacheOptions) (*MultiCache, error) {
+	caches := make(map[string]*Cache, len(namespaces))
+	for name, opts := range namespaces {
+		if name == "" {
+			return nil, errors.New("namespace name is required")
+		}
+		caches[name] = NewCache(opts)
+	}
+	return &MultiCache{caches: caches}, nil
+}
+
+// Namespace 返回命名空间对应的缓存，不存在时返回 nil
+func (m *MultiCache) Namespace(name string) *Cache {
+	return m.caches[name]
+}
+
+// Namespaces 返回所有命名空间的名称，按字典序排列
+func (m *MultiCache) Namespaces() []string {
+	names := make([]string, 0, len(m.caches))
+	for name := range m.caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// cache 返回命名空间对应的缓存，不存在时返回 ErrNamespaceNotFound
+func (m *MultiCache) cache(namespace string) (*Cache, error) {
+	c, ok := m.caches[namespace]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNamespaceNotFound, namespace)
+	}
+	return c, nil
+}
+
+// Set 向命名空间写入缓存值
+func (m *MultiCache) Set(namespace, key string, value ByteView) error {
+	c, err := m.cache(namespace)
+	if err != nil {
+		return err
+	}
+	return c.Set(key, value)
+}
+
+// SetWithExpiration 向命名空间写入带过期时间的缓存值
+func (m *MultiCache) SetWithExpiration(namespace, key string, value ByteView, expirationTime time.Time) error {
+	c, err := m.cache(namespace)
+	if err != nil {
+		return err
+	}
+	return c.SetWithExpiration(key, value, expirationTime)
+}
+
+// Get 从命名空间获取缓存值，命名空间不存在时视为未命中
+func (m *MultiCache) Get(ctx context.Context, namespace, key string) (ByteView, bool) {
+	c, err := m.cache(namespace)
+	if err != nil {
+		return ByteView{}, false
+	}
+	return c.Get(ctx, key)
+}
+
+// Delete 从命名空间删除缓存值
+func (m *MultiCache) Delete(namespace, key string) bool {
+	c, err := m.cache(namespace)
+	if err != nil {
+		return false
+	}
+	return c.Delete(key)
+}
+
+// Len 返回所有命名空间的缓存项总数
+func (m *MultiCache) Len() int {
+	total := 0
+	for _, c := range m.caches {
+		total += c.Len()
+	}
+	return total
+}
+
+// Stats 返回汇总的统计信息，namespaces 中包含每个命名空间各自的统计
+func (m *MultiCache) Stats() map[string]any {
+	var hits, misses int64
+	namespaces := make(map[string]any, len(m.caches))
+	for name, c := range m.caches {
+		hits += atomic.LoadInt64(&c.hits)
+		misses += atomic.LoadInt64(&c.misses)
+		namespaces[name] = c.Stats()
+	}
+
+	stats := map[string]any{
+		"hits":       hits,
+		"misses":     misses,
+		"size":       m.Len(),
+		"hit_rate":   0.0,
+		"namespaces": namespaces,
+	}
+	if total := hits + misses; total > 0 {
+		stats["hit_rate"] = float64(hits) / float64(total)
+	}
+	return stats
+}
+
+// Close 关闭所有命名空间
+func (m *MultiCache) Close() {
+	for _, c := range m.caches {
+		c.Close()
+	}
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lyy42995004/Cache-Go/store"
+)
+
+// 测试不同命名空间按各自的容量独立淘汰，统计信息汇总
+func TestMultiCache(t *testing.T) {
+	newOpts := func(maxBytes int64) CacheOptions {
+		opts := DefaultCacheOptions()
+		opts.CacheType = store.LRU
+		opts.MaxBytes = maxBytes
+		return opts
+	}
+	m, err := NewMultiCache(map[string]CacheOptions{
+		"users":    newOpts(100),
+		"sessions": newOpts(1 << 20),
+	})
+	if err != nil {
+		t.Fatalf("NewMultiCache failed: %v", err)
+	}
+	defer m.Close()
+
+	ctx := context.Background()
+	value := ByteView{b: make([]byte, 10)}
+	for i := range 20 {
+		key := fmt.Sprintf("key-%d", i)
+		if err := m.Set("users", key, value); err != nil {
+			t.Fatalf("Set users failed: %v", err)
+		}
+		if err := m.Set("sessions", key, value); err != nil {
+			t.Fatalf("Set sessions failed: %v", err)
+		}
+	}
+
+	// users 容量较小，旧的键被淘汰；sessions 不受影响
+	if _, ok := m.Get(ctx, "users", "key-0"); ok {
+		t.Errorf("Expected key-0 to be evicted from users")
+	}
+	if _, ok := m.Get(ctx, "sessions", "key-0"); !ok {
+		t.Errorf("Expected key-0 to remain in sessions")
+	}
+	users, sessions := m.Namespace("users").Len(), m.Namespace("sessions").Len()
+	if users >= 20 || sessions != 20 {
+		t.Fatalf("Expected independent eviction, got users=%d sessions=%d", users, sessions)
+	}
+	if got := m.Len(); got != users+sessions {
+		t.Errorf("Expected aggregated Len %d, got %d", users+sessions, got)
+	}
+
+	stats := m.Stats()
+	if stats["hits"].(int64) != 1 || stats["misses"].(int64) != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, got %v", stats)
+	}
+	if len(stats["namespaces"].(map[string]any)) != 2 {
+		t.Errorf("Expected per-namespace stats, got %v", stats["namespaces"])
+	}
+
+	if err := m.Set("missing", "key", value); !errors.Is(err, ErrNamespaceNotFound) {
+		t.Errorf("Expected ErrNamespaceNotFound, got %v", err)
+	}
+	if _, ok := m.Get(ctx, "missing", "key"); ok {
+		t.Errorf("Expected miss for unknown namespace")
+	}
+}
@@ -26,6 +26,7 @@ type Request struct {
 	Group         string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
 	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
 	Value         []byte                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Ttl           int64                  `protobuf:"varint,4,opt,name=ttl,proto3" json:"ttl,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -81,9 +82,17 @@ func (x *Request) GetValue() []byte {
 	return nil
 }
 
+func (x *Request) GetTtl() int64 {
+	if x != nil {
+		return x.Ttl
+	}
+	return 0
+}
+
 type ResponseForGet struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Value         []byte                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Ttl           int64                  `protobuf:"varint,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -125,6 +134,13 @@ func (x *ResponseForGet) GetValue() []byte {
 	return nil
 }
 
+func (x *ResponseForGet) GetTtl() int64 {
+	if x != nil {
+		return x.Ttl
+	}
+	return 0
+}
+
 type ResponseForDelete struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Value         bool                   `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
@@ -169,23 +185,176 @@ func (x *ResponseForDelete) GetValue() bool {
 	return false
 }
 
+type BatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Keys          []string               `protobuf:"bytes,2,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchRequest) Reset() {
+	*x = BatchRequest{}
+	mi := &file_gcache_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchRequest) ProtoMessage() {}
+
+func (x *BatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gcache_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchRequest.ProtoReflect.Descriptor instead.
+func (*BatchRequest) Descriptor() ([]byte, []int) {
+	return file_gcache_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *BatchRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type ResponseForBatchDelete struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResponseForBatchDelete) Reset() {
+	*x = ResponseForBatchDelete{}
+	mi := &file_gcache_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResponseForBatchDelete) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseForBatchDelete) ProtoMessage() {}
+
+func (x *ResponseForBatchDelete) ProtoReflect() protoreflect.Message {
+	mi := &file_gcache_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseForBatchDelete.ProtoReflect.Descriptor instead.
+func (*ResponseForBatchDelete) Descriptor() ([]byte, []int) {
+	return file_gcache_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ResponseForBatchDelete) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type ResponseForSetNX struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         bool                   `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResponseForSetNX) Reset() {
+	*x = ResponseForSetNX{}
+	mi := &file_gcache_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResponseForSetNX) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseForSetNX) ProtoMessage() {}
+
+func (x *ResponseForSetNX) ProtoReflect() protoreflect.Message {
+	mi := &file_gcache_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseForSetNX.ProtoReflect.Descriptor instead.
+func (*ResponseForSetNX) Descriptor() ([]byte, []int) {
+	return file_gcache_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ResponseForSetNX) GetValue() bool {
+	if x != nil {
+		return x.Value
+	}
+	return false
+}
+
 var File_gcache_proto protoreflect.FileDescriptor
 
 const file_gcache_proto_rawDesc = "" +
 	"\n" +
-	"\fgcache.proto\x12\x02pb\"G\n" +
+	"\fgcache.proto\x12\x02pb\"Y\n" +
 	"\aRequest\x12\x14\n" +
 	"\x05group\x18\x01 \x01(\tR\x05group\x12\x10\n" +
 	"\x03key\x18\x02 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x03 \x01(\fR\x05value\"&\n" +
+	"\x05value\x18\x03 \x01(\fR\x05value\x12\x10\n" +
+	"\x03ttl\x18\x04 \x01(\x03R\x03ttl\"8\n" +
 	"\x0eResponseForGet\x12\x14\n" +
-	"\x05value\x18\x01 \x01(\fR\x05value\")\n" +
+	"\x05value\x18\x01 \x01(\fR\x05value\x12\x10\n" +
+	"\x03ttl\x18\x02 \x01(\x03R\x03ttl\")\n" +
 	"\x11ResponseForDelete\x12\x14\n" +
-	"\x05value\x18\x01 \x01(\bR\x05value2\x86\x01\n" +
+	"\x05value\x18\x01 \x01(\bR\x05value\"8\n" +
+	"\fBatchRequest\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x12\n" +
+	"\x04keys\x18\x02 \x03(\tR\x04keys\".\n" +
+	"\x16ResponseForBatchDelete\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\"(\n" +
+	"\x10ResponseForSetNX\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\bR\x05value2\xd8\x02\n" +
 	"\x06GCache\x12&\n" +
-	"\x03Get\x12\v.pb.Request\x1a\x12.pb.ResponseForGet\x12&\n" +
+	"\x03Get\x12\v.pb.Request\x1a\x12.pb.ResponseForGet\x12/\n" +
+	"\fGetIfPresent\x12\v.pb.Request\x1a\x12.pb.ResponseForGet\x12&\n" +
 	"\x03Set\x12\v.pb.Request\x1a\x12.pb.ResponseForGet\x12,\n" +
-	"\x06Delete\x12\v.pb.Request\x1a\x15.pb.ResponseForDeleteB\x04Z\x02./b\x06proto3"
+	"\x06Delete\x12\v.pb.Request\x1a\x15.pb.ResponseForDelete\x12;\n" +
+	"\vBatchDelete\x12\x10.pb.BatchRequest\x1a\x1a.pb.ResponseForBatchDelete\x12*\n" +
+	"\x05SetNX\x12\v.pb.Request\x1a\x14.pb.ResponseForSetNX\x126\n" +
+	"\x10CompareAndDelete\x12\v.pb.Request\x1a\x15.pb.ResponseForDeleteB\x04Z\x02./b\x06proto3"
 
 var (
 	file_gcache_proto_rawDescOnce sync.Once
@@ -199,21 +368,32 @@ func file_gcache_proto_rawDescGZIP() []byte {
 	return file_gcache_proto_rawDescData
 }
 
-var file_gcache_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_gcache_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_gcache_proto_goTypes = []any{
-	(*Request)(nil),           // 0: pb.Request
-	(*ResponseForGet)(nil),    // 1: pb.ResponseForGet
-	(*ResponseForDelete)(nil), // 2: pb.ResponseForDelete
+	(*Request)(nil),                // 0: pb.Request
+	(*ResponseForGet)(nil),         // 1: pb.ResponseForGet
+	(*ResponseForDelete)(nil),      // 2: pb.ResponseForDelete
+	(*BatchRequest)(nil),           // 3: pb.BatchRequest
+	(*ResponseForBatchDelete)(nil), // 4: pb.ResponseForBatchDelete
+	(*ResponseForSetNX)(nil),       // 5: pb.ResponseForSetNX
 }
 var file_gcache_proto_depIdxs = []int32{
 	0, // 0: pb.GCache.Get:input_type -> pb.Request
-	0, // 1: pb.GCache.Set:input_type -> pb.Request
-	0, // 2: pb.GCache.Delete:input_type -> pb.Request
-	1, // 3: pb.GCache.Get:output_type -> pb.ResponseForGet
-	1, // 4: pb.GCache.Set:output_type -> pb.ResponseForGet
-	2, // 5: pb.GCache.Delete:output_type -> pb.ResponseForDelete
-	3, // [3:6] is the sub-list for method output_type
-	0, // [0:3] is the sub-list for method input_type
+	0, // 1: pb.GCache.GetIfPresent:input_type -> pb.Request
+	0, // 2: pb.GCache.Set:input_type -> pb.Request
+	0, // 3: pb.GCache.Delete:input_type -> pb.Request
+	3, // 4: pb.GCache.BatchDelete:input_type -> pb.BatchRequest
+	0, // 5: pb.GCache.SetNX:input_type -> pb.Request
+	0, // 6: pb.GCache.CompareAndDelete:input_type -> pb.Request
+	1, // 7: pb.GCache.Get:output_type -> pb.ResponseForGet
+	1, // 8: pb.GCache.GetIfPresent:output_type -> pb.ResponseForGet
+	1, // 9: pb.GCache.Set:output_type -> pb.ResponseForGet
+	2, // 10: pb.GCache.Delete:output_type -> pb.ResponseForDelete
+	4, // 11: pb.GCache.BatchDelete:output_type -> pb.ResponseForBatchDelete
+	5, // 12: pb.GCache.SetNX:output_type -> pb.ResponseForSetNX
+	2, // 13: pb.GCache.CompareAndDelete:output_type -> pb.ResponseForDelete
+	7, // [7:14] is the sub-list for method output_type
+	0, // [0:7] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -230,7 +410,7 @@ func file_gcache_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gcache_proto_rawDesc), len(file_gcache_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
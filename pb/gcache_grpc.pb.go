@@ -19,9 +19,13 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	GCache_Get_FullMethodName    = "/pb.GCache/Get"
-	GCache_Set_FullMethodName    = "/pb.GCache/Set"
-	GCache_Delete_FullMethodName = "/pb.GCache/Delete"
+	GCache_Get_FullMethodName              = "/pb.GCache/Get"
+	GCache_GetIfPresent_FullMethodName     = "/pb.GCache/GetIfPresent"
+	GCache_Set_FullMethodName              = "/pb.GCache/Set"
+	GCache_Delete_FullMethodName           = "/pb.GCache/Delete"
+	GCache_BatchDelete_FullMethodName      = "/pb.GCache/BatchDelete"
+	GCache_SetNX_FullMethodName            = "/pb.GCache/SetNX"
+	GCache_CompareAndDelete_FullMethodName = "/pb.GCache/CompareAndDelete"
 )
 
 // GCacheClient is the client API for GCache service.
@@ -29,8 +33,12 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type GCacheClient interface {
 	Get(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ResponseForGet, error)
+	GetIfPresent(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ResponseForGet, error)
 	Set(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ResponseForGet, error)
 	Delete(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ResponseForDelete, error)
+	BatchDelete(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*ResponseForBatchDelete, error)
+	SetNX(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ResponseForSetNX, error)
+	CompareAndDelete(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ResponseForDelete, error)
 }
 
 type gCacheClient struct {
@@ -51,6 +59,16 @@ func (c *gCacheClient) Get(ctx context.Context, in *Request, opts ...grpc.CallOp
 	return out, nil
 }
 
+func (c *gCacheClient) GetIfPresent(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ResponseForGet, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResponseForGet)
+	err := c.cc.Invoke(ctx, GCache_GetIfPresent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *gCacheClient) Set(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ResponseForGet, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ResponseForGet)
@@ -71,13 +89,47 @@ func (c *gCacheClient) Delete(ctx context.Context, in *Request, opts ...grpc.Cal
 	return out, nil
 }
 
+func (c *gCacheClient) BatchDelete(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*ResponseForBatchDelete, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResponseForBatchDelete)
+	err := c.cc.Invoke(ctx, GCache_BatchDelete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gCacheClient) SetNX(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ResponseForSetNX, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResponseForSetNX)
+	err := c.cc.Invoke(ctx, GCache_SetNX_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gCacheClient) CompareAndDelete(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ResponseForDelete, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResponseForDelete)
+	err := c.cc.Invoke(ctx, GCache_CompareAndDelete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // GCacheServer is the server API for GCache service.
 // All implementations must embed UnimplementedGCacheServer
 // for forward compatibility.
 type GCacheServer interface {
 	Get(context.Context, *Request) (*ResponseForGet, error)
+	GetIfPresent(context.Context, *Request) (*ResponseForGet, error)
 	Set(context.Context, *Request) (*ResponseForGet, error)
 	Delete(context.Context, *Request) (*ResponseForDelete, error)
+	BatchDelete(context.Context, *BatchRequest) (*ResponseForBatchDelete, error)
+	SetNX(context.Context, *Request) (*ResponseForSetNX, error)
+	CompareAndDelete(context.Context, *Request) (*ResponseForDelete, error)
 	mustEmbedUnimplementedGCacheServer()
 }
 
@@ -91,12 +143,24 @@ type UnimplementedGCacheServer struct{}
 func (UnimplementedGCacheServer) Get(context.Context, *Request) (*ResponseForGet, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
 }
+func (UnimplementedGCacheServer) GetIfPresent(context.Context, *Request) (*ResponseForGet, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIfPresent not implemented")
+}
 func (UnimplementedGCacheServer) Set(context.Context, *Request) (*ResponseForGet, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Set not implemented")
 }
 func (UnimplementedGCacheServer) Delete(context.Context, *Request) (*ResponseForDelete, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedGCacheServer) BatchDelete(context.Context, *BatchRequest) (*ResponseForBatchDelete, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchDelete not implemented")
+}
+func (UnimplementedGCacheServer) SetNX(context.Context, *Request) (*ResponseForSetNX, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetNX not implemented")
+}
+func (UnimplementedGCacheServer) CompareAndDelete(context.Context, *Request) (*ResponseForDelete, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompareAndDelete not implemented")
+}
 func (UnimplementedGCacheServer) mustEmbedUnimplementedGCacheServer() {}
 func (UnimplementedGCacheServer) testEmbeddedByValue()                {}
 
@@ -136,6 +200,24 @@ func _GCache_Get_Handler(srv interface{}, ctx context.Context, dec func(interfac
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GCache_GetIfPresent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GCacheServer).GetIfPresent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GCache_GetIfPresent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GCacheServer).GetIfPresent(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _GCache_Set_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Request)
 	if err := dec(in); err != nil {
@@ -172,6 +254,60 @@ func _GCache_Delete_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GCache_BatchDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GCacheServer).BatchDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GCache_BatchDelete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GCacheServer).BatchDelete(ctx, req.(*BatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GCache_SetNX_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GCacheServer).SetNX(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GCache_SetNX_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GCacheServer).SetNX(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GCache_CompareAndDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GCacheServer).CompareAndDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GCache_CompareAndDelete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GCacheServer).CompareAndDelete(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // GCache_ServiceDesc is the grpc.ServiceDesc for GCache service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -183,6 +319,10 @@ var GCache_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Get",
 			Handler:    _GCache_Get_Handler,
 		},
+		{
+			MethodName: "GetIfPresent",
+			Handler:    _GCache_GetIfPresent_Handler,
+		},
 		{
 			MethodName: "Set",
 			Handler:    _GCache_Set_Handler,
@@ -191,6 +331,18 @@ var GCache_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Delete",
 			Handler:    _GCache_Delete_Handler,
 		},
+		{
+			MethodName: "BatchDelete",
+			Handler:    _GCache_BatchDelete_Handler,
+		},
+		{
+			MethodName: "SetNX",
+			Handler:    _GCache_SetNX_Handler,
+		},
+		{
+			MethodName: "CompareAndDelete",
+			Handler:    _GCache_CompareAndDelete_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "gcache.proto",
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lyy42995004/Cache-Go/consistenthash"
@@ -21,26 +22,85 @@ type PeerPicker interface {
 	Close() error
 }
 
+// ReplicaPicker 支持多副本的 PeerPicker
+// PickPeers 按一致性哈希顺序返回键的前 n 个副本节点中的远程节点，self 表示当前节点是否也是副本之一
+type ReplicaPicker interface {
+	PickPeers(key string, n int) (peers []Peer, self bool)
+}
+
+// WeightedReplicaPicker 可以返回副本权重的 ReplicaPicker
+// weights 与 peers 一一对应，为节点在哈希环上的虚拟节点数
+type WeightedReplicaPicker interface {
+	ReplicaPicker
+	PickWeightedPeers(key string, n int) (peers []Peer, weights []int, self bool)
+}
+
+// AddrPicker 可以按地址查找节点的 PeerPicker
+// PeerByAddr 返回地址为 addr 的已连接节点，节点不存在、尚未连接或为当前节点时返回 false
+type AddrPicker interface {
+	PeerByAddr(addr string) (Peer, bool)
+}
+
 // Peer 定义缓存节点的接口
+// GetWithTTL 同时返回值在远程节点的剩余过期时间，ttl 为 0 表示不过期或未知，调用方按自己的默认过期时间缓存；
+// ttl 小于 0 表示值在远程节点返回响应时已过期，调用方不应缓存
 type Peer interface {
 	Get(group, key string) ([]byte, error)
+	GetWithTTL(ctx context.Context, group, key string) (value []byte, ttl time.Duration, err error)
 	Set(ctx context.Context, group string, key string, value []byte) error
 	Delete(group, key string) (bool, error)
+	BatchDelete(ctx context.Context, group string, keys []string) (int, error)
 	Close() error
 }
 
+// LockPeer 支持分布式锁的 Peer，在键所属的节点上原子地加锁和释放
+// SetNX 键不存在或已过期时写入并设置过期时间；DeleteIfValue 键的当前值等于 value 时删除
+type LockPeer interface {
+	SetNX(ctx context.Context, group, key string, value []byte, ttl time.Duration) (bool, error)
+	DeleteIfValue(ctx context.Context, group, key string, value []byte) (bool, error)
+}
+
+// PresentGetter 支持只查询缓存的 Peer，远程节点未缓存该键时返回 false，不会调用数据源加载
+type PresentGetter interface {
+	GetIfPresent(group, key string) ([]byte, bool, error)
+}
+
+// ContextGetter 支持取消的 Peer，ctx 取消时中止进行中的读取
+type ContextGetter interface {
+	GetContext(ctx context.Context, group, key string) ([]byte, error)
+}
+
 // ClientPicker 实现PeerPicker接口
 type ClientPicker struct {
-	mu       sync.RWMutex
-	selfAddr string              // 当前节点地址
-	svcName  string              // 服务名
-	consHash *consistenthash.Map // 一致性哈希算法的实现
-	clients  map[string]*Client  // 服务实例的地址与节点客户端的映射
-	etcdCli  *clientv3.Client    // etcd 服务
-	ctx      context.Context     // 控制与 etcd 服务的交互
-	cancel   context.CancelFunc  // 用于取消 ctx 上下文对象的函数
+	mu               sync.RWMutex
+	selfAddr         string                          // 当前节点地址
+	svcName          string                          // 服务名
+	consHash         *consistenthash.Map             // 一致性哈希算法的实现
+	clients          map[string]Peer                 // 服务实例的地址与节点客户端的映射
+	failed           map[string]struct{}             // 连接失败、等待重试的服务实例地址
+	dialing          map[string]int                  // 正在连接的服务实例地址与进行中的连接数，节点下线时删除
+	dial             func(addr string) (Peer, error) // 创建节点客户端
+	dialConcurrency  int                             // 并发连接节点的最大协程数
+	retryInterval    time.Duration                   // 连接失败的节点重试间隔
+	routeKey         func(key string) string         // 由存储键计算路由键，为空时使用存储键路由
+	minPeers         int                             // 就绪前至少需要发现的其他节点数
+	initialized      int32                           // 原子变量，标记是否完成首次服务发现
+	etcdHealthy      func() bool                     // 检查与 etcd 的连接状态
+	clientOpts       []ClientOption                  // 创建节点客户端的配置选项
+	breakers         map[string]*circuitBreaker      // 服务实例的地址与熔断器的映射
+	breakerThreshold int                             // 熔断前允许的连续失败次数，0 表示不熔断
+	breakerCooldown  time.Duration                   // 熔断后放行探测请求前的冷却时间
+	watchWindow      time.Duration                   // 合并 etcd 监听事件的时间窗口，0 表示逐个处理
+	idleTimeout      time.Duration                   // 关闭空闲连接的超时时间，0 表示不关闭
+	idle             map[string]*idlePeer            // 服务实例的地址与可关闭空闲连接的客户端的映射
+	etcdCli          *clientv3.Client                // etcd 服务
+	ctx              context.Context                 // 控制与 etcd 服务的交互
+	cancel           context.CancelFunc              // 用于取消 ctx 上下文对象的函数
 }
 
+// 编译时，强制检查 ClientPicker 类型是否实现了 PeerPicker 接口
+var _ PeerPicker = (*ClientPicker)(nil)
+
 // PickerOption 定义配置选项
 type PickerOption func(*ClientPicker)
 
@@ -51,35 +111,57 @@ func WithServiceName(name string) PickerOption {
 	}
 }
 
-// NewClientPicker 创建新的 ClientPicker 实例
-func NewClientPicker(addr string, opts ...PickerOption) (*ClientPicker, error) {
-	ctx, cancel := context.WithCancel(context.Background())
-	picker := &ClientPicker{
-		selfAddr: addr,
-		svcName:  defaultSvcName,
-		clients:  make(map[string]*Client),
-		consHash: consistenthash.New(),
-		ctx:      ctx,
-		cancel:   cancel,
+// WithDialConcurrency 设置并发连接节点的最大协程数
+func WithDialConcurrency(n int) PickerOption {
+	return func(cp *ClientPicker) {
+		if n > 0 {
+			cp.dialConcurrency = n
+		}
 	}
+}
 
-	for _, opt := range opts {
-		opt(picker)
+// WithRouteKeyFunc 设置路由键函数，选择节点时使用 fn(key) 计算哈希，存储仍使用完整的键
+// 例如去掉键中易变的版本后缀，使同一对象的不同版本路由到同一节点
+func WithRouteKeyFunc(fn func(key string) string) PickerOption {
+	return func(cp *ClientPicker) {
+		cp.routeKey = fn
+	}
+}
+
+// WithClientOptions 设置连接其他节点时使用的客户端配置，如调用超时
+func WithClientOptions(opts ...ClientOption) PickerOption {
+	return func(cp *ClientPicker) {
+		cp.clientOpts = append(cp.clientOpts, opts...)
 	}
+}
+
+// WithWatchBatchWindow 设置合并 etcd 监听事件的时间窗口，默认 100ms
+// 窗口内收到的节点上下线事件合并为一批，哈希环只重建一次；d 为 0 时逐个处理事件
+func WithWatchBatchWindow(d time.Duration) PickerOption {
+	return func(cp *ClientPicker) {
+		if d >= 0 {
+			cp.watchWindow = d
+		}
+	}
+}
+
+// NewClientPicker 创建新的 ClientPicker 实例
+func NewClientPicker(addr string, opts ...PickerOption) (*ClientPicker, error) {
+	picker := newClientPicker(addr, opts...)
 
 	cli, err := clientv3.New(clientv3.Config{
 		Endpoints:   registry.DefaultConfig.Endpoints,
 		DialTimeout: registry.DefaultConfig.DialTimeout,
 	})
 	if err != nil {
-		cancel()
+		picker.cancel()
 		return nil, fmt.Errorf("failed to create etcd client: %v", err)
 	}
 	picker.etcdCli = cli
 
 	// 启动服务发现
 	if err := picker.startServiceDiscovery(); err != nil {
-		cancel()
+		picker.cancel()
 		cli.Close()
 		return nil, err
 	}
@@ -87,6 +169,70 @@ func NewClientPicker(addr string, opts ...PickerOption) (*ClientPicker, error) {
 	return picker, nil
 }
 
+// NewStaticPicker 使用固定的节点地址列表创建 ClientPicker，不依赖 etcd
+// 适用于节点固定的小集群或本地测试；peers 可以包含当前节点地址，连接失败的节点定期重试
+func NewStaticPicker(selfAddr string, peers []string, opts ...PickerOption) *ClientPicker {
+	picker := newClientPicker(selfAddr, opts...)
+	picker.dial = func(addr string) (Peer, error) {
+		return dialClient(addr, picker.svcName, nil, picker.clientOpts...)
+	}
+	picker.startStatic(peers)
+	return picker
+}
+
+// startStatic 连接固定的节点，当前节点也加入哈希环，使 PickPeer 能区分归属当前节点的键
+func (cp *ClientPicker) startStatic(peers []string) {
+	cp.etcdHealthy = func() bool { return true }
+
+	cp.mu.Lock()
+	cp.consHash.Add(cp.selfAddr)
+	cp.mu.Unlock()
+
+	var addrs []string
+	for _, addr := range peers {
+		if addr != "" && addr != cp.selfAddr {
+			addrs = append(addrs, addr)
+		}
+	}
+	cp.dialPeers(addrs)
+
+	atomic.StoreInt32(&cp.initialized, 1)
+	go cp.retryFailedDials()
+	if cp.idleTimeout > 0 {
+		go cp.closeIdleClients()
+	}
+}
+
+// newClientPicker 创建不依赖 etcd 的 ClientPicker 基础实例
+func newClientPicker(addr string, opts ...PickerOption) *ClientPicker {
+	ctx, cancel := context.WithCancel(context.Background())
+	picker := &ClientPicker{
+		selfAddr:        addr,
+		svcName:         defaultSvcName,
+		clients:         make(map[string]Peer),
+		failed:          make(map[string]struct{}),
+		dialing:         make(map[string]int),
+		breakers:        make(map[string]*circuitBreaker),
+		idle:            make(map[string]*idlePeer),
+		consHash:        consistenthash.New(),
+		dialConcurrency: 16,
+		retryInterval:   5 * time.Second,
+		watchWindow:     100 * time.Millisecond,
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+	picker.dial = func(addr string) (Peer, error) {
+		return NewClient(addr, picker.svcName, picker.etcdCli, picker.clientOpts...)
+	}
+	picker.etcdHealthy = picker.etcdConnected
+
+	for _, opt := range opts {
+		opt(picker)
+	}
+
+	return picker
+}
+
 // startServiceDiscovery 启动服务发现
 func (cp *ClientPicker) startServiceDiscovery() error {
 	// 先进行全量更新
@@ -94,9 +240,19 @@ func (cp *ClientPicker) startServiceDiscovery() error {
 		return err
 	}
 
+	atomic.StoreInt32(&cp.initialized, 1)
+
 	// 启动增量更新
 	go cp.watchServiceChanges()
 
+	// 定期重试连接失败的节点
+	go cp.retryFailedDials()
+
+	// 定期关闭空闲连接
+	if cp.idleTimeout > 0 {
+		go cp.closeIdleClients()
+	}
+
 	return nil
 }
 
@@ -111,16 +267,21 @@ func (cp *ClientPicker) fetchAllServices() error {
 		return fmt.Errorf("failed to get all services: %v", err)
 	}
 
-	cp.mu.Lock()
-	defer cp.mu.Unlock()
-
+	var addrs []string
+	cp.mu.RLock()
 	for _, kv := range resp.Kvs {
 		addr := string(kv.Value)
 		if addr != "" && addr != cp.selfAddr {
-			cp.set(addr)
-			logrus.Infof("Discovered service at %s", addr)
+			if _, exists := cp.clients[addr]; !exists {
+				addrs = append(addrs, addr)
+				logrus.Infof("Discovered service at %s", addr)
+			}
 		}
 	}
+	cp.mu.RUnlock()
+
+	// 连接失败不影响启动，稍后重试
+	cp.dialPeers(addrs)
 	return nil
 }
 
@@ -136,80 +297,337 @@ func (cp *ClientPicker) watchServiceChanges() {
 			watcher.Close()
 			return
 		case resp := <-watchChan:
-			cp.handleWatchEvents(resp.Events)
+			events, ok := cp.collectEvents(resp.Events, watchChan)
+			if !ok {
+				watcher.Close()
+				return
+			}
+			cp.handleWatchEvents(events)
 		}
 	}
 }
 
-// handleWatchEvents 处理监听到的事件
-func (cp *ClientPicker) handleWatchEvents(events []*clientv3.Event) {
-	cp.mu.Lock()
-	defer cp.mu.Unlock()
+// collectEvents 在合并窗口内继续接收事件，与 first 合并为一批处理
+// 批量上下线时避免每个事件都修改一次哈希环；ctx 取消时返回 false
+func (cp *ClientPicker) collectEvents(first []*clientv3.Event, watchChan clientv3.WatchChan) ([]*clientv3.Event, bool) {
+	if cp.watchWindow <= 0 {
+		return first, true
+	}
+
+	events := first
+	timer := time.NewTimer(cp.watchWindow)
+	defer timer.Stop()
 
+	for {
+		select {
+		case <-cp.ctx.Done():
+			return nil, false
+		case <-timer.C:
+			return events, true
+		case resp, ok := <-watchChan:
+			if !ok {
+				return events, true
+			}
+			events = append(events, resp.Events...)
+		}
+	}
+}
+
+// handleWatchEvents 处理监听到的一批事件，同一地址只保留最后一个事件
+// 下线的节点一次性从哈希环移除，新节点连接完成后一次性加入哈希环
+func (cp *ClientPicker) handleWatchEvents(events []*clientv3.Event) {
+	// 地址最后一个事件是否为上线
+	online := make(map[string]bool, len(events))
+	var order []string
 	for _, event := range events {
 		addr := string(event.Kv.Value)
 		if addr == cp.selfAddr {
 			continue
 		}
+		if _, seen := online[addr]; !seen {
+			order = append(order, addr)
+		}
+		online[addr] = event.Type == clientv3.EventTypePut
+	}
+
+	var addrs, removed []string
 
-		switch event.Type {
+	cp.mu.Lock()
+	for _, addr := range order {
 		// 处理新增服务实例事件
-		case clientv3.EventTypePut:
+		if online[addr] {
 			if _, exists := cp.clients[addr]; !exists {
-				cp.set(addr)
+				addrs = append(addrs, addr)
 				logrus.Infof("New service discovered at %s", addr)
 			}
-		// 处理删除服务实例事件
-		case clientv3.EventTypeDelete:
-			if client, exists := cp.clients[addr]; exists {
-				client.Close()
-				cp.remove(addr)
-				logrus.Infof("Service removed at %s", addr)
+			continue
+		}
+
+		// 处理删除服务实例事件，正在进行的连接完成后丢弃
+		delete(cp.failed, addr)
+		delete(cp.dialing, addr)
+		if client, exists := cp.clients[addr]; exists {
+			client.Close()
+			cp.remove(addr)
+			removed = append(removed, addr)
+			logrus.Infof("Service removed at %s", addr)
+		}
+	}
+	if len(removed) > 0 {
+		cp.consHash.Update(nil, removed)
+	}
+	cp.beginDials(addrs)
+	cp.mu.Unlock()
+
+	// 连接新节点时不持有锁，避免阻塞节点选择
+	cp.connectPeers(addrs)
+}
+
+// dialPeers 登记并连接节点
+func (cp *ClientPicker) dialPeers(addrs []string) {
+	cp.mu.Lock()
+	cp.beginDials(addrs)
+	cp.mu.Unlock()
+
+	cp.connectPeers(addrs)
+}
+
+// connectPeers 使用有限数量的协程并发连接已登记的节点，全部连接结束后一次性加入哈希环
+// 连接失败的节点不会阻塞其他节点，记录下来由 retryFailedDials 稍后重试
+// 连接期间或加入哈希环之前已下线的节点不会加入哈希环
+func (cp *ClientPicker) connectPeers(addrs []string) {
+	sem := make(chan struct{}, cp.dialConcurrency)
+	var wg sync.WaitGroup
+	var added []string
+
+	for _, addr := range addrs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(addr string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			client, err := cp.dial(addr)
+
+			cp.mu.Lock()
+			defer cp.mu.Unlock()
+
+			if !cp.finishDial(addr) {
+				if err == nil {
+					client.Close()
+				}
+				logrus.Infof("Dropped connection to %s removed while dialing", addr)
+				return
+			}
+			if err != nil {
+				cp.failed[addr] = struct{}{}
+				logrus.Errorf("Failed to create client for %s: %v", addr, err)
+				return
 			}
+			if cp.addClient(addr, client) {
+				added = append(added, addr)
+			}
+		}(addr)
+	}
+
+	wg.Wait()
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	// 连接结束到加入哈希环之间下线的节点已从 clients 中删除
+	live := added[:0]
+	for _, addr := range added {
+		if _, ok := cp.clients[addr]; ok {
+			live = append(live, addr)
 		}
 	}
+	if len(live) > 0 {
+		cp.consHash.Update(live, nil)
+	}
 }
 
-// set 添加服务实例
-func (cp *ClientPicker) set(addr string) {
-	client, err := NewClient(addr, cp.svcName, cp.etcdCli)
-	if err != nil {
-		logrus.Errorf("Failed to create client for %s: %v", addr, err)
-		return
+// beginDials 登记对 addrs 的连接，节点下线时登记被删除，调用此方法必须持有锁
+func (cp *ClientPicker) beginDials(addrs []string) {
+	for _, addr := range addrs {
+		cp.dialing[addr]++
+	}
+}
+
+// finishDial 结束一次对 addr 的连接，返回连接期间节点是否仍在线，调用此方法必须持有锁
+func (cp *ClientPicker) finishDial(addr string) bool {
+	n, ok := cp.dialing[addr]
+	if !ok {
+		return false
+	}
+	if n <= 1 {
+		delete(cp.dialing, addr)
+	} else {
+		cp.dialing[addr] = n - 1
+	}
+	return true
+}
+
+// retryFailedDials 定期重试连接失败的节点
+func (cp *ClientPicker) retryFailedDials() {
+	ticker := time.NewTicker(cp.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cp.ctx.Done():
+			return
+		case <-ticker.C:
+			// 读取失败列表与登记连接在同一次加锁内完成，期间下线的节点不会被重试
+			cp.mu.Lock()
+			addrs := make([]string, 0, len(cp.failed))
+			for addr := range cp.failed {
+				addrs = append(addrs, addr)
+			}
+			cp.beginDials(addrs)
+			cp.mu.Unlock()
+
+			if len(addrs) > 0 {
+				logrus.Infof("Retrying %d failed peer connections", len(addrs))
+				cp.connectPeers(addrs)
+			}
+		}
+	}
+}
+
+// set 添加服务实例并加入哈希环，调用此方法必须持有锁
+func (cp *ClientPicker) set(addr string, client Peer) {
+	if cp.addClient(addr, client) {
+		cp.consHash.Add(addr)
+	}
+}
+
+// addClient 保存服务实例的客户端，不修改哈希环，重复连接时关闭新客户端并返回 false
+// 调用此方法必须持有锁
+func (cp *ClientPicker) addClient(addr string, client Peer) bool {
+	delete(cp.failed, addr)
+	if _, exists := cp.clients[addr]; exists {
+		// 并发发现导致的重复连接
+		client.Close()
+		return false
+	}
+	if cp.idleTimeout > 0 {
+		p := newIdlePeer(addr, client, cp.dial)
+		cp.idle[addr] = p
+		client = p
+	}
+	if cp.breakerThreshold > 0 {
+		b := newCircuitBreaker(cp.breakerThreshold, cp.breakerCooldown)
+		cp.breakers[addr] = b
+		client = &breakerPeer{Peer: client, addr: addr, breaker: b}
 	}
-	cp.consHash.Add(addr)
 	cp.clients[addr] = client
 	logrus.Infof("Successfully created client for %s", addr)
+	return true
 }
 
-// remove 移除服务实例
+// remove 移除服务实例，不修改哈希环，调用此方法必须持有锁
 func (cp *ClientPicker) remove(addr string) {
-	cp.consHash.Remove(addr)
 	delete(cp.clients, addr)
+	delete(cp.breakers, addr)
+	delete(cp.idle, addr)
 }
 
-// PickPeer 选择 peer节点
+// PickPeer 选择 peer节点，开启熔断时跳过熔断中的节点
 // 返回值 Peer节点， 是否找到，是否为当前节点自身
 func (cp *ClientPicker) PickPeer(key string) (Peer, bool, bool) {
 	cp.mu.RLock()
 	defer cp.mu.RUnlock()
 
-	addr := cp.consHash.Get(key)
+	routeKey := cp.routingKey(key)
+	addr := cp.pickAvailable(routeKey, cp.consHash.Get(routeKey))
 	if addr == "" {
 		return nil, false, false
 	}
+	if addr == cp.selfAddr {
+		return nil, true, true
+	}
 
 	client, ok := cp.clients[addr]
 	if !ok {
 		return nil, false, false
 	}
 
-	return client, true, addr == cp.selfAddr
+	return client, true, false
+}
+
+// PickPeers 选择键的前 n 个副本节点
+func (cp *ClientPicker) PickPeers(key string, n int) ([]Peer, bool) {
+	cp.mu.RLock()
+	defer cp.mu.RUnlock()
+
+	var peers []Peer
+	self := false
+	for _, addr := range cp.consHash.GetN(cp.routingKey(key), n) {
+		if addr == cp.selfAddr {
+			self = true
+			continue
+		}
+		if client, ok := cp.clients[addr]; ok {
+			peers = append(peers, client)
+		}
+	}
+	return peers, self
+}
+
+// PickWeightedPeers 选择键的前 n 个副本节点及其虚拟节点数
+func (cp *ClientPicker) PickWeightedPeers(key string, n int) ([]Peer, []int, bool) {
+	cp.mu.RLock()
+	defer cp.mu.RUnlock()
+
+	var peers []Peer
+	var weights []int
+	self := false
+	for _, addr := range cp.consHash.GetN(cp.routingKey(key), n) {
+		if addr == cp.selfAddr {
+			self = true
+			continue
+		}
+		if client, ok := cp.clients[addr]; ok {
+			peers = append(peers, client)
+			weights = append(weights, cp.consHash.Replicas(addr))
+		}
+	}
+	return peers, weights, self
+}
+
+// PeerByAddr 返回地址为 addr 的节点客户端
+func (cp *ClientPicker) PeerByAddr(addr string) (Peer, bool) {
+	cp.mu.RLock()
+	defer cp.mu.RUnlock()
+
+	client, ok := cp.clients[addr]
+	return client, ok
+}
+
+// Pin 将键固定到节点 addr，PickPeer 优先选择固定的节点，不受哈希和重新平衡的影响
+// 设置了路由键函数时按路由键固定，路由键相同的键都固定到该节点
+func (cp *ClientPicker) Pin(key, addr string) {
+	cp.consHash.Pin(cp.routingKey(key), addr)
+}
+
+// Unpin 取消键的固定，恢复按哈希环路由
+func (cp *ClientPicker) Unpin(key string) {
+	cp.consHash.Unpin(cp.routingKey(key))
+}
+
+// routingKey 返回用于选择节点的路由键
+func (cp *ClientPicker) routingKey(key string) string {
+	if cp.routeKey == nil {
+		return key
+	}
+	return cp.routeKey(key)
 }
 
 // Close 关闭所有资源
 func (cp *ClientPicker) Close() error {
 	cp.cancel()
+	cp.consHash.Close()
 	cp.mu.Lock()
 	defer cp.mu.Unlock()
 
@@ -220,8 +638,10 @@ func (cp *ClientPicker) Close() error {
 		}
 	}
 
-	if err := cp.etcdCli.Close(); err != nil {
-		errs = append(errs, fmt.Errorf("failed to close etcd client: %v", err))
+	if cp.etcdCli != nil {
+		if err := cp.etcdCli.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close etcd client: %v", err))
+		}
 	}
 
 	if len(errs) > 0 {
@@ -0,0 +1,353 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// 测试并发连接节点数受限
+func TestClientPickerDialConcurrency(t *testing.T) {
+	cp := newClientPicker("self", WithDialConcurrency(4))
+	defer cp.Close()
+
+	var active, maxActive int32
+	cp.dial = func(addr string) (Peer, error) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			m := atomic.LoadInt32(&maxActive)
+			if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		return newFakePeer(addr), nil
+	}
+
+	addrs := make([]string, 40)
+	for i := range addrs {
+		addrs[i] = fmt.Sprintf("10.0.0.%d:8001", i)
+	}
+
+	start := time.Now()
+	cp.dialPeers(addrs)
+	elapsed := time.Since(start)
+
+	if got := atomic.LoadInt32(&maxActive); got != 4 {
+		t.Errorf("Expected at most 4 concurrent dials, got %d", got)
+	}
+	// 40 个节点、并发 4，约 10 次连接耗时，远小于串行的 40 次
+	if elapsed > 30*20*time.Millisecond {
+		t.Errorf("Dialing took %v, expected roughly N/concurrency dial times", elapsed)
+	}
+	if len(cp.clients) != len(addrs) {
+		t.Errorf("Expected %d clients, got %d", len(addrs), len(cp.clients))
+	}
+}
+
+// 测试单个节点连接失败不影响其他节点
+func TestClientPickerDialFailure(t *testing.T) {
+	cp := newClientPicker("self", WithDialConcurrency(2))
+	defer cp.Close()
+
+	cp.dial = func(addr string) (Peer, error) {
+		if addr == "bad:8001" {
+			time.Sleep(100 * time.Millisecond)
+			return nil, errors.New("connection refused")
+		}
+		return newFakePeer(addr), nil
+	}
+
+	cp.dialPeers([]string{"bad:8001", "good1:8001", "good2:8001", "good3:8001"})
+
+	for _, addr := range []string{"good1:8001", "good2:8001", "good3:8001"} {
+		if _, ok := cp.clients[addr]; !ok {
+			t.Errorf("Expected client for %s", addr)
+		}
+	}
+	if _, ok := cp.clients["bad:8001"]; ok {
+		t.Errorf("Expected no client for failing address")
+	}
+	if _, ok := cp.failed["bad:8001"]; !ok {
+		t.Errorf("Expected failing address to be recorded for retry")
+	}
+
+	// 重试成功后从失败列表中移除
+	cp.dial = func(addr string) (Peer, error) {
+		return newFakePeer(addr), nil
+	}
+	cp.dialPeers([]string{"bad:8001"})
+	if _, ok := cp.clients["bad:8001"]; !ok {
+		t.Errorf("Expected client for previously failing address after retry")
+	}
+	if len(cp.failed) != 0 {
+		t.Errorf("Expected failed set to be empty after retry, got %v", cp.failed)
+	}
+}
+
+// 测试连接期间下线的节点连接完成后被丢弃，不会加入哈希环
+func TestClientPickerDialRemoved(t *testing.T) {
+	cp := newClientPicker("self")
+	defer cp.Close()
+
+	dialing, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	cp.dial = func(addr string) (Peer, error) {
+		once.Do(func() {
+			close(dialing)
+			<-release
+		})
+		return newFakePeer(addr), nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cp.handleWatchEvents([]*clientv3.Event{watchEvent(clientv3.EventTypePut, "slow:8001")})
+	}()
+	<-dialing
+	cp.handleWatchEvents([]*clientv3.Event{watchEvent(clientv3.EventTypeDelete, "slow:8001")})
+	close(release)
+	<-done
+
+	if _, ok := cp.clients["slow:8001"]; ok {
+		t.Fatalf("Expected peer removed while dialing to be dropped")
+	}
+	if got := cp.consHash.Replicas("slow:8001"); got != 0 {
+		t.Fatalf("Expected peer removed while dialing to stay off the ring, got %d replicas", got)
+	}
+	if len(cp.dialing) != 0 {
+		t.Fatalf("Expected no dials in flight, got %v", cp.dialing)
+	}
+
+	// 重新上线后正常加入
+	cp.handleWatchEvents([]*clientv3.Event{watchEvent(clientv3.EventTypePut, "slow:8001")})
+	if got := cp.consHash.Replicas("slow:8001"); got == 0 {
+		t.Fatalf("Expected peer to join the ring after coming back online")
+	}
+}
+
+// 测试重试连接期间下线的节点不会加入哈希环，也不会被再次重试
+func TestClientPickerRetryRemoved(t *testing.T) {
+	cp := newClientPicker("self")
+	defer cp.Close()
+	cp.retryInterval = 10 * time.Millisecond
+	cp.failed["slow:8001"] = struct{}{}
+
+	dialing, release := make(chan struct{}), make(chan struct{})
+	var dials int32
+	cp.dial = func(addr string) (Peer, error) {
+		if atomic.AddInt32(&dials, 1) == 1 {
+			close(dialing)
+			<-release
+		}
+		return newFakePeer(addr), nil
+	}
+
+	go cp.retryFailedDials()
+	<-dialing
+	cp.handleWatchEvents([]*clientv3.Event{watchEvent(clientv3.EventTypeDelete, "slow:8001")})
+	close(release)
+	time.Sleep(50 * time.Millisecond)
+
+	cp.mu.RLock()
+	defer cp.mu.RUnlock()
+	if _, ok := cp.clients["slow:8001"]; ok {
+		t.Fatalf("Expected peer removed while retrying to be dropped")
+	}
+	if got := cp.consHash.Replicas("slow:8001"); got != 0 {
+		t.Fatalf("Expected peer removed while retrying to stay off the ring, got %d replicas", got)
+	}
+	if got := atomic.LoadInt32(&dials); got != 1 {
+		t.Fatalf("Expected removed peer not to be retried again, got %d dials", got)
+	}
+}
+
+// 测试路由键相同的键路由到同一节点
+func TestClientPickerRouteKeyFunc(t *testing.T) {
+	// 去掉 "@" 之后的版本后缀
+	stripVersion := func(key string) string {
+		if i := strings.LastIndex(key, "@"); i >= 0 {
+			return key[:i]
+		}
+		return key
+	}
+	cp := newClientPicker("self", WithRouteKeyFunc(stripVersion))
+	defer cp.Close()
+
+	cp.mu.Lock()
+	for _, addr := range []string{"10.0.0.1:8001", "10.0.0.2:8001", "10.0.0.3:8001"} {
+		cp.set(addr, newFakePeer(addr))
+	}
+	cp.mu.Unlock()
+
+	for i := range 50 {
+		key := fmt.Sprintf("object-%d", i)
+		base, ok, _ := cp.PickPeer(key)
+		if !ok {
+			t.Fatalf("Expected a peer for %s", key)
+		}
+		for _, version := range []string{"@v1", "@v2", "@20240101"} {
+			peer, ok, _ := cp.PickPeer(key + version)
+			if !ok || peer != base {
+				t.Fatalf("Expected %s%s to route to the same peer as %s", key, version, key)
+			}
+		}
+	}
+
+	// 未设置路由键函数时直接使用存储键
+	plain := newClientPicker("self")
+	defer plain.Close()
+	if got := plain.routingKey("object@v1"); got != "object@v1" {
+		t.Errorf("Expected storage key to be used for routing, got %s", got)
+	}
+}
+
+// 测试静态节点列表在三个节点间路由，并区分当前节点
+func TestStaticPicker(t *testing.T) {
+	cp := newClientPicker("10.0.0.1:8001")
+	defer cp.Close()
+
+	var mu sync.Mutex
+	peers := make(map[string]*fakePeer)
+	cp.dial = func(addr string) (Peer, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		p := newFakePeer(addr)
+		peers[addr] = p
+		return p, nil
+	}
+	cp.startStatic([]string{"10.0.0.1:8001", "10.0.0.2:8001", "10.0.0.3:8001"})
+
+	if len(peers) != 2 {
+		t.Fatalf("Expected clients for the 2 other nodes only, got %d", len(peers))
+	}
+	if status := cp.HealthStatus(); !status.Ready {
+		t.Fatalf("Expected static picker to be ready without etcd, got %+v", status)
+	}
+
+	owners := make(map[string]int)
+	for i := range 300 {
+		key := fmt.Sprintf("key-%d", i)
+		owner := cp.consHash.GetN(key, 1)[0]
+		owners[owner]++
+
+		peer, ok, self := cp.PickPeer(key)
+		if !ok {
+			t.Fatalf("Expected %s to be routed", key)
+		}
+		if self != (owner == "10.0.0.1:8001") {
+			t.Fatalf("Key %s owned by %s, got self=%v", key, owner, self)
+		}
+		if !self && peer != peers[owner] {
+			t.Fatalf("Expected %s to be routed to %s", key, owner)
+		}
+	}
+
+	if len(owners) != 3 {
+		t.Errorf("Expected keys to be spread over 3 nodes, got %v", owners)
+	}
+}
+
+// watchEvent 构造 etcd 监听事件
+func watchEvent(typ mvccpb.Event_EventType, addr string) *clientv3.Event {
+	return &clientv3.Event{
+		Type: typ,
+		Kv:   &mvccpb.KeyValue{Key: []byte("/services/g-cache/" + addr), Value: []byte(addr)},
+	}
+}
+
+// 测试一批监听事件只重建一次哈希环
+func TestClientPickerWatchEventsBatch(t *testing.T) {
+	cp := newClientPicker("self", WithWatchBatchWindow(50*time.Millisecond))
+	defer cp.Close()
+	cp.dial = func(addr string) (Peer, error) {
+		return newFakePeer(addr), nil
+	}
+
+	// 窗口内陆续到达的事件合并为一批
+	watchChan := make(chan clientv3.WatchResponse, 20)
+	for i := 1; i < 20; i++ {
+		watchChan <- clientv3.WatchResponse{Events: []*clientv3.Event{watchEvent(clientv3.EventTypePut, fmt.Sprintf("10.0.0.%d:8001", i))}}
+	}
+	events, ok := cp.collectEvents([]*clientv3.Event{watchEvent(clientv3.EventTypePut, "10.0.0.0:8001")}, watchChan)
+	if !ok || len(events) != 20 {
+		t.Fatalf("Expected 20 batched events, got %d (ok=%v)", len(events), ok)
+	}
+
+	before := cp.consHash.Generation()
+	cp.handleWatchEvents(events)
+	if got := cp.consHash.Generation() - before; got != 1 {
+		t.Fatalf("Expected a single ring rebuild for 20 additions, got %d", got)
+	}
+	if len(cp.clients) != 20 {
+		t.Fatalf("Expected 20 clients, got %d", len(cp.clients))
+	}
+
+	// 批量下线，同一地址先下线后上线以最后一个事件为准
+	var burst []*clientv3.Event
+	for i := range 10 {
+		burst = append(burst, watchEvent(clientv3.EventTypeDelete, fmt.Sprintf("10.0.0.%d:8001", i)))
+	}
+	burst = append(burst, watchEvent(clientv3.EventTypePut, "10.0.0.0:8001"))
+
+	before = cp.consHash.Generation()
+	cp.handleWatchEvents(burst)
+	if got := cp.consHash.Generation() - before; got != 1 {
+		t.Fatalf("Expected a single ring rebuild for 9 removals, got %d", got)
+	}
+	if len(cp.clients) != 11 {
+		t.Fatalf("Expected 11 clients after removals, got %d", len(cp.clients))
+	}
+	if _, ok := cp.clients["10.0.0.0:8001"]; !ok {
+		t.Fatalf("Expected re-added peer to be kept")
+	}
+	if got := cp.consHash.Replicas("10.0.0.1:8001"); got != 0 {
+		t.Fatalf("Expected removed peer to leave the ring, got %d replicas", got)
+	}
+}
+
+// 测试固定的键路由到指定节点
+func TestClientPickerPin(t *testing.T) {
+	cp := newClientPicker("self")
+	defer cp.Close()
+
+	cp.mu.Lock()
+	for _, addr := range []string{"10.0.0.1:8001", "10.0.0.2:8001"} {
+		cp.set(addr, newFakePeer(addr))
+	}
+	cp.mu.Unlock()
+
+	key := "gpu-shard"
+	target := "10.0.0.1:8001"
+	if cp.consHash.Get(key) == target {
+		target = "10.0.0.2:8001"
+	}
+
+	cp.Pin(key, target)
+	cp.dial = func(addr string) (Peer, error) {
+		return newFakePeer(addr), nil
+	}
+	cp.dialPeers([]string{"10.0.0.3:8001", "10.0.0.4:8001"})
+
+	peer, ok, self := cp.PickPeer(key)
+	if !ok || self || peer.(*fakePeer).name != target {
+		t.Fatalf("Expected pinned key to route to %s", target)
+	}
+
+	cp.Unpin(key)
+	peer, ok, _ = cp.PickPeer(key)
+	if !ok || peer.(*fakePeer).name != cp.consHash.GetExcluding(key, nil) {
+		t.Fatalf("Expected hash routing after unpin")
+	}
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MemoryPressureOptions 进程内存压力下自动收缩缓存容量的配置
+// 堆占用超过 HighWatermark 时将容量降到 MaxBytes*ShrinkRatio 并淘汰超出的项，低于 LowWatermark 时恢复
+// 只有支持调整容量的存储（LRU、LRU2）生效，其他存储类型在创建缓存时输出警告
+type MemoryPressureOptions struct {
+	HighWatermark uint64                  // 堆占用（HeapAlloc）达到此值时收缩
+	LowWatermark  uint64                  // 堆占用低于此值时恢复容量，0 表示与 HighWatermark 相同
+	ShrinkRatio   float64                 // 收缩后的容量占 MaxBytes 的比例，取值 (0, 1)，默认 0.5
+	CheckInterval time.Duration           // 检查间隔，默认 10 秒
+	ReadMemStats  func(*runtime.MemStats) // 读取内存统计，默认 runtime.ReadMemStats
+}
+
+// 内存压力检查的默认配置
+const (
+	defaultShrinkRatio           = 0.5
+	defaultPressureCheckInterval = 10 * time.Second
+)
+
+// maxBytesSetter 支持调整容量的存储
+type maxBytesSetter interface {
+	MaxBytes() int64
+	SetMaxBytes(maxBytes int64)
+}
+
+// pressureMonitor 定期检查内存压力并调整缓存容量
+type pressureMonitor struct {
+	opts     MemoryPressureOptions
+	mu       sync.Mutex
+	shrunk   bool  // 当前是否处于收缩状态
+	restore  int64 // 收缩前存储的容量，压力缓解后恢复，0 表示不限制
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// newPressureMonitor 创建内存压力检查，补全默认配置
+func newPressureMonitor(opts MemoryPressureOptions) *pressureMonitor {
+	if opts.LowWatermark == 0 || opts.LowWatermark > opts.HighWatermark {
+		opts.LowWatermark = opts.HighWatermark
+	}
+	if opts.ShrinkRatio <= 0 || opts.ShrinkRatio >= 1 {
+		opts.ShrinkRatio = defaultShrinkRatio
+	}
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = defaultPressureCheckInterval
+	}
+	if opts.ReadMemStats == nil {
+		opts.ReadMemStats = runtime.ReadMemStats
+	}
+	return &pressureMonitor{opts: opts, stopCh: make(chan struct{})}
+}
+
+// stop 停止后台检查
+func (m *pressureMonitor) stop() {
+	m.stopOnce.Do(func() { close(m.stopCh) })
+}
+
+// underPressure 返回当前是否处于收缩状态
+func (m *pressureMonitor) underPressure() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.shrunk
+}
+
+// watchMemoryPressure 定期检查内存压力，缓存关闭后退出
+func (c *Cache) watchMemoryPressure() {
+	ticker := time.NewTicker(c.pressure.opts.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.pressure.stopCh:
+			return
+		case <-ticker.C:
+			c.checkMemoryPressure()
+		}
+	}
+}
+
+// checkMemoryPressure 读取堆占用，超过阈值时收缩缓存容量，压力缓解后恢复
+func (c *Cache) checkMemoryPressure() {
+	m := c.pressure
+	var stats runtime.MemStats
+	m.opts.ReadMemStats(&stats)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case !m.shrunk && stats.HeapAlloc >= m.opts.HighWatermark:
+		limit := int64(float64(c.opts.MaxBytes) * m.opts.ShrinkRatio)
+		if restore, ok := c.setMaxBytes(max(limit, 1)); ok {
+			m.shrunk, m.restore = true, restore
+			logrus.Warnf("[G-Cache] heap %d bytes over %d, shrinking cache to %d bytes", stats.HeapAlloc, m.opts.HighWatermark, limit)
+		}
+	case m.shrunk && stats.HeapAlloc < m.opts.LowWatermark:
+		if _, ok := c.setMaxBytes(m.restore); ok {
+			m.shrunk = false
+			logrus.Infof("[G-Cache] heap %d bytes under %d, restoring cache to %d bytes", stats.HeapAlloc, m.opts.LowWatermark, m.restore)
+		}
+	}
+}
+
+// setMaxBytes 调整底层存储的容量并淘汰超出的项，返回调整前的容量，存储未初始化或不支持时返回 false
+func (c *Cache) setMaxBytes(maxBytes int64) (int64, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, err := c.storeLocked()
+	if err != nil {
+		return 0, false
+	}
+	setter, ok := s.(maxBytesSetter)
+	if !ok {
+		return 0, false
+	}
+	prev := setter.MaxBytes()
+	setter.SetMaxBytes(maxBytes)
+	return prev, true
+}
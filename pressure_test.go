@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lyy42995004/Cache-Go/store"
+)
+
+// newPressureTestCache 创建容量 1000 字节、堆占用由 heap 模拟的缓存
+func newPressureTestCache(t *testing.T, interval time.Duration) (*Cache, *uint64) {
+	t.Helper()
+	var heap uint64
+	opts := DefaultCacheOptions()
+	opts.CacheType = store.LRU
+	opts.MaxBytes = 1000
+	opts.MemoryPressure = &MemoryPressureOptions{
+		HighWatermark: 800,
+		LowWatermark:  500,
+		ShrinkRatio:   0.3,
+		CheckInterval: interval,
+		ReadMemStats: func(s *runtime.MemStats) {
+			s.HeapAlloc = atomic.LoadUint64(&heap)
+		},
+	}
+	c := NewCache(opts)
+	t.Cleanup(c.Close)
+	return c, &heap
+}
+
+// fillPressureTestCache 写入 n 项，每项占用 len("key-00") + 94 = 100 字节
+func fillPressureTestCache(t *testing.T, c *Cache, n int) {
+	t.Helper()
+	for i := range n {
+		if err := c.Set(fmt.Sprintf("key-%02d", i), ByteView{b: make([]byte, 94)}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+}
+
+// 测试内存压力下收缩缓存，压力缓解后恢复容量
+func TestCacheMemoryPressure(t *testing.T) {
+	c, heap := newPressureTestCache(t, time.Hour)
+	fillPressureTestCache(t, c, 10)
+	if used := c.usedBytes(); used != 1000 {
+		t.Fatalf("Expected 1000 bytes used, got %d", used)
+	}
+
+	// 未达到阈值时不收缩
+	atomic.StoreUint64(heap, 700)
+	c.checkMemoryPressure()
+	if used := c.usedBytes(); used != 1000 {
+		t.Fatalf("Expected no trim below the high watermark, got %d bytes", used)
+	}
+
+	atomic.StoreUint64(heap, 900)
+	c.checkMemoryPressure()
+	if used := c.usedBytes(); used > 300 {
+		t.Fatalf("Expected cache to be trimmed to 300 bytes, got %d", used)
+	}
+	if !c.Stats()["memory_pressure"].(bool) {
+		t.Fatalf("Expected memory_pressure in stats")
+	}
+	// 收缩期间写入不会超过收缩后的容量
+	fillPressureTestCache(t, c, 10)
+	if used := c.usedBytes(); used > 300 {
+		t.Fatalf("Expected writes to respect the shrunk limit, got %d bytes", used)
+	}
+
+	// 介于两个阈值之间时保持收缩
+	atomic.StoreUint64(heap, 600)
+	c.checkMemoryPressure()
+	fillPressureTestCache(t, c, 10)
+	if used := c.usedBytes(); used > 300 {
+		t.Fatalf("Expected cache to stay shrunk above the low watermark, got %d bytes", used)
+	}
+
+	atomic.StoreUint64(heap, 400)
+	c.checkMemoryPressure()
+	fillPressureTestCache(t, c, 10)
+	if used := c.usedBytes(); used != 1000 {
+		t.Fatalf("Expected cache to grow back to 1000 bytes, got %d", used)
+	}
+}
+
+// 测试后台定期检查内存压力
+func TestCacheMemoryPressureBackground(t *testing.T) {
+	c, heap := newPressureTestCache(t, 10*time.Millisecond)
+	fillPressureTestCache(t, c, 10)
+	atomic.StoreUint64(heap, 900)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for c.usedBytes() > 300 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected cache to be trimmed in the background, got %d bytes", c.usedBytes())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// 测试默认的 LRU2 存储在内存压力下收缩，压力缓解后恢复为不限制
+func TestCacheMemoryPressureDefaultStore(t *testing.T) {
+	var heap uint64
+	opts := DefaultCacheOptions()
+	opts.MaxBytes = 32000
+	opts.MemoryPressure = &MemoryPressureOptions{
+		HighWatermark: 800,
+		LowWatermark:  500,
+		CheckInterval: time.Hour,
+		ReadMemStats: func(s *runtime.MemStats) {
+			s.HeapAlloc = atomic.LoadUint64(&heap)
+		},
+	}
+	c := NewCache(opts)
+	t.Cleanup(c.Close)
+	if opts.CacheType != store.LRU2 {
+		t.Fatalf("Expected default cache type lru2, got %s", opts.CacheType)
+	}
+
+	fillPressureTestCache(t, c, 100)
+	if used := c.usedBytes(); used != 10000 {
+		t.Fatalf("Expected 10000 bytes used, got %d", used)
+	}
+
+	atomic.StoreUint64(&heap, 900)
+	c.checkMemoryPressure()
+	if used := c.usedBytes(); used > 16000 {
+		t.Fatalf("Expected cache to be trimmed to 16000 bytes, got %d", used)
+	}
+	for i := range 300 {
+		if err := c.Set(fmt.Sprintf("more-%03d", i), ByteView{b: make([]byte, 92)}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	if used := c.usedBytes(); used > 16000 {
+		t.Fatalf("Expected writes to respect the shrunk limit, got %d bytes", used)
+	}
+
+	// LRU2 原本不按字节限制，恢复后可以超过 MaxBytes
+	atomic.StoreUint64(&heap, 400)
+	c.checkMemoryPressure()
+	for i := range 400 {
+		if err := c.Set(fmt.Sprintf("more-%03d", i), ByteView{b: make([]byte, 92)}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	if used := c.usedBytes(); used != 40000 {
+		t.Fatalf("Expected cache to be unlimited again, got %d bytes", used)
+	}
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrPeerNotFound 指定地址的节点不存在或尚未连接错误
+var ErrPeerNotFound = errors.New("peer not found")
+
+// PushTo 将本地缓存中 keys 的值连同过期时间写入地址为 peerAddr 的节点，用于故障转移时在节点接管前预热其缓存
+// 本地未缓存的键被跳过；需要 PeerPicker 实现 AddrPicker，返回所有写入失败的错误，ctx 取消时停止推送
+func (g *Group) PushTo(ctx context.Context, peerAddr string, keys []string) error {
+	if atomic.LoadInt32(&g.closed) == 1 {
+		return ErrGroupClosed
+	}
+
+	picker, ok := g.peers.(AddrPicker)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrPeerNotFound, peerAddr)
+	}
+	peer, ok := picker.PeerByAddr(peerAddr)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrPeerNotFound, peerAddr)
+	}
+
+	// 目标节点不再同步给其他节点
+	syncCtx := context.WithValue(ctx, fromPeerKey, true)
+
+	var errs []error
+	pushed := 0
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		value, expireAt, ok := g.mainCache.lookup(key)
+		if !ok {
+			continue
+		}
+
+		setCtx := syncCtx
+		if !expireAt.IsZero() {
+			setCtx = withExpireAt(syncCtx, expireAt)
+		}
+		if err := peer.Set(setCtx, g.name, key, value.ByteSLice()); err != nil {
+			errs = append(errs, fmt.Errorf("push key %s: %w", key, err))
+			continue
+		}
+		pushed++
+	}
+
+	logrus.Infof("[G-Cache] pushed %d/%d keys to peer %s", pushed, len(keys), peerAddr)
+	return errors.Join(errs...)
+}
+
+// lookup 返回键的值和过期时间，不计入命中统计，存储不支持查询过期时间时视为不过期
+func (c *Cache) lookup(key string) (ByteView, time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, err := c.storeLocked()
+	if err != nil {
+		return ByteView{}, time.Time{}, false
+	}
+
+	val, found := s.Get(key)
+	if !found {
+		return ByteView{}, time.Time{}, false
+	}
+	bv, ok := c.decode(val)
+	if !ok {
+		return ByteView{}, time.Time{}, false
+	}
+
+	expireAt, _ := storeExpiration(s, key)
+	return bv, expireAt, true
+}
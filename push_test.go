@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// 测试向指定节点推送本地缓存的值和过期时间，跳过本地未缓存的键
+func TestGroupPushTo(t *testing.T) {
+	target := newFakePeer("B")
+	picker := &fakePicker{
+		self:  "self",
+		peers: map[string]*fakePeer{"B": target},
+		owner: func(string) string { return "self" },
+	}
+	g := newTestGroup(t, nil)
+	g.RegisterPeers(picker)
+
+	ctx := context.Background()
+	if err := g.Set(ctx, "k1", []byte("v1")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := g.mainCache.SetWithExpiration("k2", ByteView{b: []byte("v2")}, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("SetWithExpiration failed: %v", err)
+	}
+	expireAt := localExpiration(t, g, "k2")
+
+	if err := g.PushTo(ctx, "B", []string{"k1", "k2", "missing"}); err != nil {
+		t.Fatalf("PushTo failed: %v", err)
+	}
+
+	target.mu.Lock()
+	defer target.mu.Unlock()
+	if string(target.data["k1"]) != "v1" || string(target.data["k2"]) != "v2" {
+		t.Fatalf("Expected target to receive local values, got %v", target.data)
+	}
+	if _, ok := target.data["missing"]; ok {
+		t.Fatalf("Expected key missing locally to be skipped")
+	}
+	if got, ok := target.expires["k2"]; !ok || !got.Equal(expireAt) {
+		t.Fatalf("Expected expiry %v to be pushed, got %v %v", expireAt, got, ok)
+	}
+	if _, ok := target.expires["k1"]; ok {
+		t.Fatalf("Expected key without expiry to be pushed without one")
+	}
+}
+
+// 测试目标节点不存在或写入失败时返回错误
+func TestGroupPushToErrors(t *testing.T) {
+	target := newFakePeer("B")
+	picker := &fakePicker{
+		self:  "self",
+		peers: map[string]*fakePeer{"B": target},
+		owner: func(string) string { return "self" },
+	}
+	g := newTestGroup(t, nil)
+	g.RegisterPeers(picker)
+
+	ctx := context.Background()
+	g.Set(ctx, "k1", []byte("v1"))
+
+	if err := g.PushTo(ctx, "C", []string{"k1"}); !errors.Is(err, ErrPeerNotFound) {
+		t.Fatalf("Expected ErrPeerNotFound, got %v", err)
+	}
+
+	target.err = errors.New("unavailable")
+	if err := g.PushTo(ctx, "B", []string{"k1"}); !errors.Is(err, target.err) {
+		t.Fatalf("Expected set error to be returned, got %v", err)
+	}
+}
@@ -2,8 +2,10 @@ package registry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -22,8 +24,16 @@ var DefaultConfig = &Config{
 	DialTimeout: 5 * time.Second,
 }
 
-// Register 注册服务到etcd
+// ErrInvalidAddr 服务地址格式错误，地址应为 host:port 或 :port
+var ErrInvalidAddr = errors.New("invalid service address")
+
+// Register 注册服务到etcd，addr 为 :port 时使用本机的非回环 IPv4 地址
 func Register(svcName, addr string, stopCh <-chan error) error {
+	host, port, err := splitAddr(addr)
+	if err != nil {
+		return err
+	}
+
 	cli, err := clientv3.New(clientv3.Config{
 		Endpoints:   DefaultConfig.Endpoints,
 		DialTimeout: DefaultConfig.DialTimeout,
@@ -32,14 +42,13 @@ func Register(svcName, addr string, stopCh <-chan error) error {
 		return fmt.Errorf("failed to create etcd client: %v", err)
 	}
 
-	localIP, err := getLoaclIP()
-	if err != nil {
-		cli.Close()
-		return fmt.Errorf("failed to get local IP: %v", err)
-	}
-
-	if addr[0] == ':' {
-		addr = fmt.Sprintf("%s%s", localIP, addr)
+	if host == "" {
+		localIP, err := getLoaclIP()
+		if err != nil {
+			cli.Close()
+			return fmt.Errorf("failed to get local IP: %v", err)
+		}
+		addr = net.JoinHostPort(localIP, port)
 	}
 
 	// 创建租约
@@ -89,6 +98,21 @@ func Register(svcName, addr string, stopCh <-chan error) error {
 	return nil
 }
 
+// splitAddr 校验服务地址并拆分为主机和端口，:port 形式的地址主机为空
+func splitAddr(addr string) (host, port string, err error) {
+	if addr == "" {
+		return "", "", fmt.Errorf("%w: empty address", ErrInvalidAddr)
+	}
+	host, port, err = net.SplitHostPort(addr)
+	if err != nil {
+		return "", "", fmt.Errorf("%w %q: %v", ErrInvalidAddr, addr, err)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return "", "", fmt.Errorf("%w %q: port must be between 1 and 65535", ErrInvalidAddr, addr)
+	}
+	return host, port, nil
+}
+
 // getLoaclIP 获取本地的非回环 IPv4 地址
 func getLoaclIP() (string, error) {
 	addrs, err := net.InterfaceAddrs() // 获取本地的所有网络地址
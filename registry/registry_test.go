@@ -0,0 +1,48 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+)
+
+// 测试服务地址的校验和拆分
+func TestSplitAddr(t *testing.T) {
+	tests := []struct {
+		addr       string
+		host, port string
+		ok         bool
+	}{
+		{"", "", "", false},
+		{":8001", "", "8001", true},
+		{"localhost:8001", "localhost", "8001", true},
+		{"10.0.0.1:8001", "10.0.0.1", "8001", true},
+		{"[::1]:8001", "::1", "8001", true},
+		{"localhost", "", "", false},
+		{":", "", "", false},
+		{"localhost:http", "", "", false},
+		{"localhost:70000", "", "", false},
+		{"a:b:8001", "", "", false},
+	}
+
+	for _, tt := range tests {
+		host, port, err := splitAddr(tt.addr)
+		if !tt.ok {
+			if !errors.Is(err, ErrInvalidAddr) {
+				t.Errorf("splitAddr(%q): expected ErrInvalidAddr, got %v", tt.addr, err)
+			}
+			continue
+		}
+		if err != nil || host != tt.host || port != tt.port {
+			t.Errorf("splitAddr(%q) = %q, %q, %v; want %q, %q", tt.addr, host, port, err, tt.host, tt.port)
+		}
+	}
+}
+
+// 测试地址格式错误时 Register 直接返回错误，不连接 etcd
+func TestRegisterInvalidAddr(t *testing.T) {
+	for _, addr := range []string{"", "localhost"} {
+		if err := Register("g-cache", addr, nil); !errors.Is(err, ErrInvalidAddr) {
+			t.Errorf("Register(%q): expected ErrInvalidAddr, got %v", addr, err)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrWriteQuorum 写入成功的副本数未达到法定数量错误
+var ErrWriteQuorum = errors.New("write quorum not reached")
+
+// ReadStrategy 从副本读取时选择节点的策略
+type ReadStrategy int
+
+const (
+	ReadPrimary        ReadStrategy = iota // 总是读取主节点
+	ReadRoundRobin                         // 在副本间轮询
+	ReadWeightedRandom                     // 按副本在哈希环上的虚拟节点数加权随机
+)
+
+// WithReplicas 设置每个键的副本数，需要 PeerPicker 实现 ReplicaPicker
+func WithReplicas(n int) GroupOption {
+	return func(g *Group) {
+		if n > 0 {
+			g.replicas = n
+		}
+	}
+}
+
+// WithWriteQuorum 设置写入的法定副本数，Set 在 w 个副本确认后才返回成功
+// 副本数小于 w 时按 w 个副本写入；需要 PeerPicker 实现 ReplicaPicker
+func WithWriteQuorum(w int) GroupOption {
+	return func(g *Group) {
+		if w > 0 {
+			g.writeQuorum = w
+		}
+	}
+}
+
+// WithReadRepair 开启读修复：从主节点读取成功后，异步检查其他副本，
+// 副本缺失该键或值不一致时，将主节点的值写入该副本；需要 PeerPicker 实现 ReplicaPicker
+func WithReadRepair() GroupOption {
+	return func(g *Group) {
+		g.readRepair = true
+	}
+}
+
+// WithReadStrategy 设置从副本读取时选择节点的策略，需要 PeerPicker 实现 ReplicaPicker
+// 当前节点是键的副本之一时仍按主节点读取；ReadWeightedRandom 在 PeerPicker 未实现
+// WeightedReplicaPicker 时退化为等权随机
+func WithReadStrategy(s ReadStrategy) GroupOption {
+	return func(g *Group) {
+		g.readStrategy = s
+	}
+}
+
+// pickReadPeer 按读取策略从远程副本中选择节点，无法选择时返回 false，由调用方按主节点读取
+func (g *Group) pickReadPeer(key string) (Peer, bool) {
+	if g.readStrategy == ReadPrimary {
+		return nil, false
+	}
+
+	var peers []Peer
+	var weights []int
+	var self bool
+	switch picker := g.peers.(type) {
+	case WeightedReplicaPicker:
+		peers, weights, self = picker.PickWeightedPeers(key, g.replicas)
+	case ReplicaPicker:
+		peers, self = picker.PickPeers(key, g.replicas)
+	default:
+		return nil, false
+	}
+	if self || len(peers) == 0 {
+		return nil, false
+	}
+
+	switch g.readStrategy {
+	case ReadRoundRobin:
+		i := atomic.AddUint64(&g.readCursor, 1) - 1
+		return peers[i%uint64(len(peers))], true
+	case ReadWeightedRandom:
+		return weightedPick(peers, weights), true
+	default:
+		return nil, false
+	}
+}
+
+// weightedPick 按权重随机选择节点，权重缺失或总和为 0 时等权选择
+func weightedPick(peers []Peer, weights []int) Peer {
+	total := 0
+	if len(weights) == len(peers) {
+		for _, w := range weights {
+			total += max(w, 0)
+		}
+	}
+	if total == 0 {
+		return peers[rand.IntN(len(peers))]
+	}
+
+	r := rand.IntN(total)
+	for i, w := range weights {
+		r -= max(w, 0)
+		if r < 0 {
+			return peers[i]
+		}
+	}
+	return peers[len(peers)-1]
+}
+
+// replicaWriteTimeout 副本写入的超时时间，写入不随调用方的 ctx 取消，达到法定数量后剩余的写入在后台完成
+const replicaWriteTimeout = 5 * time.Second
+
+// writeReplicas 并发写入键的所有副本节点，w 个副本确认后返回
+// 当前节点是副本之一时，本地写入计为一次确认；调用方的 ctx 取消时返回，已发出的写入继续完成
+func (g *Group) writeReplicas(ctx context.Context, picker ReplicaPicker, key string, value []byte) error {
+	w := g.writeQuorum
+	peers, self := picker.PickPeers(key, max(g.replicas, w))
+
+	acks := 0
+	if self {
+		acks++
+	}
+	if acks+len(peers) < w {
+		return fmt.Errorf("%w: %d replicas available, need %d", ErrWriteQuorum, acks+len(peers), w)
+	}
+
+	// 结果通道带缓冲，提前返回后剩余的写入仍可完成
+	results := make(chan error, len(peers))
+	syncCtx, cancel := context.WithTimeout(context.WithValue(context.WithoutCancel(ctx), fromPeerKey, true), replicaWriteTimeout)
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer Peer) {
+			defer wg.Done()
+			results <- peer.Set(syncCtx, g.name, key, value)
+		}(peer)
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+	}()
+
+	var errs []error
+	for pending := len(peers); acks < w; pending-- {
+		// 剩余的副本全部成功也无法达到法定数量
+		if acks+pending < w {
+			logrus.Errorf("[G-Cache] write quorum not reached for key %s: %v", key, errs)
+			return fmt.Errorf("%w: %d/%d acks: %w", ErrWriteQuorum, acks, w, errors.Join(errs...))
+		}
+
+		select {
+		case err := <-results:
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			acks++
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %d/%d acks: %w", ErrWriteQuorum, acks, w, ctx.Err())
+		}
+	}
+
+	return nil
+}
+
+// repairReplicas 以主节点的值为准修复其他副本，写入时使用主节点副本的过期时间，expireAt 为零值表示不过期
+// 只查询副本的缓存，不会触发副本加载数据；副本未实现 PresentGetter 时跳过
+func (g *Group) repairReplicas(picker ReplicaPicker, primary Peer, key string, value []byte, expireAt time.Time) {
+	syncCtx := context.WithValue(context.Background(), fromPeerKey, true)
+	if !expireAt.IsZero() {
+		if !time.Now().Before(expireAt) {
+			return
+		}
+		syncCtx = withExpireAt(syncCtx, expireAt)
+	}
+
+	peers, _ := picker.PickPeers(key, g.replicas)
+	for _, peer := range peers {
+		if peer == primary {
+			continue
+		}
+		pg, ok := peer.(PresentGetter)
+		if !ok {
+			continue
+		}
+
+		current, ok, err := pg.GetIfPresent(g.name, key)
+		if err == nil && ok && bytes.Equal(current, value) {
+			continue
+		}
+
+		if err := peer.Set(syncCtx, g.name, key, value); err != nil {
+			logrus.Warnf("[G-Cache] failed to repair replica for key %s: %v", key, err)
+			continue
+		}
+		atomic.AddInt64(&g.stats.readRepairs, 1)
+	}
+}
+
+// WithHedgedReads 开启对冲读取：先向主节点读取，delay 内未返回时同时向第二个副本读取，
+// 使用最先成功的响应并取消较慢的请求；需要 PeerPicker 实现 ReplicaPicker，delay <= 0 时不开启
+// 当前节点是副本之一或远程副本不足两个时按原有方式读取；节点不响应 ctx 取消时较慢的响应被丢弃
+func WithHedgedReads(delay time.Duration) GroupOption {
+	return func(g *Group) {
+		if delay > 0 {
+			g.hedgeDelay = delay
+		}
+	}
+}
+
+// hedgedGet 对冲读取键的前两个副本，hedged 为 false 表示无法对冲，由调用方按原有方式读取
+// expireAt 为返回值所在副本的过期时间，零值表示不过期或未知
+func (g *Group) hedgedGet(ctx context.Context, key string) (value ByteView, expireAt time.Time, hedged bool, err error) {
+	if g.hedgeDelay <= 0 {
+		return ByteView{}, time.Time{}, false, nil
+	}
+	picker, ok := g.peers.(ReplicaPicker)
+	if !ok {
+		return ByteView{}, time.Time{}, false, nil
+	}
+	peers, self := picker.PickPeers(key, 2)
+	if self || len(peers) < 2 {
+		return ByteView{}, time.Time{}, false, nil
+	}
+
+	// 返回时取消仍在进行的请求
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		value    ByteView
+		expireAt time.Time
+		err      error
+	}
+	// 结果通道带缓冲，提前返回后较慢的请求不会阻塞
+	results := make(chan result, len(peers))
+	pending := 0
+	launch := func(peer Peer) {
+		pending++
+		go func() {
+			value, expireAt, err := g.getFromPeer(ctx, peer, key)
+			results <- result{value, expireAt, err}
+		}()
+	}
+	launch(peers[0])
+
+	timer := time.NewTimer(g.hedgeDelay)
+	defer timer.Stop()
+	hedge := timer.C
+
+	var errs []error
+	for {
+		select {
+		case <-hedge:
+			hedge = nil
+			atomic.AddInt64(&g.stats.hedgedReads, 1)
+			launch(peers[1])
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.value, r.expireAt, true, nil
+			}
+			errs = append(errs, r.err)
+			// 主节点在延迟内失败时立即读取第二个副本
+			if hedge != nil {
+				hedge = nil
+				atomic.AddInt64(&g.stats.hedgedReads, 1)
+				launch(peers[1])
+				continue
+			}
+			if pending == 0 {
+				return ByteView{}, time.Time{}, true, errors.Join(errs...)
+			}
+		case <-ctx.Done():
+			return ByteView{}, time.Time{}, true, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,457 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试写入法定数量
+func TestGroupWriteQuorum(t *testing.T) {
+	tests := []struct {
+		name    string
+		quorum  int
+		failing []string // 写入失败的副本
+		wantErr bool
+	}{
+		{"全部成功", 3, nil, false},
+		{"达到法定数量", 2, []string{"C"}, false},
+		{"恰好达到法定数量", 1, []string{"B", "C"}, false},
+		{"低于法定数量", 2, []string{"B", "C"}, true},
+		{"全部失败", 1, []string{"A", "B", "C"}, true},
+		{"副本数不足", 4, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peers := map[string]*fakePeer{"A": newFakePeer("A"), "B": newFakePeer("B"), "C": newFakePeer("C")}
+			for _, name := range tt.failing {
+				peers[name].err = errors.New("peer unavailable")
+			}
+			picker := &fakePicker{
+				self:     "self",
+				peers:    peers,
+				owner:    ownerByPrefix,
+				replicas: []string{"A", "B", "C"},
+			}
+
+			g := newTestGroup(t, nil, WithReplicas(3), WithWriteQuorum(tt.quorum))
+			g.RegisterPeers(picker)
+
+			err := g.Set(context.Background(), "key", []byte("value"))
+			if tt.wantErr {
+				if !errors.Is(err, ErrWriteQuorum) {
+					t.Fatalf("Expected ErrWriteQuorum, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected quorum write to succeed, got %v", err)
+			}
+		})
+	}
+}
+
+// gatedSetPeer 写入阻塞到 release 关闭或 ctx 取消的节点
+type gatedSetPeer struct {
+	*fakePeer
+	release chan struct{}
+}
+
+func (p *gatedSetPeer) Set(ctx context.Context, group, key string, value []byte) error {
+	select {
+	case <-p.release:
+		return p.fakePeer.Set(ctx, group, key, value)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 测试达到法定数量后调用方取消 ctx，剩余的副本写入仍在后台完成
+func TestGroupWriteQuorumBackgroundWrites(t *testing.T) {
+	fast := newFakePeer("A")
+	slow := &gatedSetPeer{fakePeer: newFakePeer("B"), release: make(chan struct{})}
+
+	g := newTestGroup(t, nil, WithReplicas(2), WithWriteQuorum(1))
+	g.RegisterPeers(&hedgePicker{peers: []Peer{fast, slow}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := g.Set(ctx, "key", []byte("value")); err != nil {
+		t.Fatalf("Expected quorum write to succeed, got %v", err)
+	}
+	cancel()
+	close(slow.release)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		slow.mu.Lock()
+		got := string(slow.data["key"])
+		slow.mu.Unlock()
+		if got == "value" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected background replica write to complete after cancel, got %q", got)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// 测试当前节点作为副本时本地写入计为一次确认
+func TestGroupWriteQuorumSelfReplica(t *testing.T) {
+	peerA := newFakePeer("A")
+	peerA.err = errors.New("peer unavailable")
+	picker := &fakePicker{
+		self:     "self",
+		peers:    map[string]*fakePeer{"A": peerA},
+		owner:    ownerByPrefix,
+		replicas: []string{"self", "A"},
+	}
+
+	g := newTestGroup(t, nil, WithReplicas(2), WithWriteQuorum(1))
+	g.RegisterPeers(picker)
+
+	if err := g.Set(context.Background(), "key", []byte("value")); err != nil {
+		t.Fatalf("Expected local replica to satisfy quorum of 1, got %v", err)
+	}
+
+	g2 := NewGroup(t.Name()+"-2", 1<<20, g.getter, WithReplicas(2), WithWriteQuorum(2))
+	t.Cleanup(func() { g2.Close() })
+	g2.RegisterPeers(picker)
+	if err := g2.Set(context.Background(), "key", []byte("value")); !errors.Is(err, ErrWriteQuorum) {
+		t.Fatalf("Expected ErrWriteQuorum with failing remote replica, got %v", err)
+	}
+
+	// 来自其他节点的写入不再转发
+	peerA.mu.Lock()
+	peerA.err = nil
+	peerA.mu.Unlock()
+	ctx := context.WithValue(context.Background(), fromPeerKey, true)
+	if err := g2.Set(ctx, "other", []byte("value")); err != nil {
+		t.Fatalf("Expected peer request to skip quorum write, got %v", err)
+	}
+	peerA.mu.Lock()
+	defer peerA.mu.Unlock()
+	if _, ok := peerA.data["other"]; ok {
+		t.Errorf("Expected peer request not to be forwarded")
+	}
+}
+
+// 测试读修复将主节点的值写入过期副本
+func TestGroupReadRepair(t *testing.T) {
+	tests := []struct {
+		name  string
+		stale map[string][]byte // B 上的初始数据
+	}{
+		{"副本缺失", map[string][]byte{}},
+		{"副本过期", map[string][]byte{"a1": []byte("old")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peerA, peerB := newFakePeer("A"), newFakePeer("B")
+			peerA.data["a1"] = []byte("new")
+			peerB.data = tt.stale
+			picker := &fakePicker{
+				self:     "self",
+				peers:    map[string]*fakePeer{"A": peerA, "B": peerB},
+				owner:    ownerByPrefix,
+				replicas: []string{"A", "B"},
+			}
+
+			g := newTestGroup(t, nil, WithReplicas(2), WithReadRepair())
+			g.RegisterPeers(picker)
+
+			view, err := g.Get(context.Background(), "a1")
+			if err != nil || view.String() != "new" {
+				t.Fatalf("Expected value from primary, got %q, %v", view.String(), err)
+			}
+
+			// 修复是异步的
+			deadline := time.Now().Add(time.Second)
+			for {
+				peerB.mu.Lock()
+				got := string(peerB.data["a1"])
+				peerB.mu.Unlock()
+				if got == "new" {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("Expected stale replica to be repaired, got %q", got)
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		})
+	}
+}
+
+// 测试读修复按主节点副本的过期时间写入，不会延长修复后副本的生命周期
+func TestGroupReadRepairExpiry(t *testing.T) {
+	peerA, peerB := newFakePeer("A"), newFakePeer("B")
+	peerA.data["a1"] = []byte("new")
+	peerA.ttl = time.Minute
+	picker := &fakePicker{
+		self:     "self",
+		peers:    map[string]*fakePeer{"A": peerA, "B": peerB},
+		owner:    ownerByPrefix,
+		replicas: []string{"A", "B"},
+	}
+
+	g := newTestGroup(t, nil, WithReplicas(2), WithReadRepair())
+	g.RegisterPeers(picker)
+
+	start := time.Now()
+	if _, err := g.Get(context.Background(), "a1"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	g.syncs.wait()
+
+	peerB.mu.Lock()
+	defer peerB.mu.Unlock()
+	if string(peerB.data["a1"]) != "new" {
+		t.Fatalf("Expected replica to be repaired, got %q", peerB.data["a1"])
+	}
+	if got, ok := peerB.expires["a1"]; !ok || !closeTimes(got, start.Add(time.Minute)) {
+		t.Fatalf("Expected repaired replica to expire with the primary, got %v %v", got, ok)
+	}
+}
+
+// 测试副本一致或未开启读修复时不写入
+func TestGroupReadRepairNoop(t *testing.T) {
+	for _, repair := range []bool{true, false} {
+		peerA, peerB := newFakePeer("A"), newFakePeer("B")
+		peerA.data["a1"] = []byte("value")
+		if repair {
+			peerB.data["a1"] = []byte("value")
+		}
+		picker := &fakePicker{
+			self:     "self",
+			peers:    map[string]*fakePeer{"A": peerA, "B": peerB},
+			owner:    ownerByPrefix,
+			replicas: []string{"A", "B"},
+		}
+
+		opts := []GroupOption{WithReplicas(2)}
+		if repair {
+			opts = append(opts, WithReadRepair())
+		}
+		g := NewGroup(fmt.Sprintf("%s-%v", t.Name(), repair), 1<<20, GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+			return nil, errors.New("no loader")
+		}), opts...)
+		g.RegisterPeers(picker)
+
+		if _, err := g.Get(context.Background(), "a1"); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+		g.Close()
+
+		if got := g.Stats()["read_repairs"].(int64); got != 0 {
+			t.Errorf("Expected no repairs (repair=%v), got %d", repair, got)
+		}
+		peerB.mu.Lock()
+		if !repair && len(peerB.data) != 0 {
+			t.Errorf("Expected no repair when disabled, got %v", peerB.data)
+		}
+		peerB.mu.Unlock()
+	}
+}
+
+// weightedPicker 为 fakePicker 的副本附加权重
+type weightedPicker struct {
+	*fakePicker
+	weights map[string]int
+}
+
+func (p *weightedPicker) PickWeightedPeers(key string, n int) ([]Peer, []int, bool) {
+	peers, self := p.PickPeers(key, n)
+	weights := make([]int, len(peers))
+	for i, peer := range peers {
+		weights[i] = p.weights[peer.(*fakePeer).name]
+	}
+	return peers, weights, self
+}
+
+// 测试读取按策略分布到各副本
+func TestGroupReadStrategy(t *testing.T) {
+	const reads = 3000
+
+	tests := []struct {
+		name     string
+		strategy ReadStrategy
+		want     map[string]float64 // 各副本期望的读取占比
+	}{
+		{"Primary", ReadPrimary, map[string]float64{"A": 1, "B": 0, "C": 0}},
+		{"RoundRobin", ReadRoundRobin, map[string]float64{"A": 1.0 / 3, "B": 1.0 / 3, "C": 1.0 / 3}},
+		{"WeightedRandom", ReadWeightedRandom, map[string]float64{"A": 0.5, "B": 0.25, "C": 0.25}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peers := map[string]*fakePeer{"A": newFakePeer("A"), "B": newFakePeer("B"), "C": newFakePeer("C")}
+			for i := range reads {
+				for _, p := range peers {
+					p.data[fmt.Sprintf("a%d", i)] = []byte("value")
+				}
+			}
+			picker := &weightedPicker{
+				fakePicker: &fakePicker{
+					self:     "self",
+					peers:    peers,
+					owner:    ownerByPrefix,
+					replicas: []string{"A", "B", "C"},
+				},
+				weights: map[string]int{"A": 100, "B": 50, "C": 50},
+			}
+
+			g := newTestGroup(t, nil, WithReplicas(3), WithReadStrategy(tt.strategy))
+			g.RegisterPeers(picker)
+
+			for i := range reads {
+				if _, err := g.Get(context.Background(), fmt.Sprintf("a%d", i)); err != nil {
+					t.Fatalf("Get failed: %v", err)
+				}
+			}
+
+			for name, ratio := range tt.want {
+				got := float64(peers[name].gets) / reads
+				if got < ratio-0.05 || got > ratio+0.05 {
+					t.Errorf("Expected %s to serve %.2f of reads, got %.2f", name, ratio, got)
+				}
+			}
+		})
+	}
+}
+
+// 测试当前节点是副本时按主节点读取
+func TestGroupReadStrategySelfReplica(t *testing.T) {
+	peerA, peerB := newFakePeer("A"), newFakePeer("B")
+	peerA.data["a1"] = []byte("value")
+	picker := &fakePicker{
+		self:     "self",
+		peers:    map[string]*fakePeer{"A": peerA, "B": peerB},
+		owner:    ownerByPrefix,
+		replicas: []string{"A", "self", "B"},
+	}
+
+	g := newTestGroup(t, nil, WithReplicas(3), WithReadStrategy(ReadRoundRobin))
+	g.RegisterPeers(picker)
+
+	for range 2 {
+		g.mainCache.Delete("a1")
+		if _, err := g.Get(context.Background(), "a1"); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+	}
+	if peerA.gets != 2 || peerB.gets != 0 {
+		t.Errorf("Expected all reads to go to the primary, got A=%d B=%d", peerA.gets, peerB.gets)
+	}
+}
+
+// latencyPeer 读取前等待一段时间的节点，记录读取是否被取消
+type latencyPeer struct {
+	*fakePeer
+	delay     time.Duration
+	calls     int32
+	cancelled chan struct{}
+}
+
+func newLatencyPeer(name string, delay time.Duration) *latencyPeer {
+	p := &latencyPeer{fakePeer: newFakePeer(name), delay: delay, cancelled: make(chan struct{})}
+	p.data["key"] = []byte("value-" + name)
+	return p
+}
+
+func (p *latencyPeer) GetWithTTL(ctx context.Context, group, key string) ([]byte, time.Duration, error) {
+	atomic.AddInt32(&p.calls, 1)
+	select {
+	case <-time.After(p.delay):
+		value, err := p.fakePeer.Get(group, key)
+		return value, 0, err
+	case <-ctx.Done():
+		close(p.cancelled)
+		return nil, 0, ctx.Err()
+	}
+}
+
+// hedgePicker 按顺序返回固定副本的节点选择器，第一个为主节点
+type hedgePicker struct {
+	peers []Peer
+}
+
+func (p *hedgePicker) PickPeer(key string) (Peer, bool, bool) {
+	return p.peers[0], true, false
+}
+
+func (p *hedgePicker) PickPeers(key string, n int) ([]Peer, bool) {
+	return p.peers[:min(n, len(p.peers))], false
+}
+
+func (p *hedgePicker) Close() error {
+	return nil
+}
+
+// 测试对冲读取使用较快副本的响应并取消较慢的请求
+func TestGroupHedgedReads(t *testing.T) {
+	slow := newLatencyPeer("A", time.Minute)
+	fast := newLatencyPeer("B", 10*time.Millisecond)
+
+	g := newTestGroup(t, nil, WithHedgedReads(20*time.Millisecond))
+	g.RegisterPeers(&hedgePicker{peers: []Peer{slow, fast}})
+
+	start := time.Now()
+	view, err := g.Get(context.Background(), "key")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got := view.String(); got != "value-B" {
+		t.Fatalf("Expected fast replica to win, got %q", got)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Expected hedged read to return early, took %v", elapsed)
+	}
+
+	select {
+	case <-slow.cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected slow request to be cancelled")
+	}
+	if n := g.Stats()["hedged_reads"].(int64); n != 1 {
+		t.Fatalf("Expected 1 hedged read, got %d", n)
+	}
+}
+
+// 测试主节点在延迟内返回时不向第二个副本发起读取
+func TestGroupHedgedReadsPrimaryFast(t *testing.T) {
+	primary := newLatencyPeer("A", 0)
+	secondary := newLatencyPeer("B", 0)
+
+	g := newTestGroup(t, nil, WithHedgedReads(time.Second))
+	g.RegisterPeers(&hedgePicker{peers: []Peer{primary, secondary}})
+
+	view, err := g.Get(context.Background(), "key")
+	if err != nil || view.String() != "value-A" {
+		t.Fatalf("Expected primary value, got %q %v", view.String(), err)
+	}
+	if n := atomic.LoadInt32(&secondary.calls); n != 0 {
+		t.Fatalf("Expected no request to secondary, got %d", n)
+	}
+}
+
+// 测试主节点失败时立即读取第二个副本
+func TestGroupHedgedReadsPrimaryError(t *testing.T) {
+	primary := newLatencyPeer("A", 0)
+	primary.err = errors.New("peer unavailable")
+	secondary := newLatencyPeer("B", 0)
+
+	g := newTestGroup(t, nil, WithHedgedReads(time.Minute))
+	g.RegisterPeers(&hedgePicker{peers: []Peer{primary, secondary}})
+
+	view, err := g.Get(context.Background(), "key")
+	if err != nil || view.String() != "value-B" {
+		t.Fatalf("Expected secondary value after primary failure, got %q %v", view.String(), err)
+	}
+}
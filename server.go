@@ -13,9 +13,12 @@ import (
 	"github.com/sirupsen/logrus"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // Server 定义缓存服务器
@@ -161,14 +164,77 @@ func (s *Server) Get(ctx context.Context, req *pb.Request) (*pb.ResponseForGet,
 		return nil, fmt.Errorf("group %s not found", req.Group)
 	}
 
-	view, err := group.Get(ctx, req.Key)
+	key := requestKey(ctx, req.Key)
+	view, err := group.Get(ctx, key)
 	if err != nil {
 		return nil, err
 	}
 
+	return &pb.ResponseForGet{Value: view.ByteSLice(), Ttl: remainingTTL(group, key)}, nil
+}
+
+// GetIfPresent 实现Cache服务的GetIfPresent方法，只查询缓存不加载数据，未缓存返回 NotFound
+func (s *Server) GetIfPresent(ctx context.Context, req *pb.Request) (*pb.ResponseForGet, error) {
+	group := GetGroup(req.Group)
+	if group == nil {
+		return nil, fmt.Errorf("group %s not found", req.Group)
+	}
+
+	key := requestKey(ctx, req.Key)
+	view, ok := group.GetIfPresent(ctx, key)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "key %q not cached", key)
+	}
+
 	return &pb.ResponseForGet{Value: view.ByteSLice()}, nil
 }
 
+// expiredTTL 响应中表示值在读取后、返回前已过期的 ttl，调用方不应缓存该值
+const expiredTTL = -1
+
+// remainingTTL 返回键在本地缓存中的剩余过期时间（纳秒），用于响应的 ttl 字段
+// 永不过期或读取后已被移出缓存时返回 0，调用方按自己的默认过期时间缓存；已过期但尚未移出时返回 expiredTTL
+func remainingTTL(group *Group, key string) int64 {
+	expireAt, ok := group.mainCache.expiration(key)
+	if !ok {
+		return 0
+	}
+	ttl := time.Until(expireAt)
+	if ttl <= 0 {
+		return expiredTTL
+	}
+	return int64(ttl)
+}
+
+// keyHeader 请求元数据中以二进制传输的缓存键，批量请求按顺序包含所有键
+// protobuf 的 string 字段只能编码合法的 UTF-8，其他字节序列的键通过该元数据传输
+const keyHeader = "gcache-key-bin"
+
+// requestKey 返回请求的缓存键，请求元数据中有二进制传输的键时以其为准
+func requestKey(ctx context.Context, key string) string {
+	if keys := binaryKeys(ctx); len(keys) > 0 {
+		return keys[0]
+	}
+	return key
+}
+
+// requestKeys 返回批量请求的缓存键，请求元数据中有二进制传输的键时以其为准
+func requestKeys(ctx context.Context, keys []string) []string {
+	if binary := binaryKeys(ctx); len(binary) > 0 {
+		return binary
+	}
+	return keys
+}
+
+// binaryKeys 返回请求元数据中以二进制传输的缓存键
+func binaryKeys(ctx context.Context) []string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+	return md.Get(keyHeader)
+}
+
 // Set 实现Cache服务的Set方法
 func (s *Server) Set(ctx context.Context, req *pb.Request) (*pb.ResponseForGet, error) {
 	group := GetGroup(req.Group)
@@ -176,13 +242,18 @@ func (s *Server) Set(ctx context.Context, req *pb.Request) (*pb.ResponseForGet,
 		return nil, fmt.Errorf("group %s not found", req.Group)
 	}
 
+	key := requestKey(ctx, req.Key)
+
 	// 从 context 中获取标记，如果没有则创建新的 context
 	fromPeer := ctx.Value("from_peer")
 	if fromPeer == nil {
 		ctx = context.WithValue(ctx, fromPeerKey, true)
 	}
+	if req.Ttl > 0 {
+		ctx = withExpireAt(ctx, time.Now().Add(time.Duration(req.Ttl)))
+	}
 
-	if err := group.Set(ctx, req.Key, req.Value); err != nil {
+	if err := group.Set(ctx, key, req.Value); err != nil {
 		return nil, err
 	}
 
@@ -196,10 +267,51 @@ func (s *Server) Delete(ctx context.Context, req *pb.Request) (*pb.ResponseForDe
 		return nil, fmt.Errorf("group %s not found", req.Group)
 	}
 
-	err := group.Delete(ctx, req.Key)
+	err := group.Delete(ctx, requestKey(ctx, req.Key))
 	return &pb.ResponseForDelete{Value: err == nil}, err
 }
 
+// SetNX 实现Cache服务的SetNX方法，在本节点的锁表中获取分布式锁，不同步到其他节点
+func (s *Server) SetNX(ctx context.Context, req *pb.Request) (*pb.ResponseForSetNX, error) {
+	group := GetGroup(req.Group)
+	if group == nil {
+		return nil, fmt.Errorf("group %s not found", req.Group)
+	}
+	if req.Ttl <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "lock ttl must be positive, got %d", req.Ttl)
+	}
+
+	acquired, err := group.setNX(requestKey(ctx, req.Key), req.Value, time.Duration(req.Ttl))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ResponseForSetNX{Value: acquired}, nil
+}
+
+// CompareAndDelete 实现Cache服务的CompareAndDelete方法，锁的令牌仍等于请求中的值时释放
+func (s *Server) CompareAndDelete(ctx context.Context, req *pb.Request) (*pb.ResponseForDelete, error) {
+	group := GetGroup(req.Group)
+	if group == nil {
+		return nil, fmt.Errorf("group %s not found", req.Group)
+	}
+
+	return &pb.ResponseForDelete{Value: group.deleteIfValue(requestKey(ctx, req.Key), req.Value)}, nil
+}
+
+// BatchDelete 实现Cache服务的BatchDelete方法
+func (s *Server) BatchDelete(ctx context.Context, req *pb.BatchRequest) (*pb.ResponseForBatchDelete, error) {
+	group := GetGroup(req.Group)
+	if group == nil {
+		return nil, fmt.Errorf("group %s not found", req.Group)
+	}
+
+	// 来自其他节点的批量删除只作用于本地
+	ctx = context.WithValue(ctx, fromPeerKey, true)
+
+	count, err := group.MDelete(ctx, requestKeys(ctx, req.Keys))
+	return &pb.ResponseForBatchDelete{Count: int64(count)}, err
+}
+
 // loadTLSCredentials 加载TLS证书
 func loadTLSCredentials(certFile, keyFile string) (credentials.TransportCredentials, error) {
 	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
@@ -1,38 +1,112 @@
 package singleflight
 
-import "sync"
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrWaitTimeout 等待正在进行的请求超时错误
+var ErrWaitTimeout = errors.New("singleflight: timed out waiting for in-flight call")
+
+// ErrPanicked 发起请求的调用中 f panic，等待者得到的错误
+var ErrPanicked = errors.New("singleflight: in-flight call panicked")
 
 // call 正在进行或已结束的请求
 type call struct {
-	wg sync.WaitGroup
-	val any
-	err error
+	done chan struct{} // 请求结束时关闭
+	val  any
+	err  error
 }
 
 // Group 管理所有的请求
 type Group struct {
 	m sync.Map // 并发安全的映射
+	// WaitTimeout 加入正在进行的请求后最多等待的时间，超时返回 ErrWaitTimeout，0 表示一直等待
+	// 只影响超时的等待者，发起请求的调用和其他等待者仍然得到 f 的结果
+	WaitTimeout time.Duration
+	// MaxInFlight 正在进行的请求数超过此值时记录一次警告日志，用于发现持续增长的请求，0 表示不检查
+	MaxInFlight int
+
+	inflight int64 // 原子变量，正在进行的请求数
+	warned   int32 // 原子变量，超过 MaxInFlight 后是否已经记录过警告，回落后重置
 }
 
 // Do 针对相同的key，保证多次调用Do()，都只会调用一次f()
 func (g *Group) Do(key string, f func() (any, error)) (any, error) {
-	//检查是否已经存在该键对应的请求
-	if existing, ok := g.m.Load(key); ok {
-		c := existing.(*call) // 转换为 *call 类型
-		c.wg.Wait()
-		return c.val, c.err
+	// 检查并登记在同一次原子操作中完成，避免两个调用同时发现没有请求而各自调用 f()
+	c := &call{done: make(chan struct{})}
+	if existing, loaded := g.m.LoadOrStore(key, c); loaded {
+		return g.wait(existing.(*call))
 	}
+	g.started()
 
-	// 不存在正在进行的请求，创建新的请求
-	c := &call{}
-	c.wg.Add(1)
-	g.m.Store(key, c)
+	// f panic 时也要结束并删除请求，避免等待者永远阻塞、映射中残留请求
+	returned := false
+	defer func() {
+		if !returned {
+			c.err = ErrPanicked
+		}
+		close(c.done)
+		g.m.Delete(key)
+		g.finished()
+	}()
 
 	// 调用函数
 	c.val, c.err = f()
-	c.wg.Done()
+	returned = true
+	return c.val, c.err
+}
 
-	g.m.Delete(key)
+// started 记录新的请求，超过 MaxInFlight 时记录警告
+func (g *Group) started() {
+	n := atomic.AddInt64(&g.inflight, 1)
+	if g.MaxInFlight > 0 && n > int64(g.MaxInFlight) && atomic.CompareAndSwapInt32(&g.warned, 0, 1) {
+		logrus.Warnf("[singleflight] %d calls in flight exceeds limit %d, map holds %d keys", n, g.MaxInFlight, g.Len())
+	}
+}
 
-	return c.val, c.err
+// finished 记录请求结束，回落到 MaxInFlight 以内后允许再次警告
+func (g *Group) finished() {
+	n := atomic.AddInt64(&g.inflight, -1)
+	if g.MaxInFlight > 0 && n <= int64(g.MaxInFlight) {
+		atomic.StoreInt32(&g.warned, 0)
+	}
+}
+
+// InFlight 返回正在进行的请求数
+func (g *Group) InFlight() int64 {
+	return atomic.LoadInt64(&g.inflight)
+}
+
+// Len 遍历映射返回其中的请求数，遍历期间开始或结束的请求可能被计入也可能不计入
+// 所有请求结束后仍不为 0 说明有请求没有被删除
+func (g *Group) Len() int {
+	n := 0
+	g.m.Range(func(key, value any) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// wait 等待正在进行的请求结束，设置了 WaitTimeout 时超时返回 ErrWaitTimeout
+func (g *Group) wait(c *call) (any, error) {
+	if g.WaitTimeout <= 0 {
+		<-c.done
+		return c.val, c.err
+	}
+
+	timer := time.NewTimer(g.WaitTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-timer.C:
+		return nil, ErrWaitTimeout
+	}
 }
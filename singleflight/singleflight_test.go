@@ -0,0 +1,178 @@
+package singleflight
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试等待超时的跟随者返回 ErrWaitTimeout，发起请求的调用仍然完成
+func TestDoWaitTimeout(t *testing.T) {
+	g := &Group{WaitTimeout: 20 * time.Millisecond}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var calls int32
+
+	leader := make(chan any, 1)
+	go func() {
+		v, _ := g.Do("key", func() (any, error) {
+			atomic.AddInt32(&calls, 1)
+			close(started)
+			<-release
+			return "value", nil
+		})
+		leader <- v
+	}()
+	<-started
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := g.Do("key", func() (any, error) {
+				t.Errorf("Expected follower not to call f")
+				return nil, nil
+			}); err != ErrWaitTimeout {
+				t.Errorf("Expected ErrWaitTimeout, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	close(release)
+	if v := <-leader; v != "value" {
+		t.Fatalf("Expected leader to complete with value, got %v", v)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("Expected f to be called once, got %d", n)
+	}
+}
+
+// 测试跟随者在超时前得到发起请求的结果
+func TestDoWaitTimeoutNotReached(t *testing.T) {
+	g := &Group{WaitTimeout: time.Second}
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	go g.Do("key", func() (any, error) {
+		close(started)
+		<-release
+		return "value", nil
+	})
+	<-started
+
+	result := make(chan any, 1)
+	go func() {
+		v, err := g.Do("key", func() (any, error) { return "other", nil })
+		if err != nil {
+			t.Errorf("Expected follower to succeed, got %v", err)
+		}
+		result <- v
+	}()
+
+	// 等待跟随者加入正在进行的请求
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	if v := <-result; v != "value" {
+		t.Fatalf("Expected follower to share the leader's value, got %v", v)
+	}
+}
+
+// 测试大量请求结束后映射和正在进行的请求数都回到 0
+func TestDoLenReturnsToZero(t *testing.T) {
+	g := &Group{}
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range 1000 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.Do(strconv.Itoa(i%50), func() (any, error) {
+				<-release
+				return i, nil
+			})
+		}()
+	}
+
+	// 等待所有键的请求开始
+	deadline := time.Now().Add(time.Second)
+	for g.InFlight() < 50 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n, l := g.InFlight(), g.Len(); n != 50 || l != 50 {
+		t.Fatalf("Expected 50 calls in flight, got InFlight %d Len %d", n, l)
+	}
+
+	close(release)
+	wg.Wait()
+	if n, l := g.InFlight(), g.Len(); n != 0 || l != 0 {
+		t.Fatalf("Expected no calls left, got InFlight %d Len %d", n, l)
+	}
+}
+
+// 测试 f panic 时请求被删除，等待者得到 ErrPanicked
+func TestDoPanic(t *testing.T) {
+	g := &Group{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	go func() {
+		defer func() { recover() }()
+		g.Do("key", func() (any, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+
+	follower := make(chan error, 1)
+	go func() {
+		_, err := g.Do("key", func() (any, error) { return nil, nil })
+		follower <- err
+	}()
+	// 等待跟随者加入正在进行的请求
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	if err := <-follower; err != ErrPanicked {
+		t.Fatalf("Expected ErrPanicked for the follower, got %v", err)
+	}
+	if n, l := g.InFlight(), g.Len(); n != 0 || l != 0 {
+		t.Fatalf("Expected panicked call to be removed, got InFlight %d Len %d", n, l)
+	}
+}
+
+// 测试超过 MaxInFlight 时只警告一次，回落后可以再次警告
+func TestDoMaxInFlight(t *testing.T) {
+	g := &Group{MaxInFlight: 2}
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.Do(strconv.Itoa(i), func() (any, error) {
+				<-release
+				return nil, nil
+			})
+		}()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for g.InFlight() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if atomic.LoadInt32(&g.warned) != 1 {
+		t.Fatalf("Expected a warning after exceeding MaxInFlight")
+	}
+
+	close(release)
+	wg.Wait()
+	if atomic.LoadInt32(&g.warned) != 0 {
+		t.Fatalf("Expected warning state to reset once calls finish")
+	}
+}
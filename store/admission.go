@@ -0,0 +1,82 @@
+package store
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNotAdmitted 新键被准入策略拒绝
+var ErrNotAdmitted = errors.New("value rejected by admission policy")
+
+// AdmissionPolicy 准入策略，在缓存需要淘汰数据才能容纳新键时被调用
+// cost 为新键占用的字节数，返回 false 表示拒绝写入
+type AdmissionPolicy interface {
+	ShouldAdmit(key string, cost int64) bool
+}
+
+// accessRecorder 可选接口，准入策略实现后可以感知缓存命中
+type accessRecorder interface {
+	Record(key string)
+}
+
+// AlwaysAdmit 默认准入策略，接受所有写入
+type AlwaysAdmit struct{}
+
+// ShouldAdmit 实现 AdmissionPolicy 接口
+func (AlwaysAdmit) ShouldAdmit(key string, cost int64) bool {
+	return true
+}
+
+// FrequencyAdmission 基于访问频率的准入策略
+// 使用带衰减的 count-min sketch 估计键的访问频率，拒绝只出现过一次的键，防止扫描污染缓存
+type FrequencyAdmission struct {
+	mu        sync.Mutex
+	sketch    *CountMinSketch
+	threshold uint32 // 准入所需的最小频率
+}
+
+// NewFrequencyAdmission 创建基于频率的准入策略
+// width 为每行计数器个数（向上取整为 2 的幂），threshold 为准入所需的最小访问次数
+func NewFrequencyAdmission(width int, threshold int) *FrequencyAdmission {
+	if width <= 0 {
+		width = 1024
+	}
+	if threshold <= 0 {
+		threshold = 2
+	}
+	if threshold > 255 {
+		threshold = 255
+	}
+
+	sketch := NewCountMinSketch(width, 0)
+	sketch.resetAt = sketch.Width() * 10
+	return &FrequencyAdmission{
+		sketch:    sketch,
+		threshold: uint32(threshold),
+	}
+}
+
+// ShouldAdmit 实现 AdmissionPolicy 接口，记录一次访问并判断频率是否达到阈值
+func (f *FrequencyAdmission) ShouldAdmit(key string, cost int64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sketch.Increment(key)
+	return f.sketch.Estimate(key) >= f.threshold
+}
+
+// Record 记录一次访问
+func (f *FrequencyAdmission) Record(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sketch.Increment(key)
+}
+
+// Estimate 返回键的估计访问频率
+func (f *FrequencyAdmission) Estimate(key string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return int(f.sketch.Estimate(key))
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+)
+
+// 测试频率准入策略的计数与衰减
+func TestFrequencyAdmission(t *testing.T) {
+	f := NewFrequencyAdmission(1024, 2)
+
+	// 第一次出现的键不满足阈值
+	if f.ShouldAdmit("key", 1) {
+		t.Fatalf("Expected first-seen key to be rejected")
+	}
+
+	// 第二次出现的键满足阈值
+	if !f.ShouldAdmit("key", 1) {
+		t.Fatalf("Expected key seen twice to be admitted")
+	}
+
+	f.Record("hot")
+	f.Record("hot")
+	f.Record("hot")
+	if got := f.Estimate("hot"); got != 3 {
+		t.Fatalf("Expected estimate 3 for hot key, got %d", got)
+	}
+
+	// 达到衰减次数后计数减半
+	f.sketch.additions = f.sketch.resetAt - 1
+	f.Record("filler")
+	if got := f.Estimate("hot"); got != 1 {
+		t.Fatalf("Expected estimate to decay to 1, got %d", got)
+	}
+}
+
+// 测试扫描负载下的热点保护
+func TestLRUAdmissionScanResistance(t *testing.T) {
+	hotKeys := make([]string, 8)
+	for i := range hotKeys {
+		hotKeys[i] = fmt.Sprintf("hot-%d", i)
+	}
+	// 每个热点键占用 len("hot-0") + len("value-0000") = 15 字节，容量可容纳 10 个热点键
+	maxBytes := int64(10 * 15)
+
+	run := func(policy AdmissionPolicy) (*lruCache, int) {
+		lru := newLRUCache(Options{MaxBytes: maxBytes, Admission: policy})
+
+		// 建立热点集合
+		for _, key := range hotKeys {
+			lru.Set(key, String("value-0000"))
+		}
+		for range 5 {
+			for _, key := range hotKeys {
+				lru.Get(key)
+			}
+		}
+
+		// 扫描大量只访问一次的键
+		rejected := 0
+		for i := range 1000 {
+			if err := lru.Set(fmt.Sprintf("scan-%04d", i), String("value-0000")); err == ErrNotAdmitted {
+				rejected++
+			}
+		}
+		return lru, rejected
+	}
+
+	// 未启用准入策略，热点被扫描冲刷
+	lru, rejected := run(nil)
+	if rejected != 0 {
+		t.Fatalf("Expected no rejections without a policy, got %d", rejected)
+	}
+	for _, key := range hotKeys {
+		if _, ok := lru.Get(key); ok {
+			t.Fatalf("Expected hot key %s to be evicted by the scan without a policy", key)
+		}
+	}
+
+	// 启用频率准入策略，热点集合保留
+	lru, rejected = run(NewFrequencyAdmission(1<<16, 2))
+	if rejected == 0 {
+		t.Fatalf("Expected the frequency policy to reject scan keys")
+	}
+	for _, key := range hotKeys {
+		if _, ok := lru.Get(key); !ok {
+			t.Fatalf("Expected hot key %s to survive the scan", key)
+		}
+	}
+	if lru.usedBytes > maxBytes {
+		t.Fatalf("Used bytes %d exceed max bytes %d", lru.usedBytes, maxBytes)
+	}
+}
@@ -0,0 +1,733 @@
+// Package boltstore 基于 bbolt 的磁盘缓存存储，适用于数据量超过内存的场景
+// 导入本包时注册 store.Bolt 缓存类型，依赖 bbolt 的代码只在导入本包时编译
+package boltstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lyy42995004/Cache-Go/store"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrPathRequired 未设置数据文件路径错误
+var ErrPathRequired = errors.New("path is required for bolt store")
+
+// bucketName 存放缓存项的 bucket
+var bucketName = []byte("cache")
+
+// headerSize 每条记录头部的长度：过期时间、写入时间、版本号各 8 字节
+const headerSize = 24
+
+func init() {
+	store.Register(store.Bolt, func(opts store.Options) (store.Store, error) {
+		s, err := Open(opts)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+}
+
+// Store 基于 bbolt 的磁盘缓存，过期时间与值一起持久化，重新打开文件后数据仍然有效
+// 不受 MaxBytes 限制，过期项在读取时或定期清理时删除并触发淘汰回调
+type Store struct {
+	db              *bolt.DB
+	codec           store.Codec
+	onEvicted       func(key string, value store.Value)
+	maxAge          time.Duration    // 最大存活时间
+	strictExpiry    bool             // 严格过期，Len 前同步清理过期项
+	now             func() time.Time // 时钟，默认为 time.Now，测试时可替换
+	cleanupInterval time.Duration
+	closeCh         chan struct{} // 用于优雅关闭协程
+	closeOnce       sync.Once
+	wg              sync.WaitGroup
+	loads           store.LoadGroup // 合并同一个键的并发加载
+	hits            int64           // 读取命中次数，原子操作
+	misses          int64           // 读取未命中次数，原子操作
+	expirations     int64           // 删除的过期项数，原子操作
+}
+
+// 编译时检查 Store 是否实现了 store.Store 接口
+var _ store.Store = (*Store)(nil)
+
+// record 磁盘上的一条缓存记录
+type record struct {
+	expireAt  int64 // 过期时间（纳秒），0 表示永不过期
+	createdAt int64 // 写入时间（纳秒）
+	version   uint64
+	payload   []byte // 编码后的值
+}
+
+// encode 将记录编码为磁盘格式
+func (r record) encode() []byte {
+	buf := make([]byte, headerSize+len(r.payload))
+	binary.BigEndian.PutUint64(buf[0:], uint64(r.expireAt))
+	binary.BigEndian.PutUint64(buf[8:], uint64(r.createdAt))
+	binary.BigEndian.PutUint64(buf[16:], r.version)
+	copy(buf[headerSize:], r.payload)
+	return buf
+}
+
+// decodeRecord 解析磁盘上的记录，拷贝值的字节，返回的记录在事务结束后仍然有效
+func decodeRecord(data []byte) (record, bool) {
+	if len(data) < headerSize {
+		return record{}, false
+	}
+	return record{
+		expireAt:  int64(binary.BigEndian.Uint64(data[0:])),
+		createdAt: int64(binary.BigEndian.Uint64(data[8:])),
+		version:   binary.BigEndian.Uint64(data[16:]),
+		payload:   bytes.Clone(data[headerSize:]),
+	}, true
+}
+
+// evicted 等待事务提交后触发淘汰回调的项
+type evicted struct {
+	key     string
+	payload []byte
+}
+
+// Open 打开或创建 opts.Path 指定的数据文件，文件被其他进程占用时等待 1 秒后返回错误
+func Open(opts store.Options) (*Store, error) {
+	if opts.Path == "" {
+		return nil, ErrPathRequired
+	}
+	if opts.Codec == nil {
+		return nil, store.ErrCodecRequired
+	}
+
+	db, err := bolt.Open(opts.Path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	cleanupInterval := opts.CleanupInterval
+	if cleanupInterval <= 0 {
+		cleanupInterval = time.Minute
+	}
+
+	s := &Store{
+		db:              db,
+		codec:           opts.Codec,
+		onEvicted:       opts.OnEvicted,
+		maxAge:          opts.MaxAge,
+		strictExpiry:    opts.StrictExpiry,
+		now:             time.Now,
+		cleanupInterval: cleanupInterval,
+		closeCh:         make(chan struct{}),
+	}
+
+	// 定期清理协程
+	s.wg.Add(1)
+	go s.cleanupLoop()
+
+	return s, nil
+}
+
+// Get 实现 store.Store 接口
+func (s *Store) Get(key string) (store.Value, bool) {
+	value, _, ok := s.GetWithVersion(key)
+	return value, ok
+}
+
+// GetWithVersion 实现 store.Store 接口，读取到过期项时同步删除
+func (s *Store) GetWithVersion(key string) (store.Value, uint64, bool) {
+	var rec record
+	var found bool
+	s.db.View(func(tx *bolt.Tx) error {
+		rec, found = decodeRecord(tx.Bucket(bucketName).Get([]byte(key)))
+		return nil
+	})
+	if !found {
+		s.lookup(false)
+		return nil, 0, false
+	}
+	if s.expired(rec, s.now()) {
+		s.lookup(false)
+		s.removeIfExpired(key)
+		return nil, 0, false
+	}
+
+	value, err := s.codec.Decode(rec.payload)
+	s.lookup(err == nil)
+	if err != nil {
+		return nil, 0, false
+	}
+	return value, rec.version, true
+}
+
+// Peek 实现 store.Store 接口，磁盘存储没有访问顺序，与 Get 的区别是不删除过期项
+func (s *Store) Peek(key string) (store.Value, bool) {
+	var rec record
+	var found bool
+	s.db.View(func(tx *bolt.Tx) error {
+		rec, found = decodeRecord(tx.Bucket(bucketName).Get([]byte(key)))
+		return nil
+	})
+	if !found || s.expired(rec, s.now()) {
+		return nil, false
+	}
+
+	value, err := s.codec.Decode(rec.payload)
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// Contains 实现 store.Store 接口，只读取记录头，不解码值也不删除过期项
+func (s *Store) Contains(key string) bool {
+	var rec record
+	var found bool
+	s.db.View(func(tx *bolt.Tx) error {
+		rec, found = decodeRecord(tx.Bucket(bucketName).Get([]byte(key)))
+		return nil
+	})
+	return found && !s.expired(rec, s.now())
+}
+
+// GetOrLoad 实现 store.Store 接口，加载期间不持有数据库事务
+func (s *Store) GetOrLoad(key string, loader store.LoadFunc) (store.Value, error) {
+	return s.loads.GetOrLoad(s, key, loader)
+}
+
+// Set 实现 store.Store 接口
+func (s *Store) Set(key string, value store.Value) error {
+	return s.SetWithExpiration(key, value, 0)
+}
+
+// SetWithExpiration 实现 store.Store 接口，expiration <= 0 或为 Forever 时永不过期
+func (s *Store) SetWithExpiration(key string, value store.Value, expiration time.Duration) error {
+	if value == nil {
+		s.Delete(key)
+		return nil
+	}
+
+	payload, err := s.codec.Encode(value)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return s.put(tx.Bucket(bucketName), key, payload, expiration)
+	})
+}
+
+// SetIfVersion 实现 store.Store 接口，value 为 nil 时删除
+func (s *Store) SetIfVersion(key string, value store.Value, expectedVersion uint64, expiration time.Duration) (bool, error) {
+	var payload []byte
+	if value != nil {
+		var err error
+		if payload, err = s.codec.Encode(value); err != nil {
+			return false, err
+		}
+	}
+
+	var removed []evicted
+	swapped := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		rec, ok := decodeRecord(b.Get([]byte(key)))
+		var current uint64
+		if ok && !s.expired(rec, s.now()) {
+			current = rec.version
+		}
+		if current != expectedVersion {
+			return nil
+		}
+
+		swapped = true
+		if value == nil {
+			if ok {
+				removed = append(removed, evicted{key, rec.payload})
+				return b.Delete([]byte(key))
+			}
+			return nil
+		}
+		return s.put(b, key, payload, expiration)
+	})
+	if err != nil {
+		return false, err
+	}
+	s.notify(removed)
+	return swapped, nil
+}
+
+// GetAndTouch 实现 store.Store 接口，命中时在同一个事务中将过期时间重置为 now + newTTL
+func (s *Store) GetAndTouch(key string, newTTL time.Duration) (store.Value, bool) {
+	var rec record
+	var found bool
+	var removed []evicted
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		var ok bool
+		if rec, ok = decodeRecord(b.Get([]byte(key))); !ok {
+			return nil
+		}
+		now := s.now()
+		if s.expired(rec, now) {
+			removed = append(removed, evicted{key, rec.payload})
+			return b.Delete([]byte(key))
+		}
+
+		found = true
+		rec.expireAt = expireAtAfter(now, newTTL)
+		return b.Put([]byte(key), rec.encode())
+	})
+	if err != nil {
+		s.lookup(false)
+		return nil, false
+	}
+	atomic.AddInt64(&s.expirations, int64(len(removed)))
+	s.notify(removed)
+	if !found {
+		s.lookup(false)
+		return nil, false
+	}
+
+	value, err := s.codec.Decode(rec.payload)
+	s.lookup(err == nil)
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// GetExpiration 获取缓存项过期时间，永不过期或不存在时返回 false
+func (s *Store) GetExpiration(key string) (time.Time, bool) {
+	var rec record
+	var found bool
+	s.db.View(func(tx *bolt.Tx) error {
+		rec, found = decodeRecord(tx.Bucket(bucketName).Get([]byte(key)))
+		return nil
+	})
+	if !found || rec.expireAt == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, rec.expireAt), true
+}
+
+// Delete 实现 store.Store 接口
+func (s *Store) Delete(key string) bool {
+	var removed []evicted
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		rec, ok := decodeRecord(b.Get([]byte(key)))
+		if !ok {
+			return nil
+		}
+		removed = append(removed, evicted{key, rec.payload})
+		return b.Delete([]byte(key))
+	})
+	if err != nil {
+		return false
+	}
+	s.notify(removed)
+	return len(removed) > 0
+}
+
+// GetMulti 实现 store.Store 接口，在同一个只读事务中读取，读取到的过期项随后同步删除
+func (s *Store) GetMulti(keys []string) map[string]store.Value {
+	values := make(map[string]store.Value, len(keys))
+	var expired []string
+	now := s.now()
+	s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		for _, key := range keys {
+			rec, ok := decodeRecord(b.Get([]byte(key)))
+			if !ok {
+				continue
+			}
+			if s.expired(rec, now) {
+				expired = append(expired, key)
+				continue
+			}
+			if value, err := s.codec.Decode(rec.payload); err == nil {
+				values[key] = value
+			}
+		}
+		return nil
+	})
+
+	for _, key := range expired {
+		s.removeIfExpired(key)
+	}
+	hits := 0
+	for _, key := range keys {
+		if _, ok := values[key]; ok {
+			hits++
+		}
+	}
+	atomic.AddInt64(&s.hits, int64(hits))
+	atomic.AddInt64(&s.misses, int64(len(keys)-hits))
+	return values
+}
+
+// SetMulti 实现 store.Store 接口，在同一个事务中写入，序列化失败的键被跳过
+func (s *Store) SetMulti(items map[string]store.Value) error {
+	var errs []error
+	var deletes []string
+	payloads := make(map[string][]byte, len(items))
+	for key, value := range items {
+		if value == nil {
+			deletes = append(deletes, key)
+			continue
+		}
+		payload, err := s.codec.Encode(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		payloads[key] = payload
+	}
+
+	var removed []evicted
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		for key, payload := range payloads {
+			if err := s.put(b, key, payload, 0); err != nil {
+				return err
+			}
+		}
+		for _, key := range deletes {
+			if rec, ok := decodeRecord(b.Get([]byte(key))); ok {
+				removed = append(removed, evicted{key, rec.payload})
+				if err := b.Delete([]byte(key)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	s.notify(removed)
+	return errors.Join(errs...)
+}
+
+// DeleteMulti 实现 store.Store 接口，在同一个事务中删除
+func (s *Store) DeleteMulti(keys []string) int {
+	var removed []evicted
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		for _, key := range keys {
+			rec, ok := decodeRecord(b.Get([]byte(key)))
+			if !ok {
+				continue
+			}
+			removed = append(removed, evicted{key, rec.payload})
+			if err := b.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0
+	}
+	s.notify(removed)
+	return len(removed)
+}
+
+// Rename 实现 store.Store 接口，被覆盖的值和 oldKey 都不触发淘汰回调，移动后分配新的版本号
+func (s *Store) Rename(oldKey, newKey string) bool {
+	var removed []evicted
+	renamed := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		rec, ok := decodeRecord(b.Get([]byte(oldKey)))
+		if !ok {
+			return nil
+		}
+		if s.expired(rec, s.now()) {
+			removed = append(removed, evicted{oldKey, rec.payload})
+			return b.Delete([]byte(oldKey))
+		}
+
+		renamed = true
+		if oldKey == newKey {
+			return nil
+		}
+		version, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		rec.version = version
+		if err := b.Put([]byte(newKey), rec.encode()); err != nil {
+			return err
+		}
+		return b.Delete([]byte(oldKey))
+	})
+	if err != nil {
+		return false
+	}
+	s.notify(removed)
+	return renamed
+}
+
+// Clear 实现 store.Store 接口，逐个删除以保留版本号序列
+func (s *Store) Clear() {
+	var removed []evicted
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketName).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.First() {
+			if rec, ok := decodeRecord(v); ok && s.onEvicted != nil {
+				removed = append(removed, evicted{string(k), rec.payload})
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	s.notify(removed)
+}
+
+// Len 实现 store.Store 接口，返回数据库中的键数，严格过期模式下不包含过期项
+func (s *Store) Len() int {
+	if s.strictExpiry {
+		s.removeExpired()
+	}
+
+	n := 0
+	s.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(bucketName).Stats().KeyN
+		return nil
+	})
+	return n
+}
+
+// Stats 实现 store.Store 接口，磁盘存储不受容量限制，Evictions 始终为 0
+func (s *Store) Stats() store.Stats {
+	return store.Stats{
+		Hits:        atomic.LoadInt64(&s.hits),
+		Misses:      atomic.LoadInt64(&s.misses),
+		Expirations: atomic.LoadInt64(&s.expirations),
+		ItemCount:   s.Len(),
+	}
+}
+
+// lookup 记录一次读取的命中或未命中
+func (s *Store) lookup(hit bool) {
+	if hit {
+		atomic.AddInt64(&s.hits, 1)
+	} else {
+		atomic.AddInt64(&s.misses, 1)
+	}
+}
+
+// ForEach 实现 store.Store 接口，按键的字节序遍历，遍历期间持有只读事务
+func (s *Store) ForEach(fn func(key string, value store.Value, expireAt time.Time) bool) {
+	now := s.now()
+	s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketName).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			rec, ok := decodeRecord(v)
+			if !ok || s.expired(rec, now) {
+				continue
+			}
+			value, err := s.codec.Decode(rec.payload)
+			if err != nil {
+				continue
+			}
+			var expireAt time.Time
+			if rec.expireAt != 0 {
+				expireAt = time.Unix(0, rec.expireAt)
+			}
+			if !fn(string(k), value, expireAt) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// Keys 实现 store.Store 接口，按字节序返回未过期的键
+func (s *Store) Keys() []string {
+	var keys []string
+	now := s.now()
+	s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketName).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			rec, ok := decodeRecord(v)
+			if !ok || s.expired(rec, now) {
+				continue
+			}
+			keys = append(keys, string(k))
+		}
+		return nil
+	})
+	return keys
+}
+
+// Scan 实现 store.Store 接口，游标为键按字节序排列的位置，遍历期间的写入可能导致重复或遗漏
+func (s *Store) Scan(cursor uint64, count int) ([]string, uint64) {
+	if count <= 0 {
+		count = 10
+	}
+
+	now := s.now()
+	keys := make([]string, 0, count)
+	next := uint64(0)
+	s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketName).Cursor()
+		k, v := c.First()
+		for i := uint64(0); i < cursor && k != nil; i++ {
+			k, v = c.Next()
+		}
+
+		pos := cursor
+		for ; k != nil && len(keys) < count; k, v = c.Next() {
+			pos++
+			if rec, ok := decodeRecord(v); ok && !s.expired(rec, now) {
+				keys = append(keys, string(k))
+			}
+		}
+		if k != nil {
+			next = pos
+		}
+		return nil
+	})
+	return keys, next
+}
+
+// Sweep 同步删除所有过期项
+func (s *Store) Sweep() {
+	s.removeExpired()
+}
+
+// Close 实现 store.Store 接口，停止清理协程并关闭数据文件，重复调用是安全的
+func (s *Store) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+		s.wg.Wait()
+		s.db.Close()
+	})
+}
+
+// put 写入记录并分配新的版本号，调用此方法必须处于读写事务中
+func (s *Store) put(b *bolt.Bucket, key string, payload []byte, expiration time.Duration) error {
+	version, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	rec := record{
+		expireAt:  expireAtAfter(now, expiration),
+		createdAt: now.UnixNano(),
+		version:   version,
+		payload:   payload,
+	}
+	return b.Put([]byte(key), rec.encode())
+}
+
+// expired 判断记录是否已过期或超过最大存活时间
+func (s *Store) expired(rec record, now time.Time) bool {
+	if rec.expireAt != 0 && now.UnixNano() > rec.expireAt {
+		return true
+	}
+	return s.maxAge > 0 && now.UnixNano()-rec.createdAt >= int64(s.maxAge)
+}
+
+// expireAtAfter 返回 now 之后 d 的过期时间，d <= 0 或为 Forever 时返回 0 表示永不过期
+func expireAtAfter(now time.Time, d time.Duration) int64 {
+	if d <= 0 || d == store.Forever {
+		return 0
+	}
+	return now.Add(d).UnixNano()
+}
+
+// removeIfExpired 键仍存在且已过期时删除
+func (s *Store) removeIfExpired(key string) {
+	var removed []evicted
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		// 读取和删除之间键可能已被删除或重新写入
+		rec, ok := decodeRecord(b.Get([]byte(key)))
+		if !ok || !s.expired(rec, s.now()) {
+			return nil
+		}
+		removed = append(removed, evicted{key, rec.payload})
+		return b.Delete([]byte(key))
+	})
+	if err != nil {
+		return
+	}
+	atomic.AddInt64(&s.expirations, int64(len(removed)))
+	s.notify(removed)
+}
+
+// removeExpired 删除所有过期或超过最大存活时间的项，返回删除的项数
+func (s *Store) removeExpired() int {
+	var removed []evicted
+	now := s.now()
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		// 遍历时删除会使游标跳过下一项，先收集再删除
+		b.ForEach(func(k, v []byte) error {
+			if rec, ok := decodeRecord(v); ok && s.expired(rec, now) {
+				removed = append(removed, evicted{string(k), rec.payload})
+			}
+			return nil
+		})
+		for _, e := range removed {
+			if err := b.Delete([]byte(e.key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0
+	}
+	atomic.AddInt64(&s.expirations, int64(len(removed)))
+	s.notify(removed)
+	return len(removed)
+}
+
+// notify 事务提交后对删除的项触发淘汰回调，值无法解码时跳过
+func (s *Store) notify(removed []evicted) {
+	if s.onEvicted == nil {
+		return
+	}
+	for _, e := range removed {
+		value, err := s.codec.Decode(e.payload)
+		if err != nil {
+			continue
+		}
+		s.onEvicted(e.key, value)
+	}
+}
+
+// cleanupLoop 定期清理过期项
+func (s *Store) cleanupLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.removeExpired()
+		case <-s.closeCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package boltstore
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lyy42995004/Cache-Go/store"
+)
+
+type String string
+
+func (s String) Len() int {
+	return len(s)
+}
+
+// stringCodec 测试用的 String 序列化方式
+type stringCodec struct{}
+
+func (stringCodec) Encode(value store.Value) ([]byte, error) {
+	s, ok := value.(String)
+	if !ok {
+		return nil, errors.New("not a String")
+	}
+	return []byte(s), nil
+}
+
+func (stringCodec) Decode(data []byte) (store.Value, error) {
+	return String(data), nil
+}
+
+// newTestOptions 返回使用临时文件的配置
+func newTestOptions(t *testing.T) store.Options {
+	t.Helper()
+	opts := store.NewOptions()
+	opts.Path = filepath.Join(t.TempDir(), "cache.db")
+	opts.Codec = stringCodec{}
+	return opts
+}
+
+// openTestStore 打开测试用的磁盘存储，测试结束时关闭
+func openTestStore(t *testing.T, opts store.Options) *Store {
+	t.Helper()
+	s, err := Open(opts)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// 测试写入、读取、删除和遍历
+func TestStoreRoundTrip(t *testing.T) {
+	s := openTestStore(t, newTestOptions(t))
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := s.Set(key, String("value-"+key)); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	if value, ok := s.Get("b"); !ok || value.(String) != "value-b" {
+		t.Fatalf("Expected value-b, got %v %v", value, ok)
+	}
+	if n := s.Len(); n != 3 {
+		t.Fatalf("Expected 3 items, got %d", n)
+	}
+
+	var keys []string
+	s.ForEach(func(key string, value store.Value, expireAt time.Time) bool {
+		keys = append(keys, key)
+		if !expireAt.IsZero() {
+			t.Fatalf("Expected no expiry for %s, got %v", key, expireAt)
+		}
+		return true
+	})
+	if len(keys) != 3 || keys[0] != "a" || keys[2] != "c" {
+		t.Fatalf("Expected keys in byte order, got %v", keys)
+	}
+
+	page, next := s.Scan(0, 2)
+	if len(page) != 2 || next == 0 {
+		t.Fatalf("Expected first page of 2 with cursor, got %v %d", page, next)
+	}
+	page, next = s.Scan(next, 2)
+	if len(page) != 1 || page[0] != "c" || next != 0 {
+		t.Fatalf("Expected last page [c], got %v %d", page, next)
+	}
+
+	if !s.Delete("a") || s.Delete("a") {
+		t.Fatalf("Expected Delete to report whether the key existed")
+	}
+	if !s.Rename("b", "d") {
+		t.Fatalf("Expected Rename to succeed")
+	}
+	if _, ok := s.Get("b"); ok {
+		t.Fatalf("Expected old key to be gone after Rename")
+	}
+	if value, ok := s.Get("d"); !ok || value.(String) != "value-b" {
+		t.Fatalf("Expected renamed value, got %v %v", value, ok)
+	}
+
+	s.Clear()
+	if n := s.Len(); n != 0 {
+		t.Fatalf("Expected empty store after Clear, got %d", n)
+	}
+}
+
+// 测试版本号和条件写入
+func TestStoreSetIfVersion(t *testing.T) {
+	s := openTestStore(t, newTestOptions(t))
+
+	if ok, err := s.SetIfVersion("key", String("v1"), 0, 0); !ok || err != nil {
+		t.Fatalf("Expected insert with version 0, got %v %v", ok, err)
+	}
+	_, version, ok := s.GetWithVersion("key")
+	if !ok || version == 0 {
+		t.Fatalf("Expected assigned version, got %d %v", version, ok)
+	}
+	if ok, _ := s.SetIfVersion("key", String("v2"), version+1, 0); ok {
+		t.Fatalf("Expected stale version to be rejected")
+	}
+	if ok, _ := s.SetIfVersion("key", String("v2"), version, 0); !ok {
+		t.Fatalf("Expected matching version to be accepted")
+	}
+	if value, _ := s.Get("key"); value.(String) != "v2" {
+		t.Fatalf("Expected v2, got %v", value)
+	}
+}
+
+// 测试过期项读取时删除并触发淘汰回调
+func TestStoreExpiration(t *testing.T) {
+	opts := newTestOptions(t)
+	evicted := make(map[string]store.Value)
+	opts.OnEvicted = func(key string, value store.Value) {
+		evicted[key] = value
+	}
+	s := openTestStore(t, opts)
+
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	s.SetWithExpiration("short", String("s"), time.Second)
+	s.SetWithExpiration("long", String("l"), time.Hour)
+	s.SetWithExpiration("forever", String("f"), store.Forever)
+
+	if _, ok := s.GetExpiration("forever"); ok {
+		t.Fatalf("Expected Forever to mean no expiry")
+	}
+	if expireAt, ok := s.GetExpiration("long"); !ok || !expireAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("Expected expiry %v, got %v %v", now.Add(time.Hour), expireAt, ok)
+	}
+
+	now = now.Add(2 * time.Second)
+	if _, ok := s.Get("short"); ok {
+		t.Fatalf("Expected expired key to miss")
+	}
+	if evicted["short"] != String("s") {
+		t.Fatalf("Expected OnEvicted for expired key, got %v", evicted)
+	}
+	if n := s.Len(); n != 2 {
+		t.Fatalf("Expected expired key to be removed, got %d items", n)
+	}
+
+	// 命中时重置过期时间
+	if value, ok := s.GetAndTouch("long", time.Minute); !ok || value.(String) != "l" {
+		t.Fatalf("Expected GetAndTouch hit, got %v %v", value, ok)
+	}
+	now = now.Add(2 * time.Minute)
+	if n := s.removeExpired(); n != 1 {
+		t.Fatalf("Expected cleanup to remove 1 item, got %d", n)
+	}
+	if evicted["long"] != String("l") {
+		t.Fatalf("Expected OnEvicted during cleanup, got %v", evicted)
+	}
+	if _, ok := s.Get("forever"); !ok {
+		t.Fatalf("Expected key without expiry to remain")
+	}
+}
+
+// 测试 Peek 读取未过期的值，过期的键返回 false 但不删除
+func TestStorePeek(t *testing.T) {
+	s := openTestStore(t, newTestOptions(t))
+
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	s.Set("key", String("value"))
+	s.SetWithExpiration("short", String("s"), time.Second)
+	if value, ok := s.Peek("key"); !ok || value.(String) != "value" {
+		t.Fatalf("Expected Peek hit, got %v %v", value, ok)
+	}
+
+	now = now.Add(2 * time.Second)
+	if _, ok := s.Peek("short"); ok {
+		t.Fatalf("Expected Peek to miss an expired key")
+	}
+	if n := s.removeExpired(); n != 1 {
+		t.Fatalf("Expected expired key to be left for cleanup, removed %d", n)
+	}
+}
+
+// 测试 Keys 按字节序返回未过期的键，Contains 与之一致
+func TestStoreKeys(t *testing.T) {
+	s := openTestStore(t, newTestOptions(t))
+
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	s.Set("b", String("v"))
+	s.Set("a", String("v"))
+	s.SetWithExpiration("c", String("v"), time.Second)
+	now = now.Add(2 * time.Second)
+
+	if keys := s.Keys(); len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("Expected keys [a b], got %v", keys)
+	}
+	if !s.Contains("a") || s.Contains("c") || s.Contains("missing") {
+		t.Fatalf("Expected Contains to report only unexpired keys")
+	}
+}
+
+// 测试 GetOrLoad 未命中时加载并写入，命中时不再调用 loader
+func TestStoreGetOrLoad(t *testing.T) {
+	s := openTestStore(t, newTestOptions(t))
+
+	loads := 0
+	loader := func() (store.Value, time.Duration, error) {
+		loads++
+		return String("value"), time.Hour, nil
+	}
+	for range 2 {
+		if value, err := s.GetOrLoad("key", loader); err != nil || value.(String) != "value" {
+			t.Fatalf("Expected loaded value, got %v %v", value, err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("Expected loader to run once, ran %d times", loads)
+	}
+	if _, ok := s.GetExpiration("key"); !ok {
+		t.Fatalf("Expected the returned TTL to be applied")
+	}
+}
+
+// 测试批量操作在同一个事务中完成，nil 值删除并触发淘汰回调
+func TestStoreMulti(t *testing.T) {
+	opts := newTestOptions(t)
+	evicted := make(map[string]store.Value)
+	opts.OnEvicted = func(key string, value store.Value) {
+		evicted[key] = value
+	}
+	s := openTestStore(t, opts)
+
+	if err := s.SetMulti(map[string]store.Value{"a": String("1"), "b": String("2"), "c": String("3")}); err != nil {
+		t.Fatalf("SetMulti failed: %v", err)
+	}
+	if values := s.GetMulti([]string{"a", "b", "missing"}); len(values) != 2 || values["b"].(String) != "2" {
+		t.Fatalf("Expected hits for a and b, got %v", values)
+	}
+
+	if err := s.SetMulti(map[string]store.Value{"a": nil, "d": String("4")}); err != nil {
+		t.Fatalf("SetMulti failed: %v", err)
+	}
+	if s.Contains("a") || !s.Contains("d") || evicted["a"] != String("1") {
+		t.Fatalf("Expected a deleted with OnEvicted and d added, evicted %v", evicted)
+	}
+
+	if n := s.DeleteMulti([]string{"b", "c", "b", "missing"}); n != 2 {
+		t.Fatalf("Expected DeleteMulti to remove 2 keys, got %d", n)
+	}
+	if keys := s.Keys(); len(keys) != 1 || keys[0] != "d" {
+		t.Fatalf("Expected only d to remain, got %v", keys)
+	}
+}
+
+// 测试访问统计，读取到的过期项计入未命中和过期，磁盘存储没有容量淘汰
+func TestStoreStats(t *testing.T) {
+	s := openTestStore(t, newTestOptions(t))
+
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	s.Set("a", String("1"))
+	s.SetWithExpiration("b", String("2"), time.Second)
+	s.SetWithExpiration("c", String("3"), time.Second)
+	s.Get("a")
+	s.GetMulti([]string{"a", "missing"})
+
+	now = now.Add(2 * time.Second)
+	if _, ok := s.Get("b"); ok {
+		t.Fatalf("Expected expired key to miss")
+	}
+	s.removeExpired()
+
+	stats := s.Stats()
+	want := store.Stats{Hits: 2, Misses: 2, Expirations: 2, ItemCount: 1}
+	if stats != want {
+		t.Fatalf("Expected %+v, got %+v", want, stats)
+	}
+}
+
+// 测试重新打开数据文件后数据和过期时间仍然有效
+func TestStorePersistence(t *testing.T) {
+	opts := newTestOptions(t)
+	s, err := Open(opts)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	s.Set("key", String("value"))
+	s.SetWithExpiration("ttl", String("ttl-value"), time.Hour)
+	expireAt, _ := s.GetExpiration("ttl")
+	_, version, _ := s.GetWithVersion("key")
+	s.Close()
+
+	reopened := openTestStore(t, opts)
+	if value, ok := reopened.Get("key"); !ok || value.(String) != "value" {
+		t.Fatalf("Expected value to persist, got %v %v", value, ok)
+	}
+	if got, ok := reopened.GetExpiration("ttl"); !ok || !got.Equal(expireAt) {
+		t.Fatalf("Expected expiry %v to persist, got %v %v", expireAt, got, ok)
+	}
+
+	// 版本号在重新打开后继续递增
+	reopened.Set("key", String("new"))
+	if _, v, _ := reopened.GetWithVersion("key"); v <= version {
+		t.Fatalf("Expected version greater than %d after reopen, got %d", version, v)
+	}
+}
+
+// 测试通过 NewStore 创建磁盘存储
+func TestNewStoreBolt(t *testing.T) {
+	opts := newTestOptions(t)
+	s, err := store.NewStore(store.Bolt, opts)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	defer s.Close()
+	if _, ok := s.(*Store); !ok {
+		t.Fatalf("Expected *Store, got %T", s)
+	}
+
+	opts.Codec = nil
+	if _, err := store.NewStore(store.Bolt, opts); !errors.Is(err, store.ErrCodecRequired) {
+		t.Fatalf("Expected ErrCodecRequired, got %v", err)
+	}
+	opts.Path = ""
+	if _, err := store.NewStore(store.Bolt, opts); !errors.Is(err, ErrPathRequired) {
+		t.Fatalf("Expected ErrPathRequired, got %v", err)
+	}
+}
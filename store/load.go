@@ -0,0 +1,43 @@
+package store
+
+import (
+	"time"
+
+	"github.com/lyy42995004/Cache-Go/singleflight"
+)
+
+// LoadFunc GetOrLoad 未命中时调用的加载函数，返回的过期时间 <= 0 时永不过期
+type LoadFunc func() (Value, time.Duration, error)
+
+// LoadGroup 合并同一个键的并发加载，供各存储实现 GetOrLoad，零值可直接使用
+// 加载期间不持有存储的锁，加载较慢的键不会阻塞同一个桶中其他键的读写
+type LoadGroup struct {
+	flight singleflight.Group
+}
+
+// GetOrLoad 读取 s 中的键，未命中时同一个键同时只调用一次 loader，其他调用等待并共享结果
+// loader 返回错误或 nil 值时不写入缓存，错误返回给所有等待的调用；写入被存储拒绝时仍返回加载的值
+func (g *LoadGroup) GetOrLoad(s Store, key string, loader LoadFunc) (Value, error) {
+	if value, ok := s.Get(key); ok {
+		return value, nil
+	}
+
+	v, err := g.flight.Do(key, func() (any, error) {
+		// 上一次加载可能在本次 Get 之后刚刚写入
+		if value, ok := s.Peek(key); ok {
+			return value, nil
+		}
+
+		value, expiration, err := loader()
+		if err != nil || value == nil {
+			return value, err
+		}
+		s.SetWithExpiration(key, value, expiration)
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	value, _ := v.(Value)
+	return value, nil
+}
@@ -2,7 +2,11 @@ package store
 
 import (
 	"container/list"
+	"errors"
+	"fmt"
+	"math/rand/v2"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,18 +16,36 @@ type lruCache struct {
 	list            *list.List
 	items           map[string]*list.Element // 键与节点的映射
 	expires         map[string]time.Time     // 键与过期时间的映射
+	slots           []*lruEntry              // 位置固定的条目数组，供 Scan 按游标遍历，nil 表示空闲
+	freeSlots       []int                    // 空闲位置
+	version         uint64                   // 最近分配的版本号，每次写入递增
 	maxBytes        int64
 	usedBytes       int64
 	onEvicted       func(key string, value Value)
+	admission       AdmissionPolicy  // 准入策略
+	maxAge          time.Duration    // 最大存活时间
+	now             func() time.Time // 时钟，默认为 time.Now，测试时可替换
+	strictExpiry    bool             // 严格过期，读取和统计前同步清理过期项
+	samples         int              // 近似 LRU 淘汰时的采样数，0 表示精确 LRU
+	noPromote       bool             // 读取不调整顺序，按写入顺序淘汰
 	cleanupInterval time.Duration
 	cleanupTicker   *time.Ticker
+	cleanupStats    CleanupStats  // 定期清理统计
+	counters        statsCounters // 访问统计
+	loads           LoadGroup     // 合并同一个键的并发加载
 	closeCh         chan struct{} // 用于优雅关闭协程
+	closeOnce       sync.Once
+	deletes         inflight // 进行中的异步删除
 }
 
 // lruEntry 缓存条目
 type lruEntry struct {
-	key   string
-	value Value
+	key        string
+	value      Value
+	createdAt  time.Time // 写入时间
+	slot       int       // 在 slots 中的位置
+	version    uint64    // 版本号，每次写入更新
+	lastAccess int64     // 最近访问时间（纳秒），近似 LRU 模式下使用，原子操作
 }
 
 // newLRUCache 创建 lRU 缓存实例
@@ -33,12 +55,23 @@ func newLRUCache(opts Options) *lruCache {
 		cleanupInterval = time.Minute
 	}
 
+	admission := opts.Admission
+	if admission == nil {
+		admission = AlwaysAdmit{}
+	}
+
 	c := &lruCache{
 		list:            list.New(),
 		items:           make(map[string]*list.Element),
 		expires:         make(map[string]time.Time),
 		maxBytes:        opts.MaxBytes,
 		onEvicted:       opts.OnEvicted,
+		admission:       admission,
+		maxAge:          opts.MaxAge,
+		now:             time.Now,
+		strictExpiry:    opts.StrictExpiry,
+		samples:         opts.EvictionSamples,
+		noPromote:       opts.NoPromote,
 		cleanupInterval: cleanupInterval,
 		closeCh:         make(chan struct{}),
 	}
@@ -56,33 +89,82 @@ func (c *lruCache) Get(key string) (Value, bool) {
 	elem, ok := c.items[key]
 	if !ok {
 		c.mu.RUnlock()
+		c.counters.lookup(false)
 		return nil, false
 	}
 
 	// 检查过期
-	if expTime, hasExp := c.expires[key]; hasExp && time.Now().After(expTime) {
+	entry := elem.Value.(*lruEntry)
+	now := c.now()
+	if c.expired(entry, now) {
 		c.mu.RUnlock()
-		// 异步删除
-		go c.Delete(key)
+		c.counters.lookup(false)
+		if c.strictExpiry {
+			c.removeIfExpired(key)
+		} else {
+			// 异步删除，删除前再次检查，避免删除期间重新写入的值
+			c.deletes.run(func() { c.removeIfExpired(key) })
+		}
 		return nil, false
 	}
+	c.counters.lookup(true)
 
 	// 获取值并释放锁
-	entry := elem.Value.(*lruEntry)
 	value := entry.value
-	c.mu.RUnlock()
+	if c.noPromote {
+		// 不调整顺序，只需要读锁
+		c.mu.RUnlock()
+	} else if c.samples > 0 {
+		// 近似 LRU 只记录访问时间，不需要写锁
+		atomic.StoreInt64(&entry.lastAccess, now.UnixNano())
+		c.mu.RUnlock()
+	} else {
+		c.mu.RUnlock()
 
-	// 更新 LRU 位置需要写锁
-	c.mu.Lock()
-	// 再次检查，防止再读写锁期间被其他协程删除
-	if _, ok := c.items[key]; ok {
-		c.list.MoveToBack(elem)
+		// 更新 LRU 位置需要写锁
+		c.mu.Lock()
+		// 再次检查，防止再读写锁期间被其他协程删除
+		if _, ok := c.items[key]; ok {
+			c.list.MoveToBack(elem)
+		}
+		c.mu.Unlock()
+	}
+
+	// 通知准入策略记录本次命中
+	if recorder, ok := c.admission.(accessRecorder); ok {
+		recorder.Record(key)
 	}
-	c.mu.Unlock()
 
 	return value, true
 }
 
+// Peek 实现Store接口，只持有读锁，不调整 LRU 顺序也不记录访问时间
+func (c *lruCache) Peek(key string) (Value, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if c.expired(entry, c.now()) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Contains 实现Store接口，只持有读锁，不调整 LRU 顺序
+func (c *lruCache) Contains(key string) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+// GetOrLoad 实现Store接口，加载期间不持有锁
+func (c *lruCache) GetOrLoad(key string, loader LoadFunc) (Value, error) {
+	return c.loads.GetOrLoad(c, key, loader)
+}
+
 // Set 添加或更新缓存值
 func (c *lruCache) Set(key string, value Value) error {
 	return c.SetWithExpiration(key, value, 0)
@@ -98,26 +180,108 @@ func (c *lruCache) SetWithExpiration(key string, value Value, expiration time.Du
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	return c.set(key, value, expiration)
+}
+
+// GetWithVersion 获取缓存值及其版本号
+func (c *lruCache) GetWithVersion(key string) (Value, uint64, bool) {
+	c.mu.Lock()
+	elem, ok := c.items[key]
+	if !ok {
+		c.mu.Unlock()
+		c.counters.lookup(false)
+		return nil, 0, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	now := c.now()
+	if c.expired(entry, now) {
+		c.expireElement(elem)
+		c.mu.Unlock()
+		c.counters.lookup(false)
+		return nil, 0, false
+	}
+	c.counters.lookup(true)
+	c.access(elem, now)
+	value, version := entry.value, entry.version
+	c.mu.Unlock()
+
+	if recorder, ok := c.admission.(accessRecorder); ok {
+		recorder.Record(key)
+	}
+
+	return value, version, true
+}
+
+// SetIfVersion 当前版本号等于 expectedVersion 时写入，键不存在或已过期时版本号视为 0
+// value 为 nil 时删除该键
+func (c *lruCache) SetIfVersion(key string, value Value, expectedVersion uint64, expiration time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	var current uint64
+	if ok && !c.expired(elem.Value.(*lruEntry), c.now()) {
+		current = elem.Value.(*lruEntry).version
+	}
+	if current != expectedVersion {
+		return false, nil
+	}
+
+	if value == nil {
+		if ok {
+			c.removeElement(elem)
+		}
+		return true, nil
+	}
+	if err := c.set(key, value, expiration); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// set 添加或更新缓存值并分配新的版本号，调用此方法必须持有锁
+func (c *lruCache) set(key string, value Value, expiration time.Duration) error {
+	// 超过总容量的值写入后会被立即淘汰，直接拒绝
+	if c.maxBytes > 0 && int64(len(key)+value.Len()) > c.maxBytes {
+		return ErrValueTooLarge
+	}
+
+	// 新键在需要淘汰才能容纳时，由准入策略决定是否写入
+	_, exists := c.items[key]
+	if !exists {
+		cost := int64(len(key) + value.Len())
+		if c.maxBytes > 0 && c.usedBytes+cost > c.maxBytes && !c.admission.ShouldAdmit(key, cost) {
+			return ErrNotAdmitted
+		}
+	}
+
 	// 计算过期时间
+	now := c.now()
 	var expTime time.Time
-	if expiration > 0 {
-		expTime = time.Now().Add(expiration)
+	// Forever 表示永不过期，与 lru2Store 保持一致
+	if expiration > 0 && expiration != Forever {
+		expTime = now.Add(expiration)
 		c.expires[key] = expTime
 	} else {
 		delete(c.expires, key) // 移除缓存项的过期时间限制
 	}
+	c.version++
 
 	// 键存在，更新值
 	if elem, ok := c.items[key]; ok {
 		oldEntry := elem.Value.(*lruEntry)
 		c.usedBytes += int64(value.Len() - oldEntry.value.Len())
 		oldEntry.value = value
-		c.list.MoveToBack(elem)
+		oldEntry.createdAt = now
+		oldEntry.version = c.version
+		c.touch(elem, now)
 		return nil
 	}
 
 	// 添加新项
-	entry := &lruEntry{key: key, value: value}
+	entry := &lruEntry{key: key, value: value, createdAt: now, version: c.version, lastAccess: now.UnixNano()}
+	c.allocSlot(entry)
 	elem := c.list.PushBack(entry)
 	c.items[key] = elem
 	c.usedBytes += int64(len(key) + value.Len())
@@ -141,6 +305,114 @@ func (c *lruCache) Delete(key string) bool {
 	return false
 }
 
+// GetMulti 实现Store接口，所有键在同一次加锁中读取，过期的键同步删除
+func (c *lruCache) GetMulti(keys []string) map[string]Value {
+	values := make(map[string]Value, len(keys))
+	hits := 0
+	c.mu.Lock()
+	now := c.now()
+	for _, key := range keys {
+		elem, ok := c.items[key]
+		if !ok {
+			continue
+		}
+		if c.expired(elem.Value.(*lruEntry), now) {
+			c.expireElement(elem)
+			continue
+		}
+		c.access(elem, now)
+		values[key] = elem.Value.(*lruEntry).value
+		hits++
+	}
+	c.mu.Unlock()
+	atomic.AddInt64(&c.counters.hits, int64(hits))
+	atomic.AddInt64(&c.counters.misses, int64(len(keys)-hits))
+
+	if recorder, ok := c.admission.(accessRecorder); ok {
+		for key := range values {
+			recorder.Record(key)
+		}
+	}
+	return values
+}
+
+// SetMulti 实现Store接口，所有键在同一次加锁中写入
+func (c *lruCache) SetMulti(items map[string]Value) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for key, value := range items {
+		if value == nil {
+			if elem, ok := c.items[key]; ok {
+				c.removeElement(elem)
+			}
+			continue
+		}
+		if err := c.set(key, value, 0); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeleteMulti 实现Store接口，所有键在同一次加锁中删除
+func (c *lruCache) DeleteMulti(keys []string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deleted := 0
+	for _, key := range keys {
+		if elem, ok := c.items[key]; ok {
+			c.removeElement(elem)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// Rename 将 oldKey 的值和过期时间原子地移动到 newKey，覆盖 newKey 原有的值，oldKey 不存在时返回 false
+// 被覆盖的值和 oldKey 都不触发淘汰回调，移动后分配新的版本号，在 LRU 中的位置不变
+func (c *lruCache) Rename(oldKey, newKey string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[oldKey]
+	if !ok {
+		return false
+	}
+	entry := elem.Value.(*lruEntry)
+	if c.expired(entry, c.now()) {
+		c.expireElement(elem)
+		return false
+	}
+	if oldKey == newKey {
+		return true
+	}
+
+	if dst, ok := c.items[newKey]; ok {
+		c.unlink(dst)
+	}
+
+	expTime, hasExp := c.expires[oldKey]
+	delete(c.items, oldKey)
+	delete(c.expires, oldKey)
+	entry.key = newKey
+	c.items[newKey] = elem
+	if hasExp {
+		c.expires[newKey] = expTime
+	}
+	c.usedBytes += int64(len(newKey) - len(oldKey))
+	c.version++
+	entry.version = c.version
+
+	// 新键更长时可能超出容量
+	if c.maxBytes > 0 && c.usedBytes > c.maxBytes {
+		c.evict()
+	}
+	return true
+}
+
 // Clear 清空缓存
 func (c *lruCache) Clear() {
 	c.mu.Lock()
@@ -158,56 +430,261 @@ func (c *lruCache) Clear() {
 	c.list.Init()
 	c.items = make(map[string]*list.Element)
 	c.expires =  make(map[string]time.Time)
+	c.slots = nil
+	c.freeSlots = nil
 	c.usedBytes = 0
 }
 
-// Len 返回缓存项数
+// Len 返回未过期的缓存项数，过期但尚未清理的项不计入也不删除，严格过期模式下同时清理过期项
+// 需要检查所有设置了过期时间的项，设置了最大存活时间时检查所有项，耗时与项数成正比
 func (c *lruCache) Len() int {
+	if c.strictExpiry {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.purgeExpired()
+		return c.list.Len()
+	}
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return c.list.Len()
+	now := c.now()
+	if c.maxAge > 0 {
+		n := 0
+		for elem := c.list.Front(); elem != nil; elem = elem.Next() {
+			if !c.expired(elem.Value.(*lruEntry), now) {
+				n++
+			}
+		}
+		return n
+	}
+
+	// 只有设置了过期时间的项可能过期
+	n := c.list.Len()
+	for _, expTime := range c.expires {
+		if now.After(expTime) {
+			n--
+		}
+	}
+	return n
 }
 
-// Close 关闭缓存，清理协程
+// ForEach 按最久未使用到最近使用的顺序遍历所有未过期的项，近似 LRU 模式下按写入顺序遍历
+func (c *lruCache) ForEach(fn func(key string, value Value, expireAt time.Time) bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.now()
+	for elem := c.list.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*lruEntry)
+		if c.expired(entry, now) {
+			continue
+		}
+		if !fn(entry.key, entry.value, c.expires[entry.key]) {
+			return
+		}
+	}
+}
+
+// Keys 实现Store接口，按从旧到新的访问顺序返回未过期的键
+func (c *lruCache) Keys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.now()
+	keys := make([]string, 0, len(c.items))
+	for elem := c.list.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*lruEntry)
+		if !c.expired(entry, now) {
+			keys = append(keys, entry.key)
+		}
+	}
+	return keys
+}
+
+// Scan 从游标 cursor 开始返回最多 count 个未过期的键，以及下次调用使用的游标
+// 首次调用传入 0，返回的游标为 0 时遍历结束；每次调用只在本页内持有锁
+// 条目位置固定，整个遍历期间一直存在的键至少会被返回一次
+func (c *lruCache) Scan(cursor uint64, count int) ([]string, uint64) {
+	if count <= 0 {
+		count = 10
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.now()
+	keys := make([]string, 0, count)
+	i := int(cursor)
+	for ; i < len(c.slots) && len(keys) < count; i++ {
+		entry := c.slots[i]
+		if entry == nil || c.expired(entry, now) {
+			continue
+		}
+		keys = append(keys, entry.key)
+	}
+
+	if i >= len(c.slots) {
+		return keys, 0
+	}
+	return keys, uint64(i)
+}
+
+// Close 关闭缓存，清理协程，重复调用是安全的
 func (c *lruCache) Close() {
-	if c.cleanupTicker != nil {
-		c.cleanupTicker.Stop()
-		close(c.closeCh)
+	c.closeOnce.Do(func() {
+		if c.cleanupTicker != nil {
+			c.cleanupTicker.Stop()
+			close(c.closeCh)
+		}
+	})
+}
+
+// allocSlot 为新条目分配位置，优先复用空闲位置，调用此方法必须持有锁
+func (c *lruCache) allocSlot(entry *lruEntry) {
+	if n := len(c.freeSlots); n > 0 {
+		entry.slot = c.freeSlots[n-1]
+		c.freeSlots = c.freeSlots[:n-1]
+	} else {
+		entry.slot = len(c.slots)
+		c.slots = append(c.slots, nil)
 	}
+	c.slots[entry.slot] = entry
 }
 
 // removeElement 从缓存中删除项，调用此方法必须持有锁
 func (c *lruCache) removeElement(elem *list.Element) {
+	entry := c.unlink(elem)
+	if c.onEvicted != nil {
+		c.onEvicted(entry.key, entry.value)
+	}
+}
+
+// evictElement 因容量不足淘汰缓存项，调用此方法必须持有锁
+func (c *lruCache) evictElement(elem *list.Element) {
+	atomic.AddInt64(&c.counters.evictions, 1)
+	c.removeElement(elem)
+}
+
+// expireElement 删除过期或超过最大存活时间的缓存项，调用此方法必须持有锁
+func (c *lruCache) expireElement(elem *list.Element) {
+	atomic.AddInt64(&c.counters.expirations, 1)
+	c.removeElement(elem)
+}
+
+// unlink 从缓存中删除项，不触发淘汰回调，调用此方法必须持有锁
+func (c *lruCache) unlink(elem *list.Element) *lruEntry {
 	entry := elem.Value.(*lruEntry)
 	c.list.Remove(elem)
 	delete(c.items, entry.key)
 	delete(c.expires, entry.key)
+	c.slots[entry.slot] = nil
+	c.freeSlots = append(c.freeSlots, entry.slot)
 	c.usedBytes -= int64(len(entry.key) + entry.value.Len())
+	return entry
+}
 
-	if c.onEvicted != nil {
-		c.onEvicted(entry.key, entry.value)
+// expired 判断缓存项是否已过期或超过最大存活时间，调用此方法必须持有锁
+func (c *lruCache) expired(entry *lruEntry, now time.Time) bool {
+	if expTime, ok := c.expires[entry.key]; ok && now.After(expTime) {
+		return true
 	}
+	return c.maxAge > 0 && now.Sub(entry.createdAt) >= c.maxAge
 }
 
 // evict 清理过期和超出内存的缓存，调用此方法必须持有锁
 func (c *lruCache) evict() {
 	// 清理过期项
-	now := time.Now()
+	c.removeExpired()
+
+	// 根据内存限制清理最久未使用的锁
+	for c.maxBytes > 0 && c.usedBytes > c.maxBytes && c.list.Len() > 0 {
+		c.evictElement(c.victim())
+	}
+}
+
+// touch 记录缓存项在 now 被访问，调用此方法必须持有锁
+func (c *lruCache) touch(elem *list.Element, now time.Time) {
+	if c.samples > 0 {
+		atomic.StoreInt64(&elem.Value.(*lruEntry).lastAccess, now.UnixNano())
+		return
+	}
+	c.list.MoveToBack(elem)
+}
+
+// access 记录一次读取，NoPromote 模式下不调整顺序，调用此方法必须持有锁
+func (c *lruCache) access(elem *list.Element, now time.Time) {
+	if !c.noPromote {
+		c.touch(elem, now)
+	}
+}
+
+// victim 选择淘汰的缓存项，调用此方法必须持有锁且缓存非空
+// 精确 LRU 返回链表头部；近似 LRU 随机采样 samples 个缓存项，返回其中最久未访问的
+func (c *lruCache) victim() *list.Element {
+	if c.samples <= 0 {
+		return c.list.Front()
+	}
+
+	var oldest *lruEntry
+	// slots 中可能有空闲位置，限制尝试次数避免空转
+	for tries, sampled := 0, 0; sampled < c.samples && tries < 4*c.samples; tries++ {
+		entry := c.slots[rand.IntN(len(c.slots))]
+		if entry == nil {
+			continue
+		}
+		sampled++
+		if oldest == nil || atomic.LoadInt64(&entry.lastAccess) < atomic.LoadInt64(&oldest.lastAccess) {
+			oldest = entry
+		}
+	}
+	if oldest == nil {
+		return c.list.Front()
+	}
+	return c.items[oldest.key]
+}
+
+// removeExpired 移除所有过期项，返回移除的数量，调用此方法必须持有锁
+func (c *lruCache) removeExpired() int {
+	reaped := 0
+	now := c.now()
 	for key, expTime := range c.expires {
 		if now.After(expTime) {
 			if elem, ok := c.items[key]; ok {
-				c.removeElement(elem)
+				c.expireElement(elem)
+				reaped++
 			}
 		}
 	}
+	return reaped
+}
 
-	// 根据内存限制清理最久未使用的锁
-	for c.maxBytes > 0 && c.usedBytes > c.maxBytes && c.list.Len() > 0 {
-		elem := c.list.Front()
-		if elem != nil {
-			c.removeElement(elem)
+// removeIfExpired 键仍存在且已过期时删除
+func (c *lruCache) removeIfExpired(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 释放读锁期间键可能已被删除或重新写入
+	if elem, ok := c.items[key]; ok && c.expired(elem.Value.(*lruEntry), c.now()) {
+		c.expireElement(elem)
+	}
+}
+
+// purgeExpired 移除所有过期或超过最大存活时间的项，调用此方法必须持有锁
+func (c *lruCache) purgeExpired() {
+	if c.maxAge <= 0 {
+		c.removeExpired()
+		return
+	}
+
+	now := c.now()
+	for elem := c.list.Front(); elem != nil; {
+		next := elem.Next()
+		if c.expired(elem.Value.(*lruEntry), now) {
+			c.expireElement(elem)
 		}
+		elem = next
 	}
 }
 
@@ -216,15 +693,48 @@ func (c *lruCache) cleanupLoop() {
 	for {
 		select {
 		case <-c.cleanupTicker.C:
-			c.mu.Lock()
-			c.evict()
-			c.mu.Unlock()
+			c.sweep()
 		case <-c.closeCh:
 			return
 		}
 	}
 }
 
+// sweep 执行一次定期清理并记录统计信息
+func (c *lruCache) sweep() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	start := time.Now()
+	examined := len(c.expires)
+	reaped := c.removeExpired()
+	c.evict()
+
+	c.cleanupStats.Sweeps++
+	c.cleanupStats.LastReaped = reaped
+	c.cleanupStats.LastExamined = examined
+	c.cleanupStats.LastDuration = time.Since(start)
+}
+
+// Sweep 等待进行中的异步删除完成后同步执行一次清理，返回后 Len 和 UsedBytes 不包含过期项
+func (c *lruCache) Sweep() {
+	c.deletes.wait()
+	c.sweep()
+}
+
+// Stats 实现Store接口
+func (c *lruCache) Stats() Stats {
+	return c.counters.snapshot(c.Len())
+}
+
+// CleanupStats 返回定期清理的统计信息
+func (c *lruCache) CleanupStats() CleanupStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cleanupStats
+}
+
 // GetExpiration 获取缓存项过期时间
 func (c *lruCache) GetExpiration(key string) (time.Time, bool) {
 	c.mu.RLock()
@@ -234,6 +744,40 @@ func (c *lruCache) GetExpiration(key string) (time.Time, bool) {
 	return expTime, ok
 }
 
+// GetAndTouch 实现Store接口，命中时在同一次加锁中将过期时间重置为 now + newTTL
+func (c *lruCache) GetAndTouch(key string, newTTL time.Duration) (Value, bool) {
+	c.mu.Lock()
+	elem, ok := c.items[key]
+	if !ok {
+		c.mu.Unlock()
+		c.counters.lookup(false)
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	now := c.now()
+	if c.expired(entry, now) {
+		c.expireElement(elem)
+		c.mu.Unlock()
+		c.counters.lookup(false)
+		return nil, false
+	}
+	c.counters.lookup(true)
+	c.access(elem, now)
+	if newTTL > 0 && newTTL != Forever {
+		c.expires[key] = now.Add(newTTL)
+	} else {
+		delete(c.expires, key)
+	}
+	value := entry.value
+	c.mu.Unlock()
+
+	if recorder, ok := c.admission.(accessRecorder); ok {
+		recorder.Record(key)
+	}
+	return value, true
+}
+
 // UpdateExpiration 更新过期时间
 func (c *lruCache) UpdateExpiration(key string, expiration time.Duration) bool {
 	c.mu.Lock()
@@ -244,7 +788,7 @@ func (c *lruCache) UpdateExpiration(key string, expiration time.Duration) bool {
 	}
 
 	if expiration > 0 {
-		c.expires[key] = time.Now().Add(expiration)
+		c.expires[key] = c.now().Add(expiration)
 	} else {
 		delete(c.expires, key)
 	}
@@ -252,8 +796,16 @@ func (c *lruCache) UpdateExpiration(key string, expiration time.Duration) bool {
 	return true
 }
 
-// UsedBytes 返回当前使用字节数
-func (c *lruCache) UsedBytes(key string) int64 {
+// UsedBytes 返回当前使用字节数，严格过期模式下不包含过期项
+func (c *lruCache) UsedBytes() int64 {
+	if c.strictExpiry {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.purgeExpired()
+		return c.usedBytes
+	}
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -261,7 +813,7 @@ func (c *lruCache) UsedBytes(key string) int64 {
 }
 
 // MaxBytes 返回最大允许字节数
-func (c *lruCache) MaxBytes(key string) int64 {
+func (c *lruCache) MaxBytes() int64 {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -270,11 +822,72 @@ func (c *lruCache) MaxBytes(key string) int64 {
 
 // SetMaxBytes 设置最大允许字节数
 func (c *lruCache) SetMaxBytes(maxBytes int64) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	c.maxBytes = maxBytes
 	if maxBytes > 0 {
 		c.evict()
 	}
-}
\ No newline at end of file
+}
+
+// EvictBytes 按最久未使用顺序淘汰缓存项，直到释放至少 n 字节，返回实际释放的字节数
+func (c *lruCache) EvictBytes(n int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var freed int64
+	for freed < n && c.list.Len() > 0 {
+		before := c.usedBytes
+		c.evictElement(c.victim())
+		freed += before - c.usedBytes
+	}
+	return freed
+}
+
+// inflight 记录进行中的后台任务，零值可用
+type inflight struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{} // 等待者关注的通道，任务全部完成时关闭
+}
+
+// run 在新协程中执行 f
+func (f *inflight) run(fn func()) {
+	f.mu.Lock()
+	f.n++
+	f.mu.Unlock()
+
+	go func() {
+		defer f.done()
+		fn()
+	}()
+}
+
+// done 结束一个任务，全部完成时唤醒等待者
+func (f *inflight) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.n--
+	if f.n == 0 && f.idle != nil {
+		close(f.idle)
+		f.idle = nil
+	}
+}
+
+// wait 等待调用时进行中的任务及其间新增的任务全部完成
+func (f *inflight) wait() {
+	f.mu.Lock()
+	if f.n == 0 {
+		f.mu.Unlock()
+		return
+	}
+	if f.idle == nil {
+		f.idle = make(chan struct{})
+	}
+	idle := f.idle
+	f.mu.Unlock()
+
+	<-idle
+}
@@ -1,7 +1,7 @@
 package store
 
 import (
-	"fmt"
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,6 +14,23 @@ type lru2Store struct {
 	onEvicted     func(key string, value Value)
 	cleanupTicker *time.Ticker
 	mask          int32
+	shardHash     func(key string) uint32 // 计算键所属桶的哈希函数
+	maxAge        int64                   // 最大存活时间（纳秒），0 表示不限制
+	strictExpiry  bool                    // 严格过期，统计前同步清理过期项
+	cleanupBatch  int                     // 每次定期清理每个桶最多检查的项数，0 表示检查全部
+	promoteAfter  uint32                  // 一级缓存中的项晋升到二级缓存所需的访问次数，<= 1 表示首次命中即晋升
+	promoteWindow int64                   // 统计访问次数的时间窗口（纳秒），0 表示不限制
+	noPromote     bool                    // 读取不调整顺序也不晋升，按写入顺序淘汰
+	sweepPos      []uint32                // 每个桶下次清理的起始位置，高位为缓存级别，低 16 位为节点位置
+	sweepWorkers  int                     // 定期清理的并发协程数，不超过桶数
+	version       uint64                  // 最近分配的版本号，原子操作，每次写入递增
+	maxBytes      int64                   // 最大允许字节数，原子操作，按桶平均分配，0 表示不限制
+	statsMu       sync.Mutex
+	cleanupStats  CleanupStats  // 定期清理统计
+	counters      statsCounters // 访问统计
+	loads         LoadGroup     // 合并同一个键的并发加载
+	closeCh       chan struct{} // 关闭清理协程
+	closeOnce     sync.Once
 }
 
 // newLRU2Cache 创建 LRU2Store 实例
@@ -30,6 +47,9 @@ func newLRU2Cache(opts Options) *lru2Store {
 	if opts.CleanupInterval <= 0 {
 		opts.CleanupInterval = time.Minute
 	}
+	if opts.ShardHash == nil {
+		opts.ShardHash = func(key string) uint32 { return uint32(hashBKRD(key)) }
+	}
 
 	mask := maskOfNextPowOf2(opts.BucketCount)
 	s := &lru2Store{
@@ -38,6 +58,16 @@ func newLRU2Cache(opts Options) *lru2Store {
 		onEvicted:     opts.OnEvicted,
 		cleanupTicker: time.NewTicker(opts.CleanupInterval),
 		mask:          int32(mask),
+		shardHash:     opts.ShardHash,
+		maxAge:        int64(opts.MaxAge),
+		strictExpiry:  opts.StrictExpiry,
+		cleanupBatch:  opts.CleanupBatch,
+		promoteAfter:  uint32(max(opts.PromoteAfter, 0)),
+		promoteWindow: int64(opts.PromoteWindow),
+		noPromote:     opts.NoPromote,
+		sweepPos:      make([]uint32, mask+1),
+		sweepWorkers:  min(max(opts.CleanupWorkers, 1), int(mask)+1),
+		closeCh:       make(chan struct{}),
 	}
 
 	for i := range s.caches {
@@ -54,41 +84,134 @@ func newLRU2Cache(opts Options) *lru2Store {
 
 // Get
 func (s *lru2Store) Get(key string) (Value, bool) {
-	idx := hashBKRD(key) & s.mask
+	value, _, ok := s.GetWithVersion(key)
+	return value, ok
+}
+
+// GetWithVersion 获取缓存值及其版本号
+func (s *lru2Store) GetWithVersion(key string) (Value, uint64, bool) {
+	idx := s.bucket(key)
+	s.locks[idx].Lock()
+	defer s.locks[idx].Unlock()
+
+	n := s.lookup(key, idx)
+	s.counters.lookup(n != nil)
+	if n == nil {
+		return nil, 0, false
+	}
+	return n.value, n.version, true
+}
+
+// Peek 实现Store接口，不调整链表位置，也不从一级缓存晋升到二级缓存
+// 一级缓存中的项比二级缓存中的同名旧项更新，优先返回一级缓存中的值
+func (s *lru2Store) Peek(key string) (Value, bool) {
+	idx := s.bucket(key)
 	s.locks[idx].Lock()
 	defer s.locks[idx].Unlock()
 
 	currentTime := Now()
+	for _, c := range s.caches[idx] {
+		if n := c.peek(key); n != nil {
+			if currentTime >= n.expireAt || s.aged(n, currentTime) {
+				return nil, false
+			}
+			return n.value, true
+		}
+	}
+	return nil, false
+}
+
+// Contains 实现Store接口，与 Peek 相同，不调整链表位置也不晋升
+func (s *lru2Store) Contains(key string) bool {
+	_, ok := s.Peek(key)
+	return ok
+}
+
+// GetOrLoad 实现Store接口，加载期间不持有桶的锁
+func (s *lru2Store) GetOrLoad(key string, loader LoadFunc) (Value, error) {
+	return s.loads.GetOrLoad(s, key, loader)
+}
 
-	// 查找一级缓存，命中会触发移动（未过期）或删除（已过期）
-	n1, status1, expireAt := s.caches[idx][0].del(key)
-	if status1 > 0 {
+// GetAndTouch 实现Store接口，命中时在同一次加锁中将过期时间重置为 now + newTTL
+// newTTL <= 0 或为 Forever 时永不过期
+func (s *lru2Store) GetAndTouch(key string, newTTL time.Duration) (Value, bool) {
+	idx := s.bucket(key)
+	s.locks[idx].Lock()
+	defer s.locks[idx].Unlock()
+
+	n := s.lookup(key, idx)
+	s.counters.lookup(n != nil)
+	if n == nil {
+		return nil, false
+	}
+	n.expireAt = expireAtAfter(newTTL)
+	return n.value, true
+}
+
+// lookup 查找键对应的有效节点并按访问调整所在级别，过期时删除并返回 nil，调用此方法必须持有锁
+func (s *lru2Store) lookup(key string, idx int32) *node {
+	currentTime := Now()
+
+	// 查找一级缓存，命中会触发移动（达到晋升次数）或删除（已过期）
+	if n1 := s.caches[idx][0].peek(key); n1 != nil {
 		// 从一级缓存找到项目
-		if expireAt > 0 && currentTime >= expireAt {
+		expireAt := n1.expireAt
+		if currentTime >= expireAt || s.aged(n1, currentTime) {
 			// 项目已过期，删除它
-			s.delete(key, idx)
-			fmt.Println("找到条目已过期，并删除")
-			return nil, false
+			s.expire(key, idx)
+			return nil
+		}
+		// 不调整顺序时留在一级缓存的原位置
+		if s.noPromote {
+			return n1
 		}
-		// 项目有效，将其移至二级缓存
-		s.caches[idx][1].put(key, n1.value, expireAt, s.onEvicted)
-		fmt.Println("条目有效，移至二级缓存")
-		return n1.value, true
+		// 访问次数不足，留在一级缓存，只调整到链表头部
+		if !s.promote(n1, currentTime) {
+			s.caches[idx][0].get(key)
+			return n1
+		}
+		// 项目有效，将其移至二级缓存，保留原写入时间和版本号
+		s.caches[idx][0].del(key)
+		s.caches[idx][1].put(key, n1.value, expireAt, s.evicted)
+		n := s.caches[idx][1].peek(key)
+		n.createdAt = n1.createdAt
+		n.version = n1.version
+		return n
 	}
 
-	// 查找二级缓存
-	n2, status2 := s.get(key, idx, 1)
-	if n2 != nil && status2 > 0 {
-		if n2.expireAt > 0 && currentTime >= n2.expireAt {
+	// 查找二级缓存，直接读取节点以便过期的项在此删除并计入过期数
+	if n2 := s.caches[idx][1].peek(key); n2 != nil {
+		if currentTime >= n2.expireAt || s.aged(n2, currentTime) {
 			// 项目已过期，删除它
-			s.delete(key, idx)
-			fmt.Println("找到条目已过期，并删除")
-			return nil, false
+			s.expire(key, idx)
+			return nil
 		}
-		return n2.value, true
+		s.caches[idx][1].get(key)
+		return n2
 	}
 
-	return nil, false
+	return nil
+}
+
+// promote 记录一级缓存中节点的一次访问，返回是否达到晋升到二级缓存的访问次数
+// 只被访问一次的项（如扫描）留在一级缓存中被淘汰，不会挤占二级缓存，调用此方法必须持有锁
+func (s *lru2Store) promote(n *node, currentTime int64) bool {
+	if s.promoteAfter <= 1 {
+		return true
+	}
+	if s.promoteWindow > 0 && currentTime-n.firstHit >= s.promoteWindow {
+		n.hits = 0
+	}
+	if n.hits == 0 {
+		n.firstHit = currentTime
+	}
+	n.hits++
+	return n.hits >= s.promoteAfter
+}
+
+// aged 判断节点是否超过最大存活时间
+func (s *lru2Store) aged(n *node, currentTime int64) bool {
+	return s.maxAge > 0 && currentTime-n.createdAt >= s.maxAge
 }
 
 // get 从指定缓存桶和缓存级别中，获取指定键对应的缓存节点
@@ -105,9 +228,30 @@ func (s *lru2Store) get(key string, idx, level int32) (*node, int) {
 	return nil, 0
 }
 
-// 常量表示永不过期
+// 常量表示永不过期，作为 SetWithExpiration 的过期时间传入时不会过期
 const Forever = time.Duration(0x7FFFFFFFF)
 
+// neverExpire 永不过期的项的过期时间戳，expireAt 为 0 表示已删除，不能用 0 表示永不过期
+const neverExpire = math.MaxInt64
+
+// expireAtAfter 返回 expiration 之后的过期时间戳
+// Forever 和未指定过期时间都表示永不过期，与 lruCache 保持一致
+func expireAtAfter(expiration time.Duration) int64 {
+	if expiration <= 0 || expiration == Forever {
+		return neverExpire
+	}
+	// now() 返回纳秒时间戳，确保 expiration 也是纳秒单位
+	return Now() + int64(expiration.Nanoseconds())
+}
+
+// expireTime 将过期时间戳转换为 time.Time，永不过期时返回零值
+func expireTime(expireAt int64) time.Time {
+	if expireAt == neverExpire {
+		return time.Time{}
+	}
+	return time.Unix(0, expireAt)
+}
+
 // Set 实现Store接口
 func (s *lru2Store) Set(key string, value Value) error {
 	return s.SetWithExpiration(key, value, Forever)
@@ -115,24 +259,67 @@ func (s *lru2Store) Set(key string, value Value) error {
 
 // SetWithExpiration 实现Store接口
 func (s *lru2Store) SetWithExpiration(key string, value Value, expiration time.Duration) error {
-	expireAt := int64(0)
-	if expiration > 0 {
-		// now() 返回纳秒时间戳，确保 expiration 也是纳秒单位
-		expireAt = Now() + int64(expiration.Nanoseconds())
+	// 与 lruCache 保持一致，nil 值视为删除
+	if value == nil {
+		s.Delete(key)
+		return nil
 	}
 
-	idx := hashBKRD(key) & s.mask
+	idx := s.bucket(key)
 	s.locks[idx].Lock()
 	defer s.locks[idx].Unlock()
 
-	s.caches[idx][0].put(key, value, expireAt, s.onEvicted)
+	s.set(key, idx, value, expiration)
 
 	return nil
 }
 
+// SetIfVersion 当前版本号等于 expectedVersion 时写入，键不存在或已过期时版本号视为 0
+// value 为 nil 时删除该键，expiration 为 0 时与 Set 相同
+func (s *lru2Store) SetIfVersion(key string, value Value, expectedVersion uint64, expiration time.Duration) (bool, error) {
+	idx := s.bucket(key)
+	s.locks[idx].Lock()
+	defer s.locks[idx].Unlock()
+
+	// 一级缓存中的项比二级缓存中的同名旧项更新
+	currentTime := Now()
+	var current uint64
+	for _, c := range s.caches[idx] {
+		if n := c.peek(key); n != nil {
+			if currentTime < n.expireAt && !s.aged(n, currentTime) {
+				current = n.version
+			}
+			break
+		}
+	}
+	if current != expectedVersion {
+		return false, nil
+	}
+
+	if value == nil {
+		s.delete(key, idx)
+		return true, nil
+	}
+	// 与 Set 保持一致，未指定过期时间时永不过期
+	if expiration <= 0 {
+		expiration = Forever
+	}
+	s.set(key, idx, value, expiration)
+	return true, nil
+}
+
+// set 写入一级缓存并分配新的版本号，调用此方法必须持有锁
+func (s *lru2Store) set(key string, idx int32, value Value, expiration time.Duration) {
+	s.caches[idx][0].put(key, value, expireAtAfter(expiration), s.evicted)
+	if n := s.caches[idx][0].peek(key); n != nil {
+		n.version = atomic.AddUint64(&s.version, 1)
+	}
+	s.trim(idx)
+}
+
 // Delete 实现Store接口
 func (s *lru2Store) Delete(key string) bool {
-	idx := hashBKRD(key) & s.mask
+	idx := s.bucket(key)
 	s.locks[idx].Lock()
 	defer s.locks[idx].Unlock()
 
@@ -156,43 +343,293 @@ func (s *lru2Store) delete(key string, idx int32) bool {
 	return deleted
 }
 
+// expire 删除过期或超过最大存活时间的键，调用此方法必须持有锁
+func (s *lru2Store) expire(key string, idx int32) bool {
+	if !s.delete(key, idx) {
+		return false
+	}
+	atomic.AddInt64(&s.counters.expirations, 1)
+	return true
+}
+
+// evicted 作为 put 的淘汰回调，记录因容量不足被替换的项
+func (s *lru2Store) evicted(key string, value Value) {
+	atomic.AddInt64(&s.counters.evictions, 1)
+	if s.onEvicted != nil {
+		s.onEvicted(key, value)
+	}
+}
+
+// withBuckets 按所属的桶对键计数排序后依次以键在 keys 中的下标调用 fn，同一个桶的键相邻，每个桶只加锁一次
+// fn 调用期间持有 idx 对应桶的锁
+func (s *lru2Store) withBuckets(keys []string, fn func(i int, idx int32)) {
+	idxs := make([]int32, len(keys))
+	starts := make([]int, len(s.caches)+1)
+	for i, key := range keys {
+		idxs[i] = s.bucket(key)
+		starts[idxs[i]+1]++
+	}
+	for i := 1; i < len(starts); i++ {
+		starts[i] += starts[i-1]
+	}
+	order := make([]int, len(keys))
+	for i, idx := range idxs {
+		order[starts[idx]] = i
+		starts[idx]++
+	}
+
+	locked := int32(-1)
+	for _, i := range order {
+		if idxs[i] != locked {
+			if locked >= 0 {
+				s.locks[locked].Unlock()
+			}
+			locked = idxs[i]
+			s.locks[locked].Lock()
+		}
+		fn(i, locked)
+	}
+	if locked >= 0 {
+		s.locks[locked].Unlock()
+	}
+}
+
+// GetMulti 实现Store接口，按桶分组，每个桶只加锁一次
+func (s *lru2Store) GetMulti(keys []string) map[string]Value {
+	values := make(map[string]Value, len(keys))
+	hits := 0
+	s.withBuckets(keys, func(i int, idx int32) {
+		if n := s.lookup(keys[i], idx); n != nil {
+			values[keys[i]] = n.value
+			hits++
+		}
+	})
+	atomic.AddInt64(&s.counters.hits, int64(hits))
+	atomic.AddInt64(&s.counters.misses, int64(len(keys)-hits))
+	return values
+}
+
+// SetMulti 实现Store接口，按桶分组，每个桶只加锁一次
+func (s *lru2Store) SetMulti(items map[string]Value) error {
+	keys := make([]string, 0, len(items))
+	values := make([]Value, 0, len(items))
+	for key, value := range items {
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+
+	s.withBuckets(keys, func(i int, idx int32) {
+		if values[i] != nil {
+			s.set(keys[i], idx, values[i], Forever)
+		} else {
+			s.delete(keys[i], idx)
+		}
+	})
+	return nil
+}
+
+// DeleteMulti 实现Store接口，按桶分组，每个桶只加锁一次
+func (s *lru2Store) DeleteMulti(keys []string) int {
+	deleted := 0
+	s.withBuckets(keys, func(i int, idx int32) {
+		if s.delete(keys[i], idx) {
+			deleted++
+		}
+	})
+	return deleted
+}
+
+// Rename 实现Store接口，移动后的项写入新键所在桶的一级缓存，保留过期时间和写入时间
+func (s *lru2Store) Rename(oldKey, newKey string) bool {
+	oi, ni := s.bucket(oldKey), s.bucket(newKey)
+
+	// 按桶序号加锁，避免方向相反的并发重命名死锁
+	first, second := min(oi, ni), max(oi, ni)
+	s.locks[first].Lock()
+	defer s.locks[first].Unlock()
+	if second != first {
+		s.locks[second].Lock()
+		defer s.locks[second].Unlock()
+	}
+
+	// 一级缓存中的项比二级缓存中的同名旧项更新
+	var src *node
+	for _, c := range s.caches[oi] {
+		if src = c.peek(oldKey); src != nil {
+			break
+		}
+	}
+	currentTime := Now()
+	if src == nil || currentTime >= src.expireAt || s.aged(src, currentTime) {
+		return false
+	}
+	if oldKey == newKey {
+		return true
+	}
+
+	value, expireAt, createdAt := src.value, src.expireAt, src.createdAt
+
+	// 移除旧键和被覆盖的新键，不触发淘汰回调
+	for _, c := range s.caches[oi] {
+		c.del(oldKey)
+	}
+	for _, c := range s.caches[ni] {
+		c.del(newKey)
+	}
+
+	s.caches[ni][0].put(newKey, value, expireAt, s.evicted)
+	defer s.trim(ni)
+	if n := s.caches[ni][0].peek(newKey); n != nil {
+		n.createdAt = createdAt
+		n.version = atomic.AddUint64(&s.version, 1)
+	}
+	return true
+}
+
 // Clear 实现Store接口
+// 逐个桶在持有锁时删除，同时存在于两级缓存的键只触发一次 onEvicted，值为一级缓存中较新的值
 func (s *lru2Store) Clear() {
-	keys := make(map[string]struct{})
-
 	for i := range s.caches {
 		s.locks[i].Lock()
 
+		keys := make(map[string]struct{})
 		walker := func(key string, value Value, expireAt int64) bool {
 			keys[key] = struct{}{}
 			return true
 		}
+		s.caches[i][0].walk(walker)
+		s.caches[i][1].walk(walker)
+
+		for key := range keys {
+			s.delete(key, int32(i))
+		}
+
+		s.locks[i].Unlock()
+	}
+}
 
+// UsedBytes 返回所有桶中键和值占用的字节数，同时存在于两级缓存的键按一级缓存中的值计算一次
+func (s *lru2Store) UsedBytes() int64 {
+	var used int64
+	for i := range s.caches {
+		s.locks[i].Lock()
+
+		seen := make(map[string]struct{})
+		walker := func(key string, value Value, expireAt int64) bool {
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				used += int64(len(key) + value.Len())
+			}
+			return true
+		}
 		s.caches[i][0].walk(walker)
 		s.caches[i][1].walk(walker)
 
 		s.locks[i].Unlock()
 	}
+	return used
+}
 
-	for key := range keys {
-		s.Delete(key)
+// MaxBytes 返回最大允许字节数，0 表示不限制
+func (s *lru2Store) MaxBytes() int64 {
+	return atomic.LoadInt64(&s.maxBytes)
+}
+
+// SetMaxBytes 设置最大允许字节数并淘汰超出的项，maxBytes <= 0 表示不限制
+// 容量按桶平均分配，每个桶独立淘汰，与 CapPerBucket 一样，键分布不均时实际可用的容量小于 maxBytes
+func (s *lru2Store) SetMaxBytes(maxBytes int64) {
+	atomic.StoreInt64(&s.maxBytes, max(maxBytes, 0))
+	if maxBytes <= 0 {
+		return
+	}
+	for i := range s.caches {
+		s.locks[i].Lock()
+		s.trim(int32(i))
+		s.locks[i].Unlock()
+	}
+}
+
+// trim 桶的占用超过分配的容量时从最久未使用的项开始淘汰，调用此方法必须持有锁
+func (s *lru2Store) trim(idx int32) {
+	limit := atomic.LoadInt64(&s.maxBytes)
+	if limit <= 0 {
+		return
+	}
+	budget := max(limit/int64(len(s.caches)), 1)
+	for s.caches[idx][0].bytes+s.caches[idx][1].bytes > budget {
+		if _, ok := s.evictOldest(idx); !ok {
+			return
+		}
+	}
+}
+
+// evictOldest 淘汰桶中最久未使用的项，先淘汰一级缓存，返回释放的字节数，桶为空时返回 false
+// 同时存在于两级缓存的键一并删除，与 UsedBytes 一致按一级缓存中的值计算释放的字节数，调用此方法必须持有锁
+func (s *lru2Store) evictOldest(idx int32) (int64, bool) {
+	for _, c := range s.caches[idx] {
+		n := c.back()
+		if n == nil {
+			continue
+		}
+		key, freed := n.key, nodeBytes(n.key, n.value)
+		s.delete(key, idx)
+		atomic.AddInt64(&s.counters.evictions, 1)
+		return freed, true
+	}
+	return 0, false
+}
+
+// EvictBytes 轮流从每个桶淘汰最久未使用的项，直到释放至少 n 字节，返回实际释放的字节数
+func (s *lru2Store) EvictBytes(n int64) int64 {
+	var freed int64
+	for freed < n {
+		evicted := false
+		for i := range s.caches {
+			if freed >= n {
+				break
+			}
+			s.locks[i].Lock()
+			b, ok := s.evictOldest(int32(i))
+			s.locks[i].Unlock()
+			freed += b
+			evicted = evicted || ok
+		}
+		if !evicted {
+			break
+		}
 	}
+	return freed
 }
 
-// Len 实现Store接口
+// Len 实现Store接口，返回未过期的项数，过期但尚未清理的项不计入也不删除，严格过期模式下同时清理过期项
+// 需要遍历所有桶，耗时与项数成正比
 func (s *lru2Store) Len() int {
 	cnt := 0
+	currentTime := Now()
 
 	for i := range s.caches {
 		s.locks[i].Lock()
 
-		walker := func(key string, value Value, expireAt int64) bool {
-			cnt++
-			return true
+		var expireKeys []string
+		for _, c := range s.caches[i] {
+			for idx := c.dlnk[0][suc]; idx != 0; idx = c.dlnk[idx][suc] {
+				n := &c.m[idx-1]
+				if n.expireAt <= 0 {
+					continue // 已删除
+				}
+				if currentTime >= n.expireAt || s.aged(n, currentTime) {
+					if s.strictExpiry {
+						expireKeys = append(expireKeys, n.key)
+					}
+					continue
+				}
+				cnt++
+			}
 		}
 
-		s.caches[i][0].walk(walker)
-		s.caches[i][1].walk(walker)
+		for _, key := range expireKeys {
+			s.expire(key, int32(i))
+		}
 
 		s.locks[i].Unlock()
 	}
@@ -200,41 +637,289 @@ func (s *lru2Store) Len() int {
 	return cnt
 }
 
+// Keys 实现Store接口，遍历每个桶的两级缓存，同时存在于两级的键只返回一次
+// 一级缓存中的项比二级缓存中的同名旧项更新，以一级缓存中的项判断是否过期
+func (s *lru2Store) Keys() []string {
+	var keys []string
+	currentTime := Now()
+
+	for i := range s.caches {
+		s.locks[i].Lock()
+
+		seen := make(map[string]struct{})
+		for _, c := range s.caches[i] {
+			for idx := c.dlnk[0][suc]; idx != 0; idx = c.dlnk[idx][suc] {
+				n := &c.m[idx-1]
+				if n.expireAt <= 0 {
+					continue // 已删除
+				}
+				if _, ok := seen[n.key]; ok {
+					continue
+				}
+				seen[n.key] = struct{}{}
+
+				if currentTime >= n.expireAt || s.aged(n, currentTime) {
+					continue
+				}
+				keys = append(keys, n.key)
+			}
+		}
+
+		s.locks[i].Unlock()
+	}
+	return keys
+}
+
+// ForEach 实现Store接口，一级缓存中的项优先于二级缓存中的同名旧项
+func (s *lru2Store) ForEach(fn func(key string, value Value, expireAt time.Time) bool) {
+	currentTime := Now()
+
+	for i := range s.caches {
+		s.locks[i].Lock()
+
+		seen := make(map[string]struct{})
+		for _, c := range s.caches[i] {
+			for idx := c.dlnk[0][suc]; idx != 0; idx = c.dlnk[idx][suc] {
+				n := &c.m[idx-1]
+				if n.expireAt <= 0 {
+					continue // 已删除
+				}
+				if _, ok := seen[n.key]; ok {
+					continue
+				}
+				seen[n.key] = struct{}{}
+
+				if currentTime >= n.expireAt || s.aged(n, currentTime) {
+					continue
+				}
+				if !fn(n.key, n.value, expireTime(n.expireAt)) {
+					s.locks[i].Unlock()
+					return
+				}
+			}
+		}
+
+		s.locks[i].Unlock()
+	}
+}
+
+// Scan 实现Store接口
+// 游标高位为桶与缓存级别的序号，低 16 位为节点在数组中的位置；每次调用只在当前桶内持有锁
+// 节点位置固定，整个遍历期间一直存在且未被访问移动的键至少会被返回一次，同一个键可能在两级缓存中各返回一次
+func (s *lru2Store) Scan(cursor uint64, count int) ([]string, uint64) {
+	if count <= 0 {
+		count = 10
+	}
+
+	currentTime := Now()
+	keys := make([]string, 0, count)
+	level, slot := int(cursor>>16), int(cursor&0xFFFF)
+
+	for ; level < 2*len(s.caches); level, slot = level+1, 0 {
+		idx := level / 2
+		s.locks[idx].Lock()
+		c := s.caches[idx][level%2]
+		for ; slot < int(c.last) && len(keys) < count; slot++ {
+			n := &c.m[slot]
+			if n.expireAt <= 0 || currentTime >= n.expireAt || s.aged(n, currentTime) {
+				continue
+			}
+			keys = append(keys, n.key)
+		}
+		full := slot < int(c.last)
+		s.locks[idx].Unlock()
+
+		if full {
+			return keys, uint64(level)<<16 | uint64(slot)
+		}
+		if len(keys) >= count {
+			// 当前缓存已遍历完，从下一个缓存开始
+			if level+1 < 2*len(s.caches) {
+				return keys, uint64(level+1) << 16
+			}
+			return keys, 0
+		}
+	}
+
+	return keys, 0
+}
+
 // Close 实现Store接口
 func (s *lru2Store) Close() {
-	if s.cleanupTicker != nil {
-		s.cleanupTicker.Stop()
-	}
+	s.closeOnce.Do(func() {
+		if s.cleanupTicker != nil {
+			s.cleanupTicker.Stop()
+		}
+		close(s.closeCh)
+	})
 }
 
 // cleanupLoop
 func (s *lru2Store) cleanupLoop() {
-	for range s.cleanupTicker.C {
-		currentTime := Now()
+	for {
+		select {
+		case <-s.cleanupTicker.C:
+			s.sweep()
+		case <-s.closeCh:
+			return
+		}
+	}
+}
 
-		for i := range s.caches {
-			s.locks[i].Lock()
+// sweep 清理所有桶的过期项并记录统计信息，等待所有清理协程结束后返回
+// 设置了 cleanupBatch 时，每个桶每次最多检查 cleanupBatch 个节点，下次从上次停止的位置继续，
+// 避免大桶在一次清理中长时间持有锁
+func (s *lru2Store) sweep() {
+	start := time.Now()
+	currentTime := Now()
+	examined, reaped := 0, 0
+
+	if s.sweepWorkers <= 1 {
+		examined, reaped = s.sweepBuckets(0, 1, currentTime)
+	} else {
+		results := make([][2]int, s.sweepWorkers)
+		var wg sync.WaitGroup
+		for w := range s.sweepWorkers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[w][0], results[w][1] = s.sweepBuckets(w, s.sweepWorkers, currentTime)
+			}()
+		}
+		wg.Wait()
+
+		for _, r := range results {
+			examined += r[0]
+			reaped += r[1]
+		}
+	}
 
-			expireKeys := make(map[string]struct{})
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
 
-			walker := func(key string, value Value, expireAt int64) bool {
-				if expireAt > 0 && currentTime >= expireAt {
-					expireKeys[key] = struct{}{}
+	s.cleanupStats.Sweeps++
+	s.cleanupStats.LastReaped = reaped
+	s.cleanupStats.LastExamined = examined
+	s.cleanupStats.LastDuration = time.Since(start)
+}
+
+// sweepBuckets 清理下标除以 workers 余 worker 的桶，不同 worker 负责的桶互不重叠
+// 每次只持有一个桶的锁
+func (s *lru2Store) sweepBuckets(worker, workers int, currentTime int64) (examined, reaped int) {
+	for i := worker; i < len(s.caches); i += workers {
+		s.locks[i].Lock()
+		e, r := s.sweepBucket(int32(i), currentTime)
+		s.locks[i].Unlock()
+
+		examined += e
+		reaped += r
+	}
+	return examined, reaped
+}
+
+// Sweep 同步清理所有桶中过期或超过最大存活时间的项，不受 CleanupBatch 限制
+func (s *lru2Store) Sweep() {
+	currentTime := Now()
+
+	for i := range s.caches {
+		s.locks[i].Lock()
+
+		var expireKeys []string
+		for _, c := range s.caches[i] {
+			for idx := c.dlnk[0][suc]; idx != 0; idx = c.dlnk[idx][suc] {
+				n := &c.m[idx-1]
+				if n.expireAt > 0 && (currentTime >= n.expireAt || s.aged(n, currentTime)) {
+					expireKeys = append(expireKeys, n.key)
 				}
-				return true
 			}
+		}
+		for _, key := range expireKeys {
+			s.expire(key, int32(i))
+		}
 
-			s.caches[i][0].walk(walker)
-			s.caches[i][1].walk(walker)
+		s.locks[i].Unlock()
+	}
+}
 
-			for key := range expireKeys {
-				s.delete(key, int32(i))
+// sweepBucket 从上次停止的位置按节点数组顺序清理一个桶，每次最多遍历一轮，调用此方法必须持有锁
+// 节点位置固定，删除节点不影响遍历位置
+func (s *lru2Store) sweepBucket(idx int32, currentTime int64) (examined, reaped int) {
+	level, slot := int(s.sweepPos[idx]>>16), int(s.sweepPos[idx]&0xFFFF)
+
+	for s.cleanupBatch <= 0 || examined < s.cleanupBatch {
+		c := s.caches[idx][level]
+		if slot >= int(c.last) {
+			level, slot = level+1, 0
+			if level == len(s.caches[idx]) {
+				// 完成一轮，下次从头开始
+				level = 0
+				break
 			}
+			continue
+		}
 
-			s.locks[i].Unlock()
+		n := &c.m[slot]
+		slot++
+		examined++
+		if n.expireAt > 0 && currentTime >= n.expireAt && s.expire(n.key, idx) {
+			reaped++
+		}
+	}
+
+	s.sweepPos[idx] = uint32(level)<<16 | uint32(slot)
+	return examined, reaped
+}
+
+// GetExpiration 获取缓存项过期时间，不改变缓存项所在的缓存级别
+// 一级缓存中的项比二级缓存中的同名旧项更新，优先返回一级缓存中的过期时间
+// 与 lruCache 一致，永不过期的项返回 false
+func (s *lru2Store) GetExpiration(key string) (time.Time, bool) {
+	idx := s.bucket(key)
+	s.locks[idx].Lock()
+	defer s.locks[idx].Unlock()
+
+	for _, c := range s.caches[idx] {
+		if n := c.peek(key); n != nil {
+			return expireTime(n.expireAt), n.expireAt != neverExpire
 		}
+	}
+	return time.Time{}, false
+}
+
+// 缓存项所在级别的位掩码，同时在两级缓存中时为 Level1|Level2
+const (
+	Level1 = 1 << iota // 一级缓存
+	Level2             // 二级缓存
+)
+
+// Level 返回键所在的缓存级别，不存在时返回 0，不改变缓存项所在的缓存级别，用于调试晋升逻辑
+// 已过期但尚未被清理的项仍计入所在级别
+func (s *lru2Store) Level(key string) int {
+	idx := s.bucket(key)
+	s.locks[idx].Lock()
+	defer s.locks[idx].Unlock()
 
+	level := 0
+	if s.caches[idx][0].peek(key) != nil {
+		level |= Level1
 	}
+	if s.caches[idx][1].peek(key) != nil {
+		level |= Level2
+	}
+	return level
+}
+
+// Stats 实现Store接口，ItemCount 与 Len 相同，需要遍历所有桶
+func (s *lru2Store) Stats() Stats {
+	return s.counters.snapshot(s.Len())
+}
+
+// CleanupStats 返回定期清理的统计信息
+func (s *lru2Store) CleanupStats() CleanupStats {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	return s.cleanupStats
 }
 
 // 内部时钟，减少 time.Now() 调用的造成的 GC 压力
@@ -259,6 +944,11 @@ func init() {
 	}()
 }
 
+// bucket 返回键所属的桶，桶数为 2 的幂，取哈希值的低位
+func (s *lru2Store) bucket(key string) int32 {
+	return int32(s.shardHash(key)) & s.mask
+}
+
 // hashBKRD BKDR 哈希算法，用于计算键的哈希值
 func hashBKRD(s string) (hash int32) {
 	for i := range s {
@@ -283,9 +973,13 @@ func maskOfNextPowOf2(cap uint16) uint16 {
 }
 
 type node struct {
-	key      string
-	value    Value
-	expireAt int64 // 过期时间戳，0表示删除
+	key       string
+	value     Value
+	expireAt  int64  // 过期时间戳，0表示删除
+	createdAt int64  // 写入时间戳
+	version   uint64 // 版本号，每次写入更新
+	hits      uint32 // 在一级缓存中的访问次数，用于判断是否晋升
+	firstHit  int64  // 本轮统计访问次数的起始时间戳
 }
 
 // 双向链表的前驱节点和后继结点
@@ -295,10 +989,19 @@ var pred, suc = uint16(0), uint16(1)
 type cache struct {
 	// dlnk[0] 是哨兵节点，记录链表头尾
 	// dlnk[0][pred]存储尾部索引，dlnk[0][suc]存储头部索引
-	dlnk [][2]uint16       // 双向链表，0 表示前驱，1 表示后继
-	m    []node            // 预分配的节点数组
-	hmap map[string]uint16 // 键与节点索引的映射
-	last uint16            // 最后一个节点元素索引
+	dlnk  [][2]uint16       // 双向链表，0 表示前驱，1 表示后继
+	m     []node            // 预分配的节点数组
+	hmap  map[string]uint16 // 键与节点索引的映射
+	last  uint16            // 最后一个节点元素索引
+	bytes int64             // 有效项的键和值占用的字节数
+}
+
+// nodeBytes 返回项的键和值占用的字节数
+func nodeBytes(key string, value Value) int64 {
+	if value == nil {
+		return int64(len(key))
+	}
+	return int64(len(key) + value.Len())
 }
 
 // Create 创建 cache 实例
@@ -315,7 +1018,12 @@ func Create(cap uint16) *cache {
 func (c *cache) put(key string, value Value, expireAt int64, onEvicted func(string, Value)) int {
 	// 更新
 	if idx, ok := c.hmap[key]; ok {
-		c.m[idx-1].value, c.m[idx-1].expireAt = value, expireAt
+		if c.m[idx-1].expireAt > 0 {
+			c.bytes -= nodeBytes(key, c.m[idx-1].value)
+		}
+		c.bytes += nodeBytes(key, value)
+		c.m[idx-1].value, c.m[idx-1].expireAt, c.m[idx-1].createdAt = value, expireAt, Now()
+		c.m[idx-1].hits = 0
 		c.adjust(idx, pred, suc)
 		return 0
 	}
@@ -323,13 +1031,19 @@ func (c *cache) put(key string, value Value, expireAt int64, onEvicted func(stri
 	// 缓存已满，产生替换
 	if c.last == uint16(cap(c.m)) {
 		tail := &c.m[c.dlnk[0][pred]-1]
-		if onEvicted != nil && tail.expireAt > 0 {
-			onEvicted(tail.key, tail.value)
+		if tail.expireAt > 0 {
+			c.bytes -= nodeBytes(tail.key, tail.value)
+			if onEvicted != nil {
+				onEvicted(tail.key, tail.value)
+			}
 		}
+		c.bytes += nodeBytes(key, value)
 
+		// 先复用尾部节点再移动到头部，移动后 c.dlnk[0][pred] 不再指向该节点
 		delete(c.hmap, tail.key)
-		c.adjust(c.dlnk[0][pred], pred, suc)
 		c.hmap[key], tail.key, tail.value, tail.expireAt = c.dlnk[0][pred], key, value, expireAt
+		tail.createdAt, tail.hits = Now(), 0
+		c.adjust(c.dlnk[0][pred], pred, suc)
 		return 1
 	}
 
@@ -346,7 +1060,9 @@ func (c *cache) put(key string, value Value, expireAt int64, onEvicted func(stri
 	c.dlnk[0][suc] = c.last // 哨兵->新头
 
 	c.hmap[key] = c.last
+	c.bytes += nodeBytes(key, value)
 	c.m[c.last-1].key, c.m[c.last-1].value, c.m[c.last-1].expireAt = key, value, expireAt
+	c.m[c.last-1].createdAt, c.m[c.last-1].hits = Now(), 0
 
 	return 1
 }
@@ -378,10 +1094,19 @@ func (c *cache) get(key string) (*node, int) {
 	return nil, 0
 }
 
+// peek 获取键对应的有效节点，不调整链表位置
+func (c *cache) peek(key string) *node {
+	if idx, ok := c.hmap[key]; ok && c.m[idx-1].expireAt > 0 {
+		return &c.m[idx-1]
+	}
+	return nil
+}
+
 // del 从缓存中删除键对应的项
 func (c *cache) del(key string) (*node, int, int64) {
 	if idx, ok := c.hmap[key]; ok && c.m[idx-1].expireAt > 0 {
 		e := c.m[idx-1].expireAt
+		c.bytes -= nodeBytes(key, c.m[idx-1].value)
 		c.m[idx-1].expireAt = 0  // 标记为删除
 		c.adjust(idx, suc, pred) // 移动到链表尾部
 		return &c.m[idx-1], 1, e
@@ -389,12 +1114,26 @@ func (c *cache) del(key string) (*node, int, int64) {
 	return nil, 0, 0
 }
 
+// back 返回链表尾部最久未使用的有效节点，没有有效项时返回 nil
+func (c *cache) back() *node {
+	for idx := c.dlnk[0][pred]; idx != 0; idx = c.dlnk[idx][pred] {
+		if n := &c.m[idx-1]; n.expireAt > 0 {
+			return n
+		}
+	}
+	return nil
+}
+
 // walk 遍历缓存中的所有有效项
 func (c *cache) walk(walker func(key string, value Value, expireAt int64) bool) {
 	for idx := c.dlnk[0][suc]; idx != 0; idx = c.dlnk[idx][suc] {
 		n := c.m[idx-1]
-		// 缓存已被删除 or walker 函数返回 false
-		if n.expireAt <= 0 || !walker(n.key, n.value, n.expireAt) {
+		// 跳过已删除的项，被访问过的已删除项可能不在链表尾部
+		if n.expireAt <= 0 {
+			continue
+		}
+		// walker 函数返回 false
+		if !walker(n.key, n.value, n.expireAt) {
 			return
 		}
 	}
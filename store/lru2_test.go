@@ -2,8 +2,11 @@ package store
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
 	// "strconv"
-	// "sync"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -328,6 +331,28 @@ func TestCacheWalk(t *testing.T) {
 	}
 }
 
+// 测试walk跳过被访问后移到链表头部的已删除项，继续遍历后面的有效项
+func TestCacheWalkSkipsDeleted(t *testing.T) {
+	c := Create(5)
+
+	c.put("key1", testValue("value1"), Now()+int64(time.Hour), nil)
+	c.put("key2", testValue("value2"), Now()+int64(time.Hour), nil)
+	c.put("key3", testValue("value3"), Now()+int64(time.Hour), nil)
+
+	// 删除的项移到链表尾部，get 仍会把它移到链表头部
+	c.del("key2")
+	c.get("key2")
+
+	var keys []string
+	c.walk(func(key string, value Value, expireAt int64) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if len(keys) != 2 || !contains(keys, "key1") || !contains(keys, "key3") {
+		t.Errorf("Expected walk to skip the deleted head and visit key1 and key3, got %v", keys)
+	}
+}
+
 // 测试adjust方法
 func TestCacheAdjust(t *testing.T) {
 	c := Create(5)
@@ -461,6 +486,15 @@ func TestLRU2StoreLRUEviction(t *testing.T) {
 	// }
 }
 
+// 测试全局时钟在后台按 100ms 精度推进
+func TestLRU2ClockAdvances(t *testing.T) {
+	start := Now()
+	time.Sleep(250 * time.Millisecond)
+	if elapsed := time.Duration(Now() - start); elapsed < 100*time.Millisecond {
+		t.Fatalf("Expected clock to advance at least 100ms, got %v", elapsed)
+	}
+}
+
 // 测试过期时间
 func TestLRU2StoreExpiration(t *testing.T) {
 	opts := Options{
@@ -514,6 +548,38 @@ func TestLRU2StoreExpiration(t *testing.T) {
 	}
 }
 
+// 测试LRU2Store的最大存活时间
+func TestLRU2StoreMaxAge(t *testing.T) {
+	opts := Options{
+		BucketCount:     1,
+		CapPerBucket:    5,
+		Level2Cap:       5,
+		CleanupInterval: time.Minute,
+		MaxAge:          200 * time.Millisecond,
+	}
+
+	store := newLRU2Cache(opts)
+	defer store.Close()
+
+	store.SetWithExpiration("in-level1", testValue("value"), time.Hour)
+	store.SetWithExpiration("in-level2", testValue("value"), time.Hour)
+
+	// 访问一次，使 in-level2 被移至二级缓存
+	if _, found := store.Get("in-level2"); !found {
+		t.Fatalf("in-level2 should be found before MaxAge elapsed")
+	}
+
+	// 等待超过最大存活时间（内部时钟精度为 100ms）
+	time.Sleep(400 * time.Millisecond)
+
+	if _, found := store.Get("in-level1"); found {
+		t.Errorf("in-level1 should be treated as expired after MaxAge")
+	}
+	if _, found := store.Get("in-level2"); found {
+		t.Errorf("in-level2 should be treated as expired after MaxAge, promotion must keep the original insertion time")
+	}
+}
+
 // 测试LRU2Store的清理循环
 func TestLRU2StoreCleanupLoop(t *testing.T) {
 	opts := Options{
@@ -874,4 +940,1052 @@ func contains(slice []string, str string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+
+// 测试LRU2Store的定期清理统计
+func TestLRU2StoreCleanupStats(t *testing.T) {
+	opts := Options{
+		BucketCount:     1,
+		CapPerBucket:    10,
+		Level2Cap:       10,
+		CleanupInterval: time.Hour,
+	}
+
+	store := newLRU2Cache(opts)
+	defer store.Close()
+
+	for i := range 3 {
+		store.SetWithExpiration(fmt.Sprintf("expires%d", i), testValue("value"), time.Hour)
+	}
+	store.SetWithExpiration("keeps", testValue("value"), time.Hour)
+
+	// 直接将过期时间改为过去的时间，避免等待内部时钟
+	for i := range 3 {
+		store.caches[0][0].peek(fmt.Sprintf("expires%d", i)).expireAt = 1
+	}
+
+	store.sweep()
+
+	stats := store.CleanupStats()
+	if stats.Sweeps != 1 || stats.LastReaped != 3 {
+		t.Fatalf("Expected 1 sweep reaping 3 entries, got %+v", stats)
+	}
+	if stats.LastDuration <= 0 {
+		t.Fatalf("Expected positive sweep duration, got %v", stats.LastDuration)
+	}
+	if _, found := store.Get("keeps"); !found {
+		t.Errorf("keeps should still be valid")
+	}
+}
+
+// 测试读取已过期的二级缓存项时删除该项并计入过期数
+func TestLRU2StoreLevel2Expiration(t *testing.T) {
+	var evicted []string
+	store := newLRU2Cache(Options{
+		BucketCount:     1,
+		CapPerBucket:    10,
+		Level2Cap:       10,
+		CleanupInterval: time.Hour,
+		OnEvicted: func(key string, value Value) {
+			evicted = append(evicted, key)
+		},
+	})
+	defer store.Close()
+
+	store.SetWithExpiration("key", testValue("value"), time.Hour)
+	if _, found := store.Get("key"); !found {
+		t.Fatalf("Expected key to be found")
+	}
+	n := store.caches[0][1].peek("key")
+	if n == nil {
+		t.Fatalf("Expected key to be promoted to level 2")
+	}
+
+	// 直接将过期时间改为过去的时间，避免等待内部时钟
+	n.expireAt = 1
+	if _, found := store.Get("key"); found {
+		t.Fatalf("Expected expired key to be missing")
+	}
+	if got := store.Stats().Expirations; got != 1 {
+		t.Fatalf("Expected 1 expiration, got %d", got)
+	}
+	if store.caches[0][1].peek("key") != nil {
+		t.Fatalf("Expected expired key to be removed from level 2")
+	}
+	if len(evicted) != 1 || evicted[0] != "key" {
+		t.Fatalf("Expected OnEvicted for the expired key, got %v", evicted)
+	}
+}
+
+// 测试LRU2Store的ForEach方法
+func TestLRU2StoreForEach(t *testing.T) {
+	opts := Options{
+		BucketCount:     1,
+		CapPerBucket:    5,
+		Level2Cap:       5,
+		CleanupInterval: time.Hour,
+	}
+
+	store := newLRU2Cache(opts)
+	defer store.Close()
+
+	store.SetWithExpiration("key1", testValue("old"), time.Hour)
+	store.Get("key1") // 移至二级缓存
+	store.SetWithExpiration("key1", testValue("new"), time.Hour)
+	store.SetWithExpiration("key2", testValue("value2"), time.Hour)
+	store.SetWithExpiration("key3", testValue("value3"), time.Hour)
+	store.Delete("key3")
+
+	got := make(map[string]Value)
+	store.ForEach(func(key string, value Value, expireAt time.Time) bool {
+		if _, dup := got[key]; dup {
+			t.Errorf("Key %s visited twice", key)
+		}
+		got[key] = value
+		return true
+	})
+
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 live keys, got %v", got)
+	}
+	// 一级缓存中的新值优先
+	if got["key1"] != testValue("new") {
+		t.Errorf("Expected key1 to report the newer value, got %v", got["key1"])
+	}
+
+	// 提前终止遍历
+	count := 0
+	store.ForEach(func(key string, value Value, expireAt time.Time) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Errorf("Expected ForEach to stop after the first item, got %d", count)
+	}
+}
+
+// 测试LRU2Store写入nil值等同于删除
+func TestLRU2StoreSetNil(t *testing.T) {
+	opts := Options{
+		BucketCount:     1,
+		CapPerBucket:    5,
+		Level2Cap:       5,
+		CleanupInterval: time.Hour,
+	}
+
+	store := newLRU2Cache(opts)
+	defer store.Close()
+
+	// 一级缓存中的键
+	store.Set("key1", testValue("value1"))
+	// 二级缓存中的键
+	store.Set("key2", testValue("value2"))
+	store.Get("key2")
+
+	for _, key := range []string{"key1", "key2", "missing"} {
+		if err := store.Set(key, nil); err != nil {
+			t.Fatalf("Set(%s, nil) returned error: %v", key, err)
+		}
+		if _, found := store.Get(key); found {
+			t.Errorf("Expected %s to be removed after setting nil", key)
+		}
+	}
+
+	// 不会留下值为 nil 的有效项
+	store.ForEach(func(key string, value Value, expireAt time.Time) bool {
+		if value == nil {
+			t.Errorf("Found live entry %s with nil value", key)
+		}
+		return true
+	})
+	if got := store.Len(); got != 0 {
+		t.Errorf("Expected empty store, got %d entries", got)
+	}
+}
+
+// 测试LRU2Store分页遍历覆盖全部键
+func TestLRU2StoreScan(t *testing.T) {
+	opts := Options{
+		BucketCount:     16,
+		CapPerBucket:    8192,
+		Level2Cap:       8192,
+		CleanupInterval: time.Hour,
+	}
+
+	store := newLRU2Cache(opts)
+	defer store.Close()
+
+	const n = 50000
+	for i := range n {
+		store.SetWithExpiration(fmt.Sprintf("key-%d", i), testValue("v"), time.Hour)
+	}
+	// 部分键移至二级缓存
+	for i := 0; i < n; i += 3 {
+		store.Get(fmt.Sprintf("key-%d", i))
+	}
+	store.Delete("key-1")
+
+	seen := scanAll(t, store, 777, nil)
+	for i := range n {
+		key := fmt.Sprintf("key-%d", i)
+		if i == 1 {
+			if seen[key] != 0 {
+				t.Fatalf("Deleted key was returned by Scan")
+			}
+			continue
+		}
+		if seen[key] == 0 {
+			t.Fatalf("Key %s was never returned by Scan", key)
+		}
+	}
+
+	// 空存储
+	empty := newLRU2Cache(opts)
+	defer empty.Close()
+	if keys, next := empty.Scan(0, 10); len(keys) != 0 || next != 0 {
+		t.Fatalf("Expected empty scan, got %v, %d", keys, next)
+	}
+}
+
+// 测试严格过期模式下 Len 立即排除过期项
+func TestLRU2StoreStrictExpiry(t *testing.T) {
+	opts := Options{
+		BucketCount:     1,
+		CapPerBucket:    5,
+		Level2Cap:       5,
+		CleanupInterval: time.Hour, // 不依赖定期清理
+		StrictExpiry:    true,
+	}
+
+	store := newLRU2Cache(opts)
+	defer store.Close()
+
+	store.SetWithExpiration("short", testValue("value"), 100*time.Millisecond)
+	store.SetWithExpiration("long", testValue("value"), time.Hour)
+	if got := store.Len(); got != 2 {
+		t.Fatalf("Expected 2 entries, got %d", got)
+	}
+
+	// 内部时钟精度为 100ms
+	time.Sleep(300 * time.Millisecond)
+
+	if got := store.Len(); got != 1 {
+		t.Fatalf("Expected expired entry to be excluded from Len, got %d", got)
+	}
+	if n := store.caches[0][0].peek("short"); n != nil {
+		t.Fatalf("Expected expired entry to be purged by Len")
+	}
+}
+
+// cacheOrder 按链表从头到尾（最近使用到最久未使用）的顺序返回未删除的键
+func cacheOrder(c *cache) []string {
+	keys := []string{}
+	c.walk(func(key string, value Value, expireAt int64) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// 测试写入、读取和淘汰后链表按最近使用到最久未使用排列
+func TestCacheOrder(t *testing.T) {
+	c := Create(3)
+	for _, key := range []string{"a", "b", "c"} {
+		c.put(key, testValue("v"), neverExpire, nil)
+	}
+	if got := cacheOrder(c); !reflect.DeepEqual(got, []string{"c", "b", "a"}) {
+		t.Fatalf("Expected [c b a] after puts, got %v", got)
+	}
+
+	c.get("a")
+	if got := cacheOrder(c); !reflect.DeepEqual(got, []string{"a", "c", "b"}) {
+		t.Fatalf("Expected [a c b] after get(a), got %v", got)
+	}
+
+	// peek 不调整顺序
+	c.peek("b")
+	if got := cacheOrder(c); !reflect.DeepEqual(got, []string{"a", "c", "b"}) {
+		t.Fatalf("Expected peek to keep [a c b], got %v", got)
+	}
+
+	c.put("d", testValue("v"), neverExpire, nil)
+	if got := cacheOrder(c); !reflect.DeepEqual(got, []string{"d", "a", "c"}) {
+		t.Fatalf("Expected [d a c] after evicting b, got %v", got)
+	}
+}
+
+// 测试 lru2Store 命中后从一级缓存移至二级缓存头部，两级缓存分别保持最近使用顺序
+func TestLRU2StoreOrder(t *testing.T) {
+	s := newLRU2Cache(Options{BucketCount: 1, CapPerBucket: 3, Level2Cap: 3, CleanupInterval: time.Hour})
+	defer s.Close()
+
+	for _, key := range []string{"a", "b", "c"} {
+		s.Set(key, testValue("v"))
+	}
+	if got := cacheOrder(s.caches[0][0]); !reflect.DeepEqual(got, []string{"c", "b", "a"}) {
+		t.Fatalf("Expected level 1 [c b a] after puts, got %v", got)
+	}
+
+	s.Get("a")
+	s.Get("b")
+	if got := cacheOrder(s.caches[0][0]); !reflect.DeepEqual(got, []string{"c"}) {
+		t.Fatalf("Expected level 1 [c] after gets, got %v", got)
+	}
+	if got := cacheOrder(s.caches[0][1]); !reflect.DeepEqual(got, []string{"b", "a"}) {
+		t.Fatalf("Expected level 2 [b a] after gets, got %v", got)
+	}
+
+	s.Get("a")
+	if got := cacheOrder(s.caches[0][1]); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("Expected level 2 [a b] after Get(a), got %v", got)
+	}
+}
+
+// storedEntries 返回实际存放的项数，包含尚未清理的过期项
+func storedEntries(s *lru2Store) int {
+	cnt := 0
+	for i := range s.caches {
+		for _, c := range s.caches[i] {
+			for j := range c.m {
+				if c.m[j].expireAt > 0 {
+					cnt++
+				}
+			}
+		}
+	}
+	return cnt
+}
+
+// 测试分批清理每次检查的项数有上限，多次清理后回收所有过期项
+func TestLRU2StoreIncrementalSweep(t *testing.T) {
+	opts := Options{
+		BucketCount:     2,
+		CapPerBucket:    64,
+		Level2Cap:       64,
+		CleanupInterval: time.Hour,
+		CleanupBatch:    8,
+	}
+
+	store := newLRU2Cache(opts)
+	defer store.Close()
+
+	for i := range 60 {
+		store.SetWithExpiration(fmt.Sprintf("key%d", i), testValue("value"), time.Hour)
+	}
+	// 访问部分键，使其移至二级缓存
+	for i := 0; i < 60; i += 3 {
+		store.Get(fmt.Sprintf("key%d", i))
+	}
+
+	// 除 key0 外全部过期
+	for i := range store.caches {
+		for _, c := range store.caches[i] {
+			for j := range c.m {
+				if c.m[j].expireAt > 0 && c.m[j].key != "key0" {
+					c.m[j].expireAt = 1
+				}
+			}
+		}
+	}
+
+	sweeps := 0
+	for storedEntries(store) > 1 {
+		store.sweep()
+		sweeps++
+
+		stats := store.CleanupStats()
+		if limit := opts.CleanupBatch * len(store.caches); stats.LastExamined > limit {
+			t.Fatalf("Sweep %d examined %d entries, expected at most %d", sweeps, stats.LastExamined, limit)
+		}
+		if sweeps > 20 {
+			t.Fatalf("Expired entries were not reaped after %d sweeps, %d left", sweeps, storedEntries(store))
+		}
+	}
+
+	if sweeps < 2 {
+		t.Errorf("Expected reaping to span several sweeps, took %d", sweeps)
+	}
+	if _, found := store.Get("key0"); !found {
+		t.Errorf("key0 should still be valid")
+	}
+}
+
+// 测试多个清理协程在一次清理中回收所有桶的过期项，清理期间有并发读写
+func TestLRU2StoreCleanupWorkers(t *testing.T) {
+	if s := newLRU2Cache(Options{BucketCount: 2, CleanupWorkers: 8}); s.sweepWorkers != 2 {
+		s.Close()
+		t.Fatalf("Expected workers to be capped at the bucket count, got %d", s.sweepWorkers)
+	} else {
+		s.Close()
+	}
+
+	interval := 200 * time.Millisecond
+	store := newLRU2Cache(Options{
+		BucketCount:     16,
+		CapPerBucket:    256,
+		Level2Cap:       256,
+		CleanupInterval: interval,
+		CleanupWorkers:  4,
+	})
+	defer store.Close()
+
+	for i := range 1000 {
+		store.SetWithExpiration(fmt.Sprintf("expires%d", i), testValue("value"), 50*time.Millisecond)
+	}
+
+	// 清理期间持续读写其他键
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				key := fmt.Sprintf("live%d-%d", w, i%16)
+				store.Set(key, testValue("value"))
+				store.Get(key)
+			}
+		}()
+	}
+
+	// 等待内部时钟越过过期时间后的第一次完整清理
+	time.Sleep(250 * time.Millisecond)
+	sweeps := store.CleanupStats().Sweeps
+	deadline := time.Now().Add(2 * interval)
+	for store.CleanupStats().Sweeps <= sweeps && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(stop)
+	wg.Wait()
+
+	if store.CleanupStats().Sweeps <= sweeps {
+		t.Fatalf("Expected a sweep within %v", 2*interval)
+	}
+	left := 0
+	for i := range store.caches {
+		store.locks[i].Lock()
+		for _, c := range store.caches[i] {
+			for j := range c.m {
+				if c.m[j].expireAt > 0 && strings.HasPrefix(c.m[j].key, "expires") {
+					left++
+				}
+			}
+		}
+		store.locks[i].Unlock()
+	}
+	if left != 0 {
+		t.Fatalf("Expected all expired entries to be reaped in one sweep, %d left", left)
+	}
+}
+
+// 测试LRU2Store的版本号与条件写入
+func TestLRU2StoreVersion(t *testing.T) {
+	opts := Options{
+		BucketCount:     1,
+		CapPerBucket:    5,
+		Level2Cap:       5,
+		CleanupInterval: time.Hour,
+	}
+
+	store := newLRU2Cache(opts)
+	defer store.Close()
+
+	if ok, err := store.SetIfVersion("key", testValue("v1"), 0, 0); !ok || err != nil {
+		t.Fatalf("Expected create with version 0 to succeed, got %v, %v", ok, err)
+	}
+
+	// 首次读取将键移至二级缓存，版本号保持不变
+	_, v1, ok := store.GetWithVersion("key")
+	if !ok || v1 == 0 {
+		t.Fatalf("Expected non-zero version, got %d, %v", v1, ok)
+	}
+	if _, v, _ := store.GetWithVersion("key"); v != v1 {
+		t.Fatalf("Expected version to survive promotion to level 2, got %d, want %d", v, v1)
+	}
+
+	if ok, _ := store.SetIfVersion("key", testValue("v2"), v1, 0); !ok {
+		t.Fatalf("Expected CAS with current version to succeed")
+	}
+	value, v2, _ := store.GetWithVersion("key")
+	if v2 == v1 || value.(testValue) != "v2" {
+		t.Fatalf("Expected new value and version after CAS, got %v, %d", value, v2)
+	}
+	if ok, _ := store.SetIfVersion("key", testValue("stale"), v1, 0); ok {
+		t.Fatalf("Expected CAS with stale version to fail")
+	}
+
+	// 普通写入同样更新版本号
+	store.Set("key", testValue("v3"))
+	if _, v3, _ := store.GetWithVersion("key"); v3 == v2 {
+		t.Fatalf("Expected Set to change the version")
+	}
+}
+
+// 测试获取过期时间不改变缓存项所在的级别
+func TestLRU2StoreGetExpiration(t *testing.T) {
+	opts := Options{
+		BucketCount:     1,
+		CapPerBucket:    5,
+		Level2Cap:       5,
+		CleanupInterval: time.Hour,
+	}
+
+	store := newLRU2Cache(opts)
+	defer store.Close()
+
+	if _, ok := store.GetExpiration("missing"); ok {
+		t.Fatalf("Expected no expiration for missing key")
+	}
+
+	store.SetWithExpiration("key", testValue("value"), time.Minute)
+	expireAt, ok := store.GetExpiration("key")
+	if !ok || time.Until(expireAt) <= 50*time.Second || time.Until(expireAt) > 2*time.Minute {
+		t.Fatalf("Expected expiration about a minute from now, got %v, %v", expireAt, ok)
+	}
+	if store.caches[0][1].peek("key") != nil {
+		t.Fatalf("Expected GetExpiration not to promote the key to level 2")
+	}
+
+	// 移至二级缓存后重新写入，二级缓存中留有旧项，应返回一级缓存中新项的过期时间
+	store.Get("key")
+	store.SetWithExpiration("key", testValue("value"), time.Hour)
+	expireAt, ok = store.GetExpiration("key")
+	if !ok || time.Until(expireAt) <= 50*time.Minute {
+		t.Fatalf("Expected expiration of the latest write, got %v, %v", expireAt, ok)
+	}
+}
+
+// 测试 Clear 对同时存在于两级缓存的键只触发一次 onEvicted，值为最近写入的值
+func TestLRU2StoreClearEvictsOnce(t *testing.T) {
+	evicted := make(map[string][]string)
+	store := newLRU2Cache(Options{
+		BucketCount:     2,
+		CapPerBucket:    5,
+		Level2Cap:       5,
+		CleanupInterval: time.Minute,
+		OnEvicted: func(key string, value Value) {
+			evicted[key] = append(evicted[key], string(value.(testValue)))
+		},
+	})
+	defer store.Close()
+
+	// both 先被访问移入二级缓存，再次写入后一级缓存中是新值
+	store.Set("both", testValue("old"))
+	store.Get("both")
+	store.Set("both", testValue("new"))
+	// level2 只在二级缓存中，level1 只在一级缓存中
+	store.Set("level2", testValue("v2"))
+	store.Get("level2")
+	store.Set("level1", testValue("v1"))
+
+	store.Clear()
+
+	want := map[string][]string{
+		"both":   {"new"},
+		"level2": {"v2"},
+		"level1": {"v1"},
+	}
+	if !reflect.DeepEqual(evicted, want) {
+		t.Fatalf("Expected one eviction per key %v, got %v", want, evicted)
+	}
+	if length := store.Len(); length != 0 {
+		t.Fatalf("Expected length 0 after Clear, got %d", length)
+	}
+}
+
+// 测试 Level 报告键所在的缓存级别且不触发晋升
+func TestLRU2StoreLevel(t *testing.T) {
+	store := newLRU2Cache(Options{
+		BucketCount:     2,
+		CapPerBucket:    5,
+		Level2Cap:       5,
+		CleanupInterval: time.Minute,
+	})
+	defer store.Close()
+
+	if level := store.Level("key"); level != 0 {
+		t.Fatalf("Expected absent key to report 0, got %d", level)
+	}
+
+	store.Set("key", testValue("v1"))
+	if level := store.Level("key"); level != Level1 {
+		t.Fatalf("Expected key in level 1 after Set, got %d", level)
+	}
+	// Level 不触发晋升
+	if level := store.Level("key"); level != Level1 {
+		t.Fatalf("Expected Level not to promote the key, got %d", level)
+	}
+
+	store.Get("key")
+	if level := store.Level("key"); level != Level2 {
+		t.Fatalf("Expected key in level 2 after Get, got %d", level)
+	}
+
+	store.Set("key", testValue("v2"))
+	if level := store.Level("key"); level != Level1|Level2 {
+		t.Fatalf("Expected key in both levels after rewrite, got %d", level)
+	}
+
+	store.Delete("key")
+	if level := store.Level("key"); level != 0 {
+		t.Fatalf("Expected deleted key to report 0, got %d", level)
+	}
+}
+
+// 测试 Keys 返回只在一级缓存、只在二级缓存和同时在两级缓存中的键，跳过过期的键
+func TestLRU2StoreKeys(t *testing.T) {
+	store := newLRU2Cache(Options{
+		BucketCount:     2,
+		CapPerBucket:    5,
+		Level2Cap:       5,
+		CleanupInterval: time.Minute,
+	})
+	defer store.Close()
+
+	store.Set("level1", testValue("v"))
+	store.Set("level2", testValue("v"))
+	store.Get("level2")
+	store.Set("both", testValue("v1"))
+	store.Get("both")
+	store.Set("both", testValue("v2"))
+	store.SetWithExpiration("expired", testValue("v"), 50*time.Millisecond)
+
+	if level := store.Level("level2"); level != Level2 {
+		t.Fatalf("Expected level2 only in level 2, got %d", level)
+	}
+	if level := store.Level("both"); level != Level1|Level2 {
+		t.Fatalf("Expected both in both levels, got %d", level)
+	}
+
+	// 时钟精度为 100ms
+	time.Sleep(250 * time.Millisecond)
+
+	keys := store.Keys()
+	sort.Strings(keys)
+	if want := []string{"both", "level1", "level2"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("Expected keys %v, got %v", want, keys)
+	}
+
+	for _, key := range []string{"level1", "level2", "both"} {
+		if !store.Contains(key) {
+			t.Fatalf("Expected Contains(%s) to be true", key)
+		}
+	}
+	if store.Contains("expired") || store.Contains("missing") {
+		t.Fatalf("Expected Contains to be false for expired and missing keys")
+	}
+	// Contains 不触发晋升
+	if level := store.Level("level1"); level != Level1 {
+		t.Fatalf("Expected Contains not to promote the key, got %d", level)
+	}
+}
+
+// 测试设置 PromoteAfter 后只访问一次的键不会晋升到二级缓存
+func TestLRU2StorePromoteAfter(t *testing.T) {
+	store := newLRU2Cache(Options{
+		BucketCount:     1,
+		CapPerBucket:    16,
+		Level2Cap:       4,
+		CleanupInterval: time.Minute,
+		PromoteAfter:    2,
+	})
+	defer store.Close()
+
+	hot := []string{"hot0", "hot1", "hot2"}
+	for _, key := range hot {
+		store.Set(key, testValue(key))
+	}
+
+	// 扫描：每个键写入后只读取一次，热点键穿插反复读取
+	for i := range 50 {
+		key := fmt.Sprintf("scan%d", i)
+		store.Set(key, testValue(key))
+		if _, ok := store.Get(key); !ok {
+			t.Fatalf("Expected %s to be readable", key)
+		}
+		if level := store.Level(key); level&Level2 != 0 {
+			t.Fatalf("Expected single-access key %s to stay out of level 2, got %d", key, level)
+		}
+		if _, ok := store.Get(hot[i%len(hot)]); !ok {
+			t.Fatalf("Expected hot key %s to stay cached", hot[i%len(hot)])
+		}
+	}
+
+	for _, key := range hot {
+		if level := store.Level(key); level != Level2 {
+			t.Fatalf("Expected hot key %s in level 2, got %d", key, level)
+		}
+	}
+}
+
+// 测试超过 PromoteWindow 的访问重新计数
+func TestLRU2StorePromoteWindow(t *testing.T) {
+	store := newLRU2Cache(Options{
+		BucketCount:     1,
+		CapPerBucket:    16,
+		Level2Cap:       4,
+		CleanupInterval: time.Minute,
+		PromoteAfter:    2,
+		PromoteWindow:   150 * time.Millisecond,
+	})
+	defer store.Close()
+
+	store.Set("key", testValue("value"))
+	store.Get("key")
+	time.Sleep(400 * time.Millisecond)
+	store.Get("key")
+	if level := store.Level("key"); level != Level1 {
+		t.Fatalf("Expected key to stay in level 1 when accesses fall outside the window, got %d", level)
+	}
+
+	store.Get("key")
+	if level := store.Level("key"); level != Level2 {
+		t.Fatalf("Expected key to be promoted after 2 accesses within the window, got %d", level)
+	}
+}
+
+// 测试缓存已满时替换尾部节点后新键指向正确的节点
+func TestCachePutReplaceTail(t *testing.T) {
+	c := Create(2)
+	c.put("a", testValue("a"), Now()+int64(time.Hour), nil)
+	c.put("b", testValue("b"), Now()+int64(time.Hour), nil)
+	c.put("c", testValue("c"), Now()+int64(time.Hour), nil)
+
+	if n := c.peek("a"); n != nil {
+		t.Fatalf("Expected a to be replaced, got %v", n.key)
+	}
+	for _, key := range []string{"b", "c"} {
+		n := c.peek(key)
+		if n == nil || n.key != key || string(n.value.(testValue)) != key {
+			t.Fatalf("Expected %s to map to its own node, got %+v", key, n)
+		}
+	}
+}
+
+// 测试以 Forever 作为过期时间写入的项永不过期，普通过期时间仍会过期
+func TestLRU2StoreForever(t *testing.T) {
+	store := newLRU2Cache(Options{
+		BucketCount:     1,
+		CapPerBucket:    8,
+		Level2Cap:       8,
+		CleanupInterval: time.Minute,
+	})
+	defer store.Close()
+
+	store.SetWithExpiration("forever", testValue("v"), Forever)
+	store.Set("set", testValue("v"))
+	store.SetWithExpiration("short", testValue("v"), 200*time.Millisecond)
+
+	for _, key := range []string{"forever", "set"} {
+		n := store.caches[0][0].peek(key)
+		if n == nil || n.expireAt != neverExpire {
+			t.Fatalf("Expected %s to never expire, got %+v", key, n)
+		}
+		if _, ok := store.GetExpiration(key); ok {
+			t.Fatalf("Expected %s to report no expiration", key)
+		}
+	}
+	store.ForEach(func(key string, value Value, expireAt time.Time) bool {
+		if key != "short" && !expireAt.IsZero() {
+			t.Fatalf("Expected ForEach to report zero expiry for %s, got %v", key, expireAt)
+		}
+		return true
+	})
+
+	time.Sleep(500 * time.Millisecond)
+	if _, ok := store.Get("short"); ok {
+		t.Fatalf("Expected short-lived entry to expire")
+	}
+	if _, ok := store.Get("forever"); !ok {
+		t.Fatalf("Expected Forever entry to be cached")
+	}
+}
+
+// 测试自定义分桶哈希决定键所在的桶，Len、UsedBytes、Clear 仍汇总所有桶
+func TestLRU2StoreShardHash(t *testing.T) {
+	// 键 k<i> 的哈希值为 i
+	shardHash := func(key string) uint32 {
+		var i uint32
+		fmt.Sscanf(key, "k%d", &i)
+		return i
+	}
+	store := newLRU2Cache(Options{
+		BucketCount:     4,
+		CapPerBucket:    16,
+		Level2Cap:       16,
+		CleanupInterval: time.Minute,
+		ShardHash:       shardHash,
+	})
+	defer store.Close()
+
+	var used int64
+	for i := range 16 {
+		key := fmt.Sprintf("k%d", i)
+		store.Set(key, testValue("value"))
+		used += int64(len(key) + len("value"))
+	}
+
+	for i := range 16 {
+		key := fmt.Sprintf("k%d", i)
+		for b := range store.caches {
+			found := store.caches[b][0].peek(key) != nil
+			if found != (b == i%4) {
+				t.Fatalf("Expected %s only in bucket %d, found in bucket %d: %v", key, i%4, b, found)
+			}
+		}
+	}
+
+	if n := store.Len(); n != 16 {
+		t.Fatalf("Expected Len 16, got %d", n)
+	}
+	if n := store.UsedBytes(); n != used {
+		t.Fatalf("Expected UsedBytes %d, got %d", used, n)
+	}
+
+	// 跨桶重命名
+	if !store.Rename("k1", "k2x") {
+		t.Fatalf("Expected Rename across buckets to succeed")
+	}
+	if _, ok := store.Get("k2x"); !ok {
+		t.Fatalf("Expected renamed key to be readable")
+	}
+
+	store.Clear()
+	if n := store.Len(); n != 0 {
+		t.Fatalf("Expected Len 0 after Clear, got %d", n)
+	}
+	if n := store.UsedBytes(); n != 0 {
+		t.Fatalf("Expected UsedBytes 0 after Clear, got %d", n)
+	}
+}
+
+// 测试键同时存在于两级缓存时 UsedBytes 只计算一次
+func TestLRU2StoreUsedBytes(t *testing.T) {
+	store := newLRU2Cache(Options{
+		BucketCount:     1,
+		CapPerBucket:    5,
+		Level2Cap:       5,
+		CleanupInterval: time.Minute,
+	})
+	defer store.Close()
+
+	store.Set("key", testValue("v1"))
+	store.Get("key")
+	store.Set("key", testValue("value2"))
+	if level := store.Level("key"); level != Level1|Level2 {
+		t.Fatalf("Expected key in both levels, got %d", level)
+	}
+	if n := store.UsedBytes(); n != int64(len("key")+len("value2")) {
+		t.Fatalf("Expected UsedBytes to count the newest value once, got %d", n)
+	}
+}
+
+// 测试 EvictBytes 按最久未使用顺序淘汰，一级缓存中的项先于二级缓存淘汰
+func TestLRU2StoreEvictBytes(t *testing.T) {
+	store := newLRU2Cache(Options{
+		BucketCount:     1,
+		CapPerBucket:    16,
+		Level2Cap:       16,
+		CleanupInterval: time.Minute,
+	})
+	defer store.Close()
+
+	// 每项占用 len("key0") + len("value0") = 10 字节
+	for i := range 6 {
+		store.Set(fmt.Sprintf("key%d", i), testValue(fmt.Sprintf("value%d", i)))
+	}
+	store.Get("key0") // 晋升到二级缓存，比一级缓存中的项更晚淘汰
+
+	if freed := store.EvictBytes(15); freed != 20 {
+		t.Fatalf("Expected EvictBytes to free 20 bytes, got %d", freed)
+	}
+	if n := store.UsedBytes(); n != 40 {
+		t.Fatalf("Expected 40 bytes after EvictBytes, got %d", n)
+	}
+	for _, key := range []string{"key1", "key2"} {
+		if _, ok := store.Get(key); ok {
+			t.Fatalf("Expected %s to be evicted", key)
+		}
+	}
+	if _, ok := store.Get("key0"); !ok {
+		t.Fatalf("Expected promoted key0 to survive")
+	}
+	if got := store.Stats().Evictions; got != 2 {
+		t.Fatalf("Expected 2 evictions, got %d", got)
+	}
+
+	if freed := store.EvictBytes(100); freed != 40 {
+		t.Fatalf("Expected EvictBytes to free the remaining 40 bytes, got %d", freed)
+	}
+	if n := store.Len(); n != 0 {
+		t.Fatalf("Expected empty store, got %d items", n)
+	}
+}
+
+// 测试设置字节上限后淘汰超出的项，写入不超过上限，上限为 0 时不限制
+func TestLRU2StoreSetMaxBytes(t *testing.T) {
+	store := newLRU2Cache(Options{
+		BucketCount:     1,
+		CapPerBucket:    16,
+		Level2Cap:       16,
+		CleanupInterval: time.Minute,
+	})
+	defer store.Close()
+
+	if n := store.MaxBytes(); n != 0 {
+		t.Fatalf("Expected no byte limit by default, got %d", n)
+	}
+
+	// 每项占用 len("key0") + len("value0") = 10 字节
+	for i := range 6 {
+		store.Set(fmt.Sprintf("key%d", i), testValue(fmt.Sprintf("value%d", i)))
+	}
+
+	store.SetMaxBytes(40)
+	if n := store.UsedBytes(); n != 40 {
+		t.Fatalf("Expected 40 bytes after SetMaxBytes, got %d", n)
+	}
+	store.Set("key6", testValue("value6"))
+	if n := store.UsedBytes(); n != 40 {
+		t.Fatalf("Expected writes to respect the limit, got %d bytes", n)
+	}
+	if _, ok := store.Get("key2"); ok {
+		t.Fatalf("Expected least recently used key2 to be evicted")
+	}
+
+	store.SetMaxBytes(0)
+	for i := range 10 {
+		store.Set(fmt.Sprintf("new%d", i), testValue(fmt.Sprintf("value%d", i)))
+	}
+	if n := store.UsedBytes(); n != 140 {
+		t.Fatalf("Expected no limit after SetMaxBytes(0), got %d bytes", n)
+	}
+}
+
+// BenchmarkLRU2StoreGet 写入后首次读取，命中时从一级缓存移至二级缓存
+func BenchmarkLRU2StoreGet(b *testing.B) {
+	s := newLRU2Cache(Options{BucketCount: 16, CapPerBucket: 1024, Level2Cap: 1024, CleanupInterval: time.Hour})
+	defer s.Close()
+
+	keys := make([]string, 4096)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%05d", i)
+	}
+
+	b.ResetTimer()
+	for i := range b.N {
+		key := keys[i%len(keys)]
+		s.Set(key, testValue("v"))
+		s.Get(key)
+	}
+}
+
+// 测试批量操作按桶分组，跨多个桶的键每个桶只加锁一次
+func TestLRU2StoreMultiBuckets(t *testing.T) {
+	store := newLRU2Cache(Options{BucketCount: 4, CapPerBucket: 16, Level2Cap: 16, CleanupInterval: time.Hour})
+	defer store.Close()
+
+	keys := make([]string, 16)
+	items := make(map[string]Value)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+		items[keys[i]] = testValue(keys[i])
+	}
+	// 同一个桶的键相邻，桶序号不减，每个桶只加锁一次
+	var visited []string
+	locks := 0
+	last := int32(-1)
+	store.withBuckets(keys, func(i int, idx int32) {
+		key := keys[i]
+		if store.bucket(key) != idx {
+			t.Fatalf("Key %s visited with bucket %d, belongs to %d", key, idx, store.bucket(key))
+		}
+		if idx < last {
+			t.Fatalf("Expected buckets in order, got %d after %d", idx, last)
+		}
+		if idx != last {
+			locks++
+			last = idx
+		}
+		visited = append(visited, key)
+	})
+	if locks < 2 || len(visited) != len(keys) {
+		t.Fatalf("Expected %d keys across several buckets, got %d keys in %d buckets", len(keys), len(visited), locks)
+	}
+
+	store.SetMulti(items)
+	for _, key := range keys {
+		if level := store.Level(key); level != Level1 {
+			t.Fatalf("Expected %s in level 1 after SetMulti, got %d", key, level)
+		}
+	}
+	// 与 Get 一样，命中后晋升到二级缓存
+	if values := store.GetMulti(keys); len(values) != len(keys) {
+		t.Fatalf("Expected %d hits, got %d", len(keys), len(values))
+	}
+	for _, key := range keys {
+		if level := store.Level(key); level != Level2 {
+			t.Fatalf("Expected %s in level 2 after GetMulti, got %d", key, level)
+		}
+	}
+	if n := store.DeleteMulti(keys); n != len(keys) || storedEntries(store) != 0 {
+		t.Fatalf("Expected all %d keys deleted, got %d with %d left", len(keys), n, storedEntries(store))
+	}
+}
+
+// BenchmarkLRU2StoreMulti 比较批量操作与逐个调用 100 个键
+func BenchmarkLRU2StoreMulti(b *testing.B) {
+	s := newLRU2Cache(Options{BucketCount: 16, CapPerBucket: 1024, Level2Cap: 1024, CleanupInterval: time.Hour})
+	defer s.Close()
+
+	keys := make([]string, 100)
+	items := make(map[string]Value, len(keys))
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%05d", i)
+		items[keys[i]] = testValue("v")
+	}
+
+	b.Run("set/looped", func(b *testing.B) {
+		for range b.N {
+			for key, value := range items {
+				s.Set(key, value)
+			}
+		}
+	})
+	b.Run("set/batched", func(b *testing.B) {
+		for range b.N {
+			s.SetMulti(items)
+		}
+	})
+	b.Run("get/looped", func(b *testing.B) {
+		for range b.N {
+			for _, key := range keys {
+				s.Get(key)
+			}
+		}
+	})
+	b.Run("get/batched", func(b *testing.B) {
+		for range b.N {
+			s.GetMulti(keys)
+		}
+	})
+	b.Run("delete/looped", func(b *testing.B) {
+		for range b.N {
+			s.SetMulti(items)
+			for _, key := range keys {
+				s.Delete(key)
+			}
+		}
+	})
+	b.Run("delete/batched", func(b *testing.B) {
+		for range b.N {
+			s.SetMulti(items)
+			s.DeleteMulti(keys)
+		}
+	})
+}
@@ -14,10 +14,83 @@ func (d String) Len() int {
 	return len(d)
 }
 
+// fakeClock 可手动推进的时钟，用于替代 time.Sleep
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1700000000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestLRUCache 创建使用假时钟的 lruCache
+func newTestLRUCache(t *testing.T, opts Options) (*lruCache, *fakeClock) {
+	t.Helper()
+	clock := newFakeClock()
+	lru := newLRUCache(opts)
+	lru.now = clock.Now
+	t.Cleanup(lru.Close)
+	return lru, clock
+}
+
+// lruOrder 按最近使用到最久未使用的顺序返回链表中的键
+func lruOrder(c *lruCache) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := []string{}
+	for elem := c.list.Back(); elem != nil; elem = elem.Prev() {
+		keys = append(keys, elem.Value.(*lruEntry).key)
+	}
+	return keys
+}
+
+// 测试写入、读取和淘汰后链表按最近使用到最久未使用排列
+func TestLRUOrder(t *testing.T) {
+	opts := NewOptions()
+	opts.MaxBytes = 6 // 每项 2 字节，容量 3 项
+	lru, _ := newTestLRUCache(t, opts)
+
+	for _, key := range []string{"a", "b", "c"} {
+		lru.Set(key, String("v"))
+	}
+	if got := lruOrder(lru); !reflect.DeepEqual(got, []string{"c", "b", "a"}) {
+		t.Fatalf("Expected [c b a] after puts, got %v", got)
+	}
+
+	lru.Get("a")
+	if got := lruOrder(lru); !reflect.DeepEqual(got, []string{"a", "c", "b"}) {
+		t.Fatalf("Expected [a c b] after Get(a), got %v", got)
+	}
+
+	// 更新已有的键同样移到最近使用
+	lru.Set("c", String("w"))
+	if got := lruOrder(lru); !reflect.DeepEqual(got, []string{"c", "a", "b"}) {
+		t.Fatalf("Expected [c a b] after updating c, got %v", got)
+	}
+
+	lru.Set("d", String("v"))
+	if got := lruOrder(lru); !reflect.DeepEqual(got, []string{"d", "c", "a"}) {
+		t.Fatalf("Expected [d c a] after evicting b, got %v", got)
+	}
+}
+
 // 测试 Get 方法
 func TestGet(t *testing.T) {
-	opts := NewOptions()
-	lru := newLRUCache(opts)
+	lru, clock := newTestLRUCache(t, NewOptions())
 
 	// 1.缓存中不存在的键
 	if _, ok := lru.Get("non-existent-key"); ok {
@@ -35,7 +108,7 @@ func TestGet(t *testing.T) {
 	// 3.缓存中存在但已过期的键
 	expiration := 1 * time.Millisecond
 	lru.SetWithExpiration(key, value, expiration)
-	time.Sleep(2 * time.Millisecond) // 等待过期
+	clock.Advance(2 * time.Millisecond) // 等待过期
 	if _, ok := lru.Get(key); ok {
 		t.Fatalf("Expected cache miss for expired key, but got a hit")
 	}
@@ -103,8 +176,7 @@ func TestSet(t *testing.T) {
 
 // 测试 SetWithExpiration 方法
 func TestSetWithExpiration(t *testing.T) {
-	opts := NewOptions()
-	lru := newLRUCache(opts)
+	lru, clock := newTestLRUCache(t, NewOptions())
 
 	// 测试添加新项并设置过期时间
 	key := "test-key"
@@ -115,8 +187,14 @@ func TestSetWithExpiration(t *testing.T) {
 		t.Fatalf("SetWithExpiration operation failed: %v", err)
 	}
 
+	// 未到过期时间
+	clock.Advance(expiration)
+	if _, ok := lru.Get(key); !ok {
+		t.Fatalf("Expected cache hit for key %s at its expiration instant", key)
+	}
+
 	// 等待过期
-	time.Sleep(2 * time.Millisecond)
+	clock.Advance(time.Nanosecond)
 
 	// 验证过期后是否无法获取
 	if _, ok := lru.Get(key); ok {
@@ -153,4 +231,448 @@ func TestSetEviction(t *testing.T) {
 	if !reflect.DeepEqual(keys, evictedKeys) {
 		t.Fatalf("Eviction callback failed: expected %v, got %v", keys, evictedKeys)
 	}
-}
\ No newline at end of file
+}
+// 测试最大存活时间
+func TestMaxAge(t *testing.T) {
+	opts := NewOptions()
+	opts.MaxAge = 20 * time.Millisecond
+	lru, clock := newTestLRUCache(t, opts)
+
+	// TTL 远长于最大存活时间
+	lru.SetWithExpiration("long-ttl", String("value"), time.Hour)
+	lru.Set("no-ttl", String("value"))
+
+	if _, ok := lru.Get("long-ttl"); !ok {
+		t.Fatalf("Expected cache hit before MaxAge elapsed")
+	}
+
+	clock.Advance(20 * time.Millisecond)
+
+	if _, ok := lru.Get("long-ttl"); ok {
+		t.Fatalf("Expected cache miss after MaxAge elapsed despite long TTL")
+	}
+	if _, ok := lru.Get("no-ttl"); ok {
+		t.Fatalf("Expected cache miss after MaxAge elapsed for key without TTL")
+	}
+
+	// 重新写入会刷新写入时间
+	lru.Set("no-ttl", String("value"))
+	if _, ok := lru.Get("no-ttl"); !ok {
+		t.Fatalf("Expected cache hit after rewriting the key")
+	}
+}
+
+// 测试通过 Options 设置的 OnEvicted 在超出 MaxBytes 淘汰时收到键和值
+func TestLRUOnEvictedMaxBytes(t *testing.T) {
+	evicted := make(map[string]Value)
+	opts := NewOptions()
+	opts.MaxBytes = 12 // 每项 6 字节，容量 2 项
+	opts.OnEvicted = func(key string, value Value) {
+		evicted[key] = value
+	}
+	s, err := NewStore(LRU, opts)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	defer s.Close()
+
+	s.Set("key1", String("v1"))
+	s.Set("key2", String("v2"))
+	s.Set("key3", String("v3"))
+
+	if !reflect.DeepEqual(evicted, map[string]Value{"key1": String("v1")}) {
+		t.Fatalf("Expected key1=v1 to be evicted, got %v", evicted)
+	}
+}
+
+// 测试推进假时钟后定期清理会移除过期项
+func TestEvictExpiredWithFakeClock(t *testing.T) {
+	evicted := []string{}
+	opts := NewOptions()
+	opts.OnEvicted = func(key string, value Value) {
+		evicted = append(evicted, key)
+	}
+	lru, clock := newTestLRUCache(t, opts)
+
+	lru.SetWithExpiration("short", String("value"), time.Second)
+	lru.SetWithExpiration("long", String("value"), time.Minute)
+	lru.Set("forever", String("value"))
+
+	clock.Advance(2 * time.Second)
+	lru.mu.Lock()
+	lru.evict()
+	lru.mu.Unlock()
+
+	if !reflect.DeepEqual(evicted, []string{"short"}) {
+		t.Fatalf("Expected only short to be evicted, got %v", evicted)
+	}
+	if lru.Len() != 2 {
+		t.Fatalf("Expected 2 remaining items, got %d", lru.Len())
+	}
+
+	// 更新过期时间以假时钟当前时间为基准
+	if !lru.UpdateExpiration("forever", time.Second) {
+		t.Fatalf("UpdateExpiration failed for existing key")
+	}
+	if exp, _ := lru.GetExpiration("forever"); !exp.Equal(clock.Now().Add(time.Second)) {
+		t.Fatalf("Expected expiration %v, got %v", clock.Now().Add(time.Second), exp)
+	}
+
+	clock.Advance(time.Minute)
+	for _, key := range []string{"long", "forever"} {
+		if _, ok := lru.Get(key); ok {
+			t.Fatalf("Expected %s to expire after advancing the clock", key)
+		}
+	}
+}
+
+// 测试定期清理统计
+func TestLRUCleanupStats(t *testing.T) {
+	lru, clock := newTestLRUCache(t, NewOptions())
+
+	if stats := lru.CleanupStats(); stats.Sweeps != 0 {
+		t.Fatalf("Expected no sweeps before cleanup, got %+v", stats)
+	}
+
+	for i := range 5 {
+		lru.SetWithExpiration(fmt.Sprintf("short-%d", i), String("value"), time.Second)
+	}
+	lru.SetWithExpiration("long", String("value"), time.Hour)
+	lru.Set("forever", String("value"))
+
+	clock.Advance(2 * time.Second)
+	lru.sweep()
+
+	stats := lru.CleanupStats()
+	if stats.Sweeps != 1 || stats.LastReaped != 5 {
+		t.Fatalf("Expected 1 sweep reaping 5 entries, got %+v", stats)
+	}
+	if stats.LastDuration <= 0 {
+		t.Fatalf("Expected positive sweep duration, got %v", stats.LastDuration)
+	}
+
+	// 没有过期项时统计次数增加，移除数量为 0
+	lru.sweep()
+	if stats := lru.CleanupStats(); stats.Sweeps != 2 || stats.LastReaped != 0 {
+		t.Fatalf("Expected 2 sweeps with nothing reaped, got %+v", stats)
+	}
+}
+
+// scanAll 使用 Scan 遍历全部键，返回每个键出现的次数
+func scanAll(t *testing.T, s Store, count int, between func()) map[string]int {
+	t.Helper()
+	seen := make(map[string]int)
+	var cursor uint64
+	for calls := 0; ; calls++ {
+		start := time.Now()
+		keys, next := s.Scan(cursor, count)
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Fatalf("Scan page took %v, expected a short lock hold", elapsed)
+		}
+		if len(keys) > count {
+			t.Fatalf("Scan returned %d keys, expected at most %d", len(keys), count)
+		}
+		for _, key := range keys {
+			seen[key]++
+		}
+		if next == 0 {
+			return seen
+		}
+		if calls > 1<<20 {
+			t.Fatalf("Scan did not terminate")
+		}
+		cursor = next
+		if between != nil {
+			between()
+		}
+	}
+}
+
+// 测试分页遍历覆盖全部键
+func TestLRUScan(t *testing.T) {
+	opts := NewOptions()
+	opts.MaxBytes = 0
+	lru, _ := newTestLRUCache(t, opts)
+
+	const n = 100000
+	for i := range n {
+		lru.Set(fmt.Sprintf("key-%d", i), String("v"))
+	}
+
+	// 遍历期间的访问和写入不影响已有键的覆盖
+	extra := 0
+	seen := scanAll(t, lru, 1000, func() {
+		lru.Get(fmt.Sprintf("key-%d", extra))
+		lru.Set(fmt.Sprintf("extra-%d", extra), String("v"))
+		extra++
+	})
+	for i := range n {
+		if seen[fmt.Sprintf("key-%d", i)] == 0 {
+			t.Fatalf("Key key-%d was never returned by Scan", i)
+		}
+	}
+	if extra < n/1000-1 {
+		t.Fatalf("Expected roughly %d pages, got %d", n/1000, extra+1)
+	}
+
+	// 删除后复用的位置不影响其他键
+	lru.Delete("key-10")
+	lru.Set("reused", String("v"))
+	seen = scanAll(t, lru, 500, nil)
+	if seen["key-10"] != 0 || seen["reused"] != 1 {
+		t.Fatalf("Expected deleted key to be skipped and reused slot to be scanned, got %d, %d", seen["key-10"], seen["reused"])
+	}
+}
+
+// 测试严格过期模式下统计结果立即排除过期项
+func TestLRUStrictExpiry(t *testing.T) {
+	opts := NewOptions()
+	opts.StrictExpiry = true
+	opts.MaxAge = time.Hour
+	lru, clock := newTestLRUCache(t, opts)
+
+	lru.SetWithExpiration("short", String("v1"), time.Second)
+	lru.Set("long", String("v2"))
+	lru.Set("old", String("v3"))
+	if got := lru.Len(); got != 3 {
+		t.Fatalf("Expected 3 entries, got %d", got)
+	}
+
+	clock.Advance(2 * time.Second)
+	if got := lru.Len(); got != 2 {
+		t.Fatalf("Expected expired entry to be excluded from Len, got %d", got)
+	}
+	if got, want := lru.UsedBytes(), int64(len("long")+2+len("old")+2); got != want {
+		t.Fatalf("Expected UsedBytes %d, got %d", want, got)
+	}
+
+	// 超过最大存活时间的项同样被清理
+	lru.Set("long", String("v2"))
+	clock.Advance(time.Hour - time.Second)
+	if got := lru.UsedBytes(); got != int64(len("long")+2) {
+		t.Fatalf("Expected aged entry to be excluded from UsedBytes, got %d", got)
+	}
+
+	// Get 遇到过期项时同步删除
+	lru.SetWithExpiration("get", String("v4"), time.Second)
+	clock.Advance(2 * time.Second)
+	if _, ok := lru.Get("get"); ok {
+		t.Fatalf("Expected expired entry to miss")
+	}
+	if _, ok := lru.items["get"]; ok {
+		t.Fatalf("Expected expired entry to be removed synchronously by Get")
+	}
+}
+
+// 测试版本号与条件写入
+func TestLRUVersion(t *testing.T) {
+	lru, clock := newTestLRUCache(t, NewOptions())
+
+	if _, _, ok := lru.GetWithVersion("key"); ok {
+		t.Fatalf("Expected miss for absent key")
+	}
+
+	// 版本号 0 表示键不存在时写入
+	if ok, err := lru.SetIfVersion("key", String("v1"), 0, 0); !ok || err != nil {
+		t.Fatalf("Expected create with version 0 to succeed, got %v, %v", ok, err)
+	}
+	if ok, _ := lru.SetIfVersion("key", String("v1"), 0, 0); ok {
+		t.Fatalf("Expected create with version 0 to fail for existing key")
+	}
+
+	value, v1, ok := lru.GetWithVersion("key")
+	if !ok || value.(String) != "v1" || v1 == 0 {
+		t.Fatalf("Expected v1 with non-zero version, got %v, %d, %v", value, v1, ok)
+	}
+
+	if ok, _ := lru.SetIfVersion("key", String("v2"), v1, 0); !ok {
+		t.Fatalf("Expected CAS with current version to succeed")
+	}
+	_, v2, _ := lru.GetWithVersion("key")
+	if v2 == v1 {
+		t.Fatalf("Expected version to change after CAS")
+	}
+	if ok, _ := lru.SetIfVersion("key", String("stale"), v1, 0); ok {
+		t.Fatalf("Expected CAS with stale version to fail")
+	}
+
+	// 普通写入同样更新版本号
+	lru.Set("key", String("v3"))
+	if _, v3, _ := lru.GetWithVersion("key"); v3 == v2 {
+		t.Fatalf("Expected Set to change the version")
+	}
+
+	// 已过期的键版本号视为 0
+	lru.SetWithExpiration("ttl", String("old"), time.Second)
+	clock.Advance(2 * time.Second)
+	if ok, _ := lru.SetIfVersion("ttl", String("new"), 0, 0); !ok {
+		t.Fatalf("Expected expired key to be treated as absent")
+	}
+
+	// nil 值删除键
+	_, v, _ := lru.GetWithVersion("ttl")
+	if ok, _ := lru.SetIfVersion("ttl", nil, v, 0); !ok {
+		t.Fatalf("Expected CAS delete to succeed")
+	}
+	if _, ok := lru.Get("ttl"); ok {
+		t.Fatalf("Expected key to be deleted")
+	}
+}
+
+// 测试近似 LRU 模式下淘汰的主要是较久未访问的项
+func TestLRUApproximateEviction(t *testing.T) {
+	// 每项 key+value 为 6 字节，容量 1000 项
+	c, clock := newTestLRUCache(t, Options{MaxBytes: 6000, EvictionSamples: 5})
+
+	for i := range 1000 {
+		c.Set(fmt.Sprintf("k%04d", i), String("v"))
+		clock.Advance(time.Millisecond)
+	}
+	// 最早写入的 100 项被再次访问，成为最近使用的项
+	for i := range 100 {
+		c.Get(fmt.Sprintf("k%04d", i))
+		clock.Advance(time.Millisecond)
+	}
+	for i := range 200 {
+		c.Set(fmt.Sprintf("n%04d", i), String("v"))
+		clock.Advance(time.Millisecond)
+	}
+
+	if got := c.Len(); got != 1000 {
+		t.Fatalf("Expected 1000 entries after eviction, got %d", got)
+	}
+
+	var evicted, evictedOld, evictedRecent int
+	for i := range 1000 {
+		if _, ok := c.items[fmt.Sprintf("k%04d", i)]; ok {
+			continue
+		}
+		evicted++
+		switch {
+		case i < 100:
+			evictedRecent++
+		case i < 600:
+			evictedOld++
+		}
+	}
+	for i := range 200 {
+		if _, ok := c.items[fmt.Sprintf("n%04d", i)]; !ok {
+			evicted++
+			evictedRecent++
+		}
+	}
+
+	if evicted != 200 {
+		t.Fatalf("Expected 200 evictions, got %d", evicted)
+	}
+	// 随机淘汰时只有约 40% 来自最久未访问的 500 项
+	if evictedOld < 150 {
+		t.Errorf("Expected most evictions among the 500 least recently used entries, got %d of %d", evictedOld, evicted)
+	}
+	if evictedRecent > 5 {
+		t.Errorf("Expected recently used entries to survive, %d were evicted", evictedRecent)
+	}
+}
+
+// 测试精确与近似 LRU 在持续淘汰下的读写吞吐
+func BenchmarkLRUEviction(b *testing.B) {
+	for _, bc := range []struct {
+		name    string
+		samples int
+	}{
+		{"exact", 0},
+		{"sampled", 5},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			// 容量 10000 项，键空间 20000，写入持续触发淘汰
+			c := newLRUCache(Options{MaxBytes: 10000 * 10, EvictionSamples: bc.samples, CleanupInterval: time.Hour})
+			defer c.Close()
+
+			keys := make([]string, 20000)
+			for i := range keys {
+				keys[i] = fmt.Sprintf("key-%05d", i)
+				c.Set(keys[i], String("v"))
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					key := keys[(i*7919)%len(keys)]
+					if i%4 == 0 {
+						c.Set(key, String("v"))
+					} else {
+						c.Get(key)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkLRUGet 比较命中时调整 LRU 顺序与 NoPromote 模式的读取开销
+func BenchmarkLRUGet(b *testing.B) {
+	for _, bc := range []struct {
+		name      string
+		noPromote bool
+	}{
+		{"promote", false},
+		{"no-promote", true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			c := newLRUCache(Options{MaxBytes: 10000 * 10, NoPromote: bc.noPromote, CleanupInterval: time.Hour})
+			defer c.Close()
+
+			keys := make([]string, 10000)
+			for i := range keys {
+				keys[i] = fmt.Sprintf("key-%05d", i)
+				c.Set(keys[i], String("v"))
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					c.Get(keys[(i*7919)%len(keys)])
+					i++
+				}
+			})
+		})
+	}
+}
+
+// 测试 Rename 不移动已过期的项，被覆盖的值不触发淘汰回调，字节统计随键长变化
+func TestLRURename(t *testing.T) {
+	var evicted []string
+	opts := NewOptions()
+	opts.OnEvicted = func(key string, value Value) { evicted = append(evicted, key) }
+	lru, clock := newTestLRUCache(t, opts)
+
+	lru.SetWithExpiration("short", String("v"), time.Second)
+	clock.Advance(2 * time.Second)
+	if lru.Rename("short", "target") {
+		t.Fatalf("Expected rename of expired key to fail")
+	}
+	if _, ok := lru.GetExpiration("target"); ok {
+		t.Fatalf("Expected no expiration recorded for target")
+	}
+
+	evicted = nil
+	lru.Set("a", String("1"))
+	lru.Set("bb", String("2"))
+	before := lru.UsedBytes()
+	if !lru.Rename("a", "bb") {
+		t.Fatalf("Expected rename to succeed")
+	}
+	if len(evicted) != 0 {
+		t.Fatalf("Expected no eviction callbacks on rename, got %v", evicted)
+	}
+	// 少了一个 "bb"->"2" 项，键 "a" 变为 "bb"
+	if want := before - int64(len("bb")+len("2")) + int64(len("bb")-len("a")); lru.UsedBytes() != want {
+		t.Fatalf("Expected %d used bytes, got %d", want, lru.UsedBytes())
+	}
+	if lru.Len() != 1 {
+		t.Fatalf("Expected 1 entry after rename, got %d", lru.Len())
+	}
+}
@@ -0,0 +1,90 @@
+package store
+
+import "math"
+
+// sketchDepth count-min sketch 的行数
+const sketchDepth = 4
+
+// CountMinSketch 带可选衰减的 count-min sketch，用于估计键的访问频率
+// 内存固定，估计值不小于真实值；不是并发安全的，调用方负责加锁
+type CountMinSketch struct {
+	rows      [sketchDepth][]uint32 // 计数器，每行使用不同的哈希种子
+	mask      uint32
+	additions int // 自上次衰减以来的计数次数
+	resetAt   int // 达到此计数次数后所有计数器减半，0 表示不衰减
+}
+
+// NewCountMinSketch 创建 count-min sketch
+// width 为每行计数器个数（向上取整为 2 的幂），resetAt 为触发衰减的计数次数，0 表示不衰减
+func NewCountMinSketch(width int, resetAt int) *CountMinSketch {
+	if width <= 0 {
+		width = 1024
+	}
+
+	size := 1
+	for size < width {
+		size <<= 1
+	}
+
+	s := &CountMinSketch{
+		mask:    uint32(size - 1),
+		resetAt: max(resetAt, 0),
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint32, size)
+	}
+	return s
+}
+
+// Width 返回每行计数器个数
+func (s *CountMinSketch) Width() int {
+	return int(s.mask) + 1
+}
+
+// Increment 增加键的计数，返回增加后的估计频率
+func (s *CountMinSketch) Increment(key string) uint32 {
+	h1, h2 := sketchHash(key)
+	freq := uint32(math.MaxUint32)
+	for i := range s.rows {
+		idx := (h1 + uint32(i)*h2) & s.mask
+		if s.rows[i][idx] < math.MaxUint32 {
+			s.rows[i][idx]++
+		}
+		freq = min(freq, s.rows[i][idx])
+	}
+
+	// 周期性衰减，让历史热点逐渐失效
+	if s.resetAt > 0 {
+		s.additions++
+		if s.additions >= s.resetAt {
+			for i := range s.rows {
+				for j := range s.rows[i] {
+					s.rows[i][j] >>= 1
+				}
+			}
+			s.additions = 0
+		}
+	}
+	return freq
+}
+
+// Estimate 取各行计数的最小值作为估计频率
+func (s *CountMinSketch) Estimate(key string) uint32 {
+	h1, h2 := sketchHash(key)
+	freq := uint32(math.MaxUint32)
+	for i := range s.rows {
+		idx := (h1 + uint32(i)*h2) & s.mask
+		freq = min(freq, s.rows[i][idx])
+	}
+	return freq
+}
+
+// sketchHash FNV-1a 哈希，拆分为两个 32 位哈希值用于双重哈希
+func sketchHash(key string) (uint32, uint32) {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	return uint32(h), uint32(h>>32) | 1
+}
@@ -1,6 +1,21 @@
 package store
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrValueTooLarge 单个缓存项超过缓存容量错误
+var ErrValueTooLarge = errors.New("value exceeds cache capacity")
+
+// ErrUnknownCacheType 未知的缓存类型错误
+var ErrUnknownCacheType = errors.New("unknown cache type")
+
+// ErrCodecRequired 持久化存储未设置 Codec 错误
+var ErrCodecRequired = errors.New("codec is required for persistent stores")
 
 // Value 缓存值接口
 type Value interface {
@@ -10,12 +25,89 @@ type Value interface {
 // Store 缓存接口
 type Store interface {
 	Get(key string) (Value, bool)
+	// Peek 获取缓存值但不影响淘汰顺序，不晋升、不记录访问也不删除过期项，过期的键返回 false
+	Peek(key string) (Value, bool)
+	// Contains 判断键是否存在且未过期，与 Peek 一样不影响淘汰顺序
+	Contains(key string) bool
+	// Set 写入键值，value 为 nil 时删除键；长度为 0 的非 nil 值正常写入，读取时命中
 	Set(key string, value Value) error
 	SetWithExpiration(key string, value Value, expiraion time.Duration) error
 	Delete(key string) bool
+	// GetMulti 批量获取，返回命中的键值，未命中或已过期的键不在结果中
+	GetMulti(keys []string) map[string]Value
+	// SetMulti 批量写入永不过期的键值，nil 值删除对应的键；部分写入失败时其余键仍然写入，返回合并后的错误
+	SetMulti(items map[string]Value) error
+	// DeleteMulti 批量删除，返回实际删除的项数
+	DeleteMulti(keys []string) int
+	// Rename 将 oldKey 的值和过期时间原子地移动到 newKey，覆盖 newKey 原有的值，oldKey 不存在时返回 false
+	Rename(oldKey, newKey string) bool
 	Clear()
+	// Len 返回未过期的项数，过期但尚未清理的项不计入，耗时可能与项数成正比
 	Len() int
+	// Keys 返回所有未过期的键，顺序不固定，同时存在于多个层级的键只返回一次
+	Keys() []string
 	Close()
+	// ForEach 遍历所有未过期的项，fn 返回 false 时停止遍历
+	// expireAt 为零值表示永不过期；遍历期间持有存储的锁，fn 中不能再访问该存储
+	ForEach(fn func(key string, value Value, expireAt time.Time) bool)
+	// Scan 分页遍历未过期的键，cursor 为 0 时从头开始，返回的游标为 0 时遍历结束
+	Scan(cursor uint64, count int) (keys []string, next uint64)
+	// GetWithVersion 获取缓存值及其版本号，每次写入都会分配新的版本号
+	GetWithVersion(key string) (Value, uint64, bool)
+	// SetIfVersion 当前版本号等于 expectedVersion 时写入并返回 true，键不存在时版本号为 0
+	SetIfVersion(key string, value Value, expectedVersion uint64, expiration time.Duration) (bool, error)
+	// GetAndTouch 获取缓存值，命中时在同一次加锁中将过期时间重置为 now + newTTL，newTTL <= 0 时永不过期
+	GetAndTouch(key string, newTTL time.Duration) (Value, bool)
+	// GetOrLoad 获取缓存值，未命中时调用 loader 加载并按返回的过期时间写入，同一个键同时只调用一次 loader
+	// 其他调用等待并共享结果；loader 返回错误时不写入缓存，所有等待的调用都返回该错误
+	GetOrLoad(key string, loader LoadFunc) (Value, error)
+	// Stats 返回累计的访问、淘汰和过期统计以及当前的项数
+	Stats() Stats
+}
+
+// Stats 存储的访问统计，Peek、Contains 等不影响淘汰顺序的读取不计入命中和未命中
+type Stats struct {
+	Hits        int64 // 读取命中次数
+	Misses      int64 // 读取未命中次数，包括读到已过期的项
+	Evictions   int64 // 因容量不足被淘汰的项数
+	Expirations int64 // 因过期或超过最大存活时间被删除的项数
+	ItemCount   int   // 当前未过期的项数
+}
+
+// statsCounters 存储内部的统计计数器，原子操作
+type statsCounters struct {
+	hits        int64
+	misses      int64
+	evictions   int64
+	expirations int64
+}
+
+// lookup 记录一次读取的命中或未命中
+func (s *statsCounters) lookup(hit bool) {
+	if hit {
+		atomic.AddInt64(&s.hits, 1)
+	} else {
+		atomic.AddInt64(&s.misses, 1)
+	}
+}
+
+// snapshot 返回计数器的当前值
+func (s *statsCounters) snapshot(itemCount int) Stats {
+	return Stats{
+		Hits:        atomic.LoadInt64(&s.hits),
+		Misses:      atomic.LoadInt64(&s.misses),
+		Evictions:   atomic.LoadInt64(&s.evictions),
+		Expirations: atomic.LoadInt64(&s.expirations),
+		ItemCount:   itemCount,
+	}
+}
+
+// CleanupStats 定期清理过期项的统计信息
+type CleanupStats struct {
+	Sweeps       int64         // 累计清理次数
+	LastReaped   int           // 最近一次清理移除的过期项数
+	LastExamined int           // 最近一次清理检查的项数
+	LastDuration time.Duration // 最近一次清理耗时
 }
 
 // CacheType 缓存类型
@@ -24,8 +116,16 @@ type CacheType string
 const (
 	LRU  CacheType = "lru"
 	LRU2 CacheType = "lru2"
+	// Bolt 基于 bbolt 的磁盘存储，需要导入 store/boltstore 包注册后才能使用
+	Bolt CacheType = "bolt"
 )
 
+// Codec 持久化存储序列化缓存值的方式
+type Codec interface {
+	Encode(value Value) ([]byte, error)
+	Decode(data []byte) (Value, error)
+}
+
 // Options 缓存配置选项
 type Options struct {
 	MaxBytes        int64
@@ -33,7 +133,19 @@ type Options struct {
 	CapPerBucket    uint16                        // 每个桶容量(lru2)
 	Level2Cap       uint16                        // 二级缓存容量(lru2)
 	CleanupInterval time.Duration                 // 清理时间间隔
+	MaxAge          time.Duration                 // 最大存活时间，写入超过此时长的项视为过期，0 表示不限制
 	OnEvicted       func(key string, value Value) // 回调函数
+	Admission       AdmissionPolicy               // 准入策略(lru)，为空时接受所有写入
+	CleanupBatch    int                           // 每次定期清理每个桶最多检查的项数(lru2)，0 表示检查全部
+	CleanupWorkers  int                           // 定期清理的并发协程数(lru2)，每个协程负责互不重叠的一组桶，<= 1 表示逐个桶清理
+	StrictExpiry    bool                          // 严格过期，Get/Len/UsedBytes 同步清理遇到的过期项，统计结果不包含过期数据
+	EvictionSamples int                           // 近似 LRU 淘汰时随机采样的项数(lru)，淘汰其中最久未访问的，0 表示精确 LRU
+	PromoteAfter    int                           // 一级缓存中的项被访问多少次后晋升到二级缓存(lru2)，<= 1 表示首次命中即晋升
+	PromoteWindow   time.Duration                 // 统计晋升访问次数的时间窗口(lru2)，超过窗口重新计数，0 表示不限制
+	NoPromote       bool                          // 读取不调整缓存项的顺序，只按写入顺序和过期时间淘汰(FIFO)，lru2 中的项不再晋升到二级缓存
+	ShardHash       func(key string) uint32       // 计算键所属桶的哈希函数(lru2)，取低位选择桶，为空时使用 BKDR 哈希
+	Path            string                        // 数据文件路径(bolt)
+	Codec           Codec                         // 缓存值的序列化方式(bolt)
 }
 
 func NewOptions() Options {
@@ -47,13 +159,40 @@ func NewOptions() Options {
 	}
 }
 
-func NewStore(cacheType CacheType, opts Options) Store {
+// Factory 创建存储实例的函数
+type Factory func(opts Options) (Store, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[CacheType]Factory)
+)
+
+// Register 注册 cacheType 对应的存储实现，供依赖外部库的存储在各自的包中注册，重复注册时覆盖
+// 不能覆盖内置的 LRU 和 LRU2
+func Register(cacheType CacheType, factory Factory) {
+	if cacheType == LRU || cacheType == LRU2 || cacheType == "" {
+		panic(fmt.Sprintf("store: cannot register built-in cache type %q", cacheType))
+	}
+
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[cacheType] = factory
+}
+
+// NewStore 创建指定类型的缓存，类型为空时使用 LRU，未知或未注册的类型返回 ErrUnknownCacheType
+func NewStore(cacheType CacheType, opts Options) (Store, error) {
 	switch cacheType {
-	case LRU:
-		return newLRUCache(opts)
+	case LRU, "":
+		return newLRUCache(opts), nil
 	case LRU2:
-		return newLRU2Cache(opts)
-	default:
-		return newLRUCache(opts)
+		return newLRU2Cache(opts), nil
+	}
+
+	factoriesMu.RLock()
+	factory, ok := factories[cacheType]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCacheType, cacheType)
 	}
+	return factory(opts)
 }
\ No newline at end of file
@@ -0,0 +1,669 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试 NewStore 按类型创建缓存，未知类型返回错误
+func TestNewStore(t *testing.T) {
+	tests := []struct {
+		cacheType CacheType
+		want      string
+	}{
+		{LRU, "lru"},
+		{LRU2, "lru2"},
+		{"", "lru"}, // 未指定类型时使用 LRU
+	}
+
+	for _, tt := range tests {
+		s, err := NewStore(tt.cacheType, NewOptions())
+		if err != nil {
+			t.Fatalf("NewStore(%q) failed: %v", tt.cacheType, err)
+		}
+
+		var got string
+		switch s.(type) {
+		case *lruCache:
+			got = "lru"
+		case *lru2Store:
+			got = "lru2"
+		}
+
+		// 创建的存储可以正常读写
+		if err := s.Set("key", String("value")); err != nil {
+			t.Fatalf("Set on %q store failed: %v", tt.cacheType, err)
+		}
+		if value, ok := s.Get("key"); !ok || value.(String) != "value" {
+			t.Fatalf("Expected round trip on %q store, got %v %v", tt.cacheType, value, ok)
+		}
+		s.Close()
+		if got != tt.want {
+			t.Errorf("NewStore(%q) built %s, want %s", tt.cacheType, got, tt.want)
+		}
+	}
+
+	s, err := NewStore("lur", NewOptions())
+	if !errors.Is(err, ErrUnknownCacheType) {
+		t.Fatalf("Expected ErrUnknownCacheType for typo, got %v", err)
+	}
+	if s != nil {
+		t.Fatalf("Expected no store for unknown type")
+	}
+
+	// 未导入 boltstore 包时 Bolt 未注册
+	if _, err := NewStore(Bolt, NewOptions()); !errors.Is(err, ErrUnknownCacheType) {
+		t.Fatalf("Expected ErrUnknownCacheType for unregistered type, got %v", err)
+	}
+}
+
+// 测试各存储的 Rename：移动已有的键、覆盖已有的目标键、源键不存在
+func TestStoreRename(t *testing.T) {
+	builders := map[string]func() Store{
+		"lru":  func() Store { return newLRUCache(NewOptions()) },
+		"lru2": func() Store { return newLRU2Cache(NewOptions()) },
+		"tiered-write-through": func() Store {
+			return NewTieredStore(newLRUCache(NewOptions()), newLRUCache(NewOptions()), WriteThrough)
+		},
+		"tiered-write-back": func() Store {
+			return NewTieredStore(newLRUCache(NewOptions()), newLRUCache(NewOptions()), WriteBack)
+		},
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			s := build()
+			defer s.Close()
+
+			expireAt := time.Now().Add(time.Hour)
+			s.SetWithExpiration("staged", String("v1"), time.Hour)
+			s.Set("live", String("old"))
+			s.Get("staged") // lru2 中移至二级缓存
+
+			if s.Rename("missing", "live") {
+				t.Fatalf("Expected rename of missing key to fail")
+			}
+			if v, ok := s.Get("live"); !ok || v.(String) != "old" {
+				t.Fatalf("Expected destination untouched after failed rename, got %v %v", v, ok)
+			}
+
+			if !s.Rename("staged", "fresh") {
+				t.Fatalf("Expected rename of existing key to succeed")
+			}
+			if _, ok := s.Get("staged"); ok {
+				t.Fatalf("Expected source key to be gone after rename")
+			}
+			if v, ok := s.Get("fresh"); !ok || v.(String) != "v1" {
+				t.Fatalf("Expected fresh=v1, got %v %v", v, ok)
+			}
+			if g, ok := s.(interface {
+				GetExpiration(key string) (time.Time, bool)
+			}); ok {
+				got, found := g.GetExpiration("fresh")
+				if !found || got.Sub(expireAt).Abs() > 2*time.Second {
+					t.Fatalf("Expected expiration near %v to move with the key, got %v", expireAt, got)
+				}
+			}
+
+			// 覆盖已有的目标键
+			if !s.Rename("fresh", "live") {
+				t.Fatalf("Expected rename onto existing key to succeed")
+			}
+			if v, ok := s.Get("live"); !ok || v.(String) != "v1" {
+				t.Fatalf("Expected live=v1 after overwrite, got %v %v", v, ok)
+			}
+			if _, ok := s.Get("fresh"); ok {
+				t.Fatalf("Expected fresh to be gone after rename")
+			}
+
+			if !s.Rename("live", "live") {
+				t.Fatalf("Expected rename onto itself to succeed")
+			}
+			if v, ok := s.Get("live"); !ok || v.(String) != "v1" {
+				t.Fatalf("Expected live=v1 after self rename, got %v %v", v, ok)
+			}
+		})
+	}
+}
+
+// 测试各存储将 Forever 视为永不过期
+func TestStoreForever(t *testing.T) {
+	builders := map[string]func() Store{
+		"lru":  func() Store { return newLRUCache(NewOptions()) },
+		"lru2": func() Store { return newLRU2Cache(NewOptions()) },
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			s := build()
+			defer s.Close()
+
+			s.SetWithExpiration("forever", String("v"), Forever)
+			s.ForEach(func(key string, value Value, expireAt time.Time) bool {
+				if !expireAt.IsZero() {
+					t.Fatalf("Expected no expiry for %s, got %v", key, expireAt)
+				}
+				return true
+			})
+			if _, ok := s.Get("forever"); !ok {
+				t.Fatalf("Expected Forever entry to be cached")
+			}
+		})
+	}
+}
+
+// 测试 GetAndTouch 命中时重置过期时间，并发续期期间键不会过期
+func TestStoreGetAndTouch(t *testing.T) {
+	builders := map[string]func() Store{
+		"lru":  func() Store { return newLRUCache(NewOptions()) },
+		"lru2": func() Store { return newLRU2Cache(NewOptions()) },
+		"tiered-write-through": func() Store {
+			return NewTieredStore(newLRUCache(NewOptions()), newLRUCache(NewOptions()), WriteThrough)
+		},
+		"tiered-write-back": func() Store {
+			return NewTieredStore(newLRUCache(NewOptions()), newLRUCache(NewOptions()), WriteBack)
+		},
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			s := build()
+			defer s.Close()
+
+			if _, ok := s.GetAndTouch("missing", time.Hour); ok {
+				t.Fatalf("Expected miss for missing key")
+			}
+
+			s.SetWithExpiration("session", String("v"), 300*time.Millisecond)
+
+			// 并发续期，每次调用都应命中
+			var wg sync.WaitGroup
+			var misses int32
+			deadline := time.Now().Add(600 * time.Millisecond)
+			for range 4 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for time.Now().Before(deadline) {
+						if v, ok := s.GetAndTouch("session", 300*time.Millisecond); !ok || v.(String) != "v" {
+							atomic.AddInt32(&misses, 1)
+						}
+						time.Sleep(10 * time.Millisecond)
+					}
+				}()
+			}
+			wg.Wait()
+			if n := atomic.LoadInt32(&misses); n != 0 {
+				t.Fatalf("Expected every touch to hit, got %d misses", n)
+			}
+
+			if _, ok := s.GetAndTouch("session", time.Hour); !ok {
+				t.Fatalf("Expected session to be cached")
+			}
+			if g, ok := s.(expirationGetter); ok {
+				expireAt, ok := g.GetExpiration("session")
+				if remaining := time.Until(expireAt); !ok || remaining < 59*time.Minute {
+					t.Fatalf("Expected expiry to be reset to 1h, got %v", remaining)
+				}
+			}
+
+			// 停止续期后按最后一次的过期时间过期
+			s.GetAndTouch("session", 200*time.Millisecond)
+			time.Sleep(500 * time.Millisecond)
+			if _, ok := s.Get("session"); ok {
+				t.Fatalf("Expected session to expire after touches stop")
+			}
+		})
+	}
+}
+
+// 测试各存储的 Sweep 同步清理过期项，返回后 Len 不包含过期项
+func TestStoreSweep(t *testing.T) {
+	builders := map[string]func() Store{
+		"lru":  func() Store { return newLRUCache(NewOptions()) },
+		"lru2": func() Store { return newLRU2Cache(NewOptions()) },
+		"tiered-write-through": func() Store {
+			return NewTieredStore(newLRUCache(NewOptions()), newLRUCache(NewOptions()), WriteThrough)
+		},
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			s := build()
+			defer s.Close()
+
+			for i := range 3 {
+				s.SetWithExpiration(fmt.Sprintf("short-%d", i), String("v"), 50*time.Millisecond)
+			}
+			s.Set("keep", String("v"))
+			// lru2 的时钟精度为 100ms
+			time.Sleep(250 * time.Millisecond)
+
+			s.(interface{ Sweep() }).Sweep()
+			if n := s.Len(); n != 1 {
+				t.Fatalf("Expected 1 item after Sweep, got %d", n)
+			}
+			if _, ok := s.Get("keep"); !ok {
+				t.Fatalf("Expected key without expiry to remain")
+			}
+		})
+	}
+}
+
+// 测试各存储的 Len 在过期后立即不计入过期项，且不删除过期项
+func TestStoreLenExcludesExpired(t *testing.T) {
+	tests := map[string]struct {
+		build  func() Store
+		stored func(Store) int // 实际存放的项数
+	}{
+		"lru": {
+			func() Store { return newLRUCache(NewOptions()) },
+			func(s Store) int { return s.(*lruCache).list.Len() },
+		},
+		"lru2": {
+			func() Store { return newLRU2Cache(NewOptions()) },
+			func(s Store) int { return storedEntries(s.(*lru2Store)) },
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := tt.build()
+			defer s.Close()
+
+			for i := range 3 {
+				s.SetWithExpiration(fmt.Sprintf("short-%d", i), String("v"), 50*time.Millisecond)
+			}
+			s.Set("keep", String("v"))
+			if n := s.Len(); n != 4 {
+				t.Fatalf("Expected 4 items before expiry, got %d", n)
+			}
+
+			// lru2 的时钟精度为 100ms
+			time.Sleep(250 * time.Millisecond)
+			if n := s.Len(); n != 1 {
+				t.Fatalf("Expected expired items to be excluded from Len, got %d", n)
+			}
+			if n := tt.stored(s); n != 4 {
+				t.Fatalf("Expected Len not to remove expired items, %d stored", n)
+			}
+		})
+	}
+}
+
+// 测试长度为 0 的值正常写入并命中，nil 值删除键
+func TestStoreEmptyValue(t *testing.T) {
+	builders := map[string]func() Store{
+		"lru":  func() Store { return newLRUCache(NewOptions()) },
+		"lru2": func() Store { return newLRU2Cache(NewOptions()) },
+		"tiered-write-through": func() Store {
+			return NewTieredStore(newLRUCache(NewOptions()), newLRUCache(NewOptions()), WriteThrough)
+		},
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			s := build()
+			defer s.Close()
+
+			s.Set("empty", String(""))
+			s.SetWithExpiration("empty-ttl", String(""), time.Hour)
+			for _, key := range []string{"empty", "empty-ttl"} {
+				if value, ok := s.Get(key); !ok || value.Len() != 0 {
+					t.Fatalf("Expected empty value for %s to be a hit, got %v %v", key, value, ok)
+				}
+			}
+			if _, ok := s.Get("absent"); ok {
+				t.Fatalf("Expected absent key to miss")
+			}
+
+			s.Set("empty", nil)
+			if _, ok := s.Get("empty"); ok {
+				t.Fatalf("Expected nil value to delete the key")
+			}
+		})
+	}
+}
+
+// 测试 NoPromote 模式下读取不影响淘汰顺序，最早写入的项最先被淘汰
+func TestStoreNoPromote(t *testing.T) {
+	builders := map[string]func() Store{
+		// 每项占用 2 字节，容量 3 项
+		"lru": func() Store {
+			return newLRUCache(Options{MaxBytes: 6, NoPromote: true, CleanupInterval: time.Hour})
+		},
+		"lru2": func() Store {
+			return newLRU2Cache(Options{BucketCount: 1, CapPerBucket: 3, Level2Cap: 3, NoPromote: true, CleanupInterval: time.Hour})
+		},
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			s := build()
+			defer s.Close()
+
+			for _, key := range []string{"a", "b", "c"} {
+				s.Set(key, String("v"))
+			}
+			// 反复读取最早写入的项
+			for range 3 {
+				if _, ok := s.Get("a"); !ok {
+					t.Fatalf("Expected a to be cached")
+				}
+			}
+			s.Set("d", String("v"))
+
+			if _, ok := s.Get("a"); ok {
+				t.Fatalf("Expected a to be evicted first regardless of access")
+			}
+			for _, key := range []string{"b", "c", "d"} {
+				if _, ok := s.Get(key); !ok {
+					t.Fatalf("Expected %s to remain", key)
+				}
+			}
+		})
+	}
+}
+
+// 测试反复 Peek 不影响淘汰顺序，过期的键返回 false 但不被删除
+func TestStorePeek(t *testing.T) {
+	builders := map[string]func() Store{
+		// 每项占用 2 字节，容量 3 项
+		"lru": func() Store {
+			return newLRUCache(Options{MaxBytes: 6, CleanupInterval: time.Hour})
+		},
+		"lru2": func() Store {
+			return newLRU2Cache(Options{BucketCount: 1, CapPerBucket: 3, Level2Cap: 3, CleanupInterval: time.Hour})
+		},
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			s := build()
+			defer s.Close()
+
+			for _, key := range []string{"a", "b", "c"} {
+				s.Set(key, String("v"))
+			}
+			for range 3 {
+				if value, ok := s.Peek("a"); !ok || value != String("v") {
+					t.Fatalf("Expected Peek hit for a, got %v %v", value, ok)
+				}
+			}
+			s.Set("d", String("v"))
+
+			if _, ok := s.Peek("a"); ok {
+				t.Fatalf("Expected a to be evicted first despite Peek")
+			}
+			for _, key := range []string{"b", "c", "d"} {
+				if _, ok := s.Peek(key); !ok {
+					t.Fatalf("Expected %s to remain", key)
+				}
+			}
+
+			s.SetWithExpiration("b", String("v"), 50*time.Millisecond)
+			// lru2 的时钟精度为 100ms
+			time.Sleep(250 * time.Millisecond)
+			if _, ok := s.Peek("b"); ok {
+				t.Fatalf("Expected Peek to miss an expired key")
+			}
+		})
+	}
+}
+
+// 测试 Keys 和 Contains 跳过过期的键且不影响淘汰顺序
+func TestStoreKeys(t *testing.T) {
+	builders := map[string]func() Store{
+		// 每项占用 2 字节，容量 3 项
+		"lru": func() Store {
+			return newLRUCache(Options{MaxBytes: 6, CleanupInterval: time.Hour})
+		},
+		"lru2": func() Store {
+			return newLRU2Cache(Options{BucketCount: 1, CapPerBucket: 3, Level2Cap: 3, CleanupInterval: time.Hour})
+		},
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			s := build()
+			defer s.Close()
+
+			s.Set("a", String("v"))
+			s.Set("b", String("v"))
+			s.SetWithExpiration("c", String("v"), 50*time.Millisecond)
+			// lru2 的时钟精度为 100ms
+			time.Sleep(250 * time.Millisecond)
+
+			keys := s.Keys()
+			sort.Strings(keys)
+			if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+				t.Fatalf("Expected keys [a b], got %v", keys)
+			}
+			if s.Contains("c") {
+				t.Fatalf("Expected Contains to be false for an expired key")
+			}
+
+			s.Set("c", String("v"))
+			for range 3 {
+				if !s.Contains("a") {
+					t.Fatalf("Expected Contains(a) to be true")
+				}
+			}
+			s.Set("d", String("v"))
+			if s.Contains("a") {
+				t.Fatalf("Expected a to be evicted first despite Contains")
+			}
+		})
+	}
+}
+
+// 测试并发读取同一个未缓存的键时 loader 只调用一次，加载失败时所有等待者都得到错误且不写入缓存
+func TestStoreGetOrLoad(t *testing.T) {
+	builders := map[string]func() Store{
+		"lru": func() Store {
+			return newLRUCache(Options{MaxBytes: 1024, CleanupInterval: time.Hour})
+		},
+		"lru2": func() Store {
+			return newLRU2Cache(Options{BucketCount: 4, CapPerBucket: 16, Level2Cap: 16, CleanupInterval: time.Hour})
+		},
+		"tiered": func() Store {
+			return NewTieredStore(newLRUCache(NewOptions()), newLRUCache(NewOptions()), WriteThrough)
+		},
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			s := build()
+			defer s.Close()
+
+			var loads int32
+			var wg sync.WaitGroup
+			for range 100 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					value, err := s.GetOrLoad("key", func() (Value, time.Duration, error) {
+						atomic.AddInt32(&loads, 1)
+						time.Sleep(50 * time.Millisecond)
+						return String("value"), 200 * time.Millisecond, nil
+					})
+					if err != nil || value != String("value") {
+						t.Errorf("Expected loaded value, got %v %v", value, err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if n := atomic.LoadInt32(&loads); n != 1 {
+				t.Fatalf("Expected loader to run once, ran %d times", n)
+			}
+			if !s.Contains("key") {
+				t.Fatalf("Expected loaded value to be cached")
+			}
+			// 返回的过期时间生效，lru2 的时钟精度为 100ms，过期时间需大于时钟误差
+			time.Sleep(450 * time.Millisecond)
+			if s.Contains("key") {
+				t.Fatalf("Expected loaded value to expire")
+			}
+
+			errLoad := errors.New("load failed")
+			release := make(chan struct{})
+			errs := make(chan error, 10)
+			for range 10 {
+				go func() {
+					_, err := s.GetOrLoad("bad", func() (Value, time.Duration, error) {
+						<-release
+						return nil, 0, errLoad
+					})
+					errs <- err
+				}()
+			}
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+			for range 10 {
+				if err := <-errs; !errors.Is(err, errLoad) {
+					t.Fatalf("Expected loader error for every waiter, got %v", err)
+				}
+			}
+			if s.Contains("bad") {
+				t.Fatalf("Expected failed load not to populate the store")
+			}
+		})
+	}
+}
+
+// 测试批量读取、写入和删除，键分布在多个桶中
+func TestStoreMulti(t *testing.T) {
+	builders := map[string]func() Store{
+		"lru": func() Store {
+			return newLRUCache(Options{MaxBytes: 1024, CleanupInterval: time.Hour})
+		},
+		"lru2": func() Store {
+			return newLRU2Cache(Options{BucketCount: 8, CapPerBucket: 16, Level2Cap: 16, CleanupInterval: time.Hour})
+		},
+		"tiered": func() Store {
+			return NewTieredStore(newLRUCache(NewOptions()), newLRUCache(NewOptions()), WriteThrough)
+		},
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			s := build()
+			defer s.Close()
+
+			items := make(map[string]Value)
+			keys := make([]string, 0, 20)
+			for i := range 20 {
+				key := fmt.Sprintf("key%d", i)
+				items[key] = String("v" + key)
+				keys = append(keys, key)
+			}
+			if err := s.SetMulti(items); err != nil {
+				t.Fatalf("SetMulti failed: %v", err)
+			}
+			s.SetWithExpiration("expired", String("v"), 50*time.Millisecond)
+			// lru2 的时钟精度为 100ms
+			time.Sleep(250 * time.Millisecond)
+
+			values := s.GetMulti(append(keys, "missing", "expired"))
+			if len(values) != len(items) {
+				t.Fatalf("Expected %d hits, got %d: %v", len(items), len(values), values)
+			}
+			for key, want := range items {
+				if got := values[key]; got != want {
+					t.Fatalf("Expected %v for %s, got %v", want, key, got)
+				}
+			}
+
+			// nil 值删除对应的键
+			if err := s.SetMulti(map[string]Value{"key0": nil, "new": String("v")}); err != nil {
+				t.Fatalf("SetMulti failed: %v", err)
+			}
+			if s.Contains("key0") || !s.Contains("new") {
+				t.Fatalf("Expected SetMulti to delete key0 and add new")
+			}
+
+			if n := s.DeleteMulti([]string{"key1", "key2", "key1", "missing"}); n != 2 {
+				t.Fatalf("Expected DeleteMulti to remove 2 keys, got %d", n)
+			}
+			if values := s.GetMulti([]string{"key1", "key2", "key3"}); len(values) != 1 {
+				t.Fatalf("Expected only key3 to remain, got %v", values)
+			}
+		})
+	}
+}
+
+// 测试两级缓存的 Peek 不将慢速层的值提升到快速层
+func TestTieredStorePeek(t *testing.T) {
+	fast, slow := newLRUCache(NewOptions()), newLRUCache(NewOptions())
+	s := NewTieredStore(fast, slow, WriteThrough)
+	defer s.Close()
+
+	slow.Set("key", String("v"))
+	if value, ok := s.Peek("key"); !ok || value != String("v") {
+		t.Fatalf("Expected Peek to read the slow tier, got %v %v", value, ok)
+	}
+	if _, ok := fast.Peek("key"); ok {
+		t.Fatalf("Expected Peek not to promote into the fast tier")
+	}
+}
+
+// 测试访问统计随命中、未命中、容量淘汰和过期删除变化，显式删除不计入淘汰
+func TestStoreStats(t *testing.T) {
+	builders := map[string]func() Store{
+		"lru": func() Store {
+			// 每项 2 字节，最多容纳 3 项
+			return newLRUCache(Options{MaxBytes: 6, CleanupInterval: time.Hour})
+		},
+		"lru2": func() Store {
+			return newLRU2Cache(Options{BucketCount: 1, CapPerBucket: 2, Level2Cap: 2, CleanupInterval: time.Hour})
+		},
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			s := build()
+			defer s.Close()
+
+			s.Set("a", String("1"))
+			if _, ok := s.Get("a"); !ok {
+				t.Fatalf("Expected hit for a")
+			}
+			if _, ok := s.Get("x"); ok {
+				t.Fatalf("Expected miss for x")
+			}
+			if stats := s.Stats(); stats.Hits != 1 || stats.Misses != 1 || stats.Evictions != 0 {
+				t.Fatalf("Expected 1 hit and 1 miss without evictions, got %+v", stats)
+			}
+
+			// lru 淘汰最久未使用的 a，lru2 淘汰一级缓存中的 b，a 已晋升到二级缓存
+			for _, key := range []string{"b", "c", "d"} {
+				s.Set(key, String("1"))
+			}
+			if stats := s.Stats(); stats.Evictions != 1 {
+				t.Fatalf("Expected 1 eviction, got %+v", stats)
+			}
+
+			s.SetWithExpiration("e", String("1"), 50*time.Millisecond)
+			// lru2 的时钟精度为 100ms
+			time.Sleep(250 * time.Millisecond)
+			if _, ok := s.Get("e"); ok {
+				t.Fatalf("Expected expired key to miss")
+			}
+			// lru 在后台删除读取到的过期项，同步清理确保已删除
+			s.(interface{ Sweep() }).Sweep()
+
+			s.Delete("d")
+			stats := s.Stats()
+			want := Stats{Hits: 1, Misses: 2, Evictions: 2, Expirations: 1, ItemCount: 1}
+			if stats != want {
+				t.Fatalf("Expected %+v, got %+v", want, stats)
+			}
+		})
+	}
+}
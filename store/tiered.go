@@ -0,0 +1,506 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WritePolicy 两级缓存的写入策略
+type WritePolicy int
+
+const (
+	// WriteThrough 同时写入快速层和慢速层
+	WriteThrough WritePolicy = iota
+	// WriteBack 只写入快速层并记录为脏数据，Flush、Close 或脏数据过多时写回慢速层
+	// 写回前被快速层淘汰的更新会丢失，之后读取该键视为未命中
+	WriteBack
+)
+
+// maxDirty 写回策略下触发自动写回的脏数据数量
+const maxDirty = 1024
+
+// TieredStore 两级缓存，容量较小的快速层在前，容量较大的慢速层在后
+// 快速层未命中时查找慢速层，命中后提升到快速层；Len、UsedBytes、Clear 同时作用于两层
+type TieredStore struct {
+	fast   Store
+	slow   Store
+	policy WritePolicy
+	mu     sync.Mutex
+	dirty  map[string]time.Time // 尚未写回慢速层的键及其过期时间，零值表示永不过期
+	loads  LoadGroup            // 合并同一个键的并发加载
+	counts statsCounters        // 两层合计的访问统计
+}
+
+// 编译时检查 TieredStore 是否实现了 Store 接口
+var _ Store = (*TieredStore)(nil)
+
+// expirationGetter 可以查询缓存项过期时间的存储
+type expirationGetter interface {
+	GetExpiration(key string) (time.Time, bool)
+}
+
+// sweeper 可以同步清理过期项的存储
+type sweeper interface {
+	Sweep()
+}
+
+// NewTieredStore 组合快速层 fast 和慢速层 slow 创建两级缓存，两层的生命周期由 TieredStore 管理
+func NewTieredStore(fast, slow Store, policy WritePolicy) *TieredStore {
+	return &TieredStore{
+		fast:   fast,
+		slow:   slow,
+		policy: policy,
+		dirty:  make(map[string]time.Time),
+	}
+}
+
+// Get 实现Store接口，慢速层命中时提升到快速层
+func (t *TieredStore) Get(key string) (Value, bool) {
+	if value, ok := t.fast.Get(key); ok {
+		t.counts.lookup(true)
+		return value, true
+	}
+	value, ok := t.getSlow(key)
+	t.counts.lookup(ok)
+	return value, ok
+}
+
+// Peek 实现Store接口，快速层未命中时读取慢速层，不提升到快速层
+// 写回模式下快速层淘汰了尚未写回的键时，慢速层的值已过时，返回 false
+func (t *TieredStore) Peek(key string) (Value, bool) {
+	if value, ok := t.fast.Peek(key); ok {
+		return value, true
+	}
+	if t.policy == WriteBack {
+		t.mu.Lock()
+		_, dirty := t.dirty[key]
+		t.mu.Unlock()
+		if dirty {
+			return nil, false
+		}
+	}
+	return t.slow.Peek(key)
+}
+
+// Contains 实现Store接口，与 Peek 相同，不提升到快速层
+func (t *TieredStore) Contains(key string) bool {
+	_, ok := t.Peek(key)
+	return ok
+}
+
+// GetOrLoad 实现Store接口，两层都未命中时才加载，加载的值按写入策略写入两层
+func (t *TieredStore) GetOrLoad(key string, loader LoadFunc) (Value, error) {
+	return t.loads.GetOrLoad(t, key, loader)
+}
+
+// getSlow 从慢速层读取并提升到快速层
+func (t *TieredStore) getSlow(key string) (Value, bool) {
+	if t.policy == WriteBack && t.lost(key) {
+		return nil, false
+	}
+
+	value, ok := t.slow.Get(key)
+	if !ok {
+		return nil, false
+	}
+	t.promote(key, value)
+	return value, true
+}
+
+// lost 判断快速层是否淘汰了尚未写回的键，此时慢速层的值已过时，一并删除
+func (t *TieredStore) lost(key string) bool {
+	t.mu.Lock()
+	_, dirty := t.dirty[key]
+	delete(t.dirty, key)
+	t.mu.Unlock()
+
+	if dirty {
+		t.slow.Delete(key)
+	}
+	return dirty
+}
+
+// promote 将慢速层的值写入快速层，保留原过期时间
+func (t *TieredStore) promote(key string, value Value) {
+	if g, ok := t.slow.(expirationGetter); ok {
+		if expireAt, ok := g.GetExpiration(key); ok {
+			if ttl := time.Until(expireAt); ttl > 0 {
+				t.fast.SetWithExpiration(key, value, ttl)
+			}
+			return
+		}
+	}
+	// 值超过快速层容量时留在慢速层
+	t.fast.Set(key, value)
+}
+
+// Set 实现Store接口
+func (t *TieredStore) Set(key string, value Value) error {
+	return t.SetWithExpiration(key, value, 0)
+}
+
+// SetWithExpiration 实现Store接口，expiration <= 0 表示永不过期
+func (t *TieredStore) SetWithExpiration(key string, value Value, expiration time.Duration) error {
+	if t.policy == WriteBack {
+		if err := setTier(t.fast, key, value, expiration); err != nil {
+			// 快速层放不下时直接写入慢速层
+			t.fast.Delete(key)
+			t.clean(key)
+			return setTier(t.slow, key, value, expiration)
+		}
+		t.markDirty(key, expiration)
+		return nil
+	}
+
+	if err := setTier(t.slow, key, value, expiration); err != nil {
+		return err
+	}
+	if err := setTier(t.fast, key, value, expiration); err != nil {
+		// 快速层拒绝写入时删除其中的旧值，读取时回落到慢速层
+		t.fast.Delete(key)
+	}
+	return nil
+}
+
+// setTier 按过期时间写入一层缓存
+func setTier(s Store, key string, value Value, expiration time.Duration) error {
+	if expiration > 0 {
+		return s.SetWithExpiration(key, value, expiration)
+	}
+	return s.Set(key, value)
+}
+
+// markDirty 记录尚未写回的键，脏数据过多时写回慢速层
+func (t *TieredStore) markDirty(key string, expiration time.Duration) {
+	var expireAt time.Time
+	if expiration > 0 {
+		expireAt = time.Now().Add(expiration)
+	}
+
+	t.mu.Lock()
+	t.dirty[key] = expireAt
+	full := len(t.dirty) >= maxDirty
+	t.mu.Unlock()
+
+	if full {
+		t.Flush()
+	}
+}
+
+// clean 移除键的脏数据记录
+func (t *TieredStore) clean(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.dirty, key)
+}
+
+// Flush 将快速层中尚未写回的数据写入慢速层，写入失败的键保留到下次写回
+func (t *TieredStore) Flush() error {
+	t.mu.Lock()
+	dirty := t.dirty
+	t.dirty = make(map[string]time.Time)
+	t.mu.Unlock()
+
+	var firstErr error
+	now := time.Now()
+	for key, expireAt := range dirty {
+		value, ok := t.fast.Get(key)
+		if !ok {
+			// 已被淘汰或删除，慢速层中的旧值不再有效
+			t.slow.Delete(key)
+			continue
+		}
+
+		var expiration time.Duration
+		if !expireAt.IsZero() {
+			if expiration = expireAt.Sub(now); expiration <= 0 {
+				t.slow.Delete(key)
+				continue
+			}
+		}
+		if err := setTier(t.slow, key, value, expiration); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			t.mu.Lock()
+			if _, exists := t.dirty[key]; !exists {
+				t.dirty[key] = expireAt
+			}
+			t.mu.Unlock()
+		}
+	}
+	return firstErr
+}
+
+// Sweep 同步清理两层中的过期项，不写回脏数据
+func (t *TieredStore) Sweep() {
+	for _, s := range []Store{t.fast, t.slow} {
+		if sw, ok := s.(sweeper); ok {
+			sw.Sweep()
+		}
+	}
+}
+
+// GetWithVersion 实现Store接口，版本号来自快速层
+func (t *TieredStore) GetWithVersion(key string) (Value, uint64, bool) {
+	if value, version, ok := t.fast.GetWithVersion(key); ok {
+		t.counts.lookup(true)
+		return value, version, true
+	}
+	_, ok := t.getSlow(key)
+	t.counts.lookup(ok)
+	if !ok {
+		return nil, 0, false
+	}
+	return t.fast.GetWithVersion(key)
+}
+
+// GetAndTouch 实现Store接口，同时重置两层中的过期时间，避免慢速层的副本先过期
+func (t *TieredStore) GetAndTouch(key string, newTTL time.Duration) (Value, bool) {
+	value, ok := t.fast.GetAndTouch(key, newTTL)
+	if !ok {
+		if _, ok := t.getSlow(key); !ok {
+			t.counts.lookup(false)
+			return nil, false
+		}
+		t.counts.lookup(true)
+		if value, ok = t.fast.GetAndTouch(key, newTTL); !ok {
+			// 值超过快速层容量时留在慢速层
+			return t.slow.GetAndTouch(key, newTTL)
+		}
+	} else {
+		t.counts.lookup(true)
+	}
+	t.slow.GetAndTouch(key, newTTL)
+	return value, true
+}
+
+// SetIfVersion 实现Store接口，按快速层的版本号比较，写入成功后按写入策略同步到慢速层
+func (t *TieredStore) SetIfVersion(key string, value Value, expectedVersion uint64, expiration time.Duration) (bool, error) {
+	ok, err := t.fast.SetIfVersion(key, value, expectedVersion, expiration)
+	if !ok || err != nil {
+		return ok, err
+	}
+
+	if value == nil {
+		t.slow.Delete(key)
+		t.clean(key)
+		return true, nil
+	}
+	if t.policy == WriteBack {
+		t.markDirty(key, expiration)
+		return true, nil
+	}
+	return true, setTier(t.slow, key, value, expiration)
+}
+
+// Delete 实现Store接口，从两层中删除
+func (t *TieredStore) Delete(key string) bool {
+	t.clean(key)
+	fast := t.fast.Delete(key)
+	slow := t.slow.Delete(key)
+	return fast || slow
+}
+
+// GetMulti 实现Store接口，先批量读取快速层，未命中的键逐个读取慢速层并提升到快速层
+func (t *TieredStore) GetMulti(keys []string) map[string]Value {
+	values := t.fast.GetMulti(keys)
+	for _, key := range keys {
+		if _, ok := values[key]; ok {
+			continue
+		}
+		if value, ok := t.getSlow(key); ok {
+			values[key] = value
+		}
+	}
+	hits := 0
+	for _, key := range keys {
+		if _, ok := values[key]; ok {
+			hits++
+		}
+	}
+	atomic.AddInt64(&t.counts.hits, int64(hits))
+	atomic.AddInt64(&t.counts.misses, int64(len(keys)-hits))
+	return values
+}
+
+// SetMulti 实现Store接口，按写入策略逐个写入两层
+func (t *TieredStore) SetMulti(items map[string]Value) error {
+	var errs []error
+	for key, value := range items {
+		if err := t.Set(key, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeleteMulti 实现Store接口，同时存在于两层的键只计数一次
+func (t *TieredStore) DeleteMulti(keys []string) int {
+	deleted := 0
+	for _, key := range keys {
+		if t.Delete(key) {
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// Rename 实现Store接口，在两层中分别移动，只有一层存在 oldKey 时删除另一层中 newKey 的旧值
+func (t *TieredStore) Rename(oldKey, newKey string) bool {
+	fast := t.fast.Rename(oldKey, newKey)
+	// 尚未写回就被快速层淘汰的键视为不存在
+	if !fast && t.policy == WriteBack && t.lost(oldKey) {
+		return false
+	}
+	slow := t.slow.Rename(oldKey, newKey)
+	if !fast && !slow {
+		return false
+	}
+
+	if !fast {
+		t.fast.Delete(newKey)
+	}
+	if !slow {
+		t.slow.Delete(newKey)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	expireAt, dirty := t.dirty[oldKey]
+	delete(t.dirty, oldKey)
+	if dirty && fast {
+		t.dirty[newKey] = expireAt
+	} else {
+		delete(t.dirty, newKey)
+	}
+	return true
+}
+
+// Clear 实现Store接口，清空两层
+func (t *TieredStore) Clear() {
+	t.mu.Lock()
+	clear(t.dirty)
+	t.mu.Unlock()
+
+	t.fast.Clear()
+	t.slow.Clear()
+}
+
+// Len 实现Store接口，返回与 Keys 一致的项数，同时存在于两层的键只计算一次，需要遍历两层的键
+func (t *TieredStore) Len() int {
+	return len(t.Keys())
+}
+
+// Stats 实现Store接口，命中和未命中按 TieredStore 的读取统计，任意一层命中即为命中
+// Evictions 和 Expirations 为两层之和，ItemCount 与 Len 相同，同时存在于两层的键只计算一次
+func (t *TieredStore) Stats() Stats {
+	fast, slow := t.fast.Stats(), t.slow.Stats()
+	stats := t.counts.snapshot(t.Len())
+	stats.Evictions = fast.Evictions + slow.Evictions
+	stats.Expirations = fast.Expirations + slow.Expirations
+	return stats
+}
+
+// Keys 实现Store接口，返回两层键的并集，同时存在于两层的键只返回一次
+// 写回模式下快速层淘汰了尚未写回的键时，慢速层的值已过时，不返回该键
+func (t *TieredStore) Keys() []string {
+	keys := t.fast.Keys()
+	seen := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		seen[key] = struct{}{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, key := range t.slow.Keys() {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		if _, dirty := t.dirty[key]; dirty && t.policy == WriteBack {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// UsedBytes 返回两层已使用字节数之和，不支持按字节统计的层计为 0
+func (t *TieredStore) UsedBytes() int64 {
+	var used int64
+	for _, s := range []Store{t.fast, t.slow} {
+		if b, ok := s.(interface{ UsedBytes() int64 }); ok {
+			used += b.UsedBytes()
+		}
+	}
+	return used
+}
+
+// EvictBytes 淘汰至少 n 字节，先淘汰慢速层中较冷的数据，返回实际淘汰的字节数
+func (t *TieredStore) EvictBytes(n int64) int64 {
+	var freed int64
+	for _, s := range []Store{t.slow, t.fast} {
+		if freed >= n {
+			break
+		}
+		if e, ok := s.(interface{ EvictBytes(n int64) int64 }); ok {
+			freed += e.EvictBytes(n - freed)
+		}
+	}
+	return freed
+}
+
+// ForEach 实现Store接口，先遍历快速层，再遍历慢速层中快速层没有的键
+func (t *TieredStore) ForEach(fn func(key string, value Value, expireAt time.Time) bool) {
+	seen := make(map[string]struct{})
+	stopped := false
+	t.fast.ForEach(func(key string, value Value, expireAt time.Time) bool {
+		seen[key] = struct{}{}
+		if !fn(key, value, expireAt) {
+			stopped = true
+		}
+		return !stopped
+	})
+	if stopped {
+		return
+	}
+
+	t.slow.ForEach(func(key string, value Value, expireAt time.Time) bool {
+		if _, ok := seen[key]; ok {
+			return true
+		}
+		return fn(key, value, expireAt)
+	})
+}
+
+// slowCursor 游标最高位标记正在遍历慢速层
+const slowCursor = uint64(1) << 63
+
+// Scan 实现Store接口，先遍历快速层再遍历慢速层，同时存在于两层的键会返回两次
+func (t *TieredStore) Scan(cursor uint64, count int) ([]string, uint64) {
+	if cursor&slowCursor == 0 {
+		keys, next := t.fast.Scan(cursor, count)
+		if next == 0 {
+			next = slowCursor
+		}
+		return keys, next
+	}
+
+	keys, next := t.slow.Scan(cursor&^slowCursor, count)
+	if next == 0 {
+		return keys, 0
+	}
+	return keys, next | slowCursor
+}
+
+// Close 实现Store接口，写回脏数据后关闭两层
+func (t *TieredStore) Close() {
+	t.Flush()
+	t.fast.Close()
+	t.slow.Close()
+}
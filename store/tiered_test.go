@@ -0,0 +1,152 @@
+package store
+
+import (
+	"sort"
+	"testing"
+)
+
+// newTestTieredStore 创建快速层只能容纳两个值的两级缓存
+func newTestTieredStore(t *testing.T, policy WritePolicy) (*TieredStore, *lruCache, *lruCache) {
+	t.Helper()
+	fast := newLRUCache(Options{MaxBytes: 20})
+	slow := newLRUCache(Options{MaxBytes: 1 << 20})
+	ts := NewTieredStore(fast, slow, policy)
+	t.Cleanup(ts.Close)
+	return ts, fast, slow
+}
+
+// 测试慢速层命中后提升到快速层
+func TestTieredStorePromotion(t *testing.T) {
+	ts, fast, slow := newTestTieredStore(t, WriteThrough)
+
+	// 每项 key+value 为 8 字节，快速层只能保留最近两项
+	for _, key := range []string{"key1", "key2", "key3"} {
+		if err := ts.Set(key, String("vvvv")); err != nil {
+			t.Fatalf("Set %s failed: %v", key, err)
+		}
+	}
+	if _, ok := fast.Get("key1"); ok {
+		t.Fatalf("Expected key1 to be evicted from the fast tier")
+	}
+	if _, ok := slow.Get("key1"); !ok {
+		t.Fatalf("Expected key1 to remain in the slow tier")
+	}
+
+	value, ok := ts.Get("key1")
+	if !ok || value.(String) != "vvvv" {
+		t.Fatalf("Expected key1 from the slow tier, got %v, %v", value, ok)
+	}
+	if _, ok := fast.Get("key1"); !ok {
+		t.Fatalf("Expected key1 to be promoted to the fast tier")
+	}
+
+	// 快速层放不下的值只保存在慢速层
+	if err := ts.Set("large", String("0123456789abcdef0123")); err != nil {
+		t.Fatalf("Set large value failed: %v", err)
+	}
+	if _, ok := ts.Get("large"); !ok {
+		t.Fatalf("Expected value larger than the fast tier to be served from the slow tier")
+	}
+}
+
+// 测试 Len 对两层去重，UsedBytes、Clear 同时作用于两层
+func TestTieredStoreAggregation(t *testing.T) {
+	ts, fast, slow := newTestTieredStore(t, WriteThrough)
+
+	for _, key := range []string{"key1", "key2", "key3"} {
+		ts.Set(key, String("vvvv"))
+	}
+
+	if got := ts.Len(); got != 3 || got != len(ts.Keys()) {
+		t.Fatalf("Expected Len to count keys in both tiers once (3), got %d", got)
+	}
+	if got, want := ts.UsedBytes(), fast.UsedBytes()+slow.UsedBytes(); got != want || got != 40 {
+		t.Fatalf("Expected UsedBytes to sum both tiers (40), got %d", got)
+	}
+
+	ts.Clear()
+	if ts.Len() != 0 || ts.UsedBytes() != 0 {
+		t.Fatalf("Expected both tiers to be empty after Clear, got len=%d bytes=%d", ts.Len(), ts.UsedBytes())
+	}
+}
+
+// 测试写回策略只写入快速层，Flush 后写入慢速层
+func TestTieredStoreWriteBack(t *testing.T) {
+	ts, fast, slow := newTestTieredStore(t, WriteBack)
+
+	ts.Set("key1", String("vvvv"))
+	if _, ok := fast.Get("key1"); !ok {
+		t.Fatalf("Expected key1 in the fast tier")
+	}
+	if _, ok := slow.Get("key1"); ok {
+		t.Fatalf("Expected write-back to defer writing the slow tier")
+	}
+
+	if err := ts.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if _, ok := slow.Get("key1"); !ok {
+		t.Fatalf("Expected key1 in the slow tier after Flush")
+	}
+
+	// 写回前被淘汰的更新丢失，慢速层的旧值不再返回
+	ts.Set("key1", String("new1"))
+	ts.Set("key2", String("vvvv"))
+	ts.Set("key3", String("vvvv"))
+	if _, ok := ts.Get("key1"); ok {
+		t.Fatalf("Expected stale slow-tier value not to be served for an unflushed key")
+	}
+}
+
+// 测试 Keys 返回两层键的并集，跳过写回前被快速层淘汰的键
+func TestTieredStoreKeys(t *testing.T) {
+	ts, _, slow := newTestTieredStore(t, WriteBack)
+
+	ts.Set("key1", String("vvvv"))
+	if err := ts.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	slow.Set("key0", String("vvvv"))
+
+	keys := ts.Keys()
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "key0" || keys[1] != "key1" {
+		t.Fatalf("Expected keys [key0 key1], got %v", keys)
+	}
+
+	// key1 的更新在写回前被淘汰，慢速层的旧值已过时
+	ts.Set("key1", String("new1"))
+	ts.Set("key2", String("vvvv"))
+	ts.Set("key3", String("vvvv"))
+	keys = ts.Keys()
+	sort.Strings(keys)
+	if len(keys) != 3 || keys[0] != "key0" || keys[1] != "key2" || keys[2] != "key3" {
+		t.Fatalf("Expected keys [key0 key2 key3], got %v", keys)
+	}
+	if ts.Contains("key1") || !ts.Contains("key0") {
+		t.Fatalf("Expected Contains to follow Keys")
+	}
+}
+
+// 测试两层中任意一层命中即计为一次命中，淘汰数为两层之和
+func TestTieredStoreStats(t *testing.T) {
+	ts, _, _ := newTestTieredStore(t, WriteThrough)
+
+	// 快速层只能保留两项，写入 key3 时淘汰 key1
+	for _, key := range []string{"key1", "key2", "key3"} {
+		ts.Set(key, String("vvvv"))
+	}
+	// 慢速层命中，提升到快速层时淘汰 key2
+	if _, ok := ts.Get("key1"); !ok {
+		t.Fatalf("Expected slow-tier hit for key1")
+	}
+	if _, ok := ts.Get("missing"); ok {
+		t.Fatalf("Expected miss for missing key")
+	}
+
+	stats := ts.Stats()
+	want := Stats{Hits: 1, Misses: 1, Evictions: 2, ItemCount: 3}
+	if stats != want {
+		t.Fatalf("Expected %+v, got %+v", want, stats)
+	}
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLoadThrottled 键在限流间隔内已加载过且没有可用的值错误
+var ErrLoadThrottled = errors.New("load throttled")
+
+// minThrottlePurge 触发清理过期加载记录的最小记录数
+const minThrottlePurge = 1024
+
+// WithLoadInterval 限制每个键调用 Getter 的频率，interval 内最多加载一次
+// 限流期间返回该键最近一次加载成功的值，从未加载成功时返回 ErrLoadThrottled；从其他节点读取不受限制
+func WithLoadInterval(interval time.Duration) GroupOption {
+	return func(g *Group) {
+		if interval > 0 {
+			g.throttle = newLoadThrottle(interval)
+		}
+	}
+}
+
+// loadThrottle 按键限制加载频率
+type loadThrottle struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	last      map[string]*lastLoad
+	nextPurge int // 记录数达到此值时清理过期记录
+}
+
+// lastLoad 键最近一次加载的记录
+type lastLoad struct {
+	at    time.Time // 最近一次调用 Getter 的时间
+	view  ByteView  // 最近一次加载成功的值
+	valid bool      // view 是否有效
+}
+
+// newLoadThrottle 创建加载限流器
+func newLoadThrottle(interval time.Duration) *loadThrottle {
+	return &loadThrottle{
+		interval:  interval,
+		last:      make(map[string]*lastLoad),
+		nextPurge: minThrottlePurge,
+	}
+}
+
+// allow 判断键是否可以调用 Getter，允许时记录本次调用时间
+// 不允许时返回最近一次加载成功的值，valid 为 false 表示没有可用的值
+func (t *loadThrottle) allow(key string, now time.Time) (view ByteView, valid, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rec, exists := t.last[key]
+	if exists && now.Sub(rec.at) < t.interval {
+		return rec.view, rec.valid, false
+	}
+
+	if !exists {
+		if len(t.last) >= t.nextPurge {
+			t.purge(now)
+		}
+		rec = &lastLoad{}
+		t.last[key] = rec
+	}
+	rec.at = now
+	return ByteView{}, false, true
+}
+
+// record 记录键加载成功的值
+func (t *loadThrottle) record(key string, view ByteView) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if rec, ok := t.last[key]; ok {
+		rec.view, rec.valid = view, true
+	}
+}
+
+// purge 清理已超过限流间隔的记录，调用此方法必须持有锁
+func (t *loadThrottle) purge(now time.Time) {
+	for key, rec := range t.last {
+		if now.Sub(rec.at) >= t.interval {
+			delete(t.last, key)
+		}
+	}
+	t.nextPurge = max(2*len(t.last), minThrottlePurge)
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lyy42995004/Cache-Go/store"
+)
+
+// 测试短过期时间的键在持续访问下加载频率受限
+func TestGroupLoadInterval(t *testing.T) {
+	var calls int32
+	getter := GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		n := atomic.AddInt32(&calls, 1)
+		return []byte{byte('0' + n)}, nil
+	})
+
+	// LRU 使用精确时钟，缓存项在 5ms 后即过期
+	cacheOpts := DefaultCacheOptions()
+	cacheOpts.CacheType = store.LRU
+
+	const interval = 100 * time.Millisecond
+	g := newTestGroup(t, getter, WithCacheOptions(cacheOpts), WithExpiration(5*time.Millisecond), WithLoadInterval(interval))
+
+	ctx := context.Background()
+	start := time.Now()
+	for time.Since(start) < 3*interval+interval/2 {
+		view, err := g.Get(ctx, "hot")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if view.Len() != 1 {
+			t.Fatalf("Expected last loaded value, got %q", view.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// 350ms 内最多加载 4 次（0、100、200、300ms）
+	got := atomic.LoadInt32(&calls)
+	if got < 2 || got > 4 {
+		t.Fatalf("Expected loader calls to be throttled to about 4, got %d", got)
+	}
+	if throttled := g.Stats()["throttled_loads"].(int64); throttled == 0 {
+		t.Fatalf("Expected throttled loads to be recorded")
+	}
+}
+
+// 测试限流期间没有可用的值时返回 ErrLoadThrottled
+func TestGroupLoadIntervalNoValue(t *testing.T) {
+	var calls int32
+	getter := GetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return nil, errors.New("backend unavailable")
+		}
+		return []byte("value"), nil
+	})
+
+	g := newTestGroup(t, getter, WithLoadInterval(50*time.Millisecond))
+	ctx := context.Background()
+
+	if _, err := g.Get(ctx, "key"); err == nil || errors.Is(err, ErrLoadThrottled) {
+		t.Fatalf("Expected loader error on first load, got %v", err)
+	}
+	if _, err := g.Get(ctx, "key"); !errors.Is(err, ErrLoadThrottled) {
+		t.Fatalf("Expected ErrLoadThrottled within interval, got %v", err)
+	}
+
+	// 其他键不受影响
+	if _, err := g.Get(ctx, "other"); err != nil {
+		t.Fatalf("Expected other key to load, got %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if view, err := g.Get(ctx, "key"); err != nil || view.String() != "value" {
+		t.Fatalf("Expected key to load after interval, got %q, %v", view.String(), err)
+	}
+}
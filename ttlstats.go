@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/lyy42995004/Cache-Go/store"
+)
+
+// TTLHistogram 按剩余过期时间统计的缓存项数量，用于调整清理间隔和容量规划
+type TTLHistogram struct {
+	UnderMinute     int `json:"under_1m"`  // 剩余不足 1 分钟
+	UnderTenMinutes int `json:"1m_to_10m"` // 剩余 1 分钟到 10 分钟
+	UnderHour       int `json:"10m_to_1h"` // 剩余 10 分钟到 1 小时
+	OverHour        int `json:"over_1h"`   // 剩余 1 小时以上
+	NoExpiry        int `json:"no_expiry"` // 永不过期
+}
+
+// Total 返回统计的缓存项总数
+func (h TTLHistogram) Total() int {
+	return h.UnderMinute + h.UnderTenMinutes + h.UnderHour + h.OverHour + h.NoExpiry
+}
+
+// add 按剩余过期时间计入对应区间
+func (h *TTLHistogram) add(ttl time.Duration) {
+	switch {
+	case ttl < time.Minute:
+		h.UnderMinute++
+	case ttl < 10*time.Minute:
+		h.UnderTenMinutes++
+	case ttl < time.Hour:
+		h.UnderHour++
+	default:
+		h.OverHour++
+	}
+}
+
+// TTLHistogram 遍历缓存，按剩余过期时间统计未过期的项，不修改缓存
+// 遍历期间持有读锁，耗时与缓存项数成正比，不适合在请求路径上频繁调用
+func (c *Cache) TTLHistogram() TTLHistogram {
+	var h TTLHistogram
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, err := c.storeLocked()
+	if err != nil {
+		return h
+	}
+
+	now := time.Now()
+	s.ForEach(func(key string, value store.Value, expireAt time.Time) bool {
+		if expireAt.IsZero() {
+			h.NoExpiry++
+		} else {
+			h.add(expireAt.Sub(now))
+		}
+		return true
+	})
+	return h
+}
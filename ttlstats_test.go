@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lyy42995004/Cache-Go/store"
+)
+
+// 测试按剩余过期时间统计缓存项
+func TestCacheTTLHistogram(t *testing.T) {
+	for _, cacheType := range []store.CacheType{store.LRU, store.LRU2} {
+		t.Run(string(cacheType), func(t *testing.T) {
+			opts := DefaultCacheOptions()
+			opts.CacheType = cacheType
+			c := NewCache(opts)
+			defer c.Close()
+
+			if h := c.TTLHistogram(); h.Total() != 0 {
+				t.Fatalf("Expected empty histogram before first write, got %+v", h)
+			}
+
+			now := time.Now()
+			ttls := []struct {
+				ttl   time.Duration
+				count int
+			}{
+				{30 * time.Second, 1},
+				{5 * time.Minute, 2},
+				{30 * time.Minute, 3},
+				{2 * time.Hour, 4},
+			}
+			for _, tc := range ttls {
+				for i := range tc.count {
+					key := fmt.Sprintf("ttl-%v-%d", tc.ttl, i)
+					if err := c.SetWithExpiration(key, ByteView{b: []byte("v")}, now.Add(tc.ttl)); err != nil {
+						t.Fatalf("SetWithExpiration failed: %v", err)
+					}
+				}
+			}
+
+			want := TTLHistogram{UnderMinute: 1, UnderTenMinutes: 2, UnderHour: 3, OverHour: 4}
+			if got := c.TTLHistogram(); got != want {
+				t.Fatalf("Expected %+v, got %+v", want, got)
+			}
+		})
+	}
+}
+
+// 测试永不过期的项单独统计
+func TestCacheTTLHistogramNoExpiry(t *testing.T) {
+	for _, cacheType := range []store.CacheType{store.LRU, store.LRU2} {
+		t.Run(string(cacheType), func(t *testing.T) {
+			opts := DefaultCacheOptions()
+			opts.CacheType = cacheType
+			c := NewCache(opts)
+			defer c.Close()
+
+			for i := range 5 {
+				c.Set(fmt.Sprintf("forever-%d", i), ByteView{b: []byte("v")})
+			}
+			c.SetWithExpiration("ttl", ByteView{b: []byte("v")}, time.Now().Add(time.Hour/2))
+
+			want := TTLHistogram{UnderHour: 1, NoExpiry: 5}
+			if got := c.TTLHistogram(); got != want {
+				t.Fatalf("Expected %+v, got %+v", want, got)
+			}
+
+			c.Close()
+			if h := c.TTLHistogram(); h.Total() != 0 {
+				t.Fatalf("Expected empty histogram after Close, got %+v", h)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrUpdateConflict Update 重试次数用完仍与并发写入冲突错误
+var ErrUpdateConflict = errors.New("update conflicted with concurrent writes")
+
+// defaultUpdateRetries Update 遇到版本冲突时默认的最大重试次数
+const defaultUpdateRetries = 10
+
+// WithUpdateRetries 设置 Update 遇到版本冲突时的最大重试次数，n <= 0 时只尝试一次
+func WithUpdateRetries(n int) GroupOption {
+	return func(g *Group) {
+		g.casRetries = max(n, 0)
+	}
+}
+
+// Update 读取本地缓存中键的当前值和版本号，调用 fn 计算新值后按版本号条件写入，版本冲突时重新读取并重试
+// 键不存在时 fn 收到 nil，fn 可能被调用多次；fn 返回错误时不写入并原样返回
+// ttl <= 0 时使用组的过期时间；写入成功后与 Set 一样同步到其他节点，重试次数用完返回 ErrUpdateConflict
+// 只基于本地缓存的版本号做乐观并发控制，不同节点上对同一个键的 Update 之间不保证互斥
+func (g *Group) Update(ctx context.Context, key string, fn func(old []byte) ([]byte, error), ttl time.Duration) error {
+	if atomic.LoadInt32(&g.closed) == 1 {
+		return ErrGroupClosed
+	}
+	if key == "" {
+		return ErrKeyRequired
+	}
+	if ttl <= 0 {
+		ttl = g.expiration
+	}
+
+	for range g.casRetries + 1 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var old []byte
+		view, version, ok := g.mainCache.GetWithVersion(key)
+		if ok {
+			old = view.ByteSLice()
+		}
+
+		value, err := fn(old)
+		if err != nil {
+			return err
+		}
+		if len(value) == 0 && !g.emptyValues {
+			return ErrValueRequired
+		}
+
+		written, err := g.mainCache.setIfVersion(key, ByteView{b: cloneBytes(value)}, version, ttl)
+		if err != nil {
+			return err
+		}
+		if !written {
+			continue
+		}
+		g.enforceLimit()
+
+		if ttl > 0 {
+			ctx = withExpireAt(ctx, time.Now().Add(ttl))
+		}
+		return g.propagate(ctx, key, value)
+	}
+	return ErrUpdateConflict
+}
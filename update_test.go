@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 测试多个协程通过 Update 并发递增计数器不会丢失更新
+func TestGroupUpdateConcurrent(t *testing.T) {
+	g := newTestGroup(t, nil, WithUpdateRetries(1000))
+	ctx := context.Background()
+
+	increment := func(old []byte) ([]byte, error) {
+		n := 0
+		if old != nil {
+			var err error
+			if n, err = strconv.Atoi(string(old)); err != nil {
+				return nil, err
+			}
+		}
+		return []byte(strconv.Itoa(n + 1)), nil
+	}
+
+	const goroutines, increments = 50, 20
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range increments {
+				if err := g.Update(ctx, "counter", increment, 0); err != nil {
+					t.Errorf("Update failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	view, err := g.Get(ctx, "counter")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got, want := view.String(), strconv.Itoa(goroutines*increments); got != want {
+		t.Fatalf("Expected counter %s, got %s", want, got)
+	}
+}
+
+// 测试 fn 返回错误时不写入，重试次数用完返回 ErrUpdateConflict，ttl 生效
+func TestGroupUpdate(t *testing.T) {
+	g := newTestGroup(t, nil, WithUpdateRetries(0))
+	ctx := context.Background()
+
+	errAbort := errors.New("abort")
+	if err := g.Update(ctx, "key", func(old []byte) ([]byte, error) {
+		return nil, errAbort
+	}, 0); !errors.Is(err, errAbort) {
+		t.Fatalf("Expected fn error, got %v", err)
+	}
+	if _, ok := g.mainCache.Get(ctx, "key"); ok {
+		t.Fatalf("Expected failed Update not to write")
+	}
+
+	// 读取之后、写入之前有其他写入
+	if err := g.Update(ctx, "key", func(old []byte) ([]byte, error) {
+		g.Set(ctx, "key", []byte("concurrent"))
+		return []byte("mine"), nil
+	}, 0); !errors.Is(err, ErrUpdateConflict) {
+		t.Fatalf("Expected ErrUpdateConflict, got %v", err)
+	}
+	if view, _ := g.mainCache.Get(ctx, "key"); view.String() != "concurrent" {
+		t.Fatalf("Expected the concurrent write to win, got %q", view.String())
+	}
+
+	if err := g.Update(ctx, "key", func(old []byte) ([]byte, error) {
+		if string(old) != "concurrent" {
+			t.Errorf("Expected old value concurrent, got %q", old)
+		}
+		return append(old, "+1"...), nil
+	}, time.Minute); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if view, _ := g.mainCache.Get(ctx, "key"); view.String() != "concurrent+1" {
+		t.Fatalf("Expected updated value, got %q", view.String())
+	}
+	if left := time.Until(localExpiration(t, g, "key")); left > time.Minute || left < 50*time.Second {
+		t.Fatalf("Expected expiry about a minute away, got %v", left)
+	}
+
+	if err := g.Update(ctx, "", nil, 0); !errors.Is(err, ErrKeyRequired) {
+		t.Fatalf("Expected ErrKeyRequired, got %v", err)
+	}
+}